@@ -16,10 +16,37 @@ import (
 	"github.com/joaosantos/pettime/internal/config"
 	"github.com/joaosantos/pettime/internal/database"
 	"github.com/joaosantos/pettime/internal/handlers"
+	"github.com/joaosantos/pettime/internal/jobs"
 	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/notify"
+	"github.com/joaosantos/pettime/internal/ratelimit"
 	"github.com/joaosantos/pettime/internal/repositories"
 	"github.com/joaosantos/pettime/internal/services"
+	"github.com/joaosantos/pettime/internal/storage"
 	"github.com/joaosantos/pettime/pkg/jwt"
+
+	// Embedded zone database for validating user timezones
+	_ "time/tzdata"
+)
+
+// recentLoginMaxAge is how long after signing in a user can change their
+// login methods without entering credentials again.
+const recentLoginMaxAge = 10 * time.Minute
+
+// Route rate limits
+var (
+	loginLimit    = ratelimit.PerMinute("login", 10, 10)
+	registerLimit = ratelimit.PerHour("register", 10, 5)
+	refreshLimit  = ratelimit.PerMinute("refresh", 30, 30)
+	activityLimit = ratelimit.PerMinute("activities", 60, 30)
+	syncLimit     = ratelimit.PerMinute("sync", 6, 3)
+	// Friend codes are short, so sending requests is limited to keep them
+	// from being guessed
+	friendRequestLimit = ratelimit.PerHour("friend-requests", 30, 10)
+	// Pet invitation and challenge codes are short too; both routes share
+	// one allowance so guesses can't be spread across them
+	inviteCodeLimit = ratelimit.PerHour("invite-codes", 30, 10)
 )
 
 func main() {
@@ -42,25 +69,129 @@ func main() {
 	defer db.Close()
 
 	// Initialize JWT manager
-	jwtManager := jwt.NewManager(cfg.JWT.Secret, cfg.JWT.AccessTokenTTL)
+	jwtManager, err := newJWTManager(cfg.JWT)
+	if err != nil {
+		log.Fatalf("Failed to initialize JWT manager: %v", err)
+	}
 
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db.Pool)
 	petRepo := repositories.NewPetRepository(db.Pool)
 	activityRepo := repositories.NewActivityRepository(db.Pool)
+	petMemberRepo := repositories.NewPetMemberRepository(db.Pool)
+	petTransferRepo := repositories.NewPetTransferRepository(db.Pool)
+	healthRepo := repositories.NewHealthRepository(db.Pool)
+	reminderRepo := repositories.NewReminderRepository(db.Pool)
+	notificationRepo := repositories.NewNotificationRepository(db.Pool)
+	nudgeRepo := repositories.NewNudgeRepository(db.Pool)
+	gamificationRepo := repositories.NewGamificationRepository(db.Pool)
+	exportRepo := repositories.NewExportRepository(db.Pool)
+	friendRepo := repositories.NewFriendRepository(db.Pool)
+	feedRepo := repositories.NewFeedRepository(db.Pool)
+	challengeRepo := repositories.NewChallengeRepository(db.Pool)
+	leaderboardRepo := repositories.NewLeaderboardRepository(db.Pool)
+	cosmeticRepo := repositories.NewCosmeticRepository(db.Pool)
+	skillRepo := repositories.NewSkillRepository(db.Pool)
+	recordRepo := repositories.NewRecordRepository(db.Pool)
+
+	// Pet types are configured in data; refuse to start with a broken config
+	if _, err := petRepo.GetAllPetTypes(context.Background()); err != nil {
+		log.Fatalf("Failed to load pet types: %v", err)
+	}
+	if _, err := activityRepo.GetAllGameTypes(context.Background()); err != nil {
+		log.Fatalf("Failed to load game types: %v", err)
+	}
+	if _, err := challengeRepo.ListTemplates(context.Background()); err != nil {
+		log.Fatalf("Failed to load challenge templates: %v", err)
+	}
+	if _, err := skillRepo.ListSkills(context.Background()); err != nil {
+		log.Fatalf("Failed to load skill tree: %v", err)
+	}
+	leveling, err := petRepo.GetLevelingConfig(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to load leveling config: %v", err)
+	}
+
+	// Rate limiter state
+	var limiterStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "postgres" {
+		limiterStore = ratelimit.NewPostgresStore(db.Pool)
+	}
+
+	// Uploaded files
+	var blobStore storage.BlobStore = storage.NewLocalStore(cfg.Storage.LocalDir, cfg.Storage.PublicURL)
+	if cfg.Storage.Backend == "s3" {
+		blobStore = storage.NewS3Store(storage.S3Config{
+			Endpoint: cfg.Storage.S3.Endpoint,
+			Region:   cfg.Storage.S3.Region,
+			Bucket:   cfg.Storage.S3.Bucket,
+			Credentials: storage.Credentials{
+				AccessKeyID:     cfg.Storage.S3.AccessKeyID,
+				SecretAccessKey: cfg.Storage.S3.SecretAccessKey,
+			},
+			PublicURL: cfg.Storage.PublicURL,
+		})
+	}
+
+	// Push providers
+	pushProviders, err := newPushProviders(cfg.Push)
+	if err != nil {
+		log.Fatalf("Failed to initialize push providers: %v", err)
+	}
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, jwtManager, cfg.JWT.RefreshTokenTTL)
-	petService := services.NewPetService(petRepo, activityRepo)
-	activityService := services.NewActivityService(activityRepo, petRepo)
+	authService := services.NewAuthService(userRepo, jwtManager, newIdentityVerifiers(cfg.Identity), ratelimit.NewBackoff(limiterStore), cfg.JWT.RefreshTokenTTL)
+	petService := services.NewPetService(petRepo, activityRepo, petMemberRepo, petTransferRepo, userRepo, recordRepo, leveling)
+	missionService := services.NewMissionService(gamificationRepo, activityRepo, userRepo)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, pushProviders)
+	friendService := services.NewFriendService(friendRepo, userRepo, notificationService)
+	feedService := services.NewFeedService(feedRepo, userRepo, notificationService)
+	challengeService := services.NewChallengeService(challengeRepo, friendRepo, userRepo, notificationService)
+	leaderboardService := services.NewLeaderboardService(leaderboardRepo, userRepo)
+	recordService := services.NewRecordService(recordRepo, petRepo, petMemberRepo, userRepo)
+	cosmeticService := services.NewCosmeticService(cosmeticRepo, petRepo, petMemberRepo)
+	skillService := services.NewSkillService(skillRepo, petRepo, petMemberRepo, leveling)
+	achievementService := services.NewAchievementService(gamificationRepo, feedService, cosmeticService)
+	activityService := services.NewActivityService(
+		activityRepo, petRepo, petMemberRepo, userRepo,
+		missionService, skillService, feedService, leaderboardService, recordService, cosmeticService, achievementService, leveling,
+	)
+	reminderService := services.NewReminderService(reminderRepo, userRepo, activityRepo, petRepo, petMemberRepo, activityService, notificationService)
+	nudgeService := services.NewNudgeService(nudgeRepo, petRepo, petMemberRepo, userRepo, notificationService)
+	userService := services.NewUserService(userRepo, reminderService)
+	petMemberService := services.NewPetMemberService(petMemberRepo, petRepo, userRepo)
+	mediaService := services.NewMediaService(petRepo, petMemberRepo, blobStore)
+	healthService := services.NewHealthService(healthRepo, petRepo, petMemberRepo)
+	accountService := services.NewAccountService(
+		userRepo, petRepo, activityRepo, gamificationRepo, healthRepo, recordRepo, skillRepo,
+		reminderRepo, notificationRepo, nudgeRepo, challengeRepo, cosmeticRepo, friendRepo, feedRepo, exportRepo,
+		cfg.Account.DeletionGracePeriod, cfg.Account.ExportLinkTTL, []byte(cfg.Account.ExportSigningSecret),
+	)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	petHandler := handlers.NewPetHandler(petService)
 	activityHandler := handlers.NewActivityHandler(activityService)
+	jwksHandler := handlers.NewJWKSHandler(jwtManager)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	userHandler := handlers.NewUserHandler(userService)
+	petMemberHandler := handlers.NewPetMemberHandler(petMemberService)
+	mediaHandler := handlers.NewMediaHandler(mediaService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+	reminderHandler := handlers.NewReminderHandler(reminderService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	missionHandler := handlers.NewMissionHandler(missionService)
+	friendHandler := handlers.NewFriendHandler(friendService)
+	feedHandler := handlers.NewFeedHandler(feedService)
+	challengeHandler := handlers.NewChallengeHandler(challengeService)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService)
+	cosmeticHandler := handlers.NewCosmeticHandler(cosmeticService)
+	skillHandler := handlers.NewSkillHandler(skillService)
+	recordHandler := handlers.NewRecordHandler(recordService)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
+	limiter := middleware.NewRateLimiter(limiterStore)
 
 	// Setup router
 	r := chi.NewRouter()
@@ -69,7 +200,7 @@ func main() {
 	r.Use(chimiddleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.RealIP(cfg.TrustedProxies))
 	r.Use(chimiddleware.Timeout(60 * time.Second))
 
 	// CORS
@@ -77,7 +208,7 @@ func main() {
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -92,46 +223,219 @@ func main() {
 		w.Write([]byte(`{"status":"healthy"}`))
 	})
 
+	// Uploaded files, when they are stored on local disk
+	if cfg.Storage.Backend == "local" {
+		r.Get("/media/*", mediaHandler.Serve)
+	}
+
+	// Public signing keys for services that verify PetTime tokens
+	r.Get("/.well-known/jwks.json", jwksHandler.Get)
+
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// Public routes
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/register", authHandler.Register)
-			r.Post("/login", authHandler.Login)
-			r.Post("/social", authHandler.SocialLogin)
-			r.Post("/refresh", authHandler.Refresh)
+			r.With(limiter.Limit(registerLimit, middleware.KeyByIP)).Post("/register", authHandler.Register)
+			r.With(limiter.Limit(loginLimit, middleware.KeyByIP)).Post("/login", authHandler.Login)
+			r.With(limiter.Limit(loginLimit, middleware.KeyByIP)).Post("/social", authHandler.SocialLogin)
+			r.With(limiter.Limit(refreshLimit, middleware.KeyByIP)).Post("/refresh", authHandler.Refresh)
 			r.Post("/logout", authHandler.Logout)
 		})
 
+		// Signed export downloads
+		r.Get("/exports/{id}/download", accountHandler.Download)
+
 		// Public reference data
 		r.Get("/pet-types", petHandler.ListPetTypes)
+		r.Get("/pet-types/{id}/breeds", petHandler.SearchBreeds)
 		r.Get("/game-types", activityHandler.ListGameTypes)
+		r.Get("/challenge-templates", challengeHandler.ListTemplates)
+		r.Get("/cosmetics", cosmeticHandler.ListItems)
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.Authenticate)
 
+			// Current user
+			r.Route("/me", func(r chi.Router) {
+				r.Get("/", userHandler.GetMe)
+				r.Put("/", userHandler.UpdateMe)
+				r.Post("/password", authHandler.ChangePassword)
+				r.Get("/preferences", userHandler.GetPreferences)
+				r.Put("/preferences", userHandler.UpdatePreferences)
+				r.Get("/identities", authHandler.ListIdentities)
+				r.Get("/export", accountHandler.GetExport)
+				r.Post("/export", accountHandler.RequestExport)
+				r.Get("/devices", notificationHandler.ListDevices)
+				r.Post("/devices", notificationHandler.RegisterDevice)
+				r.Delete("/devices/{id}", notificationHandler.UnregisterDevice)
+				r.Get("/friend-code", friendHandler.GetFriendCode)
+				r.Post("/friend-code", friendHandler.RotateFriendCode)
+				r.Get("/cosmetics", cosmeticHandler.ListInventory)
+				r.Group(func(r chi.Router) {
+					r.Use(authMiddleware.RequireRecentLogin(recentLoginMaxAge))
+					r.Post("/identities", authHandler.LinkIdentity)
+					r.Delete("/identities/{provider}", authHandler.UnlinkIdentity)
+					r.Delete("/", accountHandler.Delete)
+				})
+			})
+
 			// Pets
 			r.Route("/pets", func(r chi.Router) {
 				r.Post("/", petHandler.Create)
 				r.Get("/", petHandler.List)
+				r.Get("/deleted", petHandler.ListDeleted)
 				r.Get("/{id}", petHandler.GetByID)
 				r.Put("/{id}", petHandler.Update)
 				r.Delete("/{id}", petHandler.Delete)
 				r.Get("/{id}/stats", petHandler.GetStats)
+				r.Get("/{id}/timeline", petHandler.Timeline)
+				r.Put("/{id}/status", petHandler.UpdateStatus)
+				r.Post("/{id}/restore", petHandler.Restore)
+				r.Post("/{id}/avatar", mediaHandler.UploadPetAvatar)
+				r.Get("/{id}/members", petMemberHandler.ListMembers)
+				r.Delete("/{id}/members/{userId}", petMemberHandler.RemoveMember)
+				r.Get("/{id}/invitations", petMemberHandler.ListInvitations)
+				r.Post("/{id}/invitations", petMemberHandler.CreateInvitation)
+				r.Delete("/{id}/invitations/{invitationId}", petMemberHandler.RevokeInvitation)
+				r.Get("/{id}/transfer", petHandler.GetTransfer)
+				r.Post("/{id}/transfer", petHandler.InitiateTransfer)
+				r.Delete("/{id}/transfer", petHandler.CancelTransfer)
+				r.Put("/{id}/loadout", cosmeticHandler.Equip)
+				r.Delete("/{id}/loadout/{slot}", cosmeticHandler.Unequip)
+				r.Get("/{id}/skills", skillHandler.GetTree)
+				r.Post("/{id}/skills/{skillId}/unlock", skillHandler.Unlock)
+				r.Get("/{id}/records", recordHandler.List)
+
+				// Health journal
+				r.Route("/{id}/health", func(r chi.Router) {
+					r.Get("/summary", healthHandler.GetSummary)
+					r.Get("/weights", healthHandler.ListWeights)
+					r.Post("/weights", healthHandler.AddWeight)
+					r.Get("/weights/trend", healthHandler.GetWeightTrend)
+					r.Put("/weights/{recordId}", healthHandler.UpdateWeight)
+					r.Delete("/weights/{recordId}", healthHandler.DeleteWeight)
+					r.Get("/vet-visits", healthHandler.ListVetVisits)
+					r.Post("/vet-visits", healthHandler.CreateVetVisit)
+					r.Get("/vet-visits/{recordId}", healthHandler.GetVetVisit)
+					r.Put("/vet-visits/{recordId}", healthHandler.UpdateVetVisit)
+					r.Delete("/vet-visits/{recordId}", healthHandler.DeleteVetVisit)
+					r.Get("/vaccinations", healthHandler.ListVaccinations)
+					r.Post("/vaccinations", healthHandler.CreateVaccination)
+					r.Get("/vaccinations/{recordId}", healthHandler.GetVaccination)
+					r.Put("/vaccinations/{recordId}", healthHandler.UpdateVaccination)
+					r.Delete("/vaccinations/{recordId}", healthHandler.DeleteVaccination)
+					r.Get("/medications", healthHandler.ListMedications)
+					r.Post("/medications", healthHandler.CreateMedication)
+					r.Get("/medications/{recordId}", healthHandler.GetMedication)
+					r.Put("/medications/{recordId}", healthHandler.UpdateMedication)
+					r.Delete("/medications/{recordId}", healthHandler.DeleteMedication)
+				})
+			})
+
+			// Ownership transfers addressed to the current user
+			r.Route("/transfers", func(r chi.Router) {
+				r.Get("/", petHandler.ListIncomingTransfers)
+				r.Post("/{id}/accept", petHandler.AcceptTransfer)
+				r.Post("/{id}/decline", petHandler.DeclineTransfer)
+			})
+
+			// Invitations to join someone else's pet
+			r.Route("/invitations", func(r chi.Router) {
+				r.Get("/", petMemberHandler.ListMyInvitations)
+				r.With(limiter.Limit(inviteCodeLimit, middleware.KeyByUser)).Post("/accept", petMemberHandler.AcceptInvitation)
+			})
+
+			// Care reminders
+			r.Route("/reminders", func(r chi.Router) {
+				r.Get("/", reminderHandler.List)
+				r.Post("/", reminderHandler.Create)
+				r.Get("/{id}", reminderHandler.GetByID)
+				r.Put("/{id}", reminderHandler.Update)
+				r.Delete("/{id}", reminderHandler.Delete)
+				r.Get("/{id}/occurrences", reminderHandler.Occurrences)
+				r.Post("/{id}/done", reminderHandler.MarkDone)
+			})
+
+			// Daily missions
+			r.Get("/missions", missionHandler.List)
+
+			// Friends
+			r.Route("/friends", func(r chi.Router) {
+				r.Get("/", friendHandler.ListFriends)
+				r.Delete("/{userId}", friendHandler.RemoveFriend)
+				r.Get("/requests", friendHandler.ListRequests)
+				r.With(limiter.Limit(friendRequestLimit, middleware.KeyByUser)).Post("/requests", friendHandler.SendRequest)
+				r.Post("/requests/{id}/accept", friendHandler.AcceptRequest)
+				r.Post("/requests/{id}/decline", friendHandler.DeclineRequest)
+			})
+
+			// Blocked users
+			r.Route("/blocks", func(r chi.Router) {
+				r.Get("/", friendHandler.ListBlocked)
+				r.Put("/{userId}", friendHandler.Block)
+				r.Delete("/{userId}", friendHandler.Unblock)
+			})
+
+			// Social feed
+			r.Route("/feed", func(r chi.Router) {
+				r.Get("/", feedHandler.List)
+				r.Put("/{id}/paw-five", feedHandler.PawFive)
+				r.Delete("/{id}/paw-five", feedHandler.RemovePawFive)
+				r.Get("/{id}/comments", feedHandler.ListComments)
+				r.Post("/{id}/comments", feedHandler.AddComment)
+				r.Delete("/{id}/comments/{commentId}", feedHandler.DeleteComment)
+			})
+
+			// Pack challenges
+			r.Route("/challenges", func(r chi.Router) {
+				r.Get("/", challengeHandler.List)
+				r.Post("/", challengeHandler.Create)
+				r.With(limiter.Limit(inviteCodeLimit, middleware.KeyByUser)).Post("/join", challengeHandler.Join)
+				r.Get("/rewards", challengeHandler.ListRewards)
+				r.Get("/invitations", challengeHandler.ListInvitations)
+				r.Get("/{id}", challengeHandler.GetByID)
+				r.Post("/{id}/accept", challengeHandler.Accept)
+				r.Post("/{id}/decline", challengeHandler.Decline)
+				r.Post("/{id}/leave", challengeHandler.Leave)
 			})
 
+			// Leaderboards
+			r.Get("/leaderboards/{metric}", leaderboardHandler.Get)
+
 			// Activities
 			r.Route("/activities", func(r chi.Router) {
+				r.Use(limiter.Limit(activityLimit, middleware.KeyByUser))
 				r.Post("/", activityHandler.Create)
 				r.Get("/", activityHandler.List)
 				r.Get("/{id}", activityHandler.GetByID)
 				r.Put("/{id}", activityHandler.Update)
-				r.Post("/sync", activityHandler.Sync)
+				r.Post("/{id}/imu", activityHandler.AddIMUSamples)
+				r.With(limiter.Limit(syncLimit, middleware.KeyByUser)).Post("/sync", activityHandler.Sync)
 			})
 		})
 	})
 
+	// Background jobs
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	runner := jobs.NewRunner()
+	runner.Every(time.Minute, "process-exports", accountService.ProcessPendingExports)
+	runner.Every(time.Minute, "send-reminders", reminderService.ProcessDue)
+	runner.Every(15*time.Second, "send-push", notificationService.ProcessDeliveries)
+	runner.Every(15*time.Minute, "send-nudges", nudgeService.Process)
+	runner.Every(time.Hour, "generate-missions", missionService.Generate)
+	runner.Every(5*time.Minute, "finish-challenges", challengeService.FinishEnded)
+	runner.Every(time.Hour, "purge-exports", accountService.PurgeExpiredExports)
+	runner.Every(time.Hour, "purge-deleted-accounts", accountService.PurgeDeletedAccounts)
+	runner.Every(time.Hour, "purge-deleted-pets", petService.PurgeDeleted)
+	runner.Every(time.Hour, "purge-push", notificationService.PurgeStale)
+	runner.Every(time.Hour, "purge-leaderboards", leaderboardService.PurgeEnded)
+	runner.Every(time.Hour, "purge-imu-samples", activityService.PurgeIMUSamples)
+	runner.Every(10*time.Minute, "prune-rate-limits", func(ctx context.Context) error {
+		return limiterStore.Prune(ctx, time.Now().Add(-2*time.Hour))
+	})
+	runner.Start(jobCtx)
+
 	// Create server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Port),
@@ -156,6 +460,9 @@ func main() {
 
 	log.Println("Shutting down server...")
 
+	stopJobs()
+	runner.Wait()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -165,3 +472,89 @@ func main() {
 
 	log.Println("Server stopped")
 }
+
+// newJWTManager loads the asymmetric signing keys from JWT_KEYS_DIR. Without
+// a keys directory it falls back to HS256 with JWT_SECRET.
+func newJWTManager(cfg config.JWTConfig) (*jwt.Manager, error) {
+	if cfg.KeysDir == "" {
+		key := jwt.NewHMACKey("default", []byte(cfg.Secret))
+		return jwt.NewManager([]*jwt.Key{key}, key.ID, cfg.AccessTokenTTL)
+	}
+
+	keys, err := jwt.LoadKeysFromDir(cfg.KeysDir)
+	if err != nil {
+		return nil, err
+	}
+
+	retired := make(map[string]bool, len(cfg.RetiredKeyIDs))
+	for _, id := range cfg.RetiredKeyIDs {
+		retired[id] = true
+	}
+	for _, key := range keys {
+		key.Retired = retired[key.ID]
+	}
+
+	return jwt.NewManager(keys, cfg.ActiveKeyID, cfg.AccessTokenTTL)
+}
+
+// newIdentityVerifiers enables social login for the providers with client
+// IDs configured. Without any, social login and linking are refused.
+func newIdentityVerifiers(cfg config.IdentityConfig) map[models.AuthProvider]services.IdentityVerifier {
+	verifiers := map[models.AuthProvider]services.IdentityVerifier{}
+	if len(cfg.GoogleClientIDs) > 0 {
+		verifiers[models.AuthProviderGoogle] = jwt.NewGoogleVerifier(cfg.GoogleClientIDs)
+	}
+	if len(cfg.AppleClientIDs) > 0 {
+		verifiers[models.AuthProviderApple] = jwt.NewAppleVerifier(cfg.AppleClientIDs)
+	}
+	return verifiers
+}
+
+// newPushProviders sets up a provider per push service. Expo needs no
+// credentials; FCM and APNs are only enabled when their keys are configured.
+func newPushProviders(cfg config.PushConfig) (map[models.PushProvider]notify.PushProvider, error) {
+	if cfg.Fake {
+		fake := notify.NewFakeProvider(true)
+		return map[models.PushProvider]notify.PushProvider{
+			models.PushProviderExpo: fake,
+			models.PushProviderFCM:  fake,
+			models.PushProviderAPNs: fake,
+		}, nil
+	}
+
+	providers := map[models.PushProvider]notify.PushProvider{
+		models.PushProviderExpo: notify.NewExpoProvider(cfg.ExpoAccessToken),
+	}
+
+	if cfg.FCMCredentialsFile != "" {
+		serviceAccount, err := os.ReadFile(cfg.FCMCredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+		}
+		fcm, err := notify.NewFCMProvider(serviceAccount)
+		if err != nil {
+			return nil, err
+		}
+		providers[models.PushProviderFCM] = fcm
+	}
+
+	if cfg.APNsKeyFile != "" {
+		key, err := os.ReadFile(cfg.APNsKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read APNs key: %w", err)
+		}
+		apns, err := notify.NewAPNsProvider(notify.APNsConfig{
+			Key:        key,
+			KeyID:      cfg.APNsKeyID,
+			TeamID:     cfg.APNsTeamID,
+			Topic:      cfg.APNsTopic,
+			Production: cfg.APNsProduction,
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers[models.PushProviderAPNs] = apns
+	}
+
+	return providers, nil
+}
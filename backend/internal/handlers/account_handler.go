@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+type DeleteAccountResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	deleteAt, err := h.accountService.ScheduleDeletion(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, DeleteAccountResponse{DeletionScheduledAt: deleteAt})
+}
+
+func (h *AccountHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	export, err := h.accountService.RequestExport(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to request export")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, export)
+}
+
+func (h *AccountHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	export, err := h.accountService.GetLatestExport(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrExportNotFound) {
+			respondError(w, http.StatusNotFound, "No export requested")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to get export")
+		return
+	}
+
+	respondSuccess(w, export)
+}
+
+// Download serves an export archive. It is authorized by the signed link
+// rather than a bearer token so the link can be opened in a browser.
+func (h *AccountHandler) Download(w http.ResponseWriter, r *http.Request) {
+	exportID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid export ID")
+		return
+	}
+
+	query := r.URL.Query()
+	archive, err := h.accountService.GetExportArchive(r.Context(), exportID, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		if errors.Is(err, services.ErrExportLinkInvalid) {
+			respondError(w, http.StatusForbidden, "Download link is invalid or expired")
+			return
+		}
+		if errors.Is(err, services.ErrExportNotFound) {
+			respondError(w, http.StatusNotFound, "Export not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to download export")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="pettime-export-%s.zip"`, exportID))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
@@ -113,6 +113,14 @@ func (h *ActivityHandler) Create(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "Invalid game type")
 			return
 		}
+		if errors.Is(err, models.ErrInvalidFetchEvents) || errors.Is(err, models.ErrInvalidGameData) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrPetReadOnly) {
+			respondError(w, http.StatusConflict, "Archived and memorial pets can't log activities")
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "Failed to create activity")
 		return
 	}
@@ -248,6 +256,14 @@ func (h *ActivityHandler) Update(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusForbidden, "Access denied")
 			return
 		}
+		if errors.Is(err, models.ErrInvalidFetchEvents) || errors.Is(err, models.ErrInvalidGameData) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrPetReadOnly) {
+			respondError(w, http.StatusConflict, "Archived and memorial pets can't log activities")
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "Failed to update activity")
 		return
 	}
@@ -255,6 +271,62 @@ func (h *ActivityHandler) Update(w http.ResponseWriter, r *http.Request) {
 	respondSuccess(w, activity)
 }
 
+// maxIMUBatchBytes fits a full batch of samples with room to spare.
+const maxIMUBatchBytes = 1 << 20
+
+// AddIMUSamples takes a batch of accelerometer samples for a running fetch
+// activity and returns the activity with its throws recounted.
+func (h *ActivityHandler) AddIMUSamples(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	activityID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid activity ID")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxIMUBatchBytes)
+	var input models.IMUBatchInput
+	if err := decodeJSON(r, &input); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "Batch must be at most "+strconv.Itoa(maxIMUBatchBytes>>20)+" MB")
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	activity, err := h.activityService.AddIMUSamples(r.Context(), userID, activityID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrActivityNotFound):
+			respondError(w, http.StatusNotFound, "Activity not found")
+		case errors.Is(err, services.ErrUnauthorized):
+			respondError(w, http.StatusForbidden, "Access denied")
+		case errors.Is(err, services.ErrPetReadOnly):
+			respondError(w, http.StatusConflict, "Archived and memorial pets can't log activities")
+		case errors.Is(err, services.ErrActivityFinished):
+			respondError(w, http.StatusConflict, "Activity is already finished")
+		case errors.Is(err, services.ErrThrowsNotDetected):
+			respondError(w, http.StatusBadRequest, "This game type doesn't detect throws")
+		case errors.Is(err, models.ErrInvalidIMUBatch):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrTooManyIMUSamples):
+			respondError(w, http.StatusRequestEntityTooLarge, "Too many samples for one activity")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to add samples")
+		}
+		return
+	}
+
+	respondSuccess(w, activity)
+}
+
 func (h *ActivityHandler) Sync(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r.Context())
 	if userID == uuid.Nil {
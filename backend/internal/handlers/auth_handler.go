@@ -4,6 +4,9 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
 	"github.com/joaosantos/pettime/internal/models"
 	"github.com/joaosantos/pettime/internal/services"
 )
@@ -28,17 +31,26 @@ type LoginRequest struct {
 }
 
 type SocialLoginRequest struct {
-	Provider   string `json:"provider"`
-	Token      string `json:"token"`
-	Name       string `json:"name"`
-	Email      string `json:"email"`
-	ProviderID string `json:"provider_id"`
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+	Name     string `json:"name"`
 }
 
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type LinkIdentityRequest struct {
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
 type AuthResponse struct {
 	User   *models.User        `json:"user"`
 	Tokens *models.AuthTokens  `json:"tokens"`
@@ -99,6 +111,11 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	user, tokens, err := h.authService.Login(r.Context(), input)
 	if err != nil {
+		var tooMany *services.TooManyAttemptsError
+		if errors.As(err, &tooMany) {
+			middleware.RespondTooManyRequests(w, tooMany.RetryAfter)
+			return
+		}
 		if errors.Is(err, services.ErrInvalidCredentials) {
 			respondError(w, http.StatusUnauthorized, "Invalid credentials")
 			return
@@ -117,8 +134,8 @@ func (h *AuthHandler) SocialLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Provider == "" || req.ProviderID == "" || req.Email == "" {
-		respondError(w, http.StatusBadRequest, "Provider, provider_id, and email are required")
+	if req.Provider == "" || req.Token == "" {
+		respondError(w, http.StatusBadRequest, "Provider and token are required")
 		return
 	}
 
@@ -134,16 +151,23 @@ func (h *AuthHandler) SocialLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	input := models.SocialLoginInput{
-		Provider:   provider,
-		Token:      req.Token,
-		Name:       req.Name,
-		Email:      req.Email,
-		ProviderID: req.ProviderID,
+		Provider: provider,
+		Token:    req.Token,
+		Name:     req.Name,
 	}
 
 	user, tokens, err := h.authService.SocialLogin(r.Context(), input)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to login")
+		switch {
+		case errors.Is(err, services.ErrAccountLinkRequired):
+			respondError(w, http.StatusConflict, "An account with this email already exists. Sign in and link this provider from your profile")
+		case errors.Is(err, services.ErrProviderNotEnabled):
+			respondError(w, http.StatusBadRequest, "Sign in with this provider is not enabled")
+		case errors.Is(err, services.ErrInvalidIDToken):
+			respondError(w, http.StatusUnauthorized, "Invalid identity token")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to login")
+		}
 		return
 	}
 
@@ -184,3 +208,134 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	respondNoContent(w)
 }
+
+func (h *AuthHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	identities, err := h.authService.ListIdentities(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list identities")
+		return
+	}
+
+	if identities == nil {
+		identities = []*models.UserIdentity{}
+	}
+
+	respondSuccess(w, identities)
+}
+
+func (h *AuthHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req LinkIdentityRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	input := models.LinkIdentityInput{
+		Provider: models.AuthProvider(req.Provider),
+		Token:    req.Token,
+		Password: req.Password,
+	}
+
+	identity, err := h.authService.LinkIdentity(r.Context(), userID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidProvider):
+			respondError(w, http.StatusBadRequest, "Invalid provider")
+		case errors.Is(err, services.ErrProviderNotEnabled):
+			respondError(w, http.StatusBadRequest, "Sign in with this provider is not enabled")
+		case errors.Is(err, services.ErrInvalidIDToken):
+			respondError(w, http.StatusBadRequest, "Invalid identity token")
+		case errors.Is(err, services.ErrInvalidCredentials):
+			respondError(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		case errors.Is(err, services.ErrIdentityInUse):
+			respondError(w, http.StatusConflict, "This login is already linked to another account")
+		case errors.Is(err, services.ErrIdentityAlreadyLinked):
+			respondError(w, http.StatusConflict, "Provider already linked")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to link identity")
+		}
+		return
+	}
+
+	respondCreated(w, identity)
+}
+
+func (h *AuthHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	provider := models.AuthProvider(chi.URLParam(r, "provider"))
+
+	if err := h.authService.UnlinkIdentity(r.Context(), userID, provider); err != nil {
+		switch {
+		case errors.Is(err, services.ErrIdentityNotFound):
+			respondError(w, http.StatusNotFound, "Identity not found")
+		case errors.Is(err, services.ErrLastIdentity):
+			respondError(w, http.StatusConflict, "Cannot remove the last login method")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to unlink identity")
+		}
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.CurrentPassword == "" || len(req.NewPassword) < 8 {
+		respondError(w, http.StatusBadRequest, "Current password and a new password of at least 8 characters are required")
+		return
+	}
+
+	input := models.ChangePasswordInput{
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	}
+
+	tokens, err := h.authService.ChangePassword(r.Context(), userID, input)
+	if err != nil {
+		var tooMany *services.TooManyAttemptsError
+		if errors.As(err, &tooMany) {
+			middleware.RespondTooManyRequests(w, tooMany.RetryAfter)
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrNoPassword):
+			respondError(w, http.StatusBadRequest, "Account has no password. Link the email provider instead")
+		case errors.Is(err, services.ErrInvalidCredentials):
+			respondError(w, http.StatusUnauthorized, "Invalid credentials")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to change password")
+		}
+		return
+	}
+
+	respondSuccess(w, tokens)
+}
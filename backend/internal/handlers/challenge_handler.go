@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type ChallengeHandler struct {
+	challengeService *services.ChallengeService
+}
+
+func NewChallengeHandler(challengeService *services.ChallengeService) *ChallengeHandler {
+	return &ChallengeHandler{challengeService: challengeService}
+}
+
+func (h *ChallengeHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.challengeService.ListTemplates(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list challenge templates")
+		return
+	}
+
+	respondSuccess(w, templates)
+}
+
+func (h *ChallengeHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	challenges, err := h.challengeService.List(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list challenges")
+		return
+	}
+
+	if challenges == nil {
+		challenges = []*models.Challenge{}
+	}
+
+	respondSuccess(w, challenges)
+}
+
+func (h *ChallengeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var input models.CreateChallengeInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	challenge, err := h.challengeService.Create(r.Context(), userID, input)
+	if err != nil {
+		respondChallengeError(w, err, "Failed to create challenge")
+		return
+	}
+
+	respondCreated(w, challenge)
+}
+
+// Join adds the user to a challenge by its invite code.
+func (h *ChallengeHandler) Join(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var input models.JoinChallengeInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	challenge, err := h.challengeService.Join(r.Context(), userID, input)
+	if err != nil {
+		respondChallengeError(w, err, "Failed to join challenge")
+		return
+	}
+
+	respondSuccess(w, challenge)
+}
+
+// ListInvitations returns the challenges the user was invited to and
+// hasn't answered.
+func (h *ChallengeHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	challenges, err := h.challengeService.ListInvitations(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list challenge invitations")
+		return
+	}
+
+	if challenges == nil {
+		challenges = []*models.Challenge{}
+	}
+
+	respondSuccess(w, challenges)
+}
+
+// Accept makes the user a participant of a challenge they were invited to.
+func (h *ChallengeHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid challenge ID")
+		return
+	}
+
+	challenge, err := h.challengeService.AcceptInvitation(r.Context(), userID, challengeID)
+	if err != nil {
+		respondChallengeError(w, err, "Failed to accept challenge invitation")
+		return
+	}
+
+	respondSuccess(w, challenge)
+}
+
+func (h *ChallengeHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid challenge ID")
+		return
+	}
+
+	if err := h.challengeService.DeclineInvitation(r.Context(), userID, challengeID); err != nil {
+		respondChallengeError(w, err, "Failed to decline challenge invitation")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+// GetByID returns a challenge with its standings.
+func (h *ChallengeHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid challenge ID")
+		return
+	}
+
+	challenge, err := h.challengeService.Get(r.Context(), userID, challengeID)
+	if err != nil {
+		respondChallengeError(w, err, "Failed to get challenge")
+		return
+	}
+
+	respondSuccess(w, challenge)
+}
+
+func (h *ChallengeHandler) Leave(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid challenge ID")
+		return
+	}
+
+	if err := h.challengeService.Leave(r.Context(), userID, challengeID); err != nil {
+		respondChallengeError(w, err, "Failed to leave challenge")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+// ListRewards returns the titles and cosmetics the user won in challenges.
+func (h *ChallengeHandler) ListRewards(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	rewards, err := h.challengeService.ListRewards(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list challenge rewards")
+		return
+	}
+
+	if rewards == nil {
+		rewards = []*models.ChallengeReward{}
+	}
+
+	respondSuccess(w, rewards)
+}
+
+func respondChallengeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrChallengeNotFound):
+		respondError(w, http.StatusNotFound, "Challenge not found")
+	case errors.Is(err, services.ErrChallengeTemplateNotFound):
+		respondError(w, http.StatusBadRequest, "Unknown challenge template")
+	case errors.Is(err, services.ErrNotFriends):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrChallengeFinished):
+		respondError(w, http.StatusConflict, "Challenge has ended")
+	case errors.Is(err, services.ErrChallengeFull):
+		respondError(w, http.StatusConflict, "Challenge is full")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
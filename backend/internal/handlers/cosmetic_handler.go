@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type CosmeticHandler struct {
+	cosmeticService *services.CosmeticService
+}
+
+func NewCosmeticHandler(cosmeticService *services.CosmeticService) *CosmeticHandler {
+	return &CosmeticHandler{cosmeticService: cosmeticService}
+}
+
+// ListItems returns the catalog of cosmetic items.
+func (h *CosmeticHandler) ListItems(w http.ResponseWriter, r *http.Request) {
+	items, err := h.cosmeticService.ListItems(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list cosmetic items")
+		return
+	}
+
+	if items == nil {
+		items = []*models.CosmeticItem{}
+	}
+
+	respondSuccess(w, items)
+}
+
+// ListInventory returns the items the user owns.
+func (h *CosmeticHandler) ListInventory(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	items, err := h.cosmeticService.ListInventory(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list inventory")
+		return
+	}
+
+	if items == nil {
+		items = []*models.InventoryItem{}
+	}
+
+	respondSuccess(w, items)
+}
+
+// Equip puts an item on the pet and returns the pet with its loadout.
+func (h *CosmeticHandler) Equip(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	var input models.EquipItemInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	pet, err := h.cosmeticService.Equip(r.Context(), userID, petID, input)
+	if err != nil {
+		respondCosmeticError(w, err, "Failed to equip item")
+		return
+	}
+
+	respondSuccess(w, pet)
+}
+
+func (h *CosmeticHandler) Unequip(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	slot := models.CosmeticSlot(chi.URLParam(r, "slot"))
+	if err := h.cosmeticService.Unequip(r.Context(), userID, petID, slot); err != nil {
+		respondCosmeticError(w, err, "Failed to unequip item")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func respondCosmeticError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidCosmeticSlot):
+		respondError(w, http.StatusBadRequest, "Invalid slot")
+	case errors.Is(err, services.ErrCosmeticItemNotFound):
+		respondError(w, http.StatusBadRequest, "Unknown item")
+	case errors.Is(err, services.ErrItemNotOwned):
+		respondError(w, http.StatusForbidden, "Item is not in your inventory")
+	case errors.Is(err, services.ErrPetNotFound):
+		respondError(w, http.StatusNotFound, "Pet not found")
+	case errors.Is(err, services.ErrSlotEmpty):
+		respondError(w, http.StatusNotFound, "Nothing is equipped in that slot")
+	case errors.Is(err, services.ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "Access denied")
+	case errors.Is(err, services.ErrPetReadOnly):
+		respondError(w, http.StatusConflict, "Memorial pets can't be changed")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
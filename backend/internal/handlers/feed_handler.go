@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type FeedHandler struct {
+	feedService *services.FeedService
+}
+
+func NewFeedHandler(feedService *services.FeedService) *FeedHandler {
+	return &FeedHandler{feedService: feedService}
+}
+
+// List returns a page of the user's social feed. Pass the previous page's
+// next_cursor as cursor to get the following one.
+func (h *FeedHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	page, err := h.feedService.List(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		respondFeedError(w, err, "Failed to get feed")
+		return
+	}
+
+	respondSuccess(w, page)
+}
+
+func (h *FeedHandler) PawFive(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid feed event ID")
+		return
+	}
+
+	if err := h.feedService.PawFive(r.Context(), userID, eventID); err != nil {
+		respondFeedError(w, err, "Failed to paw-five")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *FeedHandler) RemovePawFive(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid feed event ID")
+		return
+	}
+
+	if err := h.feedService.RemovePawFive(r.Context(), userID, eventID); err != nil {
+		respondFeedError(w, err, "Failed to remove paw-five")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *FeedHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid feed event ID")
+		return
+	}
+
+	comments, err := h.feedService.ListComments(r.Context(), userID, eventID)
+	if err != nil {
+		respondFeedError(w, err, "Failed to list comments")
+		return
+	}
+
+	if comments == nil {
+		comments = []*models.FeedComment{}
+	}
+
+	respondSuccess(w, comments)
+}
+
+func (h *FeedHandler) AddComment(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid feed event ID")
+		return
+	}
+
+	var input models.CommentInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	comment, err := h.feedService.AddComment(r.Context(), userID, eventID, input)
+	if err != nil {
+		respondFeedError(w, err, "Failed to add comment")
+		return
+	}
+
+	respondCreated(w, comment)
+}
+
+func (h *FeedHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid feed event ID")
+		return
+	}
+
+	commentID, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	if err := h.feedService.DeleteComment(r.Context(), userID, eventID, commentID); err != nil {
+		respondFeedError(w, err, "Failed to delete comment")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func respondFeedError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidCursor),
+		errors.Is(err, models.ErrInvalidComment):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrFeedEventNotFound):
+		respondError(w, http.StatusNotFound, "Feed event not found")
+	case errors.Is(err, services.ErrCommentNotFound):
+		respondError(w, http.StatusNotFound, "Comment not found")
+	case errors.Is(err, services.ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "Access denied")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type FriendHandler struct {
+	friendService *services.FriendService
+}
+
+func NewFriendHandler(friendService *services.FriendService) *FriendHandler {
+	return &FriendHandler{friendService: friendService}
+}
+
+// GetFriendCode returns the code others enter, or scan as a QR code, to
+// send the user a friend request.
+func (h *FriendHandler) GetFriendCode(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	code, err := h.friendService.GetFriendCode(r.Context(), userID)
+	if err != nil {
+		respondFriendError(w, err, "Failed to get friend code")
+		return
+	}
+
+	respondSuccess(w, code)
+}
+
+// RotateFriendCode replaces the user's friend code with a new one.
+func (h *FriendHandler) RotateFriendCode(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	code, err := h.friendService.RotateFriendCode(r.Context(), userID)
+	if err != nil {
+		respondFriendError(w, err, "Failed to rotate friend code")
+		return
+	}
+
+	respondSuccess(w, code)
+}
+
+func (h *FriendHandler) ListFriends(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	friends, err := h.friendService.ListFriends(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list friends")
+		return
+	}
+
+	if friends == nil {
+		friends = []*models.Friend{}
+	}
+
+	respondSuccess(w, friends)
+}
+
+func (h *FriendHandler) RemoveFriend(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	friendID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.friendService.RemoveFriend(r.Context(), userID, friendID); err != nil {
+		respondFriendError(w, err, "Failed to remove friend")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *FriendHandler) ListRequests(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	requests, err := h.friendService.ListRequests(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list friend requests")
+		return
+	}
+
+	respondSuccess(w, requests)
+}
+
+// SendRequest sends a friend request to the owner of a friend code.
+func (h *FriendHandler) SendRequest(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var input models.SendFriendRequestInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	request, err := h.friendService.SendRequest(r.Context(), userID, input)
+	if err != nil {
+		respondFriendError(w, err, "Failed to send friend request")
+		return
+	}
+
+	respondCreated(w, request)
+}
+
+func (h *FriendHandler) AcceptRequest(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	requestID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid friend request ID")
+		return
+	}
+
+	if err := h.friendService.AcceptRequest(r.Context(), userID, requestID); err != nil {
+		respondFriendError(w, err, "Failed to accept friend request")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *FriendHandler) DeclineRequest(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	requestID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid friend request ID")
+		return
+	}
+
+	if err := h.friendService.DeclineRequest(r.Context(), userID, requestID); err != nil {
+		respondFriendError(w, err, "Failed to decline friend request")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *FriendHandler) ListBlocked(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	blocked, err := h.friendService.ListBlocked(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list blocked users")
+		return
+	}
+
+	if blocked == nil {
+		blocked = []*models.BlockedUser{}
+	}
+
+	respondSuccess(w, blocked)
+}
+
+func (h *FriendHandler) Block(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	blockedID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.friendService.Block(r.Context(), userID, blockedID); err != nil {
+		respondFriendError(w, err, "Failed to block user")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *FriendHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	blockedID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.friendService.Unblock(r.Context(), userID, blockedID); err != nil {
+		respondFriendError(w, err, "Failed to unblock user")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func respondFriendError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		respondError(w, http.StatusNotFound, "User not found")
+	case errors.Is(err, services.ErrFriendNotFound):
+		respondError(w, http.StatusNotFound, "Friend not found")
+	case errors.Is(err, services.ErrFriendRequestNotFound):
+		respondError(w, http.StatusNotFound, "Friend request not found")
+	case errors.Is(err, services.ErrCannotFriendSelf),
+		errors.Is(err, services.ErrCannotBlockSelf):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrAlreadyFriends):
+		respondError(w, http.StatusConflict, "Already friends")
+	case errors.Is(err, services.ErrFriendRequestExists):
+		respondError(w, http.StatusConflict, "Friend request already sent")
+	case errors.Is(err, services.ErrTooManyFriendRequests):
+		respondError(w, http.StatusTooManyRequests, "Too many pending friend requests")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+func NewHealthHandler(healthService *services.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+func (h *HealthHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.healthService.GetSummary(r.Context(), userID, petID)
+	if err != nil {
+		respondHealthError(w, err, "Failed to get health summary")
+		return
+	}
+
+	respondSuccess(w, summary)
+}
+
+// Weights
+
+func (h *HealthHandler) ListWeights(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	weights, err := h.healthService.ListWeights(r.Context(), userID, petID)
+	if err != nil {
+		respondHealthError(w, err, "Failed to list weights")
+		return
+	}
+
+	if weights == nil {
+		weights = []*models.WeightEntry{}
+	}
+
+	respondSuccess(w, weights)
+}
+
+func (h *HealthHandler) GetWeightTrend(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	days := 0
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = parsed
+	}
+
+	trend, err := h.healthService.GetWeightTrend(r.Context(), userID, petID, days)
+	if err != nil {
+		respondHealthError(w, err, "Failed to get weight trend")
+		return
+	}
+
+	respondSuccess(w, trend)
+}
+
+func (h *HealthHandler) AddWeight(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.WeightInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	entry, err := h.healthService.AddWeight(r.Context(), userID, petID, input)
+	if err != nil {
+		respondHealthError(w, err, "Failed to add weight")
+		return
+	}
+
+	respondCreated(w, entry)
+}
+
+func (h *HealthHandler) UpdateWeight(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	entryID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.WeightInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	entry, err := h.healthService.UpdateWeight(r.Context(), userID, petID, entryID, input)
+	if err != nil {
+		respondHealthError(w, err, "Failed to update weight")
+		return
+	}
+
+	respondSuccess(w, entry)
+}
+
+func (h *HealthHandler) DeleteWeight(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	entryID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.healthService.DeleteWeight(r.Context(), userID, petID, entryID); err != nil {
+		respondHealthError(w, err, "Failed to delete weight")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+// Vet visits
+
+func (h *HealthHandler) ListVetVisits(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	visits, err := h.healthService.ListVetVisits(r.Context(), userID, petID)
+	if err != nil {
+		respondHealthError(w, err, "Failed to list vet visits")
+		return
+	}
+
+	if visits == nil {
+		visits = []*models.VetVisit{}
+	}
+
+	respondSuccess(w, visits)
+}
+
+func (h *HealthHandler) GetVetVisit(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	visitID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	visit, err := h.healthService.GetVetVisit(r.Context(), userID, petID, visitID)
+	if err != nil {
+		respondHealthError(w, err, "Failed to get vet visit")
+		return
+	}
+
+	respondSuccess(w, visit)
+}
+
+func (h *HealthHandler) CreateVetVisit(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.VetVisitInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	visit, err := h.healthService.CreateVetVisit(r.Context(), userID, petID, input)
+	if err != nil {
+		respondHealthError(w, err, "Failed to create vet visit")
+		return
+	}
+
+	respondCreated(w, visit)
+}
+
+func (h *HealthHandler) UpdateVetVisit(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	visitID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.VetVisitInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	visit, err := h.healthService.UpdateVetVisit(r.Context(), userID, petID, visitID, input)
+	if err != nil {
+		respondHealthError(w, err, "Failed to update vet visit")
+		return
+	}
+
+	respondSuccess(w, visit)
+}
+
+func (h *HealthHandler) DeleteVetVisit(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	visitID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.healthService.DeleteVetVisit(r.Context(), userID, petID, visitID); err != nil {
+		respondHealthError(w, err, "Failed to delete vet visit")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+// Vaccinations
+
+func (h *HealthHandler) ListVaccinations(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	vaccinations, err := h.healthService.ListVaccinations(r.Context(), userID, petID)
+	if err != nil {
+		respondHealthError(w, err, "Failed to list vaccinations")
+		return
+	}
+
+	if vaccinations == nil {
+		vaccinations = []*models.Vaccination{}
+	}
+
+	respondSuccess(w, vaccinations)
+}
+
+func (h *HealthHandler) GetVaccination(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	vaccinationID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	vaccination, err := h.healthService.GetVaccination(r.Context(), userID, petID, vaccinationID)
+	if err != nil {
+		respondHealthError(w, err, "Failed to get vaccination")
+		return
+	}
+
+	respondSuccess(w, vaccination)
+}
+
+func (h *HealthHandler) CreateVaccination(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.VaccinationInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	vaccination, err := h.healthService.CreateVaccination(r.Context(), userID, petID, input)
+	if err != nil {
+		respondHealthError(w, err, "Failed to create vaccination")
+		return
+	}
+
+	respondCreated(w, vaccination)
+}
+
+func (h *HealthHandler) UpdateVaccination(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	vaccinationID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.VaccinationInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	vaccination, err := h.healthService.UpdateVaccination(r.Context(), userID, petID, vaccinationID, input)
+	if err != nil {
+		respondHealthError(w, err, "Failed to update vaccination")
+		return
+	}
+
+	respondSuccess(w, vaccination)
+}
+
+func (h *HealthHandler) DeleteVaccination(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	vaccinationID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.healthService.DeleteVaccination(r.Context(), userID, petID, vaccinationID); err != nil {
+		respondHealthError(w, err, "Failed to delete vaccination")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+// Medications
+
+func (h *HealthHandler) ListMedications(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	medications, err := h.healthService.ListMedications(r.Context(), userID, petID)
+	if err != nil {
+		respondHealthError(w, err, "Failed to list medications")
+		return
+	}
+
+	if medications == nil {
+		medications = []*models.Medication{}
+	}
+
+	respondSuccess(w, medications)
+}
+
+func (h *HealthHandler) GetMedication(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	medicationID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	medication, err := h.healthService.GetMedication(r.Context(), userID, petID, medicationID)
+	if err != nil {
+		respondHealthError(w, err, "Failed to get medication")
+		return
+	}
+
+	respondSuccess(w, medication)
+}
+
+func (h *HealthHandler) CreateMedication(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.MedicationInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	medication, err := h.healthService.CreateMedication(r.Context(), userID, petID, input)
+	if err != nil {
+		respondHealthError(w, err, "Failed to create medication")
+		return
+	}
+
+	respondCreated(w, medication)
+}
+
+func (h *HealthHandler) UpdateMedication(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	medicationID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.MedicationInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	medication, err := h.healthService.UpdateMedication(r.Context(), userID, petID, medicationID, input)
+	if err != nil {
+		respondHealthError(w, err, "Failed to update medication")
+		return
+	}
+
+	respondSuccess(w, medication)
+}
+
+func (h *HealthHandler) DeleteMedication(w http.ResponseWriter, r *http.Request) {
+	userID, petID, ok := healthParams(w, r)
+	if !ok {
+		return
+	}
+	medicationID, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.healthService.DeleteMedication(r.Context(), userID, petID, medicationID); err != nil {
+		respondHealthError(w, err, "Failed to delete medication")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+// healthParams reads the authenticated user and pet ID shared by every
+// health route, writing the error response when either is missing.
+func healthParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, petID, true
+}
+
+func recordID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "recordId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid record ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondHealthError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidHealthRecord):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrPetNotFound):
+		respondError(w, http.StatusNotFound, "Pet not found")
+	case errors.Is(err, services.ErrHealthRecordNotFound):
+		respondError(w, http.StatusNotFound, "Record not found")
+	case errors.Is(err, services.ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "Access denied")
+	case errors.Is(err, services.ErrPetReadOnly):
+		respondError(w, http.StatusConflict, "Memorial pets can't be changed")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
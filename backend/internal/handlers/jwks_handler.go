@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/joaosantos/pettime/pkg/jwt"
+)
+
+type JWKSHandler struct {
+	jwtManager *jwt.Manager
+}
+
+func NewJWKSHandler(jwtManager *jwt.Manager) *JWKSHandler {
+	return &JWKSHandler{jwtManager: jwtManager}
+}
+
+func (h *JWKSHandler) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondSuccess(w, h.jwtManager.JWKS())
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type LeaderboardHandler struct {
+	leaderboardService *services.LeaderboardService
+}
+
+func NewLeaderboardHandler(leaderboardService *services.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{leaderboardService: leaderboardService}
+}
+
+// Get returns a leaderboard's top pets and the user's own pets' ranks. The
+// period defaults to weekly and the scope to global.
+func (h *LeaderboardHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	query := models.LeaderboardQuery{
+		Metric: models.LeaderboardMetric(chi.URLParam(r, "metric")),
+		Period: models.LeaderboardPeriodWeekly,
+		Scope:  models.LeaderboardScopeGlobal,
+	}
+	if period := r.URL.Query().Get("period"); period != "" {
+		query.Period = models.LeaderboardPeriod(period)
+	}
+	if scope := r.URL.Query().Get("scope"); scope != "" {
+		query.Scope = models.LeaderboardScope(scope)
+	}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	board, err := h.leaderboardService.Get(r.Context(), userID, query)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidLeaderboard):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrNoLeaderboardArea):
+			respondError(w, http.StatusNotFound, "No area yet: walk with a route to join a local leaderboard")
+		case errors.Is(err, services.ErrUserNotFound):
+			respondError(w, http.StatusNotFound, "User not found")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to get leaderboard")
+		}
+		return
+	}
+
+	respondSuccess(w, board)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/media"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/services"
+	"github.com/joaosantos/pettime/internal/storage"
+)
+
+// multipartOverhead leaves room for boundaries and part headers on top of
+// the file itself.
+const multipartOverhead = 64 << 10
+
+type MediaHandler struct {
+	mediaService *services.MediaService
+}
+
+func NewMediaHandler(mediaService *services.MediaService) *MediaHandler {
+	return &MediaHandler{mediaService: mediaService}
+}
+
+// UploadPetAvatar accepts a multipart form with the image in the "avatar"
+// field.
+func (h *MediaHandler) UploadPetAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadBytes+multipartOverhead)
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "Image must be at most "+strconv.Itoa(media.MaxUploadBytes>>20)+" MB")
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Multipart field \"avatar\" is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadBytes+1))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read upload")
+		return
+	}
+	if len(data) > media.MaxUploadBytes {
+		respondError(w, http.StatusRequestEntityTooLarge, "Image must be at most "+strconv.Itoa(media.MaxUploadBytes>>20)+" MB")
+		return
+	}
+
+	pet, err := h.mediaService.UploadPetAvatar(r.Context(), userID, petID, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPetNotFound):
+			respondError(w, http.StatusNotFound, "Pet not found")
+		case errors.Is(err, services.ErrUnauthorized):
+			respondError(w, http.StatusForbidden, "Access denied")
+		case errors.Is(err, services.ErrPetReadOnly):
+			respondError(w, http.StatusConflict, "Memorial pets can't be changed")
+		case errors.Is(err, services.ErrUnsupportedImage):
+			respondError(w, http.StatusUnsupportedMediaType, "Image must be JPEG, PNG or GIF")
+		case errors.Is(err, services.ErrInvalidImage):
+			respondError(w, http.StatusBadRequest, "Invalid or oversized image")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to upload avatar")
+		}
+		return
+	}
+
+	respondSuccess(w, pet)
+}
+
+// Serve streams an uploaded file from the local blob store. Keys are
+// content-addressed so responses are cached indefinitely.
+func (h *MediaHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+
+	blob, err := h.mediaService.GetBlob(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return
+	}
+
+	etag := `"` + key + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", blob.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(blob.Data)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", etag)
+	if blob.CacheControl != "" {
+		w.Header().Set("Cache-Control", blob.CacheControl)
+	}
+	w.Write(blob.Data)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type MissionHandler struct {
+	missionService *services.MissionService
+}
+
+func NewMissionHandler(missionService *services.MissionService) *MissionHandler {
+	return &MissionHandler{missionService: missionService}
+}
+
+// List returns the user's current missions across their pets.
+func (h *MissionHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	missions, err := h.missionService.ListActive(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list missions")
+		return
+	}
+
+	if missions == nil {
+		missions = []*models.Mission{}
+	}
+
+	respondSuccess(w, missions)
+}
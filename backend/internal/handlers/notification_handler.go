@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+func (h *NotificationHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	devices, err := h.notificationService.ListDevices(r.Context(), userID, middleware.GetSessionID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list devices")
+		return
+	}
+
+	if devices == nil {
+		devices = []*models.DeviceToken{}
+	}
+
+	respondSuccess(w, devices)
+}
+
+// RegisterDevice stores the push token of the calling app. Apps call it on
+// every launch so the token stays fresh.
+func (h *NotificationHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var input models.RegisterDeviceInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	device, err := h.notificationService.RegisterDevice(r.Context(), userID, middleware.GetSessionID(r.Context()), input)
+	if err != nil {
+		respondNotificationError(w, err, "Failed to register device")
+		return
+	}
+
+	respondCreated(w, device)
+}
+
+func (h *NotificationHandler) UnregisterDevice(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	deviceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid device ID")
+		return
+	}
+
+	if err := h.notificationService.UnregisterDevice(r.Context(), userID, deviceID); err != nil {
+		respondNotificationError(w, err, "Failed to unregister device")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func respondNotificationError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidDevice):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrSessionRequired):
+		respondError(w, http.StatusUnauthorized, "Sign in again to register this device")
+	case errors.Is(err, services.ErrDeviceNotFound):
+		respondError(w, http.StatusNotFound, "Device not found")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
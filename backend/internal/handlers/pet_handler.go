@@ -3,6 +3,8 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -23,6 +25,7 @@ type CreatePetRequest struct {
 	PetTypeID string `json:"pet_type_id"`
 	Name      string `json:"name"`
 	Breed     string `json:"breed,omitempty"`
+	BreedID   string `json:"breed_id,omitempty"`
 	AvatarURL string `json:"avatar_url,omitempty"`
 	BirthDate string `json:"birth_date,omitempty"`
 }
@@ -30,10 +33,36 @@ type CreatePetRequest struct {
 type UpdatePetRequest struct {
 	Name      *string `json:"name,omitempty"`
 	Breed     *string `json:"breed,omitempty"`
+	BreedID   *string `json:"breed_id,omitempty"`
 	AvatarURL *string `json:"avatar_url,omitempty"`
 	BirthDate *string `json:"birth_date,omitempty"`
 }
 
+// parseBirthDate reads a birth date in YYYY-MM-DD form.
+func parseBirthDate(raw string) (*time.Time, error) {
+	date, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// respondPetInputError maps errors in pet details to 400s and reports
+// whether it handled err.
+func respondPetInputError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrInvalidPetType):
+		respondError(w, http.StatusBadRequest, "Invalid pet type")
+	case errors.Is(err, services.ErrInvalidBreed):
+		respondError(w, http.StatusBadRequest, "Invalid breed for this pet type")
+	case errors.Is(err, services.ErrFutureBirth):
+		respondError(w, http.StatusBadRequest, "Birth date can't be in the future")
+	default:
+		return false
+	}
+	return true
+}
+
 func (h *PetHandler) Create(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r.Context())
 	if userID == uuid.Nil {
@@ -60,14 +89,24 @@ func (h *PetHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if req.Breed != "" {
 		input.Breed = &req.Breed
 	}
+	if req.BreedID != "" {
+		input.BreedID = &req.BreedID
+	}
 	if req.AvatarURL != "" {
 		input.AvatarURL = &req.AvatarURL
 	}
+	if req.BirthDate != "" {
+		birthDate, err := parseBirthDate(req.BirthDate)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid birth date, expected YYYY-MM-DD")
+			return
+		}
+		input.BirthDate = birthDate
+	}
 
 	pet, err := h.petService.Create(r.Context(), userID, input)
 	if err != nil {
-		if errors.Is(err, services.ErrInvalidPetType) {
-			respondError(w, http.StatusBadRequest, "Invalid pet type")
+		if respondPetInputError(w, err) {
 			return
 		}
 		respondError(w, http.StatusInternalServerError, "Failed to create pet")
@@ -114,7 +153,17 @@ func (h *PetHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pets, err := h.petService.GetByUserID(r.Context(), userID)
+	var status *models.PetStatus
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		parsed := models.PetStatus(raw)
+		if !parsed.Valid() {
+			respondError(w, http.StatusBadRequest, "Status must be active, archived or memorial")
+			return
+		}
+		status = &parsed
+	}
+
+	pets, err := h.petService.GetByUserID(r.Context(), userID, status)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to list pets")
 		return
@@ -149,11 +198,22 @@ func (h *PetHandler) Update(w http.ResponseWriter, r *http.Request) {
 	input := models.UpdatePetInput{
 		Name:      req.Name,
 		Breed:     req.Breed,
+		BreedID:   req.BreedID,
 		AvatarURL: req.AvatarURL,
 	}
+	if req.BirthDate != nil {
+		input.BirthDate, err = parseBirthDate(*req.BirthDate)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid birth date, expected YYYY-MM-DD")
+			return
+		}
+	}
 
 	pet, err := h.petService.Update(r.Context(), userID, petID, input)
 	if err != nil {
+		if respondPetInputError(w, err) {
+			return
+		}
 		if errors.Is(err, services.ErrPetNotFound) {
 			respondError(w, http.StatusNotFound, "Pet not found")
 			return
@@ -162,6 +222,10 @@ func (h *PetHandler) Update(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusForbidden, "Access denied")
 			return
 		}
+		if errors.Is(err, services.ErrPetReadOnly) {
+			respondError(w, http.StatusConflict, "Memorial pets can't be changed")
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "Failed to update pet")
 		return
 	}
@@ -198,6 +262,146 @@ func (h *PetHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	respondNoContent(w)
 }
 
+func (h *PetHandler) ListDeleted(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	pets, err := h.petService.ListDeleted(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list deleted pets")
+		return
+	}
+
+	if pets == nil {
+		pets = []*models.Pet{}
+	}
+
+	respondSuccess(w, pets)
+}
+
+func (h *PetHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	pet, err := h.petService.Restore(r.Context(), userID, petID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPetNotFound):
+			respondError(w, http.StatusNotFound, "Pet not found or no longer restorable")
+		case errors.Is(err, services.ErrUnauthorized):
+			respondError(w, http.StatusForbidden, "Only the owner can restore a pet")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to restore pet")
+		}
+		return
+	}
+
+	respondSuccess(w, pet)
+}
+
+func (h *PetHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	var input models.UpdatePetStatusInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	pet, err := h.petService.UpdateStatus(r.Context(), userID, petID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidPetStatus):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrPetNotFound):
+			respondError(w, http.StatusNotFound, "Pet not found")
+		case errors.Is(err, services.ErrUnauthorized):
+			respondError(w, http.StatusForbidden, "Access denied")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to update pet status")
+		}
+		return
+	}
+
+	respondSuccess(w, pet)
+}
+
+// Timeline pages through a pet's life, newest first. Pass the occurred_at
+// of the last event as ?before= for the next page.
+func (h *PetHandler) Timeline(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	var before *time.Time
+	if raw := r.URL.Query().Get("before"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid before format")
+			return
+		}
+		before = &parsed
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	events, err := h.petService.Timeline(r.Context(), userID, petID, before, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPetNotFound):
+			respondError(w, http.StatusNotFound, "Pet not found")
+		case errors.Is(err, services.ErrUnauthorized):
+			respondError(w, http.StatusForbidden, "Access denied")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to get timeline")
+		}
+		return
+	}
+
+	if events == nil {
+		events = []*models.TimelineEvent{}
+	}
+
+	respondSuccess(w, events)
+}
+
 func (h *PetHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r.Context())
 	if userID == uuid.Nil {
@@ -236,3 +440,194 @@ func (h *PetHandler) ListPetTypes(w http.ResponseWriter, r *http.Request) {
 
 	respondSuccess(w, petTypes)
 }
+
+// SearchBreeds autocompletes breeds of a pet type from ?q=, e.g. "lab" or
+// "retr" for Labrador Retriever.
+func (h *PetHandler) SearchBreeds(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	breeds, err := h.petService.SearchBreeds(r.Context(), chi.URLParam(r, "id"), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPetType) {
+			respondError(w, http.StatusNotFound, "Pet type not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to search breeds")
+		return
+	}
+
+	if breeds == nil {
+		breeds = []*models.Breed{}
+	}
+	respondSuccess(w, breeds)
+}
+
+type TransferPetRequest struct {
+	Email string `json:"email"`
+}
+
+func (h *PetHandler) InitiateTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	var req TransferPetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Email == "" {
+		respondError(w, http.StatusBadRequest, "Recipient email is required")
+		return
+	}
+
+	transfer, err := h.petService.InitiateTransfer(r.Context(), userID, petID, models.CreateTransferInput{Email: req.Email})
+	if err != nil {
+		respondTransferError(w, err, "Failed to start transfer")
+		return
+	}
+
+	respondCreated(w, transfer)
+}
+
+func (h *PetHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	transfer, err := h.petService.GetPendingTransfer(r.Context(), userID, petID)
+	if err != nil {
+		respondTransferError(w, err, "Failed to get transfer")
+		return
+	}
+
+	respondSuccess(w, transfer)
+}
+
+func (h *PetHandler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	if err := h.petService.CancelTransfer(r.Context(), userID, petID); err != nil {
+		respondTransferError(w, err, "Failed to cancel transfer")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *PetHandler) ListIncomingTransfers(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	transfers, err := h.petService.ListIncomingTransfers(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list transfers")
+		return
+	}
+
+	if transfers == nil {
+		transfers = []*models.PetTransfer{}
+	}
+
+	respondSuccess(w, transfers)
+}
+
+func (h *PetHandler) AcceptTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	transferID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid transfer ID")
+		return
+	}
+
+	pet, err := h.petService.AcceptTransfer(r.Context(), userID, transferID)
+	if err != nil {
+		respondTransferError(w, err, "Failed to accept transfer")
+		return
+	}
+
+	respondSuccess(w, pet)
+}
+
+func (h *PetHandler) DeclineTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	transferID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid transfer ID")
+		return
+	}
+
+	if err := h.petService.DeclineTransfer(r.Context(), userID, transferID); err != nil {
+		respondTransferError(w, err, "Failed to decline transfer")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func respondTransferError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrPetNotFound):
+		respondError(w, http.StatusNotFound, "Pet not found")
+	case errors.Is(err, services.ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "Only the owner can transfer a pet")
+	case errors.Is(err, services.ErrPetReadOnly):
+		respondError(w, http.StatusConflict, "Memorial pets can't be transferred")
+	case errors.Is(err, services.ErrTransferNotFound):
+		respondError(w, http.StatusNotFound, "Transfer not found or expired")
+	case errors.Is(err, services.ErrTransferPending):
+		respondError(w, http.StatusConflict, "Pet already has a pending transfer")
+	case errors.Is(err, services.ErrTransferToSelf):
+		respondError(w, http.StatusBadRequest, "Cannot transfer a pet to yourself")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
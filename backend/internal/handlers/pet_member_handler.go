@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type PetMemberHandler struct {
+	memberService *services.PetMemberService
+}
+
+func NewPetMemberHandler(memberService *services.PetMemberService) *PetMemberHandler {
+	return &PetMemberHandler{memberService: memberService}
+}
+
+type CreateInvitationRequest struct {
+	Role  string `json:"role"`
+	Email string `json:"email,omitempty"`
+}
+
+type AcceptInvitationRequest struct {
+	Code string `json:"code"`
+}
+
+func (h *PetMemberHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	members, err := h.memberService.ListMembers(r.Context(), userID, petID)
+	if err != nil {
+		respondMemberError(w, err, "Failed to list members")
+		return
+	}
+
+	if members == nil {
+		members = []*models.PetMember{}
+	}
+
+	respondSuccess(w, members)
+}
+
+func (h *PetMemberHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.memberService.RemoveMember(r.Context(), userID, petID, memberID); err != nil {
+		respondMemberError(w, err, "Failed to remove member")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *PetMemberHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	input := models.CreateInvitationInput{Role: models.PetRole(req.Role)}
+	if req.Email != "" {
+		input.Email = &req.Email
+	}
+
+	invitation, err := h.memberService.CreateInvitation(r.Context(), userID, petID, input)
+	if err != nil {
+		respondMemberError(w, err, "Failed to create invitation")
+		return
+	}
+
+	respondCreated(w, invitation)
+}
+
+func (h *PetMemberHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	invitations, err := h.memberService.ListInvitations(r.Context(), userID, petID)
+	if err != nil {
+		respondMemberError(w, err, "Failed to list invitations")
+		return
+	}
+
+	if invitations == nil {
+		invitations = []*models.PetInvitation{}
+	}
+
+	respondSuccess(w, invitations)
+}
+
+func (h *PetMemberHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	if err := h.memberService.RevokeInvitation(r.Context(), userID, petID, invitationID); err != nil {
+		respondMemberError(w, err, "Failed to revoke invitation")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+func (h *PetMemberHandler) ListMyInvitations(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	invitations, err := h.memberService.ListMyInvitations(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list invitations")
+		return
+	}
+
+	if invitations == nil {
+		invitations = []*models.PetInvitation{}
+	}
+
+	respondSuccess(w, invitations)
+}
+
+func (h *PetMemberHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req AcceptInvitationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Code == "" {
+		respondError(w, http.StatusBadRequest, "Invitation code is required")
+		return
+	}
+
+	member, err := h.memberService.AcceptInvitation(r.Context(), userID, req.Code)
+	if err != nil {
+		respondMemberError(w, err, "Failed to accept invitation")
+		return
+	}
+
+	respondCreated(w, member)
+}
+
+func respondMemberError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrPetNotFound):
+		respondError(w, http.StatusNotFound, "Pet not found")
+	case errors.Is(err, services.ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "Access denied")
+	case errors.Is(err, services.ErrMemberNotFound):
+		respondError(w, http.StatusNotFound, "Member not found")
+	case errors.Is(err, services.ErrCannotRemoveOwner):
+		respondError(w, http.StatusConflict, "The owner cannot be removed")
+	case errors.Is(err, services.ErrInvalidRole):
+		respondError(w, http.StatusBadRequest, "Role must be co_owner or caretaker")
+	case errors.Is(err, services.ErrInvitationNotFound):
+		respondError(w, http.StatusNotFound, "Invitation not found or expired")
+	case errors.Is(err, services.ErrAlreadyMember):
+		respondError(w, http.StatusConflict, "Already a member of this pet")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type RecordHandler struct {
+	recordService *services.RecordService
+}
+
+func NewRecordHandler(recordService *services.RecordService) *RecordHandler {
+	return &RecordHandler{recordService: recordService}
+}
+
+// List returns the pet's personal records with the history of when each
+// was broken, optionally of one game type.
+func (h *RecordHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	var gameTypeID *string
+	if id := r.URL.Query().Get("game_type_id"); id != "" {
+		gameTypeID = &id
+	}
+
+	records, err := h.recordService.List(r.Context(), userID, petID, gameTypeID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPetNotFound):
+			respondError(w, http.StatusNotFound, "Pet not found")
+		case errors.Is(err, services.ErrUnauthorized):
+			respondError(w, http.StatusForbidden, "Access denied")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to get records")
+		}
+		return
+	}
+
+	respondSuccess(w, records)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+// maxReminderOccurrences caps the upcoming occurrences preview.
+const maxReminderOccurrences = 50
+
+type ReminderHandler struct {
+	reminderService *services.ReminderService
+}
+
+func NewReminderHandler(reminderService *services.ReminderService) *ReminderHandler {
+	return &ReminderHandler{reminderService: reminderService}
+}
+
+func (h *ReminderHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var petID *uuid.UUID
+	if raw := r.URL.Query().Get("pet_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid pet ID")
+			return
+		}
+		petID = &parsed
+	}
+
+	reminders, err := h.reminderService.List(r.Context(), userID, petID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list reminders")
+		return
+	}
+
+	if reminders == nil {
+		reminders = []*models.Reminder{}
+	}
+
+	respondSuccess(w, reminders)
+}
+
+func (h *ReminderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var input models.ReminderInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	reminder, err := h.reminderService.Create(r.Context(), userID, input)
+	if err != nil {
+		respondReminderError(w, err, "Failed to create reminder")
+		return
+	}
+
+	respondCreated(w, reminder)
+}
+
+func (h *ReminderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userID, reminderID, ok := reminderParams(w, r)
+	if !ok {
+		return
+	}
+
+	reminder, err := h.reminderService.GetByID(r.Context(), userID, reminderID)
+	if err != nil {
+		respondReminderError(w, err, "Failed to get reminder")
+		return
+	}
+
+	respondSuccess(w, reminder)
+}
+
+func (h *ReminderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, reminderID, ok := reminderParams(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.ReminderInput
+	if err := decodeJSON(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	reminder, err := h.reminderService.Update(r.Context(), userID, reminderID, input)
+	if err != nil {
+		respondReminderError(w, err, "Failed to update reminder")
+		return
+	}
+
+	respondSuccess(w, reminder)
+}
+
+func (h *ReminderHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, reminderID, ok := reminderParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.reminderService.Delete(r.Context(), userID, reminderID); err != nil {
+		respondReminderError(w, err, "Failed to delete reminder")
+		return
+	}
+
+	respondNoContent(w)
+}
+
+// Occurrences previews upcoming occurrences, ?count= of them (default 5).
+func (h *ReminderHandler) Occurrences(w http.ResponseWriter, r *http.Request) {
+	userID, reminderID, ok := reminderParams(w, r)
+	if !ok {
+		return
+	}
+
+	count := 5
+	if raw := r.URL.Query().Get("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxReminderOccurrences {
+			respondError(w, http.StatusBadRequest, "Invalid count")
+			return
+		}
+		count = parsed
+	}
+
+	occurrences, err := h.reminderService.Upcoming(r.Context(), userID, reminderID, count)
+	if err != nil {
+		respondReminderError(w, err, "Failed to list occurrences")
+		return
+	}
+
+	respondSuccess(w, map[string][]time.Time{"occurrences": occurrences})
+}
+
+func (h *ReminderHandler) MarkDone(w http.ResponseWriter, r *http.Request) {
+	userID, reminderID, ok := reminderParams(w, r)
+	if !ok {
+		return
+	}
+
+	completion, err := h.reminderService.MarkDone(r.Context(), userID, reminderID)
+	if err != nil {
+		respondReminderError(w, err, "Failed to complete reminder")
+		return
+	}
+
+	respondSuccess(w, completion)
+}
+
+func reminderParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	reminderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid reminder ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, reminderID, true
+}
+
+func respondReminderError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidReminder):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrInvalidGameType):
+		respondError(w, http.StatusBadRequest, "Invalid game type for this pet")
+	case errors.Is(err, services.ErrReminderNotFound):
+		respondError(w, http.StatusNotFound, "Reminder not found")
+	case errors.Is(err, services.ErrPetNotFound):
+		respondError(w, http.StatusNotFound, "Pet not found")
+	case errors.Is(err, services.ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "Access denied")
+	case errors.Is(err, services.ErrPetReadOnly):
+		respondError(w, http.StatusConflict, "Archived and memorial pets can't log activities")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/middleware"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/services"
+)
+
+type SkillHandler struct {
+	skillService *services.SkillService
+}
+
+func NewSkillHandler(skillService *services.SkillService) *SkillHandler {
+	return &SkillHandler{skillService: skillService}
+}
+
+// GetTree returns the skill tree with the pet's points and unlocked skills.
+func (h *SkillHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	tree, err := h.skillService.GetTree(r.Context(), userID, petID)
+	if err != nil {
+		respondSkillError(w, err, "Failed to get skill tree")
+		return
+	}
+
+	respondSuccess(w, tree)
+}
+
+// Unlock spends skill points on a skill and returns the updated tree.
+func (h *SkillHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	petID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	tree, err := h.skillService.Unlock(r.Context(), userID, petID, chi.URLParam(r, "skillId"))
+	if err != nil {
+		respondSkillError(w, err, "Failed to unlock skill")
+		return
+	}
+
+	respondSuccess(w, tree)
+}
+
+func respondSkillError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrSkillNotFound):
+		respondError(w, http.StatusNotFound, "Skill not found")
+	case errors.Is(err, models.ErrSkillUnlocked),
+		errors.Is(err, models.ErrSkillLevelTooLow),
+		errors.Is(err, models.ErrSkillPrerequisites),
+		errors.Is(err, models.ErrNotEnoughSkillPoints):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrPetNotFound):
+		respondError(w, http.StatusNotFound, "Pet not found")
+	case errors.Is(err, services.ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "Access denied")
+	case errors.Is(err, services.ErrPetReadOnly):
+		respondError(w, http.StatusConflict, "Memorial pets can't be changed")
+	default:
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
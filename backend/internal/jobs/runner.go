@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Func is a unit of background work. It is called once per interval.
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      Func
+}
+
+// Runner runs periodic background jobs until its context is cancelled. Each
+// job runs in its own goroutine and never overlaps with itself.
+type Runner struct {
+	jobs []job
+	wg   sync.WaitGroup
+}
+
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+func (r *Runner) Every(interval time.Duration, name string, run Func) {
+	r.jobs = append(r.jobs, job{name: name, interval: interval, run: run})
+}
+
+func (r *Runner) Start(ctx context.Context) {
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go func(j job) {
+			defer r.wg.Done()
+			r.loop(ctx, j)
+		}(j)
+	}
+}
+
+// Wait blocks until every job has returned after cancellation.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Job %s failed: %v", j.name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package media validates and normalises uploaded images.
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// Registered decoders for accepted upload formats
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	// MaxUploadBytes caps the size of an uploaded file.
+	MaxUploadBytes = 10 << 20
+	// maxPixels guards against decompression bombs: small files that decode
+	// to enormous bitmaps.
+	maxPixels   = 40_000_000
+	jpegQuality = 85
+
+	// ContentTypeJPEG is the content type of every processed variant.
+	ContentTypeJPEG = "image/jpeg"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrImageTooLarge   = errors.New("image dimensions too large")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+// AllowedContentTypes are the upload formats accepted, keyed by sniffed
+// content type.
+var AllowedContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Variant is one output size. Avatars are cropped square.
+type Variant struct {
+	Name string
+	Size int
+}
+
+// AvatarVariants are the sizes generated for every avatar upload.
+var AvatarVariants = []Variant{
+	{Name: "small", Size: 96},
+	{Name: "medium", Size: 256},
+	{Name: "large", Size: 512},
+}
+
+// Image is a processed upload.
+type Image struct {
+	// Hash identifies the source content and is used to build
+	// content-addressed storage keys.
+	Hash     string
+	Variants map[string][]byte
+}
+
+// ProcessAvatar validates an upload, applies its EXIF orientation, crops it
+// square and re-encodes each variant as JPEG. Re-encoding drops all source
+// metadata, EXIF GPS coordinates included.
+func ProcessAvatar(data []byte, variants []Variant) (*Image, error) {
+	if !AllowedContentTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	square := cropSquare(flatten(img))
+
+	sum := sha256.Sum256(data)
+	result := &Image{
+		Hash:     hex.EncodeToString(sum[:16]),
+		Variants: make(map[string][]byte, len(variants)),
+	}
+
+	for _, v := range variants {
+		size := v.Size
+		if side := square.Bounds().Dx(); side < size {
+			// Never upscale small sources
+			size = side
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size, size), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		result.Variants[v.Name] = buf.Bytes()
+	}
+
+	return result, nil
+}
+
+// flatten draws img onto a white background, since JPEG has no alpha.
+func flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+func cropSquare(img *image.RGBA) *image.RGBA {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return img.SubImage(image.Rect(x, y, x+side, y+side)).(*image.RGBA)
+}
+
+// resize scales src to w x h by averaging every source pixel that falls
+// into each destination pixel. It is meant for downscaling.
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for dy := 0; dy < h; dy++ {
+		y0 := dy * sh / h
+		y1 := (dy + 1) * sh / h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for dx := 0; dx < w; dx++ {
+			x0 := dx * sw / w
+			x1 := (dx + 1) * sw / w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint32
+			for y := y0; y < y1; y++ {
+				offset := src.PixOffset(b.Min.X+x0, b.Min.Y+y)
+				for x := x0; x < x1; x++ {
+					r += uint32(src.Pix[offset])
+					g += uint32(src.Pix[offset+1])
+					bl += uint32(src.Pix[offset+2])
+					a += uint32(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,150 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func solidImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// exifSegment builds an APP1 segment with an orientation tag and some fake
+// GPS bytes.
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("II*\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, []byte("GPS 38.7223N 9.1393W")...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	data := buf.Bytes()
+	// Insert the APP1 segment right after SOI
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, data[2:]...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	data := jpegWithExif(t, solidImage(4, 4, color.White), 6)
+	if got := jpegOrientation(data); got != 6 {
+		t.Errorf("jpegOrientation() = %d, want 6", got)
+	}
+
+	var plain bytes.Buffer
+	jpeg.Encode(&plain, solidImage(4, 4, color.White), nil)
+	if got := jpegOrientation(plain.Bytes()); got != 1 {
+		t.Errorf("jpegOrientation() without EXIF = %d, want 1", got)
+	}
+}
+
+func TestApplyOrientation_Rotate90(t *testing.T) {
+	// Left half red, right half blue
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x < 2 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+
+	rotated := applyOrientation(img, 6)
+	if b := rotated.Bounds(); b.Dx() != 2 || b.Dy() != 4 {
+		t.Fatalf("rotated bounds = %v, want 2x4", b)
+	}
+	// Rotating clockwise puts the left edge on top
+	if r, _, _, _ := rotated.At(0, 0).RGBA(); r>>8 != 255 {
+		t.Errorf("top pixel = %v, want red", rotated.At(0, 0))
+	}
+	if _, _, b, _ := rotated.At(0, 3).RGBA(); b>>8 != 255 {
+		t.Errorf("bottom pixel = %v, want blue", rotated.At(0, 3))
+	}
+}
+
+func TestProcessAvatar_StripsMetadata(t *testing.T) {
+	data := jpegWithExif(t, solidImage(300, 200, color.RGBA{10, 200, 30, 255}), 6)
+
+	result, err := ProcessAvatar(data, AvatarVariants)
+	if err != nil {
+		t.Fatalf("ProcessAvatar() error = %v", err)
+	}
+
+	for name, out := range result.Variants {
+		if bytes.Contains(out, []byte("Exif")) || bytes.Contains(out, []byte("GPS")) {
+			t.Errorf("variant %s still contains EXIF data", name)
+		}
+		if jpegOrientation(out) != 1 {
+			t.Errorf("variant %s keeps orientation tag", name)
+		}
+	}
+}
+
+func TestProcessAvatar_Sizes(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, solidImage(600, 400, color.RGBA{0, 0, 0, 0}))
+
+	result, err := ProcessAvatar(buf.Bytes(), AvatarVariants)
+	if err != nil {
+		t.Fatalf("ProcessAvatar() error = %v", err)
+	}
+	if result.Hash == "" {
+		t.Error("Hash is empty")
+	}
+
+	want := map[string]int{"small": 96, "medium": 256, "large": 400}
+	for name, size := range want {
+		img, err := jpeg.Decode(bytes.NewReader(result.Variants[name]))
+		if err != nil {
+			t.Fatalf("variant %s is not a JPEG: %v", name, err)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("variant %s = %dx%d, want %dx%d", name, b.Dx(), b.Dy(), size, size)
+		}
+		// Transparent source is flattened onto white
+		if r, g, b, _ := img.At(10, 10).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+			t.Errorf("variant %s pixel = %v, want white", name, img.At(10, 10))
+		}
+	}
+}
+
+func TestProcessAvatar_Rejects(t *testing.T) {
+	if _, err := ProcessAvatar([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"), AvatarVariants); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("SVG upload: err = %v, want %v", err, ErrUnsupportedType)
+	}
+
+	truncated := []byte("\x89PNG\r\n\x1a\n\x00\x00")
+	if _, err := ProcessAvatar(truncated, AvatarVariants); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("truncated PNG: err = %v, want %v", err, ErrInvalidImage)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/joaosantos/pettime/pkg/jwt"
@@ -14,6 +15,8 @@ type contextKey string
 const (
 	userIDKey    contextKey = "userID"
 	userEmailKey contextKey = "userEmail"
+	authTimeKey  contextKey = "authTime"
+	sessionIDKey contextKey = "sessionID"
 )
 
 type AuthMiddleware struct {
@@ -47,11 +50,31 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 
 		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, userEmailKey, claims.Email)
+		ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
+		if claims.AuthTime != nil {
+			ctx = context.WithValue(ctx, authTimeKey, claims.AuthTime.Time)
+		}
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// RequireRecentLogin rejects requests whose credentials were entered longer
+// than maxAge ago, so sensitive changes need the user to sign in again.
+func (m *AuthMiddleware) RequireRecentLogin(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authTime := GetAuthTime(r.Context())
+			if authTime.IsZero() || time.Since(authTime) > maxAge {
+				http.Error(w, `{"error":"Unauthorized","message":"Recent login required"}`, http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func GetUserID(ctx context.Context) uuid.UUID {
 	userID, ok := ctx.Value(userIDKey).(uuid.UUID)
 	if !ok {
@@ -67,3 +90,21 @@ func GetUserEmail(ctx context.Context) string {
 	}
 	return email
 }
+
+// GetSessionID returns the login session of the request, or uuid.Nil for
+// tokens issued before sessions were tracked.
+func GetSessionID(ctx context.Context) uuid.UUID {
+	sessionID, ok := ctx.Value(sessionIDKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil
+	}
+	return sessionID
+}
+
+func GetAuthTime(ctx context.Context) time.Time {
+	authTime, ok := ctx.Value(authTimeKey).(time.Time)
+	if !ok {
+		return time.Time{}
+	}
+	return authTime
+}
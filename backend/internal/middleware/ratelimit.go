@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/ratelimit"
+)
+
+// KeyFunc picks the bucket a request counts against. An empty key skips
+// the limit.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP limits per client address: the socket peer, or the client behind
+// a trusted proxy once RealIP has run.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// KeyByUser limits per authenticated user and must run after Authenticate.
+func KeyByUser(r *http.Request) string {
+	userID := GetUserID(r.Context())
+	if userID == uuid.Nil {
+		return ""
+	}
+	return "user:" + userID.String()
+}
+
+type RateLimiter struct {
+	store ratelimit.Store
+}
+
+func NewRateLimiter(store ratelimit.Store) *RateLimiter {
+	return &RateLimiter{store: store}
+}
+
+// Limit applies policy to every request, bucketed by key. Throttled
+// requests get 429 with Retry-After; all responses carry the RateLimit-*
+// headers.
+func (l *RateLimiter) Limit(policy ratelimit.Policy, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := l.store.Take(r.Context(), policy.Name+":"+k, policy, time.Now())
+			if err != nil {
+				// Fail open: an unavailable limiter must not take the API down.
+				log.Printf("Rate limiter error: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			SetRateLimitHeaders(w, result)
+			if !result.Allowed {
+				RespondTooManyRequests(w, result.RetryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func SetRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+}
+
+func RespondTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(retryAfter.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write([]byte(`{"error":"Too Many Requests","message":"Rate limit exceeded"}`))
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP sets RemoteAddr to the client's address when the request came
+// through one of the trusted proxies. Forwarded headers from anyone else
+// are ignored, since clients can set them to whatever they like; without
+// trusted proxies RemoteAddr stays the socket peer.
+//
+// X-Forwarded-For is read from the right, skipping trusted proxies, so
+// addresses a client prepends itself are never used.
+func RealIP(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if client, ok := forwardedFor(r, trustedProxies); ok {
+				r.RemoteAddr = client.String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedFor(r *http.Request, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	peer, ok := remoteAddr(r.RemoteAddr)
+	if !ok || !trusted(peer, trustedProxies) {
+		return netip.Addr{}, false
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		if !trusted(addr.Unmap(), trustedProxies) {
+			return addr.Unmap(), true
+		}
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func remoteAddr(remoteAddr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func trusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
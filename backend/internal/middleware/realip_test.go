@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		wantKeyByIP  string
+	}{
+		{"direct client spoofing headers", "203.0.113.7:51000", "198.51.100.1", "198.51.100.2", "ip:203.0.113.7"},
+		{"behind a trusted proxy", "10.0.0.5:443", "198.51.100.1", "", "ip:198.51.100.1"},
+		{"client prepends a fake hop", "10.0.0.5:443", "1.2.3.4, 198.51.100.1", "", "ip:198.51.100.1"},
+		{"through two trusted proxies", "10.0.0.5:443", "198.51.100.1, 10.0.0.9", "", "ip:198.51.100.1"},
+		{"trusted proxy with X-Real-IP", "10.0.0.5:443", "", "198.51.100.3", "ip:198.51.100.3"},
+		{"trusted proxy without headers", "10.0.0.5:443", "", "", "ip:10.0.0.5"},
+	}
+	for _, tt := range tests {
+		var got string
+		handler := RealIP(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = KeyByIP(r)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-IP", tt.realIP)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got != tt.wantKeyByIP {
+			t.Errorf("%s: KeyByIP() = %s, want %s", tt.name, got, tt.wantKeyByIP)
+		}
+	}
+}
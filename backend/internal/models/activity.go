@@ -13,6 +13,9 @@ type GameType struct {
 	Description       *string         `json:"description,omitempty"`
 	Icon              *string         `json:"icon,omitempty"`
 	XPConfig          json.RawMessage `json:"xp_config,omitempty"`
+	// ThrowDetection is set for game types that detect throws from the
+	// phone's accelerometer
+	ThrowDetection    *ThrowDetectionConfig `json:"throw_detection,omitempty"`
 	SupportedPetTypes []string        `json:"supported_pet_types"`
 	Enabled           bool            `json:"enabled"`
 }
@@ -22,6 +25,7 @@ type Activity struct {
 	PetID           uuid.UUID       `json:"pet_id"`
 	GameTypeID      string          `json:"game_type_id"`
 	GameType        *GameType       `json:"game_type,omitempty"`
+	PerformedBy     *uuid.UUID      `json:"performed_by,omitempty"`
 	StartedAt       time.Time       `json:"started_at"`
 	EndedAt         *time.Time      `json:"ended_at,omitempty"`
 	DurationSeconds *int            `json:"duration_seconds,omitempty"`
@@ -30,6 +34,12 @@ type Activity struct {
 	ClientID        *uuid.UUID      `json:"client_id,omitempty"`
 	SyncedAt        *time.Time      `json:"synced_at,omitempty"`
 	CreatedAt       time.Time       `json:"created_at"`
+	// FlagReason is set when the activity looks implausible. Flagged
+	// activities don't count towards challenges.
+	FlagReason *ActivityFlag `json:"flag_reason,omitempty"`
+	// NewRecords are the personal records the activity broke, in the
+	// response that finished it.
+	NewRecords []*RecordBreak `json:"new_records,omitempty"`
 }
 
 type CreateActivityInput struct {
@@ -57,6 +67,8 @@ type SyncActivityInput struct {
 
 type WalkGameData struct {
 	DistanceMeters       float64     `json:"distance_meters"`
+	// Route points are [longitude, latitude], as in GeoJSON, recorded at a
+	// steady interval
 	Route                [][]float64 `json:"route,omitempty"`
 	AvgSpeedKmh          float64     `json:"avg_speed_kmh,omitempty"`
 	NewZonesDiscovered   []string    `json:"new_zones_discovered,omitempty"`
@@ -69,6 +81,16 @@ type FetchGameData struct {
 	SuccessRate          float64 `json:"success_rate"`
 	MaxCombo             int     `json:"max_combo"`
 	FrenzyModeActivated  bool    `json:"frenzy_mode_activated"`
+	// Set from the phone's accelerometer when it was uploaded during the
+	// activity, overriding the app's own counts
+	ThrowsDetected       bool         `json:"throws_detected,omitempty"`
+	ThrowTimes           []time.Time  `json:"throw_times,omitempty"`
+	Combos               []FetchCombo `json:"combos,omitempty"`
+	// Events is the session's log as sent by the app. When present, the
+	// server scores it, replacing the counts above
+	Events               []FetchEvent        `json:"events,omitempty"`
+	ThrowLog             []FetchThrow        `json:"throw_log,omitempty"`
+	FrenzyWindows        []FetchFrenzyWindow `json:"frenzy_windows,omitempty"`
 }
 
 type ActivityFilter struct {
@@ -78,4 +100,33 @@ type ActivityFilter struct {
 	EndDate    *time.Time
 	Limit      int
 	Offset     int
+
+	// MemberUserID limits results to pets the user is a member of
+	MemberUserID *uuid.UUID
+}
+
+// ActivityFlag says why an activity looks implausible.
+type ActivityFlag string
+
+const (
+	ActivityFlagTooLong       ActivityFlag = "too_long"
+	ActivityFlagTooFast       ActivityFlag = "too_fast"
+	ActivityFlagTooManyThrows ActivityFlag = "too_many_throws"
+	ActivityFlagTooManyRounds ActivityFlag = "too_many_rounds"
+)
+
+// maxActivityDuration is the longest plausible single activity.
+const maxActivityDuration = 12 * time.Hour
+
+// CheckActivity returns why a finished activity looks implausible, or nil
+// if it looks fine. Each game checks its own game data.
+func CheckActivity(a *Activity) *ActivityFlag {
+	if a.DurationSeconds == nil {
+		return nil
+	}
+	if time.Duration(*a.DurationSeconds)*time.Second > maxActivityDuration {
+		flag := ActivityFlagTooLong
+		return &flag
+	}
+	return GameFor(a.GameTypeID).Check(a)
 }
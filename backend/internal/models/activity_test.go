@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCheckActivity(t *testing.T) {
+	seconds := func(s int) *int { return &s }
+
+	tests := []struct {
+		name     string
+		activity Activity
+		want     ActivityFlag
+	}{
+		{"unfinished", Activity{GameTypeID: "walk"}, ""},
+		{"normal walk", Activity{GameTypeID: "walk", DurationSeconds: seconds(1800), GameData: json.RawMessage(`{"distance_meters": 2500}`)}, ""},
+		{"walk without distance", Activity{GameTypeID: "walk", DurationSeconds: seconds(1800)}, ""},
+		{"walk at driving speed", Activity{GameTypeID: "walk", DurationSeconds: seconds(600), GameData: json.RawMessage(`{"distance_meters": 8000}`)}, ActivityFlagTooFast},
+		{"day-long activity", Activity{GameTypeID: "walk", DurationSeconds: seconds(13 * 3600)}, ActivityFlagTooLong},
+		{"normal fetch", Activity{GameTypeID: "fetch", DurationSeconds: seconds(600), GameData: json.RawMessage(`{"throws": 40}`)}, ""},
+		{"fetch too fast", Activity{GameTypeID: "fetch", DurationSeconds: seconds(60), GameData: json.RawMessage(`{"throws": 100}`)}, ActivityFlagTooManyThrows},
+	}
+	for _, tt := range tests {
+		got := CheckActivity(&tt.activity)
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("%s: CheckActivity() = %s, want nil", tt.name, *got)
+		case tt.want != "" && (got == nil || *got != tt.want):
+			t.Errorf("%s: CheckActivity() = %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+type SizeClass string
+
+const (
+	SizeToy    SizeClass = "toy"
+	SizeSmall  SizeClass = "small"
+	SizeMedium SizeClass = "medium"
+	SizeLarge  SizeClass = "large"
+	SizeGiant  SizeClass = "giant"
+)
+
+type EnergyLevel string
+
+const (
+	EnergyLow      EnergyLevel = "low"
+	EnergyModerate EnergyLevel = "moderate"
+	EnergyHigh     EnergyLevel = "high"
+	EnergyVeryHigh EnergyLevel = "very_high"
+)
+
+// Breed is an entry of the breed catalog of a pet type.
+type Breed struct {
+	ID                   string      `json:"id"`
+	PetTypeID            string      `json:"pet_type_id"`
+	Name                 string      `json:"name"`
+	SizeClass            SizeClass   `json:"size_class"`
+	EnergyLevel          EnergyLevel `json:"energy_level"`
+	DailyExerciseMinutes int         `json:"daily_exercise_minutes"`
+	AdultWeightMinKg     *float64    `json:"adult_weight_min_kg,omitempty"`
+	AdultWeightMaxKg     *float64    `json:"adult_weight_max_kg,omitempty"`
+	SeniorAgeYears       *int        `json:"senior_age_years,omitempty"`
+}
+
+// LifeStage is adult or senior, or the pet type's young stage, e.g. puppy.
+type LifeStage string
+
+const (
+	LifeStageAdult  LifeStage = "adult"
+	LifeStageSenior LifeStage = "senior"
+)
+
+// PetAge is a pet's age in whole years and the months since its last
+// birthday.
+type PetAge struct {
+	Years  int `json:"years"`
+	Months int `json:"months"`
+}
+
+func (a PetAge) TotalMonths() int {
+	return a.Years*12 + a.Months
+}
+
+// DailyTarget is how much exercise a pet should get per day.
+type DailyTarget struct {
+	ExerciseMinutes int `json:"exercise_minutes"`
+}
+
+const (
+	// minExerciseMinutes is the smallest daily target.
+	minExerciseMinutes = 10
+	// seniorExerciseFactor scales the target down for senior pets.
+	seniorExerciseFactor = 0.75
+	// overweightFactor scales the target up for pets noticeably above
+	// their breed's adult weight range.
+	overweightFactor = 1.2
+)
+
+// CalculateAge returns the age on now of a pet born on birthDate.
+func CalculateAge(birthDate, now time.Time) PetAge {
+	months := (now.Year()-birthDate.Year())*12 + int(now.Month()-birthDate.Month())
+	if now.Day() < birthDate.Day() {
+		months--
+	}
+	if months < 0 {
+		months = 0
+	}
+	return PetAge{Years: months / 12, Months: months % 12}
+}
+
+// CalculateLifeStage places a pet of a type with the given config, breed
+// and age in its life. The breed may be nil.
+func CalculateLifeStage(config PetTypeConfig, breed *Breed, age PetAge) LifeStage {
+	size := SizeMedium
+	if breed != nil {
+		size = breed.SizeClass
+	}
+
+	if age.TotalMonths() < config.adultAtMonths(size) {
+		return config.YoungStage
+	}
+
+	seniorAt := config.seniorAtYears(size)
+	if breed != nil && breed.SeniorAgeYears != nil {
+		seniorAt = *breed.SeniorAgeYears
+	}
+	if age.Years >= seniorAt {
+		return LifeStageSenior
+	}
+
+	return LifeStageAdult
+}
+
+// CalculateDailyTarget derives the daily exercise target from the breed's
+// recommendation, adjusted for age and weight. Young pets get five minutes
+// per month of age twice a day, seniors a bit less, and pets well above
+// their breed's weight range a bit more. The breed, age and weight may be
+// unknown.
+func CalculateDailyTarget(config PetTypeConfig, breed *Breed, age *PetAge, weightKg *float64) DailyTarget {
+	minutes := float64(config.DailyExerciseMinutes)
+	if breed != nil && breed.DailyExerciseMinutes > 0 {
+		minutes = float64(breed.DailyExerciseMinutes)
+	}
+
+	if age != nil {
+		switch CalculateLifeStage(config, breed, *age) {
+		case config.YoungStage:
+			minutes = math.Min(minutes, float64(10*max(age.TotalMonths(), 1)))
+		case LifeStageSenior:
+			minutes *= seniorExerciseFactor
+		}
+	}
+
+	if weightKg != nil && breed != nil && breed.AdultWeightMaxKg != nil && *weightKg > *breed.AdultWeightMaxKg*1.1 {
+		minutes *= overweightFactor
+	}
+
+	// Round to 5 minutes so targets read naturally
+	rounded := int(math.Round(minutes/5)) * 5
+	return DailyTarget{ExerciseMinutes: max(rounded, minExerciseMinutes)}
+}
+
+// TargetPercent is how much of a daily target the given minutes cover.
+func TargetPercent(minutes int, target DailyTarget) int {
+	if target.ExerciseMinutes <= 0 {
+		return 0
+	}
+	return minutes * 100 / target.ExerciseMinutes
+}
+
+// Derive fills in the pet's age, life stage, daily target and level title
+// from its type, birth date, breed, latest weight and level.
+func (p *Pet) Derive(now time.Time) {
+	config := p.PetType.Settings()
+	if p.BirthDate != nil {
+		age := CalculateAge(*p.BirthDate, now)
+		p.Age = &age
+		p.LifeStage = CalculateLifeStage(config, p.BreedDetails, age)
+	}
+
+	target := CalculateDailyTarget(config, p.BreedDetails, p.Age, p.LatestWeightKg)
+	p.DailyTarget = &target
+	p.LevelTitle = config.LevelTitle(p.Level)
+}
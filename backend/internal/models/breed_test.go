@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateAge(t *testing.T) {
+	birth := time.Date(2020, 5, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want PetAge
+	}{
+		{"day before birthday", time.Date(2023, 5, 14, 0, 0, 0, 0, time.UTC), PetAge{Years: 2, Months: 11}},
+		{"on birthday", time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC), PetAge{Years: 3}},
+		{"months after birthday", time.Date(2023, 8, 20, 0, 0, 0, 0, time.UTC), PetAge{Years: 3, Months: 3}},
+		{"not born yet", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), PetAge{}},
+	}
+	for _, tt := range tests {
+		if got := CalculateAge(birth, tt.now); got != tt.want {
+			t.Errorf("%s: CalculateAge() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateLifeStage(t *testing.T) {
+	dog, cat := testDogConfig(t), testCatConfig(t)
+	giant := &Breed{SizeClass: SizeGiant}
+	toy := &Breed{SizeClass: SizeToy}
+
+	tests := []struct {
+		name   string
+		config PetTypeConfig
+		breed  *Breed
+		age    PetAge
+		want   LifeStage
+	}{
+		{"young dog", dog, nil, PetAge{Months: 6}, "puppy"},
+		{"young cat", cat, nil, PetAge{Months: 6}, "kitten"},
+		{"adult dog", dog, nil, PetAge{Years: 3}, LifeStageAdult},
+		{"giant breeds grow longer", dog, giant, PetAge{Years: 1, Months: 6}, "puppy"},
+		{"giant breeds age sooner", dog, giant, PetAge{Years: 6}, LifeStageSenior},
+		{"toy breeds age later", dog, toy, PetAge{Years: 10}, LifeStageAdult},
+		{"senior cat", cat, nil, PetAge{Years: 12}, LifeStageSenior},
+	}
+	for _, tt := range tests {
+		if got := CalculateLifeStage(tt.config, tt.breed, tt.age); got != tt.want {
+			t.Errorf("%s: CalculateLifeStage() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateDailyTarget(t *testing.T) {
+	dog, cat := testDogConfig(t), testCatConfig(t)
+	maxKg := 36.0
+	senior := 8
+	labrador := &Breed{SizeClass: SizeLarge, DailyExerciseMinutes: 90, AdultWeightMaxKg: &maxKg, SeniorAgeYears: &senior}
+	heavy, healthy := 42.0, 30.0
+
+	tests := []struct {
+		name     string
+		config   PetTypeConfig
+		breed    *Breed
+		age      *PetAge
+		weightKg *float64
+		want     int
+	}{
+		{"unknown dog", dog, nil, nil, nil, 60},
+		{"unknown cat", cat, nil, nil, nil, 20},
+		{"adult breed", dog, labrador, &PetAge{Years: 4}, &healthy, 90},
+		{"puppy", dog, labrador, &PetAge{Months: 4}, nil, 40},
+		{"senior", dog, labrador, &PetAge{Years: 9}, nil, 70},
+		{"overweight", dog, labrador, &PetAge{Years: 4}, &heavy, 110},
+		{"newborn gets the minimum", dog, labrador, &PetAge{}, nil, 10},
+	}
+	for _, tt := range tests {
+		if got := CalculateDailyTarget(tt.config, tt.breed, tt.age, tt.weightKg); got.ExerciseMinutes != tt.want {
+			t.Errorf("%s: CalculateDailyTarget() = %d, want %d", tt.name, got.ExerciseMinutes, tt.want)
+		}
+	}
+}
+
+func TestTargetPercent(t *testing.T) {
+	if got := TargetPercent(45, DailyTarget{ExerciseMinutes: 60}); got != 75 {
+		t.Errorf("TargetPercent() = %d, want 75", got)
+	}
+	if got := TargetPercent(45, DailyTarget{}); got != 0 {
+		t.Errorf("TargetPercent() with no target = %d, want 0", got)
+	}
+}
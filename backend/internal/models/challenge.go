@@ -0,0 +1,288 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidChallengeTemplate = errors.New("invalid challenge template")
+
+// ChallengeMetric is what a challenge measures per activity.
+type ChallengeMetric string
+
+const (
+	ChallengeMetricDurationMinutes ChallengeMetric = "duration_minutes"
+	ChallengeMetricDistanceMeters  ChallengeMetric = "distance_meters"
+	ChallengeMetricFetchThrows     ChallengeMetric = "fetch_throws"
+	ChallengeMetricActivities      ChallengeMetric = "activities"
+)
+
+// ChallengeAggregation combines a participant's activity metrics into
+// their score.
+type ChallengeAggregation string
+
+const (
+	ChallengeAggregationSum   ChallengeAggregation = "sum"
+	ChallengeAggregationCount ChallengeAggregation = "count"
+	ChallengeAggregationMax   ChallengeAggregation = "max"
+)
+
+// ChallengePeriod is the calendar span a challenge runs for.
+type ChallengePeriod string
+
+const (
+	ChallengePeriodDay  ChallengePeriod = "day"
+	ChallengePeriodWeek ChallengePeriod = "week"
+)
+
+// ChallengeFilter narrows the activities that count. Hours are local to
+// the participant: BeforeHour 9 counts activities started before 9am
+// wherever they live.
+type ChallengeFilter struct {
+	GameTypeID         string `json:"game_type_id,omitempty"`
+	BeforeHour         *int   `json:"before_hour,omitempty"`
+	AfterHour          *int   `json:"after_hour,omitempty"`
+	MinDurationMinutes int    `json:"min_duration_minutes,omitempty"`
+}
+
+type ChallengeTemplate struct {
+	ID               string               `json:"id"`
+	Name             string               `json:"name"`
+	Description      string               `json:"description"`
+	Metric           ChallengeMetric      `json:"metric"`
+	Aggregation      ChallengeAggregation `json:"aggregation"`
+	Period           ChallengePeriod      `json:"period"`
+	Filter           ChallengeFilter      `json:"filter"`
+	RewardTitle      string               `json:"reward_title"`
+	RewardCosmeticID *string              `json:"reward_cosmetic_id,omitempty"`
+	Enabled          bool                 `json:"enabled"`
+}
+
+// Validate checks that the template can be scored.
+func (t *ChallengeTemplate) Validate() error {
+	switch t.Metric {
+	case ChallengeMetricDurationMinutes, ChallengeMetricDistanceMeters, ChallengeMetricFetchThrows, ChallengeMetricActivities:
+	default:
+		return fmt.Errorf("%w: %s: unknown metric %q", ErrInvalidChallengeTemplate, t.ID, t.Metric)
+	}
+	switch t.Aggregation {
+	case ChallengeAggregationSum, ChallengeAggregationCount, ChallengeAggregationMax:
+	default:
+		return fmt.Errorf("%w: %s: unknown aggregation %q", ErrInvalidChallengeTemplate, t.ID, t.Aggregation)
+	}
+	switch t.Period {
+	case ChallengePeriodDay, ChallengePeriodWeek:
+	default:
+		return fmt.Errorf("%w: %s: unknown period %q", ErrInvalidChallengeTemplate, t.ID, t.Period)
+	}
+	for _, hour := range []*int{t.Filter.BeforeHour, t.Filter.AfterHour} {
+		if hour != nil && (*hour < 0 || *hour > 24) {
+			return fmt.Errorf("%w: %s: hours must be between 0 and 24", ErrInvalidChallengeTemplate, t.ID)
+		}
+	}
+	if t.Filter.MinDurationMinutes < 0 {
+		return fmt.Errorf("%w: %s: min_duration_minutes must not be negative", ErrInvalidChallengeTemplate, t.ID)
+	}
+	if strings.TrimSpace(t.RewardTitle) == "" {
+		return fmt.Errorf("%w: %s: reward_title is required", ErrInvalidChallengeTemplate, t.ID)
+	}
+	return nil
+}
+
+// Window returns the period containing now in loc: the local day, or the
+// week starting on Monday. Boundaries are local midnights, so a DST change
+// makes the period an hour shorter or longer.
+func (p ChallengePeriod) Window(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if p == ChallengePeriodDay {
+		return start, time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+	}
+
+	sinceMonday := (int(local.Weekday()) + 6) % 7
+	start = time.Date(local.Year(), local.Month(), local.Day()-sinceMonday, 0, 0, 0, 0, loc)
+	return start, time.Date(start.Year(), start.Month(), start.Day()+7, 0, 0, 0, 0, loc)
+}
+
+// ChallengeActivity is a finished activity scored for a participant.
+type ChallengeActivity struct {
+	UserID          uuid.UUID
+	GameTypeID      string
+	StartedAt       time.Time
+	DurationSeconds int
+	GameData        json.RawMessage
+}
+
+// Score returns the activity's metric, and false if the template's filter
+// leaves it out. loc is the participant's timezone.
+func (t *ChallengeTemplate) Score(a ChallengeActivity, loc *time.Location) (float64, bool) {
+	f := t.Filter
+	if f.GameTypeID != "" && a.GameTypeID != f.GameTypeID {
+		return 0, false
+	}
+	if a.DurationSeconds < f.MinDurationMinutes*60 {
+		return 0, false
+	}
+	hour := a.StartedAt.In(loc).Hour()
+	if f.BeforeHour != nil && hour >= *f.BeforeHour {
+		return 0, false
+	}
+	if f.AfterHour != nil && hour < *f.AfterHour {
+		return 0, false
+	}
+
+	switch t.Metric {
+	case ChallengeMetricDurationMinutes:
+		return float64(a.DurationSeconds) / 60, true
+	case ChallengeMetricDistanceMeters:
+		var walkData WalkGameData
+		if err := json.Unmarshal(a.GameData, &walkData); err != nil {
+			return 0, true
+		}
+		return walkData.DistanceMeters, true
+	case ChallengeMetricFetchThrows:
+		var fetchData FetchGameData
+		if err := json.Unmarshal(a.GameData, &fetchData); err != nil {
+			return 0, true
+		}
+		return float64(fetchData.Throws), true
+	default:
+		return 1, true
+	}
+}
+
+type Challenge struct {
+	ID         uuid.UUID          `json:"id"`
+	TemplateID string             `json:"template_id"`
+	Template   *ChallengeTemplate `json:"template,omitempty"`
+	CreatedBy  *uuid.UUID         `json:"created_by,omitempty"`
+	InviteCode string             `json:"invite_code"`
+	Timezone   string             `json:"timezone"`
+	StartsAt   time.Time          `json:"starts_at"`
+	EndsAt     time.Time          `json:"ends_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+
+	Standings []*ChallengeStanding `json:"standings,omitempty"`
+}
+
+// Finished reports whether the challenge's results are final.
+func (c *Challenge) Finished() bool {
+	return c.FinishedAt != nil
+}
+
+// ChallengeParticipant is a user taking part in a challenge, with their
+// results once it finished.
+type ChallengeParticipant struct {
+	UserProfile
+	Timezone        string
+	FinalRank       *int
+	FinalValue      *float64
+	FinalActivities *int
+}
+
+// Location is the participant's timezone, UTC if it is unknown.
+func (p *ChallengeParticipant) Location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil || p.Timezone == "" {
+		return time.UTC
+	}
+	return loc
+}
+
+// ChallengeStanding is a participant's place in a challenge. Participants
+// with the same value share a rank.
+type ChallengeStanding struct {
+	UserProfile
+	Rank       int     `json:"rank"`
+	Value      float64 `json:"value"`
+	Activities int     `json:"activities"`
+}
+
+// RankParticipants scores each participant's activities with the template
+// and ranks them, best first.
+func RankParticipants(template *ChallengeTemplate, participants []*ChallengeParticipant, activities []ChallengeActivity) []*ChallengeStanding {
+	byUser := make(map[uuid.UUID]*ChallengeStanding, len(participants))
+	locations := make(map[uuid.UUID]*time.Location, len(participants))
+	standings := make([]*ChallengeStanding, 0, len(participants))
+	for _, p := range participants {
+		standing := &ChallengeStanding{UserProfile: p.UserProfile}
+		byUser[p.ID] = standing
+		locations[p.ID] = p.Location()
+		standings = append(standings, standing)
+	}
+
+	for _, a := range activities {
+		standing, ok := byUser[a.UserID]
+		if !ok {
+			continue
+		}
+		value, ok := template.Score(a, locations[a.UserID])
+		if !ok {
+			continue
+		}
+
+		standing.Activities++
+		switch template.Aggregation {
+		case ChallengeAggregationCount:
+			standing.Value++
+		case ChallengeAggregationMax:
+			standing.Value = max(standing.Value, value)
+		default:
+			standing.Value += value
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Value != standings[j].Value {
+			return standings[i].Value > standings[j].Value
+		}
+		return standings[i].Name < standings[j].Name
+	})
+	for i, standing := range standings {
+		standing.Rank = i + 1
+		if i > 0 && standing.Value == standings[i-1].Value {
+			standing.Rank = standings[i-1].Rank
+		}
+	}
+	return standings
+}
+
+// ChallengeWinners are the participants in first place. Nobody wins a
+// challenge nobody scored in.
+func ChallengeWinners(standings []*ChallengeStanding) []*ChallengeStanding {
+	var winners []*ChallengeStanding
+	for _, standing := range standings {
+		if standing.Rank == 1 && standing.Value > 0 {
+			winners = append(winners, standing)
+		}
+	}
+	return winners
+}
+
+// ChallengeReward is what a winner gets: a title they hold for a while
+// and, for some templates, a cosmetic to keep.
+type ChallengeReward struct {
+	ID             uuid.UUID `json:"id"`
+	ChallengeID    uuid.UUID `json:"challenge_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Title          string    `json:"title"`
+	CosmeticID     *string   `json:"cosmetic_id,omitempty"`
+	TitleExpiresAt time.Time `json:"title_expires_at"`
+	GrantedAt      time.Time `json:"granted_at"`
+}
+
+type CreateChallengeInput struct {
+	TemplateID string      `json:"template_id"`
+	FriendIDs  []uuid.UUID `json:"friend_ids"`
+}
+
+type JoinChallengeInput struct {
+	Code string `json:"code"`
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestChallengePeriod_Window(t *testing.T) {
+	lisbon, err := time.LoadLocation("Europe/Lisbon")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	tests := []struct {
+		name      string
+		period    ChallengePeriod
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			"week from midweek",
+			ChallengePeriodWeek,
+			time.Date(2024, 6, 12, 15, 0, 0, 0, lisbon), // Wednesday
+			time.Date(2024, 6, 10, 0, 0, 0, 0, lisbon),
+			time.Date(2024, 6, 17, 0, 0, 0, 0, lisbon),
+		},
+		{
+			"sunday belongs to the week before",
+			ChallengePeriodWeek,
+			time.Date(2024, 6, 16, 23, 30, 0, 0, lisbon),
+			time.Date(2024, 6, 10, 0, 0, 0, 0, lisbon),
+			time.Date(2024, 6, 17, 0, 0, 0, 0, lisbon),
+		},
+		{
+			"week across the DST change",
+			ChallengePeriodWeek,
+			time.Date(2024, 3, 28, 12, 0, 0, 0, lisbon),
+			time.Date(2024, 3, 25, 0, 0, 0, 0, lisbon),
+			time.Date(2024, 4, 1, 0, 0, 0, 0, lisbon),
+		},
+		{
+			"day",
+			ChallengePeriodDay,
+			time.Date(2024, 6, 12, 15, 0, 0, 0, lisbon),
+			time.Date(2024, 6, 12, 0, 0, 0, 0, lisbon),
+			time.Date(2024, 6, 13, 0, 0, 0, 0, lisbon),
+		},
+		{
+			// 23:30 UTC on Sunday is already Monday in Lisbon summer time
+			"now in another timezone",
+			ChallengePeriodWeek,
+			time.Date(2024, 6, 16, 23, 30, 0, 0, time.UTC),
+			time.Date(2024, 6, 17, 0, 0, 0, 0, lisbon),
+			time.Date(2024, 6, 24, 0, 0, 0, 0, lisbon),
+		},
+	}
+	for _, tt := range tests {
+		start, end := tt.period.Window(tt.now, lisbon)
+		if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+			t.Errorf("%s: Window() = %v - %v, want %v - %v", tt.name, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+
+	// The week the clocks go forward is an hour short
+	start, end := ChallengePeriodWeek.Window(time.Date(2024, 3, 28, 12, 0, 0, 0, lisbon), lisbon)
+	if got := end.Sub(start); got != 7*24*time.Hour-time.Hour {
+		t.Errorf("DST week lasts %v, want 167h", got)
+	}
+}
+
+func earlyWalksTemplate() *ChallengeTemplate {
+	before := 9
+	return &ChallengeTemplate{
+		ID:          "early_walks",
+		Metric:      ChallengeMetricActivities,
+		Aggregation: ChallengeAggregationCount,
+		Period:      ChallengePeriodWeek,
+		Filter:      ChallengeFilter{GameTypeID: "walk", BeforeHour: &before, MinDurationMinutes: 5},
+		RewardTitle: "Early Bird",
+	}
+}
+
+func TestChallengeTemplate_Score(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	template := earlyWalksTemplate()
+
+	// 10:00 UTC is 07:00 in São Paulo
+	walk := ChallengeActivity{GameTypeID: "walk", StartedAt: time.Date(2024, 6, 12, 10, 0, 0, 0, time.UTC), DurationSeconds: 1200}
+
+	if _, ok := template.Score(walk, saoPaulo); !ok {
+		t.Error("Score() skipped a 7am walk in the walker's timezone")
+	}
+	if _, ok := template.Score(walk, time.UTC); ok {
+		t.Error("Score() counted a 10am walk")
+	}
+
+	short := walk
+	short.DurationSeconds = 120
+	if _, ok := template.Score(short, saoPaulo); ok {
+		t.Error("Score() counted a walk shorter than the minimum")
+	}
+
+	fetch := walk
+	fetch.GameTypeID = "fetch"
+	if _, ok := template.Score(fetch, saoPaulo); ok {
+		t.Error("Score() counted another game type")
+	}
+
+	distance := &ChallengeTemplate{Metric: ChallengeMetricDistanceMeters}
+	walk.GameData = json.RawMessage(`{"distance_meters": 2500}`)
+	if got, _ := distance.Score(walk, time.UTC); got != 2500 {
+		t.Errorf("Score() distance = %v, want 2500", got)
+	}
+}
+
+func TestRankParticipants(t *testing.T) {
+	ana := &ChallengeParticipant{UserProfile: UserProfile{ID: uuid.New(), Name: "Ana"}, Timezone: "UTC"}
+	bruno := &ChallengeParticipant{UserProfile: UserProfile{ID: uuid.New(), Name: "Bruno"}, Timezone: "UTC"}
+	carla := &ChallengeParticipant{UserProfile: UserProfile{ID: uuid.New(), Name: "Carla"}, Timezone: "UTC"}
+	stranger := uuid.New()
+
+	at := time.Date(2024, 6, 12, 18, 0, 0, 0, time.UTC)
+	walk := func(userID uuid.UUID, minutes int) ChallengeActivity {
+		return ChallengeActivity{UserID: userID, GameTypeID: "walk", StartedAt: at, DurationSeconds: minutes * 60}
+	}
+	activities := []ChallengeActivity{
+		walk(ana.ID, 30), walk(ana.ID, 30),
+		walk(bruno.ID, 45), walk(bruno.ID, 15),
+		walk(carla.ID, 20),
+		walk(stranger, 500),
+	}
+
+	sum := &ChallengeTemplate{Metric: ChallengeMetricDurationMinutes, Aggregation: ChallengeAggregationSum, Filter: ChallengeFilter{GameTypeID: "walk"}}
+	standings := RankParticipants(sum, []*ChallengeParticipant{carla, bruno, ana}, activities)
+
+	want := []struct {
+		name  string
+		rank  int
+		value float64
+	}{{"Ana", 1, 60}, {"Bruno", 1, 60}, {"Carla", 3, 20}}
+	if len(standings) != len(want) {
+		t.Fatalf("RankParticipants() returned %d standings, want %d", len(standings), len(want))
+	}
+	for i, w := range want {
+		got := standings[i]
+		if got.Name != w.name || got.Rank != w.rank || got.Value != w.value {
+			t.Errorf("standing %d = %s rank %d value %v, want %s rank %d value %v", i, got.Name, got.Rank, got.Value, w.name, w.rank, w.value)
+		}
+	}
+	if standings[0].Activities != 2 {
+		t.Errorf("Ana's activities = %d, want 2", standings[0].Activities)
+	}
+
+	maxTemplate := &ChallengeTemplate{Metric: ChallengeMetricDurationMinutes, Aggregation: ChallengeAggregationMax}
+	standings = RankParticipants(maxTemplate, []*ChallengeParticipant{ana, bruno, carla}, activities)
+	if standings[0].Name != "Bruno" || standings[0].Value != 45 {
+		t.Errorf("longest walk winner = %s with %v, want Bruno with 45", standings[0].Name, standings[0].Value)
+	}
+
+	winners := ChallengeWinners(RankParticipants(sum, []*ChallengeParticipant{ana, bruno, carla}, activities))
+	if len(winners) != 2 {
+		t.Errorf("ChallengeWinners() = %d winners, want a tie of 2", len(winners))
+	}
+	if winners := ChallengeWinners(RankParticipants(sum, []*ChallengeParticipant{ana, bruno}, nil)); len(winners) != 0 {
+		t.Errorf("ChallengeWinners() without activities = %d winners, want none", len(winners))
+	}
+}
+
+func TestChallengeTemplate_Validate(t *testing.T) {
+	if err := earlyWalksTemplate().Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ChallengeTemplate)
+	}{
+		{"unknown metric", func(t *ChallengeTemplate) { t.Metric = "steps" }},
+		{"unknown aggregation", func(t *ChallengeTemplate) { t.Aggregation = "avg" }},
+		{"unknown period", func(t *ChallengeTemplate) { t.Period = "month" }},
+		{"bad hour", func(t *ChallengeTemplate) { hour := 25; t.Filter.BeforeHour = &hour }},
+		{"no title", func(t *ChallengeTemplate) { t.RewardTitle = " " }},
+	}
+	for _, tt := range tests {
+		template := earlyWalksTemplate()
+		tt.modify(template)
+		if err := template.Validate(); !errors.Is(err, ErrInvalidChallengeTemplate) {
+			t.Errorf("%s: Validate() error = %v, want ErrInvalidChallengeTemplate", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidCosmeticSlot = errors.New("invalid cosmetic slot")
+
+// CosmeticSlot is where an item goes on a pet. A pet wears at most one
+// item per slot.
+type CosmeticSlot string
+
+const (
+	CosmeticSlotCollar     CosmeticSlot = "collar"
+	CosmeticSlotHat        CosmeticSlot = "hat"
+	CosmeticSlotBackground CosmeticSlot = "background"
+	CosmeticSlotBadgeFrame CosmeticSlot = "badge_frame"
+)
+
+func (s CosmeticSlot) Valid() bool {
+	switch s {
+	case CosmeticSlotCollar, CosmeticSlotHat, CosmeticSlotBackground, CosmeticSlotBadgeFrame:
+		return true
+	}
+	return false
+}
+
+// ItemSource is what earned a user an item.
+type ItemSource string
+
+const (
+	ItemSourceLevel       ItemSource = "level"
+	ItemSourceChallenge   ItemSource = "challenge"
+	ItemSourceAchievement ItemSource = "achievement"
+)
+
+// CosmeticItem is an item in the catalog. The app draws it from its ID.
+// Items with an UnlockLevel are granted when a pet reaches that level;
+// the others come from challenges and achievements.
+type CosmeticItem struct {
+	ID          string       `json:"id"`
+	Slot        CosmeticSlot `json:"slot"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	UnlockLevel *int         `json:"unlock_level,omitempty"`
+}
+
+// InventoryItem is an item the user owns.
+type InventoryItem struct {
+	CosmeticItem
+	Source     ItemSource `json:"source"`
+	AcquiredAt time.Time  `json:"acquired_at"`
+}
+
+// Loadout is what a pet wears, by slot.
+type Loadout map[CosmeticSlot]*CosmeticItem
+
+type EquipItemInput struct {
+	ItemID string `json:"item_id"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCosmeticSlot_Valid(t *testing.T) {
+	for _, slot := range []CosmeticSlot{CosmeticSlotCollar, CosmeticSlotHat, CosmeticSlotBackground, CosmeticSlotBadgeFrame} {
+		if !slot.Valid() {
+			t.Errorf("%q.Valid() = false", slot)
+		}
+	}
+	for _, slot := range []CosmeticSlot{"", "shoes", "Collar"} {
+		if slot.Valid() {
+			t.Errorf("%q.Valid() = true", slot)
+		}
+	}
+}
+
+// The pet query reads the loadout as an object of catalog rows by slot
+func TestLoadout_FromCatalogRows(t *testing.T) {
+	raw := `{
+		"hat": {"id": "party_hat", "slot": "hat", "name": "Party Hat", "description": "Reached level 3", "unlock_level": 3, "sort_order": 20},
+		"collar": {"id": "golden_leash", "slot": "collar", "name": "Golden Leash", "description": "Won a Walk Marathon", "unlock_level": null, "sort_order": 100}
+	}`
+
+	var loadout Loadout
+	if err := json.Unmarshal([]byte(raw), &loadout); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	hat := loadout[CosmeticSlotHat]
+	if hat == nil || hat.ID != "party_hat" || hat.UnlockLevel == nil || *hat.UnlockLevel != 3 {
+		t.Errorf("hat = %+v, want party_hat unlocked at level 3", hat)
+	}
+	collar := loadout[CosmeticSlotCollar]
+	if collar == nil || collar.ID != "golden_leash" || collar.UnlockLevel != nil {
+		t.Errorf("collar = %+v, want golden_leash without an unlock level", collar)
+	}
+	if loadout[CosmeticSlotBackground] != nil {
+		t.Error("empty background slot has an item")
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending    DataExportStatus = "pending"
+	DataExportStatusProcessing DataExportStatus = "processing"
+	DataExportStatusCompleted  DataExportStatus = "completed"
+	DataExportStatusFailed     DataExportStatus = "failed"
+)
+
+type DataExport struct {
+	ID          uuid.UUID        `json:"id"`
+	UserID      uuid.UUID        `json:"user_id"`
+	Status      DataExportStatus `json:"status"`
+	DownloadURL *string          `json:"download_url,omitempty"`
+	Error       *string          `json:"error,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// UserDataArchive is everything PetTime stores about a user, as written to
+// the export archive. Pet loadouts are part of Pets.
+type UserDataArchive struct {
+	ExportedAt       time.Time          `json:"exported_at"`
+	Profile          *User              `json:"profile"`
+	Identities       []*UserIdentity    `json:"identities"`
+	Pets             []*Pet             `json:"pets"`
+	Activities       []*Activity        `json:"activities"`
+	Health           HealthArchive      `json:"health"`
+	Records          []*PersonalRecord  `json:"records"`
+	Skills           []*UnlockedSkill   `json:"skills"`
+	Reminders        []*Reminder        `json:"reminders"`
+	Devices          []*DeviceToken     `json:"devices"`
+	Nudges           []*Nudge           `json:"nudges"`
+	Achievements     []*UserAchievement `json:"achievements"`
+	Cards            []*UserCard        `json:"cards"`
+	Missions         []*Mission         `json:"missions"`
+	Challenges       []*Challenge       `json:"challenges"`
+	ChallengeRewards []*ChallengeReward `json:"challenge_rewards"`
+	Inventory        []*InventoryItem   `json:"inventory"`
+	Friends          []*Friend          `json:"friends"`
+	FriendRequests   []*FriendRequest   `json:"friend_requests"`
+	Blocked          []*BlockedUser     `json:"blocked"`
+	FeedEvents       []*FeedEvent       `json:"feed_events"`
+	Comments         []*FeedComment     `json:"comments"`
+}
+
+// HealthArchive is the health log of the user's pets. For pets shared with
+// the user only the entries they recorded are theirs.
+type HealthArchive struct {
+	Weights      []*WeightEntry `json:"weights"`
+	VetVisits    []*VetVisit    `json:"vet_visits"`
+	Vaccinations []*Vaccination `json:"vaccinations"`
+	Medications  []*Medication  `json:"medications"`
+}
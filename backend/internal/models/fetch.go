@@ -0,0 +1,603 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInvalidThrowDetection = errors.New("invalid throw detection config")
+	ErrInvalidIMUBatch       = errors.New("invalid IMU batch")
+	ErrInvalidFetchEvents    = errors.New("invalid fetch events")
+)
+
+const (
+	// MaxIMUBatchSamples is the most samples one upload may carry, two
+	// minutes at 50 Hz.
+	MaxIMUBatchSamples = 6000
+	// MaxIMUSamples is the most samples kept for one activity, two hours
+	// at 50 Hz.
+	MaxIMUSamples = 360000
+	// MaxFetchEvents is the most events a fetch session may log.
+	MaxFetchEvents = 3000
+	// imuClockSkew is how far sample and event timestamps may fall outside
+	// the activity, for phones whose clocks drift from the server's.
+	imuClockSkew = time.Minute
+	// throwMatchTolerance is how far a logged throw may be from the throw
+	// detected for it. Both are timed by the phone's clock.
+	throwMatchTolerance = 2 * time.Second
+)
+
+// IMUSample is one accelerometer reading of the phone throwing the ball.
+// T is milliseconds since the Unix epoch; accelerations are in m/s² and
+// include gravity.
+type IMUSample struct {
+	T  int64   `json:"t"`
+	AX float64 `json:"ax"`
+	AY float64 `json:"ay"`
+	AZ float64 `json:"az"`
+}
+
+func (s IMUSample) Time() time.Time {
+	return time.UnixMilli(s.T)
+}
+
+// Magnitude is the length of the acceleration, whichever way the phone is
+// held.
+func (s IMUSample) Magnitude() float64 {
+	return math.Sqrt(s.AX*s.AX + s.AY*s.AY + s.AZ*s.AZ)
+}
+
+// IMUBatchInput is a batch of samples uploaded during a fetch activity.
+// Batches may overlap or arrive out of order.
+type IMUBatchInput struct {
+	Samples []IMUSample `json:"samples"`
+}
+
+// Validate checks the batch belongs to an activity that started at
+// startedAt and is still going at now.
+func (in IMUBatchInput) Validate(startedAt, now time.Time) error {
+	if len(in.Samples) == 0 {
+		return fmt.Errorf("%w: no samples", ErrInvalidIMUBatch)
+	}
+	if len(in.Samples) > MaxIMUBatchSamples {
+		return fmt.Errorf("%w: more than %d samples", ErrInvalidIMUBatch, MaxIMUBatchSamples)
+	}
+	earliest, latest := startedAt.Add(-imuClockSkew), now.Add(imuClockSkew)
+	for _, sample := range in.Samples {
+		at := sample.Time()
+		if at.Before(earliest) || at.After(latest) {
+			return fmt.Errorf("%w: sample at %s is outside the activity", ErrInvalidIMUBatch, at.Format(time.RFC3339))
+		}
+		for _, v := range []float64{sample.AX, sample.AY, sample.AZ} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("%w: acceleration must be a number", ErrInvalidIMUBatch)
+			}
+		}
+	}
+	return nil
+}
+
+// ThrowDetectionConfig tunes how throws are found in a game type's
+// accelerometer samples. A throw is a short burst above PeakThreshold that
+// falls back below ResetThreshold within MaxPeakMillis; longer bursts are
+// the phone being shaken or carried at a run. Missing keys fall back to
+// DefaultThrowDetectionConfig.
+type ThrowDetectionConfig struct {
+	PeakThreshold  float64 `json:"peak_threshold"`
+	ResetThreshold float64 `json:"reset_threshold"`
+	MaxPeakMillis  int64   `json:"max_peak_ms"`
+	// MinIntervalMillis keeps the wind-up and follow-through of one throw
+	// from counting twice.
+	MinIntervalMillis int64 `json:"min_interval_ms"`
+	// ComboGapSeconds is the longest pause between throws of a combo: the
+	// dog brought the ball straight back.
+	ComboGapSeconds int `json:"combo_gap_seconds"`
+}
+
+func DefaultThrowDetectionConfig() ThrowDetectionConfig {
+	return ThrowDetectionConfig{
+		PeakThreshold:     25,
+		ResetThreshold:    15,
+		MaxPeakMillis:     400,
+		MinIntervalMillis: 1000,
+		ComboGapSeconds:   30,
+	}
+}
+
+// UnmarshalJSON fills in defaults and validates, so game types loaded from
+// the database always detect throws sensibly.
+func (c *ThrowDetectionConfig) UnmarshalJSON(data []byte) error {
+	// Decode into a type without this method to avoid recursing
+	type plain ThrowDetectionConfig
+	config := plain(DefaultThrowDetectionConfig())
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidThrowDetection, err)
+	}
+
+	parsed := ThrowDetectionConfig(config)
+	if err := parsed.Validate(); err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+func (c ThrowDetectionConfig) Validate() error {
+	switch {
+	case c.ResetThreshold <= 0:
+		return fmt.Errorf("%w: reset_threshold must be positive", ErrInvalidThrowDetection)
+	case c.PeakThreshold <= c.ResetThreshold:
+		return fmt.Errorf("%w: peak_threshold must be above reset_threshold", ErrInvalidThrowDetection)
+	case c.MaxPeakMillis <= 0:
+		return fmt.Errorf("%w: max_peak_ms must be positive", ErrInvalidThrowDetection)
+	case c.MinIntervalMillis < 0:
+		return fmt.Errorf("%w: min_interval_ms must not be negative", ErrInvalidThrowDetection)
+	case c.ComboGapSeconds <= 0:
+		return fmt.Errorf("%w: combo_gap_seconds must be positive", ErrInvalidThrowDetection)
+	}
+	return nil
+}
+
+// FetchCombo is a run of throws, each soon after the one before.
+type FetchCombo struct {
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Throws    int       `json:"throws"`
+}
+
+// ThrowDetection is what the samples of a fetch activity show.
+type ThrowDetection struct {
+	ThrowTimes []time.Time
+	// Combos are the runs of two throws or more
+	Combos   []FetchCombo
+	MaxCombo int
+}
+
+// DetectThrows finds throws in the samples, which may be in any order and
+// repeat. Each throw is timed at the peak of its burst.
+func DetectThrows(samples []IMUSample, config ThrowDetectionConfig) *ThrowDetection {
+	var detector ThrowDetector
+	detector.Feed(samples, config)
+	return detector.Detection(config)
+}
+
+// ThrowDetector finds throws in samples fed to it batch by batch as a
+// fetch session runs. It keeps only what it needs to carry on, so it is
+// stored with the activity between uploads and each batch is scanned
+// once. Samples no later than the last one it saw are skipped: repeats
+// from a retried upload, or a batch that arrived after a later one.
+type ThrowDetector struct {
+	Peaks      []int64 `json:"peaks"`
+	InBurst    bool    `json:"in_burst"`
+	BurstStart int64   `json:"burst_start"`
+	PeakAt     int64   `json:"peak_at"`
+	Peak       float64 `json:"peak"`
+	Last       int64   `json:"last"`
+}
+
+// Feed scans the samples, which may be in any order within the batch.
+func (d *ThrowDetector) Feed(samples []IMUSample, config ThrowDetectionConfig) {
+	sorted := make([]IMUSample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].T < sorted[j].T })
+
+	for _, sample := range sorted {
+		if d.Last != 0 && sample.T <= d.Last {
+			continue
+		}
+		d.Last = sample.T
+		magnitude := sample.Magnitude()
+		switch {
+		case !d.InBurst && magnitude >= config.PeakThreshold:
+			d.InBurst = true
+			d.BurstStart, d.PeakAt, d.Peak = sample.T, sample.T, magnitude
+		case d.InBurst && magnitude < config.ResetThreshold:
+			d.endBurst(sample.T, config)
+		case d.InBurst && magnitude > d.Peak:
+			d.PeakAt, d.Peak = sample.T, magnitude
+		}
+	}
+}
+
+func (d *ThrowDetector) endBurst(at int64, config ThrowDetectionConfig) {
+	d.InBurst = false
+	if at-d.BurstStart > config.MaxPeakMillis {
+		return
+	}
+	if len(d.Peaks) > 0 && d.PeakAt-d.Peaks[len(d.Peaks)-1] < config.MinIntervalMillis {
+		return
+	}
+	d.Peaks = append(d.Peaks, d.PeakAt)
+}
+
+// Detection returns the throws found so far and the combos they make.
+func (d *ThrowDetector) Detection(config ThrowDetectionConfig) *ThrowDetection {
+	peaks := d.Peaks
+	// A burst still open at the end of the last batch counts for now; the
+	// next batch settles it
+	if d.InBurst {
+		open := *d
+		open.Peaks = append([]int64(nil), d.Peaks...)
+		open.endBurst(d.Last, config)
+		peaks = open.Peaks
+	}
+
+	detection := &ThrowDetection{ThrowTimes: make([]time.Time, 0, len(peaks))}
+	comboGap := int64(config.ComboGapSeconds) * 1000
+	start := 0
+	for i, at := range peaks {
+		detection.ThrowTimes = append(detection.ThrowTimes, time.UnixMilli(at).UTC())
+		if i+1 < len(peaks) && peaks[i+1]-at <= comboGap {
+			continue
+		}
+		// The run from start to i ends here
+		throws := i - start + 1
+		detection.MaxCombo = max(detection.MaxCombo, throws)
+		if throws >= 2 {
+			detection.Combos = append(detection.Combos, FetchCombo{
+				StartedAt: time.UnixMilli(peaks[start]).UTC(),
+				EndedAt:   time.UnixMilli(at).UTC(),
+				Throws:    throws,
+			})
+		}
+		start = i + 1
+	}
+	return detection
+}
+
+// Apply replaces the app's counts with the detected throws. Returns are
+// still reported by the app, so the success rate is kept in line with
+// them. Sessions with an event log are scored from it instead.
+func (d *FetchGameData) Apply(detection *ThrowDetection) {
+	d.Throws = len(detection.ThrowTimes)
+	d.ThrowTimes = detection.ThrowTimes
+	d.Combos = detection.Combos
+	d.MaxCombo = detection.MaxCombo
+	d.ThrowsDetected = true
+
+	d.Returns = min(d.Returns, d.Throws)
+	d.SuccessRate = 0
+	if d.Throws > 0 {
+		d.SuccessRate = float64(d.Returns) / float64(d.Throws)
+	}
+}
+
+// FetchEventType is what happened in a fetch session.
+type FetchEventType string
+
+const (
+	FetchEventThrow  FetchEventType = "throw"
+	FetchEventReturn FetchEventType = "return"
+	FetchEventMiss   FetchEventType = "miss"
+)
+
+// FetchEvent is one moment of a fetch session as the app logged it. A
+// return or miss settles the throw before it; a throw made before the last
+// one was settled counts the last one as missed.
+type FetchEvent struct {
+	Type FetchEventType `json:"type"`
+	At   time.Time      `json:"at"`
+}
+
+// ValidateFetchEvents checks the log is in order and fits the activity,
+// which may still be running (endedAt nil).
+func ValidateFetchEvents(events []FetchEvent, startedAt time.Time, endedAt *time.Time) error {
+	if len(events) > MaxFetchEvents {
+		return fmt.Errorf("%w: more than %d events", ErrInvalidFetchEvents, MaxFetchEvents)
+	}
+	earliest := startedAt.Add(-imuClockSkew)
+	pending := false
+	for i, event := range events {
+		if event.At.Before(earliest) || (endedAt != nil && event.At.After(endedAt.Add(imuClockSkew))) {
+			return fmt.Errorf("%w: event at %s is outside the activity", ErrInvalidFetchEvents, event.At.Format(time.RFC3339))
+		}
+		if i > 0 && event.At.Before(events[i-1].At) {
+			return fmt.Errorf("%w: events must be in order", ErrInvalidFetchEvents)
+		}
+		switch event.Type {
+		case FetchEventThrow:
+			pending = true
+		case FetchEventReturn, FetchEventMiss:
+			if !pending {
+				return fmt.Errorf("%w: %s at %s follows no throw", ErrInvalidFetchEvents, event.Type, event.At.Format(time.RFC3339))
+			}
+			pending = false
+		default:
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidFetchEvents, event.Type)
+		}
+	}
+	return nil
+}
+
+// CheckLoggedThrows checks every throw in an ordered event log was also
+// detected from the accelerometer, within throwMatchTolerance. Each
+// detected throw vouches for one logged throw, so a log can leave throws
+// out but not make them up.
+func CheckLoggedThrows(events []FetchEvent, detected []time.Time) error {
+	next := 0
+	for _, event := range events {
+		if event.Type != FetchEventThrow {
+			continue
+		}
+		for next < len(detected) && detected[next].Before(event.At.Add(-throwMatchTolerance)) {
+			next++
+		}
+		if next == len(detected) || detected[next].After(event.At.Add(throwMatchTolerance)) {
+			return fmt.Errorf("%w: no throw was detected at %s", ErrInvalidFetchEvents, event.At.Format(time.RFC3339))
+		}
+		next++
+	}
+	return nil
+}
+
+// FetchXPConfig is how fetch is scored, from the game type's xp_config.
+// Every ComboLength returns in a row earn ComboBonus, and FrenzyCombo
+// returns in a row start a frenzy: until the next miss, throws are worth
+// FrenzyMultiplier times as much.
+type FetchXPConfig struct {
+	XPPerThrow       int     `json:"xp_per_throw"`
+	ComboBonus       int     `json:"combo_bonus"`
+	ComboLength      int     `json:"combo_length"`
+	FrenzyMultiplier float64 `json:"frenzy_multiplier"`
+	FrenzyCombo      int     `json:"frenzy_combo"`
+}
+
+// ParseFetchXPConfig reads the fetch keys of an xp_config. Combos of 5 and
+// frenzies after 10 are assumed unless configured.
+func ParseFetchXPConfig(data json.RawMessage) FetchXPConfig {
+	config := FetchXPConfig{ComboLength: 5, FrenzyCombo: 10, FrenzyMultiplier: 1}
+	_ = json.Unmarshal(data, &config)
+	if config.ComboLength <= 0 {
+		config.ComboLength = 5
+	}
+	if config.FrenzyCombo <= 0 {
+		config.FrenzyCombo = 10
+	}
+	if config.FrenzyMultiplier < 1 {
+		config.FrenzyMultiplier = 1
+	}
+	return config
+}
+
+// FetchThrow is a throw as the server scored it. Combo counts the returns
+// in a row up to this one, or is 0 if the ball wasn't brought back.
+type FetchThrow struct {
+	At       time.Time `json:"at"`
+	Returned bool      `json:"returned"`
+	Combo    int       `json:"combo"`
+	Frenzy   bool      `json:"frenzy"`
+	XP       int       `json:"xp"`
+}
+
+// FetchFrenzyWindow is a stretch of a session in frenzy mode. It ends at
+// the first miss, or is still open at the end of the log.
+type FetchFrenzyWindow struct {
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Throws    int        `json:"throws"`
+}
+
+// FetchScore is a session played back from its event log.
+type FetchScore struct {
+	Throws        []FetchThrow
+	Returns       int
+	Combos        []FetchCombo
+	MaxCombo      int
+	FrenzyWindows []FetchFrenzyWindow
+	XP            int
+}
+
+// ScoreFetch plays back a validated event log. A throw still in the air at
+// the end of the log counts as thrown, neither returned nor missed.
+func ScoreFetch(events []FetchEvent, config FetchXPConfig) *FetchScore {
+	score := &FetchScore{Throws: []FetchThrow{}}
+	combo := 0
+	var frenzy *FetchFrenzyWindow
+	var pending *FetchThrow
+
+	settle := func(returned bool, at time.Time) {
+		if returned {
+			combo++
+			pending.Returned = true
+			pending.Combo = combo
+			score.Returns++
+			score.MaxCombo = max(score.MaxCombo, combo)
+			if combo%config.ComboLength == 0 {
+				pending.XP += config.ComboBonus
+			}
+			if combo == config.FrenzyCombo && frenzy == nil {
+				score.FrenzyWindows = append(score.FrenzyWindows, FetchFrenzyWindow{StartedAt: at})
+				frenzy = &score.FrenzyWindows[len(score.FrenzyWindows)-1]
+			}
+		} else {
+			if combo >= 2 {
+				score.endCombo(combo)
+			}
+			combo = 0
+			if frenzy != nil {
+				frenzy.EndedAt = &at
+				frenzy = nil
+			}
+		}
+		pending = nil
+	}
+
+	for _, event := range events {
+		switch event.Type {
+		case FetchEventThrow:
+			if pending != nil {
+				settle(false, event.At)
+			}
+			throw := FetchThrow{At: event.At, XP: config.XPPerThrow}
+			if frenzy != nil {
+				throw.Frenzy = true
+				throw.XP = int(float64(throw.XP) * config.FrenzyMultiplier)
+				frenzy.Throws++
+			}
+			score.Throws = append(score.Throws, throw)
+			pending = &score.Throws[len(score.Throws)-1]
+		case FetchEventReturn, FetchEventMiss:
+			if pending != nil {
+				settle(event.Type == FetchEventReturn, event.At)
+			}
+		}
+	}
+	if combo >= 2 {
+		score.endCombo(combo)
+	}
+
+	for _, throw := range score.Throws {
+		score.XP += throw.XP
+	}
+	return score
+}
+
+// endCombo records the run of returns ending at the latest returned throw.
+func (s *FetchScore) endCombo(length int) {
+	var last int
+	for i := len(s.Throws) - 1; i >= 0; i-- {
+		if s.Throws[i].Returned {
+			last = i
+			break
+		}
+	}
+	s.Combos = append(s.Combos, FetchCombo{
+		StartedAt: s.Throws[last-length+1].At,
+		EndedAt:   s.Throws[last].At,
+		Throws:    length,
+	})
+}
+
+// ApplyScore replaces the app's counts with the scored event log.
+func (d *FetchGameData) ApplyScore(score *FetchScore) {
+	d.Throws = len(score.Throws)
+	d.Returns = score.Returns
+	d.SuccessRate = 0
+	if d.Throws > 0 {
+		d.SuccessRate = float64(d.Returns) / float64(d.Throws)
+	}
+	d.MaxCombo = score.MaxCombo
+	d.Combos = score.Combos
+	d.FrenzyModeActivated = len(score.FrenzyWindows) > 0
+	d.FrenzyWindows = score.FrenzyWindows
+	d.ThrowLog = score.Throws
+}
+
+const (
+	// minSecondsPerThrow is the fastest plausible fetch pace.
+	minSecondsPerThrow = 2
+	// fetchMissionThrows is the target of a daily fetch mission.
+	fetchMissionThrows = 20
+)
+
+type fetchGame struct{ baseGame }
+
+// Validate checks the event log against the activity's times and, once the
+// session is finished, against the throws detected from the accelerometer.
+// Until then samples and the log may arrive in any order. Game data
+// without a log is taken as the app counted it.
+func (fetchGame) Validate(a *Activity) error {
+	var fetch FetchGameData
+	if err := json.Unmarshal(a.GameData, &fetch); err != nil || len(fetch.Events) == 0 {
+		return nil
+	}
+	if err := ValidateFetchEvents(fetch.Events, a.StartedAt, a.EndedAt); err != nil {
+		return err
+	}
+	if fetch.ThrowsDetected && a.EndedAt != nil {
+		return CheckLoggedThrows(fetch.Events, fetch.ThrowTimes)
+	}
+	return nil
+}
+
+// Prepare replaces the app's counts with the server's scoring of the event
+// log.
+func (fetchGame) Prepare(a *Activity) error {
+	var fetch FetchGameData
+	if err := json.Unmarshal(a.GameData, &fetch); err != nil || len(fetch.Events) == 0 {
+		return nil
+	}
+	var config json.RawMessage
+	if a.GameType != nil {
+		config = a.GameType.XPConfig
+	}
+
+	fetch.ApplyScore(ScoreFetch(fetch.Events, ParseFetchXPConfig(config)))
+	gameData, err := json.Marshal(fetch)
+	if err != nil {
+		return err
+	}
+	a.GameData = gameData
+	return nil
+}
+
+// XP scores each logged throw with its own combo and frenzy. Without a
+// log, the app's counts earn xp_per_throw, the combo bonus for every
+// combo_length of the longest combo, and the frenzy multiplier on the lot.
+func (fetchGame) XP(config json.RawMessage, a *Activity) int {
+	var fetch FetchGameData
+	if err := json.Unmarshal(a.GameData, &fetch); err != nil {
+		return 0
+	}
+	xpConfig := ParseFetchXPConfig(config)
+	if len(fetch.Events) > 0 {
+		return ScoreFetch(fetch.Events, xpConfig).XP
+	}
+
+	xp := fetch.Throws*xpConfig.XPPerThrow + (fetch.MaxCombo/xpConfig.ComboLength)*xpConfig.ComboBonus
+	if fetch.FrenzyModeActivated {
+		xp = int(float64(xp) * xpConfig.FrenzyMultiplier)
+	}
+	return xp
+}
+
+func (fetchGame) Check(a *Activity) *ActivityFlag {
+	var fetch FetchGameData
+	if err := json.Unmarshal(a.GameData, &fetch); err != nil || fetch.Throws <= 0 {
+		return nil
+	}
+	if fetch.Throws*minSecondsPerThrow > *a.DurationSeconds {
+		flag := ActivityFlagTooManyThrows
+		return &flag
+	}
+	return nil
+}
+
+func (fetchGame) MissionProgress(a *Activity, progress map[MissionType]int) {
+	var fetch FetchGameData
+	if err := json.Unmarshal(a.GameData, &fetch); err == nil {
+		progress[MissionTypeFetchThrows] = fetch.Throws
+	}
+}
+
+func (fetchGame) DailyMission(pet *Pet) *MissionGoal {
+	return &MissionGoal{
+		Type:        MissionTypeFetchThrows,
+		Target:      fetchMissionThrows,
+		Description: fmt.Sprintf("Throw the ball %d times for %s", fetchMissionThrows, pet.Name),
+	}
+}
+
+func (fetchGame) Records(a *Activity) []RecordValue {
+	var fetch FetchGameData
+	if err := json.Unmarshal(a.GameData, &fetch); err != nil {
+		return nil
+	}
+
+	var records []RecordValue
+	if fetch.Throws > 0 {
+		records = append(records, RecordValue{RecordMostThrows, float64(fetch.Throws)})
+	}
+	if fetch.MaxCombo > 0 {
+		records = append(records, RecordValue{RecordLongestCombo, float64(fetch.MaxCombo)})
+	}
+	return records
+}
@@ -0,0 +1,404 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// loadIMUFixture reads samples from testdata, as CSV rows of t, ax, ay, az
+// at 25 Hz. fetch_session has eight throws, three of them with a
+// follow-through strong enough to look like a second throw; fetch_jogging
+// has the phone carried at a jog and then shaken for two seconds.
+func loadIMUFixture(t *testing.T, name string) []IMUSample {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]IMUSample, 0, len(records)-1)
+	for _, record := range records[1:] {
+		var values [4]float64
+		for i, field := range record {
+			if values[i], err = strconv.ParseFloat(field, 64); err != nil {
+				t.Fatal(err)
+			}
+		}
+		samples = append(samples, IMUSample{T: int64(values[0]), AX: values[1], AY: values[2], AZ: values[3]})
+	}
+	return samples
+}
+
+func TestDetectThrows_Session(t *testing.T) {
+	samples := loadIMUFixture(t, "fetch_session.csv")
+	start := samples[0].T
+
+	detection := DetectThrows(samples, DefaultThrowDetectionConfig())
+
+	wantOffsets := []int64{3000, 11200, 19600, 28400, 37000, 75280, 86080, 130400}
+	if len(detection.ThrowTimes) != len(wantOffsets) {
+		t.Fatalf("detected %d throws, want %d: %v", len(detection.ThrowTimes), len(wantOffsets), detection.ThrowTimes)
+	}
+	for i, at := range detection.ThrowTimes {
+		if offset := at.UnixMilli() - start; offset != wantOffsets[i] {
+			t.Errorf("throw %d at %d ms, want %d ms", i, offset, wantOffsets[i])
+		}
+	}
+
+	// Five throws in a row, a break, two more and a last one on its own
+	if detection.MaxCombo != 5 {
+		t.Errorf("MaxCombo = %d, want 5", detection.MaxCombo)
+	}
+	if len(detection.Combos) != 2 || detection.Combos[0].Throws != 5 || detection.Combos[1].Throws != 2 {
+		t.Fatalf("Combos = %+v, want runs of 5 and 2", detection.Combos)
+	}
+	if !detection.Combos[0].StartedAt.Equal(detection.ThrowTimes[0]) || !detection.Combos[0].EndedAt.Equal(detection.ThrowTimes[4]) {
+		t.Errorf("first combo = %+v, want from the first to the fifth throw", detection.Combos[0])
+	}
+}
+
+func TestDetectThrows_Batches(t *testing.T) {
+	samples := loadIMUFixture(t, "fetch_session.csv")
+	want := DetectThrows(samples, DefaultThrowDetectionConfig())
+
+	// Batches arrive shuffled, and one is uploaded twice after a retry
+	var batches [][]IMUSample
+	for i := 0; i < len(samples); i += 250 {
+		batches = append(batches, samples[i:min(i+250, len(samples))])
+	}
+	batches = append(batches, batches[3])
+	rand.New(rand.NewSource(1)).Shuffle(len(batches), func(i, j int) { batches[i], batches[j] = batches[j], batches[i] })
+	var uploaded []IMUSample
+	for _, batch := range batches {
+		uploaded = append(uploaded, batch...)
+	}
+
+	got := DetectThrows(uploaded, DefaultThrowDetectionConfig())
+	if len(got.ThrowTimes) != len(want.ThrowTimes) || got.MaxCombo != want.MaxCombo {
+		t.Errorf("batched detection = %d throws, max combo %d; want %d, %d",
+			len(got.ThrowTimes), got.MaxCombo, len(want.ThrowTimes), want.MaxCombo)
+	}
+
+	// Mid-session, the throws so far are counted
+	partial := DetectThrows(samples[:1000], DefaultThrowDetectionConfig())
+	if len(partial.ThrowTimes) != 5 {
+		t.Errorf("first 40 s = %d throws, want 5", len(partial.ThrowTimes))
+	}
+}
+
+func TestThrowDetector_Feed(t *testing.T) {
+	samples := loadIMUFixture(t, "fetch_session.csv")
+	config := DefaultThrowDetectionConfig()
+	want := DetectThrows(samples, config)
+
+	// Batches are fed as they arrive, with the detector stored in between;
+	// a retried batch and one that arrives after a later one are skipped
+	var detector ThrowDetector
+	for i := 0; i < len(samples); i += 250 {
+		batch := samples[i:min(i+250, len(samples))]
+		detector.Feed(batch, config)
+		if i == 750 {
+			if got := detector.Detection(config); len(got.ThrowTimes) != 5 {
+				t.Errorf("first 40 s = %d throws, want 5", len(got.ThrowTimes))
+			}
+			detector.Feed(batch, config)
+			detector.Feed(samples[:250], config)
+		}
+
+		stored, err := json.Marshal(detector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		detector = ThrowDetector{}
+		if err := json.Unmarshal(stored, &detector); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := detector.Detection(config)
+	if len(got.ThrowTimes) != len(want.ThrowTimes) || got.MaxCombo != want.MaxCombo || len(got.Combos) != len(want.Combos) {
+		t.Fatalf("fed detection = %d throws, max combo %d; want %d, %d",
+			len(got.ThrowTimes), got.MaxCombo, len(want.ThrowTimes), want.MaxCombo)
+	}
+	for i := range want.ThrowTimes {
+		if !got.ThrowTimes[i].Equal(want.ThrowTimes[i]) {
+			t.Errorf("throw %d at %s, want %s", i, got.ThrowTimes[i], want.ThrowTimes[i])
+		}
+	}
+}
+
+func TestDetectThrows_Jogging(t *testing.T) {
+	samples := loadIMUFixture(t, "fetch_jogging.csv")
+
+	detection := DetectThrows(samples, DefaultThrowDetectionConfig())
+	if len(detection.ThrowTimes) != 0 {
+		t.Errorf("detected throws %v while jogging, want none", detection.ThrowTimes)
+	}
+
+	// Allowing long bursts counts the shake as one throw
+	config := DefaultThrowDetectionConfig()
+	config.MaxPeakMillis = 3000
+	if detection := DetectThrows(samples, config); len(detection.ThrowTimes) != 1 {
+		t.Errorf("detected %d throws with long bursts allowed, want 1", len(detection.ThrowTimes))
+	}
+}
+
+func TestDetectThrows_Thresholds(t *testing.T) {
+	samples := loadIMUFixture(t, "fetch_session.csv")
+
+	// Without a refractory period the follow-throughs count as throws of
+	// their own
+	config := DefaultThrowDetectionConfig()
+	config.MinIntervalMillis = 0
+	if detection := DetectThrows(samples, config); len(detection.ThrowTimes) != 11 {
+		t.Errorf("detected %d throws without a refractory period, want 11", len(detection.ThrowTimes))
+	}
+
+	// A higher peak threshold misses the softer throws
+	config = DefaultThrowDetectionConfig()
+	config.PeakThreshold = 30
+	if detection := DetectThrows(samples, config); len(detection.ThrowTimes) != 6 {
+		t.Errorf("detected %d throws above 30 m/s², want 6", len(detection.ThrowTimes))
+	}
+}
+
+func TestThrowDetectionConfig_UnmarshalJSON(t *testing.T) {
+	var config ThrowDetectionConfig
+	if err := json.Unmarshal([]byte(`{"peak_threshold": 30}`), &config); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := DefaultThrowDetectionConfig()
+	want.PeakThreshold = 30
+	if config != want {
+		t.Errorf("config = %+v, want %+v", config, want)
+	}
+
+	invalid := []string{
+		`{"peak_threshold": 10}`,
+		`{"reset_threshold": 0}`,
+		`{"max_peak_ms": 0}`,
+		`{"min_interval_ms": -1}`,
+		`{"combo_gap_seconds": 0}`,
+		`{"peak": 30}`,
+	}
+	for _, data := range invalid {
+		if err := json.Unmarshal([]byte(data), &config); !errors.Is(err, ErrInvalidThrowDetection) {
+			t.Errorf("Unmarshal(%s) error = %v, want ErrInvalidThrowDetection", data, err)
+		}
+	}
+}
+
+func TestIMUBatchInput_Validate(t *testing.T) {
+	startedAt := time.Date(2025, 10, 9, 9, 0, 0, 0, time.UTC)
+	now := startedAt.Add(10 * time.Minute)
+	sample := func(at time.Time) IMUSample { return IMUSample{T: at.UnixMilli(), AY: 9.81} }
+
+	valid := IMUBatchInput{Samples: []IMUSample{sample(startedAt.Add(-30 * time.Second)), sample(now)}}
+	if err := valid.Validate(startedAt, now); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	tooMany := IMUBatchInput{Samples: make([]IMUSample, MaxIMUBatchSamples+1)}
+	for i := range tooMany.Samples {
+		tooMany.Samples[i] = sample(now)
+	}
+	invalid := []IMUBatchInput{
+		{},
+		{Samples: []IMUSample{sample(startedAt.Add(-2 * time.Minute))}},
+		{Samples: []IMUSample{sample(now.Add(2 * time.Minute))}},
+		{Samples: []IMUSample{{T: now.UnixMilli(), AX: math.NaN()}}},
+		tooMany,
+	}
+	for i, input := range invalid {
+		if err := input.Validate(startedAt, now); !errors.Is(err, ErrInvalidIMUBatch) {
+			t.Errorf("case %d: Validate() error = %v, want ErrInvalidIMUBatch", i, err)
+		}
+	}
+}
+
+func TestFetchGameData_Apply(t *testing.T) {
+	data := FetchGameData{Throws: 20, Returns: 6, MaxCombo: 9, FrenzyModeActivated: true}
+	now := time.Now()
+	data.Apply(&ThrowDetection{
+		ThrowTimes: []time.Time{now, now.Add(time.Second), now.Add(2 * time.Second), now.Add(3 * time.Second)},
+		MaxCombo:   4,
+	})
+
+	// Returns can't outnumber the detected throws
+	if data.Throws != 4 || data.Returns != 4 || data.SuccessRate != 1 || data.MaxCombo != 4 || !data.ThrowsDetected {
+		t.Errorf("Apply() = %+v", data)
+	}
+	if !data.FrenzyModeActivated {
+		t.Error("Apply() cleared frenzy mode reported by the app")
+	}
+}
+
+// fetchEvents builds a log from a string of throws settled by returns
+// (r), misses (m) or nothing (t, left in the air), a second apart.
+func fetchEvents(start time.Time, throws string) []FetchEvent {
+	var events []FetchEvent
+	at := start
+	for _, throw := range throws {
+		events = append(events, FetchEvent{Type: FetchEventThrow, At: at})
+		at = at.Add(time.Second)
+		switch throw {
+		case 'r':
+			events = append(events, FetchEvent{Type: FetchEventReturn, At: at})
+		case 'm':
+			events = append(events, FetchEvent{Type: FetchEventMiss, At: at})
+		}
+		at = at.Add(time.Second)
+	}
+	return events
+}
+
+func TestScoreFetch(t *testing.T) {
+	start := time.Date(2025, 10, 9, 9, 0, 0, 0, time.UTC)
+	config := FetchXPConfig{XPPerThrow: 1, ComboBonus: 5, ComboLength: 5, FrenzyMultiplier: 2, FrenzyCombo: 10}
+
+	// Twelve returns in a row, a miss, then three more returns
+	score := ScoreFetch(fetchEvents(start, "rrrrrrrrrrrrmrrr"), config)
+
+	if len(score.Throws) != 16 || score.Returns != 15 || score.MaxCombo != 12 {
+		t.Fatalf("ScoreFetch() = %d throws, %d returns, max combo %d; want 16, 15, 12",
+			len(score.Throws), score.Returns, score.MaxCombo)
+	}
+	if len(score.Combos) != 2 || score.Combos[0].Throws != 12 || score.Combos[1].Throws != 3 {
+		t.Fatalf("Combos = %+v, want runs of 12 and 3", score.Combos)
+	}
+	if !score.Combos[1].StartedAt.Equal(score.Throws[13].At) || !score.Combos[1].EndedAt.Equal(score.Throws[15].At) {
+		t.Errorf("second combo = %+v, want the last three throws", score.Combos[1])
+	}
+
+	// The frenzy starts with the tenth return and ends with the miss,
+	// doubling the three throws made in it
+	if len(score.FrenzyWindows) != 1 {
+		t.Fatalf("FrenzyWindows = %+v, want one", score.FrenzyWindows)
+	}
+	frenzy := score.FrenzyWindows[0]
+	if !frenzy.StartedAt.Equal(start.Add(19*time.Second)) || frenzy.EndedAt == nil ||
+		!frenzy.EndedAt.Equal(start.Add(25*time.Second)) || frenzy.Throws != 3 {
+		t.Errorf("frenzy = %+v, want 3 throws from 19 s to 25 s", frenzy)
+	}
+	for i, throw := range score.Throws {
+		if throw.Frenzy != (i >= 10 && i <= 12) {
+			t.Errorf("throw %d frenzy = %v", i, throw.Frenzy)
+		}
+	}
+
+	// 16 throws, 3 doubled, and bonuses at combos of 5 and 10
+	if score.Throws[4].XP != 6 || score.Throws[9].XP != 6 || score.Throws[10].XP != 2 || score.Throws[12].XP != 2 {
+		t.Errorf("per-throw XP = %+v", score.Throws)
+	}
+	if score.XP != 29 {
+		t.Errorf("XP = %d, want 29", score.XP)
+	}
+}
+
+func TestScoreFetch_Unsettled(t *testing.T) {
+	start := time.Date(2025, 10, 9, 9, 0, 0, 0, time.UTC)
+	config := ParseFetchXPConfig([]byte(`{"xp_per_throw": 2}`))
+
+	// A throw made before the last came back counts it as missed, and the
+	// last one is still in the air
+	score := ScoreFetch(fetchEvents(start, "rrtrrt"), config)
+	if len(score.Throws) != 6 || score.Returns != 4 || score.MaxCombo != 2 || len(score.Combos) != 2 {
+		t.Errorf("ScoreFetch() = %+v", score)
+	}
+	if score.Throws[2].Returned || score.Throws[5].Returned || score.XP != 12 {
+		t.Errorf("ScoreFetch() = %+v, want throws 3 and 6 unreturned and 12 XP", score)
+	}
+	if len(score.FrenzyWindows) != 0 {
+		t.Errorf("FrenzyWindows = %+v, want none", score.FrenzyWindows)
+	}
+}
+
+func TestParseFetchXPConfig(t *testing.T) {
+	config := ParseFetchXPConfig([]byte(`{"base_xp_per_minute": 2, "xp_per_throw": 1, "combo_bonus": 5, "frenzy_multiplier": 2}`))
+	want := FetchXPConfig{XPPerThrow: 1, ComboBonus: 5, ComboLength: 5, FrenzyMultiplier: 2, FrenzyCombo: 10}
+	if config != want {
+		t.Errorf("ParseFetchXPConfig() = %+v, want %+v", config, want)
+	}
+}
+
+func TestValidateFetchEvents(t *testing.T) {
+	startedAt := time.Date(2025, 10, 9, 9, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(10 * time.Minute)
+
+	if err := ValidateFetchEvents(fetchEvents(startedAt, "rrmt"), startedAt, &endedAt); err != nil {
+		t.Errorf("ValidateFetchEvents() error = %v", err)
+	}
+	// Still running, so nothing is too late yet
+	late := fetchEvents(endedAt.Add(time.Hour), "r")
+	if err := ValidateFetchEvents(late, startedAt, nil); err != nil {
+		t.Errorf("ValidateFetchEvents() running error = %v", err)
+	}
+
+	throw := FetchEvent{Type: FetchEventThrow, At: startedAt}
+	invalid := [][]FetchEvent{
+		{{Type: FetchEventReturn, At: startedAt}},
+		{throw, {Type: FetchEventReturn, At: startedAt}, {Type: FetchEventMiss, At: startedAt}},
+		{throw, {Type: FetchEventReturn, At: startedAt.Add(-time.Second)}},
+		{{Type: "catch", At: startedAt}},
+		{{Type: FetchEventThrow, At: startedAt.Add(-2 * time.Minute)}},
+		late,
+		make([]FetchEvent, MaxFetchEvents+1),
+	}
+	for i, events := range invalid {
+		if err := ValidateFetchEvents(events, startedAt, &endedAt); !errors.Is(err, ErrInvalidFetchEvents) {
+			t.Errorf("case %d: ValidateFetchEvents() error = %v, want ErrInvalidFetchEvents", i, err)
+		}
+	}
+}
+
+func TestCheckLoggedThrows(t *testing.T) {
+	at := func(seconds int) time.Time { return time.Date(2025, 10, 9, 9, 0, seconds, 0, time.UTC) }
+	detected := []time.Time{at(10), at(20), at(30)}
+	throwAt := func(seconds ...int) []FetchEvent {
+		var events []FetchEvent
+		for _, s := range seconds {
+			events = append(events, FetchEvent{Type: FetchEventThrow, At: at(s)}, FetchEvent{Type: FetchEventReturn, At: at(s + 1)})
+		}
+		return events
+	}
+
+	// Within the tolerance, and leaving a detected throw out
+	if err := CheckLoggedThrows(throwAt(11, 29), detected); err != nil {
+		t.Errorf("CheckLoggedThrows() error = %v", err)
+	}
+
+	invalid := map[string][]FetchEvent{
+		"undetected throw":          throwAt(10, 15, 20),
+		"two throws for one":        throwAt(10, 11),
+		"more throws than detected": throwAt(10, 20, 30, 31),
+	}
+	for name, events := range invalid {
+		if err := CheckLoggedThrows(events, detected); !errors.Is(err, ErrInvalidFetchEvents) {
+			t.Errorf("%s: CheckLoggedThrows() error = %v, want ErrInvalidFetchEvents", name, err)
+		}
+	}
+}
+
+func TestFetchGameData_ApplyScore(t *testing.T) {
+	start := time.Date(2025, 10, 9, 9, 0, 0, 0, time.UTC)
+	data := FetchGameData{Throws: 40, Returns: 40, MaxCombo: 40, FrenzyModeActivated: true}
+	data.ApplyScore(ScoreFetch(fetchEvents(start, "rrrm"), ParseFetchXPConfig(nil)))
+
+	if data.Throws != 4 || data.Returns != 3 || data.SuccessRate != 0.75 || data.MaxCombo != 3 ||
+		data.FrenzyModeActivated || len(data.ThrowLog) != 4 {
+		t.Errorf("ApplyScore() = %+v", data)
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidGameData = errors.New("invalid game data")
+
+// Game is the server side of a game type with data of its own. Its
+// activities' game data decodes into the game's own type, such as
+// WalkGameData, and the Game checks and scores it and says what it counts
+// towards. Game types without a Game still log activities, earning no XP
+// of their own.
+type Game interface {
+	// Validate checks the game data the app sent for an activity, which
+	// may still be running.
+	Validate(a *Activity) error
+	// Prepare fills in what the server works out itself from valid game
+	// data, like fetch's counts from its event log.
+	Prepare(a *Activity) error
+	// XP is what a finished activity earns by the game type's XP config,
+	// before the pet type's multiplier and skills.
+	XP(config json.RawMessage, a *Activity) int
+	// Check returns why a finished activity looks implausible, or nil.
+	Check(a *Activity) *ActivityFlag
+	// MissionProgress adds how far a finished activity gets the game's own
+	// mission types.
+	MissionProgress(a *Activity, progress map[MissionType]int)
+	// DailyMission is the game's daily mission for the pet, or nil for
+	// playing it once.
+	DailyMission(pet *Pet) *MissionGoal
+	// Records returns what a finished activity reached towards the game's
+	// own records.
+	Records(a *Activity) []RecordValue
+}
+
+// MissionGoal is what a game's daily mission asks for.
+type MissionGoal struct {
+	Type        MissionType
+	Target      int
+	Description string
+}
+
+// games are the game types with data of their own, by game type ID. A new
+// game type registers its Game here.
+var games = map[string]Game{
+	"walk":  walkGame{},
+	"fetch": fetchGame{},
+	"play":  playGame{},
+}
+
+// GameFor returns the game of a game type, or one without data of its own.
+func GameFor(gameTypeID string) Game {
+	if game, ok := games[gameTypeID]; ok {
+		return game
+	}
+	return baseGame{}
+}
+
+// baseGame is a game without data of its own. Games embed it for the hooks
+// they don't need.
+type baseGame struct{}
+
+func (baseGame) Validate(*Activity) error                       { return nil }
+func (baseGame) Prepare(*Activity) error                        { return nil }
+func (baseGame) XP(json.RawMessage, *Activity) int              { return 0 }
+func (baseGame) Check(*Activity) *ActivityFlag                  { return nil }
+func (baseGame) MissionProgress(*Activity, map[MissionType]int) {}
+func (baseGame) DailyMission(*Pet) *MissionGoal                 { return nil }
+func (baseGame) Records(*Activity) []RecordValue                { return nil }
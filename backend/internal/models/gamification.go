@@ -17,6 +17,63 @@ type Achievement struct {
 	Category    *string         `json:"category,omitempty"`
 	Criteria    json.RawMessage `json:"criteria"`
 	XPReward    int             `json:"xp_reward"`
+	// RewardItemID is the cosmetic item unlocking the achievement grants
+	RewardItemID *string `json:"reward_item_id,omitempty"`
+}
+
+type AchievementCriteriaType string
+
+const (
+	AchievementActivityCount AchievementCriteriaType = "activity_count"
+	AchievementStreakDays    AchievementCriteriaType = "streak_days"
+	AchievementTotalDistance AchievementCriteriaType = "total_distance"
+)
+
+// AchievementCriteria is what a pet has to do to unlock an achievement:
+// finish Count activities of GameType (any game when empty), keep a streak
+// of Days, or walk Meters in total.
+type AchievementCriteria struct {
+	Type     AchievementCriteriaType `json:"type"`
+	GameType string                  `json:"game_type,omitempty"`
+	Count    int                     `json:"count,omitempty"`
+	Days     int                     `json:"days,omitempty"`
+	Meters   float64                 `json:"meters,omitempty"`
+}
+
+// AchievementProgress is what achievement criteria are checked against:
+// the pet's finished, unflagged activities by game type, the distance they
+// covered and the pet's current streak.
+type AchievementProgress struct {
+	ActivityCounts map[string]int
+	DistanceMeters float64
+	StreakDays     int
+}
+
+// Earned reports whether the progress meets the achievement's criteria.
+// Criteria that can't be read or are of an unknown type are never met.
+func (a *Achievement) Earned(progress *AchievementProgress) bool {
+	var criteria AchievementCriteria
+	if err := json.Unmarshal(a.Criteria, &criteria); err != nil {
+		return false
+	}
+
+	switch criteria.Type {
+	case AchievementActivityCount:
+		count := progress.ActivityCounts[criteria.GameType]
+		if criteria.GameType == "" {
+			count = 0
+			for _, n := range progress.ActivityCounts {
+				count += n
+			}
+		}
+		return criteria.Count > 0 && count >= criteria.Count
+	case AchievementStreakDays:
+		return criteria.Days > 0 && progress.StreakDays >= criteria.Days
+	case AchievementTotalDistance:
+		return criteria.Meters > 0 && progress.DistanceMeters >= criteria.Meters
+	default:
+		return false
+	}
 }
 
 type UserAchievement struct {
@@ -67,11 +124,14 @@ const (
 	MissionTypeActivityCount MissionType = "activity_count"
 	MissionTypeFetchThrows   MissionType = "fetch_throws"
 	MissionTypeExploreZones  MissionType = "explore_zones"
+	MissionTypePlayPounces   MissionType = "play_pounces"
 )
 
 type Mission struct {
 	ID           uuid.UUID   `json:"id"`
 	UserID       uuid.UUID   `json:"user_id"`
+	PetID        *uuid.UUID  `json:"pet_id,omitempty"`
+	GameTypeID   *string     `json:"game_type_id,omitempty"`
 	MissionType  MissionType `json:"mission_type"`
 	Description  string      `json:"description"`
 	TargetValue  int         `json:"target_value"`
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAchievementEarned(t *testing.T) {
+	// Criteria as seeded by the migrations
+	firstPlay := &Achievement{ID: "first_play", Criteria: json.RawMessage(`{"type": "activity_count", "game_type": "play", "count": 1}`)}
+	firstWalk := &Achievement{ID: "first_walk", Criteria: json.RawMessage(`{"type": "activity_count", "game_type": "walk", "count": 1}`)}
+	streak7 := &Achievement{ID: "streak_7", Criteria: json.RawMessage(`{"type": "streak_days", "days": 7}`)}
+	distance10k := &Achievement{ID: "distance_10k", Criteria: json.RawMessage(`{"type": "total_distance", "meters": 10000}`)}
+	anyFive := &Achievement{ID: "any_five", Criteria: json.RawMessage(`{"type": "activity_count", "count": 5}`)}
+	unknown := &Achievement{ID: "unknown", Criteria: json.RawMessage(`{"type": "moon_landings", "count": 1}`)}
+
+	firstPlaySession := &AchievementProgress{ActivityCounts: map[string]int{"play": 1}, StreakDays: 1}
+
+	tests := []struct {
+		name        string
+		achievement *Achievement
+		progress    *AchievementProgress
+		want        bool
+	}{
+		{"first play session unlocks first_play", firstPlay, firstPlaySession, true},
+		{"first play session doesn't unlock first_walk", firstWalk, firstPlaySession, false},
+		{"walks don't unlock first_play", firstPlay, &AchievementProgress{ActivityCounts: map[string]int{"walk": 3}}, false},
+		{"streak reached", streak7, &AchievementProgress{StreakDays: 7}, true},
+		{"streak short", streak7, &AchievementProgress{StreakDays: 6}, false},
+		{"distance reached", distance10k, &AchievementProgress{DistanceMeters: 10250}, true},
+		{"distance short", distance10k, &AchievementProgress{DistanceMeters: 9999}, false},
+		{"any game counts", anyFive, &AchievementProgress{ActivityCounts: map[string]int{"walk": 3, "play": 2}}, true},
+		{"unknown criteria", unknown, firstPlaySession, false},
+	}
+	for _, tt := range tests {
+		if got := tt.achievement.Earned(tt.progress); got != tt.want {
+			t.Errorf("%s: Earned() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidHealthRecord = errors.New("invalid health record")
+
+// vaccinationDueSoonWindow is how far ahead the health summary lists
+// upcoming vaccinations.
+const vaccinationDueSoonWindow = 30 * 24 * time.Hour
+
+type WeightEntry struct {
+	ID         uuid.UUID  `json:"id"`
+	PetID      uuid.UUID  `json:"pet_id"`
+	WeightKg   float64    `json:"weight_kg"`
+	MeasuredAt time.Time  `json:"measured_at"`
+	Notes      *string    `json:"notes,omitempty"`
+	RecordedBy *uuid.UUID `json:"recorded_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type WeightInput struct {
+	WeightKg   float64   `json:"weight_kg"`
+	MeasuredAt time.Time `json:"measured_at"`
+	Notes      *string   `json:"notes,omitempty"`
+}
+
+func (i WeightInput) Validate() error {
+	if i.WeightKg <= 0 || i.WeightKg > 200 {
+		return healthError("weight_kg must be between 0 and 200")
+	}
+	if i.MeasuredAt.IsZero() {
+		return healthError("measured_at is required")
+	}
+	return nil
+}
+
+type VetVisit struct {
+	ID         uuid.UUID  `json:"id"`
+	PetID      uuid.UUID  `json:"pet_id"`
+	VisitedAt  time.Time  `json:"visited_at"`
+	Clinic     *string    `json:"clinic,omitempty"`
+	VetName    *string    `json:"vet_name,omitempty"`
+	Reason     string     `json:"reason"`
+	Notes      *string    `json:"notes,omitempty"`
+	RecordedBy *uuid.UUID `json:"recorded_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type VetVisitInput struct {
+	VisitedAt time.Time `json:"visited_at"`
+	Clinic    *string   `json:"clinic,omitempty"`
+	VetName   *string   `json:"vet_name,omitempty"`
+	Reason    string    `json:"reason"`
+	Notes     *string   `json:"notes,omitempty"`
+}
+
+func (i VetVisitInput) Validate() error {
+	if i.VisitedAt.IsZero() {
+		return healthError("visited_at is required")
+	}
+	if strings.TrimSpace(i.Reason) == "" {
+		return healthError("reason is required")
+	}
+	return nil
+}
+
+type Vaccination struct {
+	ID             uuid.UUID  `json:"id"`
+	PetID          uuid.UUID  `json:"pet_id"`
+	Name           string     `json:"name"`
+	AdministeredAt time.Time  `json:"administered_at"`
+	DueAt          *time.Time `json:"due_at,omitempty"`
+	Clinic         *string    `json:"clinic,omitempty"`
+	BatchNumber    *string    `json:"batch_number,omitempty"`
+	Notes          *string    `json:"notes,omitempty"`
+	RecordedBy     *uuid.UUID `json:"recorded_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// IsOverdue reports whether the booster date has passed.
+func (v *Vaccination) IsOverdue(now time.Time) bool {
+	return v.DueAt != nil && v.DueAt.Before(now)
+}
+
+type VaccinationInput struct {
+	Name           string     `json:"name"`
+	AdministeredAt time.Time  `json:"administered_at"`
+	DueAt          *time.Time `json:"due_at,omitempty"`
+	Clinic         *string    `json:"clinic,omitempty"`
+	BatchNumber    *string    `json:"batch_number,omitempty"`
+	Notes          *string    `json:"notes,omitempty"`
+}
+
+func (i VaccinationInput) Validate() error {
+	if strings.TrimSpace(i.Name) == "" {
+		return healthError("name is required")
+	}
+	if i.AdministeredAt.IsZero() {
+		return healthError("administered_at is required")
+	}
+	if i.DueAt != nil && i.DueAt.Before(i.AdministeredAt) {
+		return healthError("due_at must be after administered_at")
+	}
+	return nil
+}
+
+// Medication is a course of treatment given every IntervalHours between
+// StartDate and EndDate (open ended when EndDate is nil).
+type Medication struct {
+	ID            uuid.UUID  `json:"id"`
+	PetID         uuid.UUID  `json:"pet_id"`
+	Name          string     `json:"name"`
+	Dosage        string     `json:"dosage"`
+	IntervalHours int        `json:"interval_hours"`
+	StartDate     time.Time  `json:"start_date"`
+	EndDate       *time.Time `json:"end_date,omitempty"`
+	Notes         *string    `json:"notes,omitempty"`
+	RecordedBy    *uuid.UUID `json:"recorded_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (m *Medication) IsActive(now time.Time) bool {
+	return !m.StartDate.After(now) && (m.EndDate == nil || m.EndDate.After(now))
+}
+
+type MedicationInput struct {
+	Name          string     `json:"name"`
+	Dosage        string     `json:"dosage"`
+	IntervalHours int        `json:"interval_hours"`
+	StartDate     time.Time  `json:"start_date"`
+	EndDate       *time.Time `json:"end_date,omitempty"`
+	Notes         *string    `json:"notes,omitempty"`
+}
+
+func (i MedicationInput) Validate() error {
+	if strings.TrimSpace(i.Name) == "" {
+		return healthError("name is required")
+	}
+	if strings.TrimSpace(i.Dosage) == "" {
+		return healthError("dosage is required")
+	}
+	if i.IntervalHours <= 0 {
+		return healthError("interval_hours must be positive")
+	}
+	if i.StartDate.IsZero() {
+		return healthError("start_date is required")
+	}
+	if i.EndDate != nil && i.EndDate.Before(i.StartDate) {
+		return healthError("end_date must be after start_date")
+	}
+	return nil
+}
+
+// WeightPoint is one entry in a weight trend with the average of the
+// entries in the preceding week, which smooths out scale noise.
+type WeightPoint struct {
+	MeasuredAt time.Time `json:"measured_at"`
+	WeightKg   float64   `json:"weight_kg"`
+	AverageKg  float64   `json:"average_kg"`
+}
+
+type WeightTrend struct {
+	Points []WeightPoint `json:"points"`
+	// ChangeKg is the difference between the last and first entry.
+	ChangeKg float64 `json:"change_kg"`
+	// WeeklyChangeKg is the least squares slope of weight over time.
+	WeeklyChangeKg float64 `json:"weekly_change_kg"`
+}
+
+// NewWeightTrend builds a trend series from weight entries in any order.
+func NewWeightTrend(entries []*WeightEntry) *WeightTrend {
+	sorted := append([]*WeightEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].MeasuredAt.Before(sorted[j].MeasuredAt)
+	})
+
+	trend := &WeightTrend{Points: []WeightPoint{}}
+	if len(sorted) == 0 {
+		return trend
+	}
+
+	const window = 7 * 24 * time.Hour
+	start := 0
+	sum := 0.0
+	for i, entry := range sorted {
+		sum += entry.WeightKg
+		for sorted[start].MeasuredAt.Before(entry.MeasuredAt.Add(-window)) {
+			sum -= sorted[start].WeightKg
+			start++
+		}
+		trend.Points = append(trend.Points, WeightPoint{
+			MeasuredAt: entry.MeasuredAt,
+			WeightKg:   entry.WeightKg,
+			AverageKg:  roundKg(sum / float64(i-start+1)),
+		})
+	}
+
+	first, last := sorted[0], sorted[len(sorted)-1]
+	trend.ChangeKg = roundKg(last.WeightKg - first.WeightKg)
+
+	// Slope in kg per week, with time measured from the first entry
+	var n, sumX, sumY, sumXY, sumXX float64
+	for _, entry := range sorted {
+		x := entry.MeasuredAt.Sub(first.MeasuredAt).Hours() / (24 * 7)
+		n++
+		sumX += x
+		sumY += entry.WeightKg
+		sumXY += x * entry.WeightKg
+		sumXX += x * x
+	}
+	if denominator := n*sumXX - sumX*sumX; denominator != 0 {
+		trend.WeeklyChangeKg = roundKg((n*sumXY - sumX*sumY) / denominator)
+	}
+
+	return trend
+}
+
+// HealthSummary is the at-a-glance view of a pet's health records.
+type HealthSummary struct {
+	LatestWeight         *WeightEntry   `json:"latest_weight,omitempty"`
+	WeightTrend          *WeightTrend   `json:"weight_trend"`
+	LastVetVisit         *VetVisit      `json:"last_vet_visit,omitempty"`
+	OverdueVaccinations  []*Vaccination `json:"overdue_vaccinations"`
+	UpcomingVaccinations []*Vaccination `json:"upcoming_vaccinations"`
+	ActiveMedications    []*Medication  `json:"active_medications"`
+}
+
+// NewHealthSummary assembles the summary. Only the most recent record of
+// each vaccine counts, so a booster clears an older overdue dose.
+func NewHealthSummary(weights []*WeightEntry, visits []*VetVisit, vaccinations []*Vaccination, medications []*Medication, now time.Time) *HealthSummary {
+	summary := &HealthSummary{
+		WeightTrend:          NewWeightTrend(weights),
+		OverdueVaccinations:  []*Vaccination{},
+		UpcomingVaccinations: []*Vaccination{},
+		ActiveMedications:    []*Medication{},
+	}
+
+	for _, w := range weights {
+		if summary.LatestWeight == nil || w.MeasuredAt.After(summary.LatestWeight.MeasuredAt) {
+			summary.LatestWeight = w
+		}
+	}
+
+	for _, v := range visits {
+		if !v.VisitedAt.After(now) && (summary.LastVetVisit == nil || v.VisitedAt.After(summary.LastVetVisit.VisitedAt)) {
+			summary.LastVetVisit = v
+		}
+	}
+
+	latest := map[string]*Vaccination{}
+	for _, v := range vaccinations {
+		key := strings.ToLower(strings.TrimSpace(v.Name))
+		if current, ok := latest[key]; !ok || v.AdministeredAt.After(current.AdministeredAt) {
+			latest[key] = v
+		}
+	}
+	for _, v := range latest {
+		switch {
+		case v.IsOverdue(now):
+			summary.OverdueVaccinations = append(summary.OverdueVaccinations, v)
+		case v.DueAt != nil && v.DueAt.Before(now.Add(vaccinationDueSoonWindow)):
+			summary.UpcomingVaccinations = append(summary.UpcomingVaccinations, v)
+		}
+	}
+	sortByDue(summary.OverdueVaccinations)
+	sortByDue(summary.UpcomingVaccinations)
+
+	for _, m := range medications {
+		if m.IsActive(now) {
+			summary.ActiveMedications = append(summary.ActiveMedications, m)
+		}
+	}
+
+	return summary
+}
+
+func sortByDue(vaccinations []*Vaccination) {
+	sort.Slice(vaccinations, func(i, j int) bool {
+		return vaccinations[i].DueAt.Before(*vaccinations[j].DueAt)
+	})
+}
+
+func roundKg(kg float64) float64 {
+	return math.Round(kg*100) / 100
+}
+
+func healthError(message string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidHealthRecord, message)
+}
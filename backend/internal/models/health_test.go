@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewWeightTrend(t *testing.T) {
+	start := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	entries := []*WeightEntry{
+		{WeightKg: 21.0, MeasuredAt: start.AddDate(0, 0, 14)},
+		{WeightKg: 20.0, MeasuredAt: start},
+		{WeightKg: 20.5, MeasuredAt: start.AddDate(0, 0, 7)},
+	}
+
+	trend := NewWeightTrend(entries)
+
+	if len(trend.Points) != 3 {
+		t.Fatalf("len(Points) = %d, want 3", len(trend.Points))
+	}
+	if !trend.Points[0].MeasuredAt.Equal(start) {
+		t.Errorf("points not sorted by date: first = %v", trend.Points[0].MeasuredAt)
+	}
+	// The second point averages itself with the first, exactly a week earlier
+	if trend.Points[1].AverageKg != 20.25 {
+		t.Errorf("Points[1].AverageKg = %v, want 20.25", trend.Points[1].AverageKg)
+	}
+	if trend.ChangeKg != 1.0 {
+		t.Errorf("ChangeKg = %v, want 1.0", trend.ChangeKg)
+	}
+	if trend.WeeklyChangeKg != 0.5 {
+		t.Errorf("WeeklyChangeKg = %v, want 0.5", trend.WeeklyChangeKg)
+	}
+}
+
+func TestNewWeightTrend_Empty(t *testing.T) {
+	trend := NewWeightTrend(nil)
+	if trend.Points == nil || len(trend.Points) != 0 || trend.ChangeKg != 0 || trend.WeeklyChangeKg != 0 {
+		t.Errorf("NewWeightTrend(nil) = %+v, want empty trend", trend)
+	}
+}
+
+func TestNewHealthSummary_Vaccinations(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	date := func(months int) *time.Time {
+		d := now.AddDate(0, months, 0)
+		return &d
+	}
+
+	vaccinations := []*Vaccination{
+		// Rabies booster is overdue
+		{Name: "Rabies", AdministeredAt: *date(-13), DueAt: date(-1)},
+		// Old leptospirosis dose was overdue but a booster was given since
+		{Name: "Leptospirosis", AdministeredAt: *date(-14), DueAt: date(-2)},
+		{Name: "leptospirosis", AdministeredAt: *date(-1), DueAt: date(11)},
+		// Kennel cough due in two weeks
+		{Name: "Kennel cough", AdministeredAt: *date(-6), DueAt: func() *time.Time { d := now.AddDate(0, 0, 14); return &d }()},
+		// No booster date
+		{Name: "Microchip check", AdministeredAt: *date(-3)},
+	}
+
+	summary := NewHealthSummary(nil, nil, vaccinations, nil, now)
+
+	if len(summary.OverdueVaccinations) != 1 || summary.OverdueVaccinations[0].Name != "Rabies" {
+		t.Errorf("OverdueVaccinations = %v, want only Rabies", names(summary.OverdueVaccinations))
+	}
+	if len(summary.UpcomingVaccinations) != 1 || summary.UpcomingVaccinations[0].Name != "Kennel cough" {
+		t.Errorf("UpcomingVaccinations = %v, want only Kennel cough", names(summary.UpcomingVaccinations))
+	}
+}
+
+func TestNewHealthSummary_LatestRecords(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	end := now.AddDate(0, 0, -1)
+
+	weights := []*WeightEntry{
+		{WeightKg: 20, MeasuredAt: now.AddDate(0, 0, -10)},
+		{WeightKg: 21, MeasuredAt: now.AddDate(0, 0, -2)},
+	}
+	visits := []*VetVisit{
+		{Reason: "Checkup", VisitedAt: now.AddDate(0, -1, 0)},
+		{Reason: "Booked", VisitedAt: now.AddDate(0, 0, 5)},
+	}
+	medications := []*Medication{
+		{Name: "Flea treatment", StartDate: now.AddDate(0, -2, 0)},
+		{Name: "Antibiotics", StartDate: now.AddDate(0, 0, -8), EndDate: &end},
+	}
+
+	summary := NewHealthSummary(weights, visits, nil, medications, now)
+
+	if summary.LatestWeight == nil || summary.LatestWeight.WeightKg != 21 {
+		t.Errorf("LatestWeight = %+v, want 21kg", summary.LatestWeight)
+	}
+	if summary.LastVetVisit == nil || summary.LastVetVisit.Reason != "Checkup" {
+		t.Errorf("LastVetVisit = %+v, want past checkup", summary.LastVetVisit)
+	}
+	if len(summary.ActiveMedications) != 1 || summary.ActiveMedications[0].Name != "Flea treatment" {
+		t.Errorf("ActiveMedications = %+v, want only flea treatment", summary.ActiveMedications)
+	}
+}
+
+func TestHealthInputValidation(t *testing.T) {
+	now := time.Now()
+	before := now.Add(-time.Hour)
+
+	invalid := []interface{ Validate() error }{
+		WeightInput{WeightKg: 0, MeasuredAt: now},
+		WeightInput{WeightKg: 12},
+		VetVisitInput{VisitedAt: now},
+		VaccinationInput{Name: "Rabies", AdministeredAt: now, DueAt: &before},
+		MedicationInput{Name: "Pill", Dosage: "1 tablet", IntervalHours: 0, StartDate: now},
+		MedicationInput{Name: "Pill", Dosage: "1 tablet", IntervalHours: 12, StartDate: now, EndDate: &before},
+	}
+	for _, input := range invalid {
+		if err := input.Validate(); !errors.Is(err, ErrInvalidHealthRecord) {
+			t.Errorf("%+v.Validate() = %v, want %v", input, err, ErrInvalidHealthRecord)
+		}
+	}
+
+	if err := (WeightInput{WeightKg: 12.5, MeasuredAt: now}).Validate(); err != nil {
+		t.Errorf("valid weight rejected: %v", err)
+	}
+}
+
+func names(vaccinations []*Vaccination) []string {
+	var result []string
+	for _, v := range vaccinations {
+		result = append(result, v.Name)
+	}
+	return result
+}
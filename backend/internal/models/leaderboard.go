@@ -0,0 +1,162 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard")
+
+// LeaderboardMetric is what pets are ranked by.
+type LeaderboardMetric string
+
+const (
+	LeaderboardMetricXP             LeaderboardMetric = "xp"
+	LeaderboardMetricDistanceMeters LeaderboardMetric = "distance_meters"
+	LeaderboardMetricWalkMinutes    LeaderboardMetric = "walk_minutes"
+	LeaderboardMetricStreakDays     LeaderboardMetric = "streak_days"
+)
+
+func (m LeaderboardMetric) Valid() bool {
+	switch m {
+	case LeaderboardMetricXP, LeaderboardMetricDistanceMeters, LeaderboardMetricWalkMinutes, LeaderboardMetricStreakDays:
+		return true
+	}
+	return false
+}
+
+// Cumulative reports whether a pet's activities add up over the period.
+// The others keep the best value reached: the longest streak of the week,
+// not the sum of every day's streak.
+func (m LeaderboardMetric) Cumulative() bool {
+	return m != LeaderboardMetricStreakDays
+}
+
+// LeaderboardPeriod is the calendar window a leaderboard covers.
+type LeaderboardPeriod string
+
+const (
+	LeaderboardPeriodDaily   LeaderboardPeriod = "daily"
+	LeaderboardPeriodWeekly  LeaderboardPeriod = "weekly"
+	LeaderboardPeriodMonthly LeaderboardPeriod = "monthly"
+	LeaderboardPeriodAllTime LeaderboardPeriod = "all_time"
+)
+
+// LeaderboardPeriods lists every period a score is recorded for.
+var LeaderboardPeriods = []LeaderboardPeriod{
+	LeaderboardPeriodDaily,
+	LeaderboardPeriodWeekly,
+	LeaderboardPeriodMonthly,
+	LeaderboardPeriodAllTime,
+}
+
+func (p LeaderboardPeriod) Valid() bool {
+	switch p {
+	case LeaderboardPeriodDaily, LeaderboardPeriodWeekly, LeaderboardPeriodMonthly, LeaderboardPeriodAllTime:
+		return true
+	}
+	return false
+}
+
+// Start returns the local date the period containing t begins on, as
+// midnight UTC so it maps onto a DATE column. Weeks start on Monday; all
+// time starts at the Unix epoch.
+func (p LeaderboardPeriod) Start(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	switch p {
+	case LeaderboardPeriodDaily:
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	case LeaderboardPeriodWeekly:
+		sinceMonday := (int(local.Weekday()) + 6) % 7
+		return time.Date(local.Year(), local.Month(), local.Day()-sinceMonday, 0, 0, 0, 0, time.UTC)
+	case LeaderboardPeriodMonthly:
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Unix(0, 0).UTC()
+	}
+}
+
+// LeaderboardScope is whose pets a leaderboard ranks.
+type LeaderboardScope string
+
+const (
+	LeaderboardScopeGlobal  LeaderboardScope = "global"
+	LeaderboardScopeFriends LeaderboardScope = "friends"
+	LeaderboardScopeArea    LeaderboardScope = "area"
+)
+
+func (s LeaderboardScope) Valid() bool {
+	return s == LeaderboardScopeGlobal || s == LeaderboardScopeFriends || s == LeaderboardScopeArea
+}
+
+// LeaderboardScore is what one activity adds to a pet's leaderboards.
+type LeaderboardScore struct {
+	Metric LeaderboardMetric
+	Value  float64
+}
+
+// ActivityScores returns the scores a finished activity earns its pet.
+// streakDays is the pet's streak after the activity. Flagged activities
+// earn nothing, so implausible walks don't top the boards.
+func ActivityScores(a *Activity, streakDays int) []LeaderboardScore {
+	if a.DurationSeconds == nil || a.FlagReason != nil {
+		return nil
+	}
+
+	scores := []LeaderboardScore{
+		{LeaderboardMetricXP, float64(a.XPEarned)},
+		{LeaderboardMetricStreakDays, float64(streakDays)},
+	}
+	if a.GameTypeID == "walk" {
+		scores = append(scores, LeaderboardScore{LeaderboardMetricWalkMinutes, float64(*a.DurationSeconds) / 60})
+
+		var walk WalkGameData
+		if err := json.Unmarshal(a.GameData, &walk); err == nil && walk.DistanceMeters > 0 {
+			scores = append(scores, LeaderboardScore{LeaderboardMetricDistanceMeters, walk.DistanceMeters})
+		}
+	}
+	return scores
+}
+
+// LeaderboardQuery picks a leaderboard and how many of its top pets to
+// list.
+type LeaderboardQuery struct {
+	Metric LeaderboardMetric
+	Period LeaderboardPeriod
+	Scope  LeaderboardScope
+	Limit  int
+}
+
+func (q LeaderboardQuery) Validate() error {
+	switch {
+	case !q.Metric.Valid():
+		return fmt.Errorf("%w: unknown metric %q", ErrInvalidLeaderboard, q.Metric)
+	case !q.Period.Valid():
+		return fmt.Errorf("%w: unknown period %q", ErrInvalidLeaderboard, q.Period)
+	case !q.Scope.Valid():
+		return fmt.Errorf("%w: unknown scope %q", ErrInvalidLeaderboard, q.Scope)
+	}
+	return nil
+}
+
+// Leaderboard is the top of a ranking, with the viewer's own pets listed
+// under Me wherever they place.
+type Leaderboard struct {
+	Metric  LeaderboardMetric   `json:"metric"`
+	Period  LeaderboardPeriod   `json:"period"`
+	Scope   LeaderboardScope    `json:"scope"`
+	Area    string              `json:"area,omitempty"`
+	Entries []*LeaderboardEntry `json:"entries"`
+	Me      []*LeaderboardEntry `json:"me"`
+}
+
+// LeaderboardEntry is a pet's place in a ranking. Pets with the same value
+// share a rank.
+type LeaderboardEntry struct {
+	Rank  int          `json:"rank"`
+	Value float64      `json:"value"`
+	Pet   *FeedPet     `json:"pet"`
+	Owner *UserProfile `json:"owner"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardPeriod_Start(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	// Sunday 20:00 UTC is already Monday in Tokyo
+	now := time.Date(2024, 6, 16, 20, 0, 0, 0, time.UTC)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		period LeaderboardPeriod
+		loc    *time.Location
+		want   time.Time
+	}{
+		{LeaderboardPeriodDaily, time.UTC, date(2024, 6, 16)},
+		{LeaderboardPeriodDaily, tokyo, date(2024, 6, 17)},
+		{LeaderboardPeriodWeekly, time.UTC, date(2024, 6, 10)},
+		{LeaderboardPeriodWeekly, tokyo, date(2024, 6, 17)},
+		{LeaderboardPeriodMonthly, tokyo, date(2024, 6, 1)},
+		{LeaderboardPeriodAllTime, tokyo, date(1970, 1, 1)},
+	}
+	for _, tt := range tests {
+		if got := tt.period.Start(now, tt.loc); !got.Equal(tt.want) {
+			t.Errorf("%s in %s: Start() = %v, want %v", tt.period, tt.loc, got, tt.want)
+		}
+	}
+
+	// The last day of the month in Tokyo belongs to the next month in UTC
+	if got := LeaderboardPeriodMonthly.Start(time.Date(2024, 6, 30, 16, 0, 0, 0, time.UTC), tokyo); !got.Equal(date(2024, 7, 1)) {
+		t.Errorf("Start() at the turn of the month = %v, want 2024-07-01", got)
+	}
+}
+
+func TestActivityScores(t *testing.T) {
+	seconds := 1800
+	walk := &Activity{
+		GameTypeID:      "walk",
+		DurationSeconds: &seconds,
+		XPEarned:        120,
+		GameData:        json.RawMessage(`{"distance_meters": 2500}`),
+	}
+
+	got := map[LeaderboardMetric]float64{}
+	for _, score := range ActivityScores(walk, 4) {
+		got[score.Metric] = score.Value
+	}
+	want := map[LeaderboardMetric]float64{
+		LeaderboardMetricXP:             120,
+		LeaderboardMetricStreakDays:     4,
+		LeaderboardMetricWalkMinutes:    30,
+		LeaderboardMetricDistanceMeters: 2500,
+	}
+	if len(got) != len(want) {
+		t.Errorf("ActivityScores() = %v, want %v", got, want)
+	}
+	for metric, value := range want {
+		if got[metric] != value {
+			t.Errorf("ActivityScores() %s = %v, want %v", metric, got[metric], value)
+		}
+	}
+
+	fetch := &Activity{GameTypeID: "fetch", DurationSeconds: &seconds, XPEarned: 50}
+	if scores := ActivityScores(fetch, 1); len(scores) != 2 {
+		t.Errorf("ActivityScores() for fetch = %v, want XP and streak only", scores)
+	}
+
+	flag := ActivityFlagTooFast
+	walk.FlagReason = &flag
+	if scores := ActivityScores(walk, 4); scores != nil {
+		t.Errorf("ActivityScores() for a flagged walk = %v, want none", scores)
+	}
+
+	if scores := ActivityScores(&Activity{GameTypeID: "walk"}, 1); scores != nil {
+		t.Errorf("ActivityScores() for an unfinished walk = %v, want none", scores)
+	}
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInvalidLevelingConfig = errors.New("invalid leveling config")
+
+// LevelingConfig is how pets level up: the XP curve, the tiers levels are
+// grouped into and the rewards for reaching a level. It is kept in the
+// leveling_config table; missing keys fall back to DefaultLevelingConfig.
+// Changing the curve moves pets as they next earn XP, and levels never go
+// down.
+type LevelingConfig struct {
+	// Level L takes BaseXP × (L-1)^Exponent total XP
+	BaseXP   int     `json:"base_xp"`
+	Exponent float64 `json:"exponent"`
+	// MaxLevel caps levels, or 0 for no cap.
+	MaxLevel int `json:"max_level,omitempty"`
+	// Tiers group levels from the given level on, e.g. Explorer from 10.
+	// Pet types name individual levels with their own level titles.
+	Tiers []LevelTier `json:"tiers"`
+	// Rewards are bonus skill points on top of the point every level
+	// earns. Cosmetic items are unlocked by their own unlock_level.
+	Rewards []LevelReward `json:"rewards,omitempty"`
+}
+
+type LevelTier struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	FromLevel int    `json:"from_level"`
+}
+
+type LevelReward struct {
+	Level       int `json:"level"`
+	SkillPoints int `json:"skill_points"`
+}
+
+// maxLevelingExponent keeps high levels within reach.
+const maxLevelingExponent = 3
+
+// DefaultLevelingConfig is used for keys the stored config leaves out.
+func DefaultLevelingConfig() LevelingConfig {
+	return LevelingConfig{
+		BaseXP:   100,
+		Exponent: 2,
+		Tiers: []LevelTier{
+			{ID: "puppy", Name: "Puppy", FromLevel: 1},
+			{ID: "adventurer", Name: "Adventurer", FromLevel: 5},
+			{ID: "explorer", Name: "Explorer", FromLevel: 10},
+			{ID: "legend", Name: "Legend", FromLevel: 20},
+		},
+	}
+}
+
+// ParseLevelingConfig reads the stored config over the defaults and
+// validates it. Unknown keys are rejected so typos don't go unnoticed.
+func ParseLevelingConfig(data []byte) (*LevelingConfig, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+
+	config := DefaultLevelingConfig()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLevelingConfig, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (c *LevelingConfig) Validate() error {
+	if c.BaseXP < 1 {
+		return fmt.Errorf("%w: base_xp must be at least 1", ErrInvalidLevelingConfig)
+	}
+	// Thresholds only keep increasing level after level from 1 on
+	if c.Exponent < 1 || c.Exponent > maxLevelingExponent {
+		return fmt.Errorf("%w: exponent must be between 1 and %d", ErrInvalidLevelingConfig, maxLevelingExponent)
+	}
+	if c.MaxLevel < 0 || c.MaxLevel == 1 {
+		return fmt.Errorf("%w: max_level must be 0 or at least 2", ErrInvalidLevelingConfig)
+	}
+
+	if len(c.Tiers) == 0 || c.Tiers[0].FromLevel != 1 {
+		return fmt.Errorf("%w: tiers must start at level 1", ErrInvalidLevelingConfig)
+	}
+	ids := make(map[string]bool, len(c.Tiers))
+	for i, tier := range c.Tiers {
+		if tier.ID == "" || tier.Name == "" {
+			return fmt.Errorf("%w: tiers need an id and a name", ErrInvalidLevelingConfig)
+		}
+		if ids[tier.ID] {
+			return fmt.Errorf("%w: duplicate tier %q", ErrInvalidLevelingConfig, tier.ID)
+		}
+		ids[tier.ID] = true
+		if i > 0 && tier.FromLevel <= c.Tiers[i-1].FromLevel {
+			return fmt.Errorf("%w: tier levels must increase", ErrInvalidLevelingConfig)
+		}
+		if c.MaxLevel > 0 && tier.FromLevel > c.MaxLevel {
+			return fmt.Errorf("%w: tier %s starts above max_level", ErrInvalidLevelingConfig, tier.ID)
+		}
+	}
+
+	for i, reward := range c.Rewards {
+		if reward.Level < 2 || (c.MaxLevel > 0 && reward.Level > c.MaxLevel) {
+			return fmt.Errorf("%w: reward for unreachable level %d", ErrInvalidLevelingConfig, reward.Level)
+		}
+		if i > 0 && reward.Level <= c.Rewards[i-1].Level {
+			return fmt.Errorf("%w: reward levels must increase", ErrInvalidLevelingConfig)
+		}
+		if reward.SkillPoints < 1 {
+			return fmt.Errorf("%w: reward for level %d gives nothing", ErrInvalidLevelingConfig, reward.Level)
+		}
+	}
+	return nil
+}
+
+// XPForLevel is the total XP a pet needs to reach the level.
+func (c *LevelingConfig) XPForLevel(level int) int {
+	if level <= 1 {
+		return 0
+	}
+	return int(math.Round(float64(c.BaseXP) * math.Pow(float64(level-1), c.Exponent)))
+}
+
+// Level is the level of a pet with the total XP.
+func (c *LevelingConfig) Level(totalXP int) int {
+	if totalXP <= 0 {
+		return 1
+	}
+	// Start from the inverse of the curve and correct for rounding
+	level := int(math.Pow(float64(totalXP)/float64(c.BaseXP), 1/c.Exponent)) + 1
+	for level > 1 && c.XPForLevel(level) > totalXP {
+		level--
+	}
+	for c.XPForLevel(level+1) <= totalXP {
+		level++
+	}
+	if c.MaxLevel > 0 {
+		level = min(level, c.MaxLevel)
+	}
+	return level
+}
+
+// XPToNextLevel is how much more XP a pet needs for its next level, or 0
+// at the top level.
+func (c *LevelingConfig) XPToNextLevel(totalXP int) int {
+	level := c.Level(totalXP)
+	if c.MaxLevel > 0 && level >= c.MaxLevel {
+		return 0
+	}
+	return c.XPForLevel(level+1) - totalXP
+}
+
+// LevelProgress is how far a pet is from its level to the next, from 0 to
+// 1.
+func (c *LevelingConfig) LevelProgress(totalXP int) float64 {
+	level := c.Level(totalXP)
+	if c.MaxLevel > 0 && level >= c.MaxLevel {
+		return 1
+	}
+	current, next := c.XPForLevel(level), c.XPForLevel(level+1)
+	return float64(totalXP-current) / float64(next-current)
+}
+
+// Tier is the tier the level belongs to.
+func (c *LevelingConfig) Tier(level int) *LevelTier {
+	tier := &c.Tiers[0]
+	for i := range c.Tiers {
+		if level < c.Tiers[i].FromLevel {
+			break
+		}
+		tier = &c.Tiers[i]
+	}
+	return tier
+}
+
+// NextTier is the tier after the level's, or nil in the last tier.
+func (c *LevelingConfig) NextTier(level int) *LevelTier {
+	for i := range c.Tiers {
+		if c.Tiers[i].FromLevel > level {
+			return &c.Tiers[i]
+		}
+	}
+	return nil
+}
+
+// SkillPoints is how many skill points a pet of the level has earned: one
+// for every level up plus the rewards of the levels reached.
+func (c *LevelingConfig) SkillPoints(level int) int {
+	points := max(level-1, 0)
+	for _, reward := range c.Rewards {
+		if reward.Level <= level {
+			points += reward.SkillPoints
+		}
+	}
+	return points
+}
+
+// LevelUp is a pet going up one or more levels at once.
+type LevelUp struct {
+	FromLevel int        `json:"from_level"`
+	ToLevel   int        `json:"to_level"`
+	FromTier  *LevelTier `json:"from_tier"`
+	ToTier    *LevelTier `json:"to_tier"`
+	// SkillPoints are the points earned by the new levels.
+	SkillPoints int `json:"skill_points"`
+}
+
+// LevelUp describes going from one level to another, or returns nil if the
+// pet didn't go up.
+func (c *LevelingConfig) LevelUp(from, to int) *LevelUp {
+	if to <= from {
+		return nil
+	}
+	return &LevelUp{
+		FromLevel:   from,
+		ToLevel:     to,
+		FromTier:    c.Tier(from),
+		ToTier:      c.Tier(to),
+		SkillPoints: c.SkillPoints(to) - c.SkillPoints(from),
+	}
+}
+
+// TierChanged reports whether the pet reached a new tier.
+func (l *LevelUp) TierChanged() bool {
+	return l.FromTier.ID != l.ToTier.ID
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLevelingConfig_Level(t *testing.T) {
+	leveling := DefaultLevelingConfig()
+	tests := []struct {
+		xp   int
+		want int
+	}{
+		{0, 1},
+		{99, 1},
+		{100, 2},
+		{399, 2},
+		{400, 3},
+		{899, 3},
+		{900, 4},
+		{1600, 5},
+		{8100, 10},
+		// Past the old cap of level 10
+		{10000, 11},
+		{250000, 51},
+		{249999, 50},
+	}
+
+	for _, tt := range tests {
+		if got := leveling.Level(tt.xp); got != tt.want {
+			t.Errorf("Level(%d) = %d, want %d", tt.xp, got, tt.want)
+		}
+	}
+}
+
+func TestLevelingConfig_XPForLevel(t *testing.T) {
+	leveling := DefaultLevelingConfig()
+	tests := []struct {
+		level int
+		want  int
+	}{
+		{1, 0},
+		{2, 100},
+		{3, 400},
+		{5, 1600},
+		{10, 8100},
+		{11, 10000},
+	}
+
+	for _, tt := range tests {
+		if got := leveling.XPForLevel(tt.level); got != tt.want {
+			t.Errorf("XPForLevel(%d) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestLevelingConfig_Progression(t *testing.T) {
+	curves := []LevelingConfig{
+		DefaultLevelingConfig(),
+		{BaseXP: 1, Exponent: 1, Tiers: DefaultLevelingConfig().Tiers},
+		{BaseXP: 75, Exponent: 1.5, Tiers: DefaultLevelingConfig().Tiers},
+		{BaseXP: 10, Exponent: 3, Tiers: DefaultLevelingConfig().Tiers},
+	}
+
+	for _, leveling := range curves {
+		for level := 1; level <= 200; level++ {
+			current, next := leveling.XPForLevel(level), leveling.XPForLevel(level+1)
+			if next <= current {
+				t.Fatalf("%+v: level %d needs %d XP, level %d only %d", leveling, level, current, level+1, next)
+			}
+			if got := leveling.Level(current); got != level {
+				t.Fatalf("%+v: Level(%d) = %d, want %d", leveling, current, got, level)
+			}
+			if got := leveling.Level(next - 1); got != level {
+				t.Fatalf("%+v: Level(%d) = %d, want %d", leveling, next-1, got, level)
+			}
+		}
+	}
+}
+
+func TestLevelingConfig_XPToNextLevel(t *testing.T) {
+	leveling := DefaultLevelingConfig()
+	tests := []struct {
+		xp   int
+		want int
+	}{
+		{0, 100},
+		{50, 50},
+		{99, 1},
+		{100, 300},
+		{250, 150},
+		{8100, 1900},
+	}
+
+	for _, tt := range tests {
+		if got := leveling.XPToNextLevel(tt.xp); got != tt.want {
+			t.Errorf("XPToNextLevel(%d) = %d, want %d", tt.xp, got, tt.want)
+		}
+	}
+
+	leveling.MaxLevel = 10
+	if got := leveling.Level(1000000); got != 10 {
+		t.Errorf("capped Level() = %d, want 10", got)
+	}
+	if got := leveling.XPToNextLevel(1000000); got != 0 {
+		t.Errorf("capped XPToNextLevel() = %d, want 0", got)
+	}
+	if got := leveling.LevelProgress(1000000); got != 1 {
+		t.Errorf("capped LevelProgress() = %v, want 1", got)
+	}
+}
+
+func TestLevelingConfig_Tiers(t *testing.T) {
+	leveling := DefaultLevelingConfig()
+	tests := []struct {
+		level    int
+		tier     string
+		nextTier string
+	}{
+		{1, "puppy", "adventurer"},
+		{4, "puppy", "adventurer"},
+		{5, "adventurer", "explorer"},
+		{10, "explorer", "legend"},
+		{19, "explorer", "legend"},
+		{20, "legend", ""},
+		{99, "legend", ""},
+	}
+
+	for _, tt := range tests {
+		if got := leveling.Tier(tt.level).ID; got != tt.tier {
+			t.Errorf("Tier(%d) = %s, want %s", tt.level, got, tt.tier)
+		}
+		next := leveling.NextTier(tt.level)
+		if (next == nil && tt.nextTier != "") || (next != nil && next.ID != tt.nextTier) {
+			t.Errorf("NextTier(%d) = %v, want %q", tt.level, next, tt.nextTier)
+		}
+	}
+}
+
+func TestLevelingConfig_LevelUp(t *testing.T) {
+	leveling := DefaultLevelingConfig()
+	leveling.Rewards = []LevelReward{{Level: 5, SkillPoints: 1}, {Level: 10, SkillPoints: 2}}
+
+	if up := leveling.LevelUp(4, 4); up != nil {
+		t.Errorf("LevelUp(4, 4) = %+v, want nil", up)
+	}
+
+	up := leveling.LevelUp(3, 4)
+	if up.TierChanged() || up.SkillPoints != 1 {
+		t.Errorf("LevelUp(3, 4) = %+v, want 1 point in the same tier", up)
+	}
+
+	// Two levels, the level 5 reward and a new tier
+	up = leveling.LevelUp(4, 6)
+	if !up.TierChanged() || up.FromTier.ID != "puppy" || up.ToTier.ID != "adventurer" || up.SkillPoints != 3 {
+		t.Errorf("LevelUp(4, 6) = %+v, want 3 points from puppy to adventurer", up)
+	}
+
+	if got := leveling.SkillPoints(12); got != 14 {
+		t.Errorf("SkillPoints(12) = %d, want 14", got)
+	}
+}
+
+func TestParseLevelingConfig(t *testing.T) {
+	leveling, err := ParseLevelingConfig([]byte(`{"base_xp": 50, "rewards": [{"level": 10, "skill_points": 1}]}`))
+	if err != nil {
+		t.Fatalf("ParseLevelingConfig() error = %v", err)
+	}
+	if leveling.BaseXP != 50 || leveling.Exponent != 2 || len(leveling.Tiers) != 4 {
+		t.Errorf("ParseLevelingConfig() = %+v, want defaults besides base_xp", leveling)
+	}
+
+	invalid := []string{
+		`{"base_xp": 0}`,
+		`{"exponent": 0.5}`,
+		`{"exponent": 4}`,
+		`{"max_level": 1}`,
+		`{"tiers": []}`,
+		`{"tiers": [{"id": "pup", "name": "Pup", "from_level": 2}]}`,
+		`{"tiers": [{"id": "pup", "name": "Pup", "from_level": 1}, {"id": "pup", "name": "Dog", "from_level": 5}]}`,
+		`{"tiers": [{"id": "pup", "name": "Pup", "from_level": 1}, {"id": "dog", "name": "Dog", "from_level": 1}]}`,
+		`{"max_level": 10, "tiers": [{"id": "pup", "name": "Pup", "from_level": 1}, {"id": "dog", "name": "Dog", "from_level": 11}]}`,
+		`{"rewards": [{"level": 1, "skill_points": 1}]}`,
+		`{"rewards": [{"level": 5, "skill_points": 0}]}`,
+		`{"rewards": [{"level": 5, "skill_points": 1}, {"level": 5, "skill_points": 1}]}`,
+		`{"curve": "quadratic"}`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseLevelingConfig([]byte(data)); !errors.Is(err, ErrInvalidLevelingConfig) {
+			t.Errorf("ParseLevelingConfig(%s) error = %v, want ErrInvalidLevelingConfig", data, err)
+		}
+	}
+}
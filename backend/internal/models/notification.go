@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidDevice = errors.New("invalid device")
+
+// PushProvider is the service a device token belongs to.
+type PushProvider string
+
+const (
+	PushProviderExpo PushProvider = "expo"
+	PushProviderFCM  PushProvider = "fcm"
+	PushProviderAPNs PushProvider = "apns"
+)
+
+func (p PushProvider) Valid() bool {
+	return p == PushProviderExpo || p == PushProviderFCM || p == PushProviderAPNs
+}
+
+// DeviceToken is a push token registered by the app for one login session.
+// Signing out of the session unregisters the device.
+type DeviceToken struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	SessionID  uuid.UUID    `json:"-"`
+	Provider   PushProvider `json:"provider"`
+	Token      string       `json:"-"`
+	DeviceName *string      `json:"device_name,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastSeenAt time.Time    `json:"last_seen_at"`
+	// Current is set when the token belongs to the requesting session.
+	Current bool `json:"current"`
+}
+
+type RegisterDeviceInput struct {
+	Provider   PushProvider `json:"provider"`
+	Token      string       `json:"token"`
+	DeviceName *string      `json:"device_name,omitempty"`
+}
+
+func (i *RegisterDeviceInput) Validate() error {
+	if !i.Provider.Valid() {
+		return fmt.Errorf("%w: provider must be expo, fcm or apns", ErrInvalidDevice)
+	}
+	i.Token = strings.TrimSpace(i.Token)
+	if i.Token == "" || len(i.Token) > 512 {
+		return fmt.Errorf("%w: token is required and must be at most 512 characters", ErrInvalidDevice)
+	}
+	if i.Provider == PushProviderExpo && !strings.HasPrefix(i.Token, "ExponentPushToken[") && !strings.HasPrefix(i.Token, "ExpoPushToken[") {
+		return fmt.Errorf("%w: not an Expo push token", ErrInvalidDevice)
+	}
+	return nil
+}
+
+type PushDeliveryStatus string
+
+const (
+	PushDeliveryPending PushDeliveryStatus = "pending"
+	PushDeliverySent    PushDeliveryStatus = "sent"
+	PushDeliveryFailed  PushDeliveryStatus = "failed"
+)
+
+// PushDelivery is one notification queued for one device. Deliveries are
+// retried with backoff until they are sent or run out of attempts.
+type PushDelivery struct {
+	ID            uuid.UUID
+	DeviceTokenID uuid.UUID
+	Provider      PushProvider
+	Token         string
+	Category      string
+	Title         string
+	Body          string
+	Data          map[string]string
+	Status        PushDeliveryStatus
+	Attempts      int
+	LastError     *string
+	NextAttemptAt time.Time
+	SentAt        *time.Time
+	CreatedAt     time.Time
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegisterDeviceInput_Validate(t *testing.T) {
+	invalid := []RegisterDeviceInput{
+		{Provider: "sms", Token: "abc"},
+		{Provider: PushProviderFCM, Token: "  "},
+		{Provider: PushProviderAPNs, Token: strings.Repeat("a", 513)},
+		{Provider: PushProviderExpo, Token: "fcm-token"},
+	}
+	for i, input := range invalid {
+		if err := input.Validate(); !errors.Is(err, ErrInvalidDevice) {
+			t.Errorf("case %d: Validate() error = %v, want ErrInvalidDevice", i, err)
+		}
+	}
+
+	input := RegisterDeviceInput{Provider: PushProviderExpo, Token: " ExponentPushToken[abc] "}
+	if err := input.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if input.Token != "ExponentPushToken[abc]" {
+		t.Errorf("Token = %q, want it trimmed", input.Token)
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NudgeKind string
+
+const (
+	// NudgeStreakAtRisk is sent in the evening when the pet's streak ends
+	// at local midnight unless someone plays with it.
+	NudgeStreakAtRisk NudgeKind = "streak_at_risk"
+	// NudgeMood is sent when the pet's mood drops to tired, sad or bored.
+	NudgeMood NudgeKind = "mood"
+)
+
+// Nudge records an engagement notification sent on behalf of a pet. The
+// first activity logged for the pet within a few hours is attributed to it.
+type Nudge struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	PetID       uuid.UUID  `json:"pet_id"`
+	Kind        NudgeKind  `json:"kind"`
+	DedupeKey   string     `json:"-"`
+	Mood        *Mood      `json:"mood,omitempty"`
+	StreakDays  *int       `json:"streak_days,omitempty"`
+	SentAt      time.Time  `json:"sent_at"`
+	ActivityID  *uuid.UUID `json:"activity_id,omitempty"`
+	ConvertedAt *time.Time `json:"converted_at,omitempty"`
+}
+
+// NudgeHistory is what the frequency caps look at: how many nudges a user
+// got recently and when the last one was sent.
+type NudgeHistory struct {
+	Count      int
+	LastSentAt *time.Time
+}
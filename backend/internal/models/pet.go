@@ -2,16 +2,18 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type PetType struct {
-	ID     string          `json:"id"`
-	Name   string          `json:"name"`
-	Icon   *string         `json:"icon,omitempty"`
-	Config json.RawMessage `json:"config,omitempty"`
+	ID     string        `json:"id"`
+	Name   string        `json:"name"`
+	Icon   *string       `json:"icon,omitempty"`
+	Config PetTypeConfig `json:"config"`
 }
 
 type Mood string
@@ -25,39 +27,148 @@ const (
 	MoodBored     Mood = "bored"
 )
 
+var ErrInvalidPetStatus = errors.New("invalid pet status")
+
+// PetStatus is where a pet is in its life with the user. Archived pets
+// (rehomed, or just not played with any more) and memorial pets are left
+// out of mood decay, nudges, missions and leaderboards.
+type PetStatus string
+
+const (
+	PetStatusActive   PetStatus = "active"
+	PetStatusArchived PetStatus = "archived"
+	PetStatusMemorial PetStatus = "memorial"
+)
+
+func (s PetStatus) Valid() bool {
+	return s == PetStatusActive || s == PetStatusArchived || s == PetStatusMemorial
+}
+
+// Allows reports whether a pet in this status can be changed in a way that
+// needs permission. Archived pets can't log activities until they are made
+// active again; memorial pets keep their history and profile read-only and
+// stay with their owner.
+func (s PetStatus) Allows(permission Permission) bool {
+	switch s {
+	case PetStatusArchived:
+		return permission != PermissionLogActivity
+	case PetStatusMemorial:
+		switch permission {
+		case PermissionLogActivity, PermissionEditActivities, PermissionEditPet, PermissionTransferPet:
+			return false
+		}
+	}
+	return true
+}
+
+// Pet is a user's pet. DeletedAt and RestoreUntil are only set on pets in
+// the restore window after deletion.
 type Pet struct {
 	ID             uuid.UUID  `json:"id"`
 	UserID         uuid.UUID  `json:"user_id"`
 	PetTypeID      string     `json:"pet_type_id"`
 	PetType        *PetType   `json:"pet_type,omitempty"`
+	Role           *PetRole   `json:"role,omitempty"`
 	Name           string     `json:"name"`
 	Breed          *string    `json:"breed,omitempty"`
+	BreedID        *string    `json:"breed_id,omitempty"`
 	AvatarURL      *string    `json:"avatar_url,omitempty"`
+	Avatars        Thumbnails `json:"avatars,omitempty"`
+	Loadout        Loadout    `json:"loadout,omitempty"`
 	BirthDate      *time.Time `json:"birth_date,omitempty"`
 	TotalXP        int        `json:"total_xp"`
 	Level          int        `json:"level"`
 	Mood           Mood       `json:"mood"`
 	StreakDays     int        `json:"streak_days"`
 	LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+	Status         PetStatus  `json:"status"`
+	StatusSince    *time.Time `json:"status_since,omitempty"`
+	PassedAwayOn   *time.Time `json:"passed_away_on,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	RestoreUntil   *time.Time `json:"restore_until,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Set by Derive from the pet type, birth date, breed, latest weight and
+	// level
+	BreedDetails   *Breed       `json:"breed_details,omitempty"`
+	LatestWeightKg *float64     `json:"-"`
+	Age            *PetAge      `json:"age,omitempty"`
+	LifeStage      LifeStage    `json:"life_stage,omitempty"`
+	DailyTarget    *DailyTarget `json:"daily_target,omitempty"`
+	LevelTitle     string       `json:"level_title,omitempty"`
 }
 
+// Thumbnails maps a size name ("small", "medium", "large") to the URL of an
+// uploaded image resized to that size.
+type Thumbnails map[string]string
+
 type CreatePetInput struct {
 	PetTypeID string     `json:"pet_type_id" validate:"required"`
 	Name      string     `json:"name" validate:"required,min=1,max=100"`
 	Breed     *string    `json:"breed,omitempty"`
+	BreedID   *string    `json:"breed_id,omitempty"`
 	AvatarURL *string    `json:"avatar_url,omitempty"`
 	BirthDate *time.Time `json:"birth_date,omitempty"`
 }
 
+// UpdatePetInput changes the given fields. An empty BreedID removes the
+// catalog breed.
 type UpdatePetInput struct {
 	Name      *string    `json:"name,omitempty"`
 	Breed     *string    `json:"breed,omitempty"`
+	BreedID   *string    `json:"breed_id,omitempty"`
 	AvatarURL *string    `json:"avatar_url,omitempty"`
 	BirthDate *time.Time `json:"birth_date,omitempty"`
 }
 
+// UpdatePetStatusInput archives a pet, turns it into a memorial or makes
+// it active again. PassedAwayOn is only kept for memorials.
+type UpdatePetStatusInput struct {
+	Status       PetStatus  `json:"status"`
+	PassedAwayOn *time.Time `json:"passed_away_on,omitempty"`
+}
+
+func (i *UpdatePetStatusInput) Validate(now time.Time) error {
+	if !i.Status.Valid() {
+		return fmt.Errorf("%w: status must be active, archived or memorial", ErrInvalidPetStatus)
+	}
+	if i.PassedAwayOn != nil {
+		if i.Status != PetStatusMemorial {
+			return fmt.Errorf("%w: passed_away_on is only for memorials", ErrInvalidPetStatus)
+		}
+		if i.PassedAwayOn.After(now) {
+			return fmt.Errorf("%w: passed_away_on can't be in the future", ErrInvalidPetStatus)
+		}
+	}
+	return nil
+}
+
+// TimelineEventType is the kind of moment in a pet's life shown on its
+// timeline.
+type TimelineEventType string
+
+const (
+	TimelineBorn        TimelineEventType = "born"
+	TimelineJoined      TimelineEventType = "joined"
+	TimelineActivity    TimelineEventType = "activity"
+	TimelineWeight      TimelineEventType = "weight"
+	TimelineVetVisit    TimelineEventType = "vet_visit"
+	TimelineVaccination TimelineEventType = "vaccination"
+	TimelineMedication  TimelineEventType = "medication"
+	TimelinePassedAway  TimelineEventType = "passed_away"
+)
+
+// TimelineEvent is one entry of a pet's timeline, newest first. RecordID is
+// the activity or health record it comes from; Details holds a few fields
+// of that record for display.
+type TimelineEvent struct {
+	Type       TimelineEventType `json:"type"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	RecordID   *uuid.UUID        `json:"record_id,omitempty"`
+	Details    json.RawMessage   `json:"details,omitempty"`
+}
+
 type PetStats struct {
 	TotalActivities   int     `json:"total_activities"`
 	TotalDuration     int     `json:"total_duration_seconds"`
@@ -66,32 +177,11 @@ type PetStats struct {
 	LongestStreak     int     `json:"longest_streak"`
 	XPToNextLevel     int     `json:"xp_to_next_level"`
 	LevelProgress     float64 `json:"level_progress"`
+	Tier              *LevelTier `json:"tier"`
+	NextTier          *LevelTier `json:"next_tier,omitempty"`
+	TodayMinutes      int     `json:"today_exercise_minutes"`
+	TargetMinutes     int     `json:"daily_target_minutes"`
+	TargetProgress    int     `json:"daily_target_percent"`
+	PersonalBests     []*PersonalRecord `json:"personal_bests"`
 }
 
-func CalculateLevel(xp int) int {
-	// Level formula: level = floor(sqrt(xp / 100)) + 1
-	// Level 1: 0-99 XP
-	// Level 2: 100-399 XP
-	// Level 3: 400-899 XP
-	// etc.
-	level := 1
-	threshold := 100
-	for xp >= threshold {
-		level++
-		threshold = level * level * 100
-	}
-	return level
-}
-
-func XPForLevel(level int) int {
-	if level <= 1 {
-		return 0
-	}
-	return (level - 1) * (level - 1) * 100
-}
-
-func XPToNextLevel(currentXP int) int {
-	currentLevel := CalculateLevel(currentXP)
-	nextLevelXP := XPForLevel(currentLevel + 1)
-	return nextLevelXP - currentXP
-}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PetRole string
+
+const (
+	PetRoleOwner     PetRole = "owner"
+	PetRoleCoOwner   PetRole = "co_owner"
+	PetRoleCaretaker PetRole = "caretaker"
+)
+
+// Permission is something a member may do with a shared pet.
+type Permission string
+
+const (
+	PermissionViewPet        Permission = "view_pet"
+	PermissionLogActivity    Permission = "log_activity"
+	PermissionEditActivities Permission = "edit_activities"
+	PermissionEditPet        Permission = "edit_pet"
+	PermissionManageMembers  Permission = "manage_members"
+	PermissionDeletePet      Permission = "delete_pet"
+	PermissionTransferPet    Permission = "transfer_pet"
+	// PermissionArchivePet covers archiving a pet and memorials.
+	PermissionArchivePet Permission = "archive_pet"
+)
+
+var rolePermissions = map[PetRole][]Permission{
+	PetRoleOwner: {
+		PermissionViewPet, PermissionLogActivity, PermissionEditActivities,
+		PermissionEditPet, PermissionManageMembers, PermissionDeletePet,
+		PermissionTransferPet, PermissionArchivePet,
+	},
+	PetRoleCoOwner: {
+		PermissionViewPet, PermissionLogActivity, PermissionEditActivities,
+		PermissionEditPet, PermissionManageMembers, PermissionArchivePet,
+	},
+	// Caretakers (dog walkers, sitters) can see the pet and log activities,
+	// and only edit the activities they logged themselves.
+	PetRoleCaretaker: {
+		PermissionViewPet, PermissionLogActivity,
+	},
+}
+
+func (r PetRole) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r PetRole) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type PetMember struct {
+	PetID     uuid.UUID  `json:"pet_id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	User      *User      `json:"user,omitempty"`
+	Role      PetRole    `json:"role"`
+	InvitedBy *uuid.UUID `json:"invited_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type PetInvitation struct {
+	ID         uuid.UUID  `json:"id"`
+	PetID      uuid.UUID  `json:"pet_id"`
+	Pet        *Pet       `json:"pet,omitempty"`
+	Role       PetRole    `json:"role"`
+	Email      *string    `json:"email,omitempty"`
+	Code       string     `json:"code"`
+	InvitedBy  *uuid.UUID `json:"invited_by,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedBy *uuid.UUID `json:"accepted_by,omitempty"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (i *PetInvitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+type CreateInvitationInput struct {
+	Role  PetRole `json:"role" validate:"required"`
+	Email *string `json:"email,omitempty"`
+}
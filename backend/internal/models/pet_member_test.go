@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPetRole_Can(t *testing.T) {
+	tests := []struct {
+		role       PetRole
+		permission Permission
+		expected   bool
+	}{
+		{PetRoleOwner, PermissionDeletePet, true},
+		{PetRoleOwner, PermissionManageMembers, true},
+		{PetRoleCoOwner, PermissionEditPet, true},
+		{PetRoleCoOwner, PermissionManageMembers, true},
+		{PetRoleCoOwner, PermissionDeletePet, false},
+		{PetRoleCoOwner, PermissionTransferPet, false},
+		{PetRoleOwner, PermissionTransferPet, true},
+		{PetRoleCaretaker, PermissionViewPet, true},
+		{PetRoleCaretaker, PermissionLogActivity, true},
+		{PetRoleCaretaker, PermissionEditActivities, false},
+		{PetRoleCaretaker, PermissionEditPet, false},
+		{PetRole("stranger"), PermissionViewPet, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.permission), func(t *testing.T) {
+			if got := tt.role.Can(tt.permission); got != tt.expected {
+				t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.permission, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPetInvitation_IsPending(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		invitation PetInvitation
+		expected   bool
+	}{
+		{"Open", PetInvitation{ExpiresAt: future}, true},
+		{"Expired", PetInvitation{ExpiresAt: now.Add(-time.Minute)}, false},
+		{"Accepted", PetInvitation{ExpiresAt: future, AcceptedAt: &now}, false},
+		{"Revoked", PetInvitation{ExpiresAt: future, RevokedAt: &now}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invitation.IsPending(); got != tt.expected {
+				t.Errorf("IsPending() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
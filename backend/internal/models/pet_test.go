@@ -1,99 +1,60 @@
 package models
 
-import "testing"
+import (
+	"errors"
+	"testing"
+	"time"
+)
 
-func TestCalculateLevel(t *testing.T) {
+func TestPetStatus_Allows(t *testing.T) {
 	tests := []struct {
-		name     string
-		xp       int
-		expected int
+		status     PetStatus
+		permission Permission
+		want       bool
 	}{
-		{"Level 1 - 0 XP", 0, 1},
-		{"Level 1 - 99 XP", 99, 1},
-		{"Level 2 - 100 XP", 100, 2},
-		{"Level 2 - 399 XP", 399, 2},
-		{"Level 3 - 400 XP", 400, 3},
-		{"Level 3 - 899 XP", 899, 3},
-		{"Level 4 - 900 XP", 900, 4},
-		{"Level 5 - 1600 XP", 1600, 5},
-		{"Level 10 - 8100 XP", 8100, 10},
+		{PetStatusActive, PermissionLogActivity, true},
+		{PetStatusArchived, PermissionLogActivity, false},
+		{PetStatusArchived, PermissionEditPet, true},
+		{PetStatusMemorial, PermissionLogActivity, false},
+		{PetStatusMemorial, PermissionEditActivities, false},
+		{PetStatusMemorial, PermissionEditPet, false},
+		{PetStatusMemorial, PermissionTransferPet, false},
+		{PetStatusMemorial, PermissionViewPet, true},
+		{PetStatusMemorial, PermissionArchivePet, true},
+		{PetStatusMemorial, PermissionDeletePet, true},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := CalculateLevel(tt.xp)
-			if result != tt.expected {
-				t.Errorf("CalculateLevel(%d) = %d, want %d", tt.xp, result, tt.expected)
-			}
-		})
+		if got := tt.status.Allows(tt.permission); got != tt.want {
+			t.Errorf("%s.Allows(%s) = %v, want %v", tt.status, tt.permission, got, tt.want)
+		}
 	}
 }
 
-func TestXPForLevel(t *testing.T) {
-	tests := []struct {
-		name     string
-		level    int
-		expected int
-	}{
-		{"Level 1", 1, 0},
-		{"Level 2", 2, 100},
-		{"Level 3", 3, 400},
-		{"Level 4", 4, 900},
-		{"Level 5", 5, 1600},
-		{"Level 10", 10, 8100},
-	}
+func TestUpdatePetStatusInput_Validate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	tomorrow := now.AddDate(0, 0, 1)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := XPForLevel(tt.level)
-			if result != tt.expected {
-				t.Errorf("XPForLevel(%d) = %d, want %d", tt.level, result, tt.expected)
-			}
-		})
+	invalid := []UpdatePetStatusInput{
+		{Status: "gone"},
+		{Status: PetStatusArchived, PassedAwayOn: &yesterday},
+		{Status: PetStatusMemorial, PassedAwayOn: &tomorrow},
 	}
-}
-
-func TestXPToNextLevel(t *testing.T) {
-	tests := []struct {
-		name       string
-		currentXP  int
-		expected   int
-	}{
-		{"0 XP needs 100 for level 2", 0, 100},
-		{"50 XP needs 50 for level 2", 50, 50},
-		{"99 XP needs 1 for level 2", 99, 1},
-		{"100 XP needs 300 for level 3", 100, 300},
-		{"250 XP needs 150 for level 3", 250, 150},
-		{"399 XP needs 1 for level 3", 399, 1},
+	for i, input := range invalid {
+		if err := input.Validate(now); !errors.Is(err, ErrInvalidPetStatus) {
+			t.Errorf("case %d: Validate() error = %v, want ErrInvalidPetStatus", i, err)
+		}
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := XPToNextLevel(tt.currentXP)
-			if result != tt.expected {
-				t.Errorf("XPToNextLevel(%d) = %d, want %d", tt.currentXP, result, tt.expected)
-			}
-		})
+	valid := []UpdatePetStatusInput{
+		{Status: PetStatusActive},
+		{Status: PetStatusArchived},
+		{Status: PetStatusMemorial},
+		{Status: PetStatusMemorial, PassedAwayOn: &yesterday},
 	}
-}
-
-func TestLevelProgression(t *testing.T) {
-	// Verify the level progression is consistent
-	for level := 1; level <= 10; level++ {
-		xpForCurrentLevel := XPForLevel(level)
-		xpForNextLevel := XPForLevel(level + 1)
-
-		// XP should always increase for higher levels
-		if level > 1 && xpForNextLevel <= xpForCurrentLevel {
-			t.Errorf("Level %d: XP progression broken. Current: %d, Next: %d",
-				level, xpForCurrentLevel, xpForNextLevel)
-		}
-
-		// Verify CalculateLevel works for the exact XP threshold
-		calculatedLevel := CalculateLevel(xpForCurrentLevel)
-		if calculatedLevel != level {
-			t.Errorf("Level %d: CalculateLevel(%d) = %d, want %d",
-				level, xpForCurrentLevel, calculatedLevel, level)
+	for i, input := range valid {
+		if err := input.Validate(now); err != nil {
+			t.Errorf("case %d: Validate() error = %v", i, err)
 		}
 	}
 }
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TransferStatus string
+
+const (
+	TransferStatusPending   TransferStatus = "pending"
+	TransferStatusAccepted  TransferStatus = "accepted"
+	TransferStatusDeclined  TransferStatus = "declined"
+	TransferStatusCancelled TransferStatus = "cancelled"
+	TransferStatusExpired   TransferStatus = "expired"
+)
+
+// PetTransfer moves a pet, with its activities and achievements, from its
+// owner to another account once the recipient accepts.
+type PetTransfer struct {
+	ID          uuid.UUID      `json:"id"`
+	PetID       *uuid.UUID     `json:"pet_id,omitempty"`
+	PetName     string         `json:"pet_name"`
+	FromUserID  *uuid.UUID     `json:"from_user_id,omitempty"`
+	FromEmail   string         `json:"from_email"`
+	ToEmail     string         `json:"to_email"`
+	ToUserID    *uuid.UUID     `json:"to_user_id,omitempty"`
+	Status      TransferStatus `json:"status"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+	RespondedAt *time.Time     `json:"responded_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// IsPending reports whether the transfer can still be accepted. Expiry is
+// not written back, so a pending row past its deadline counts as expired.
+func (t *PetTransfer) IsPending() bool {
+	return t.Status == TransferStatusPending && time.Now().Before(t.ExpiresAt)
+}
+
+type CreateTransferInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
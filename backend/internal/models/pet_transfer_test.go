@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPetTransfer_IsPending(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		transfer PetTransfer
+		expected bool
+	}{
+		{"Pending", PetTransfer{Status: TransferStatusPending, ExpiresAt: future}, true},
+		{"Pending past expiry", PetTransfer{Status: TransferStatusPending, ExpiresAt: past}, false},
+		{"Accepted", PetTransfer{Status: TransferStatusAccepted, ExpiresAt: future}, false},
+		{"Cancelled", PetTransfer{Status: TransferStatusCancelled, ExpiresAt: future}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.transfer.IsPending(); got != tt.expected {
+				t.Errorf("IsPending() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
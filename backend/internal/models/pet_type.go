@@ -0,0 +1,266 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var ErrInvalidPetTypeConfig = errors.New("invalid pet type config")
+
+// PetTypeConfig is what makes each pet type behave differently: which games
+// it plays, how fast its mood decays, how it earns XP and ages, and what
+// its levels are called. Adding a pet type only takes a pet_types row with
+// this config; missing keys fall back to DefaultPetTypeConfig.
+type PetTypeConfig struct {
+	// DefaultActivities are the game types the pet type plays, in the order
+	// they are offered. Missions are drawn from them.
+	DefaultActivities []string `json:"default_activities"`
+	// MoodDecay is the mood after each number of hours without activity,
+	// starting at 0 hours. Pets that never played have the last mood.
+	MoodDecay []MoodStep `json:"mood_decay"`
+	// XPMultipliers scale the XP of game types, keyed by game type ID.
+	XPMultipliers map[string]float64 `json:"xp_multipliers,omitempty"`
+	// LevelTitles name levels from the given level on, e.g. "Pup" from 1.
+	LevelTitles []LevelTitle `json:"level_titles"`
+	// YoungStage is the life stage of pets that aren't adults yet.
+	YoungStage LifeStage `json:"young_stage"`
+	// AdultAtMonths and SeniorAtYears place pets in their life unless
+	// their breed's size class says otherwise.
+	AdultAtMonths int `json:"adult_at_months"`
+	SeniorAtYears int `json:"senior_at_years"`
+	// SizeAging overrides when pets of a size class grow up and age.
+	SizeAging map[SizeClass]SizeAging `json:"size_aging,omitempty"`
+	// DailyExerciseMinutes is the daily target of pets without a catalog
+	// breed.
+	DailyExerciseMinutes int `json:"daily_exercise_minutes"`
+}
+
+type MoodStep struct {
+	AfterHours float64 `json:"after_hours"`
+	Mood       Mood    `json:"mood"`
+}
+
+type LevelTitle struct {
+	Level int    `json:"level"`
+	Title string `json:"title"`
+}
+
+type SizeAging struct {
+	AdultAtMonths int `json:"adult_at_months,omitempty"`
+	SeniorAtYears int `json:"senior_at_years,omitempty"`
+}
+
+// DefaultPetTypeConfig is used for keys a pet type's config leaves out.
+func DefaultPetTypeConfig() PetTypeConfig {
+	return PetTypeConfig{
+		DefaultActivities: []string{"walk"},
+		MoodDecay: []MoodStep{
+			{AfterHours: 0, Mood: MoodHappy},
+			{AfterHours: 6, Mood: MoodContent},
+			{AfterHours: 12, Mood: MoodTired},
+			{AfterHours: 24, Mood: MoodSad},
+			{AfterHours: 48, Mood: MoodBored},
+		},
+		LevelTitles:          []LevelTitle{{Level: 1, Title: "Newcomer"}},
+		YoungStage:           "young",
+		AdultAtMonths:        12,
+		SeniorAtYears:        10,
+		DailyExerciseMinutes: 30,
+	}
+}
+
+// ParsePetTypeConfig reads a pet type's config over the defaults and
+// validates it. Unknown keys are rejected so typos don't go unnoticed.
+func ParsePetTypeConfig(data []byte) (PetTypeConfig, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+
+	var config PetTypeConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		if errors.Is(err, ErrInvalidPetTypeConfig) {
+			return PetTypeConfig{}, err
+		}
+		return PetTypeConfig{}, fmt.Errorf("%w: %v", ErrInvalidPetTypeConfig, err)
+	}
+	return config, nil
+}
+
+// UnmarshalJSON fills in defaults and validates, so pet types loaded from
+// the database are always complete and valid.
+func (c *PetTypeConfig) UnmarshalJSON(data []byte) error {
+	// Decode into a type without this method to avoid recursing
+	type plain PetTypeConfig
+	config := plain(DefaultPetTypeConfig())
+	if !bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPetTypeConfig, err)
+		}
+	}
+
+	parsed := PetTypeConfig(config)
+	if err := parsed.Validate(); err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+func (c PetTypeConfig) Validate() error {
+	if len(c.DefaultActivities) == 0 {
+		return fmt.Errorf("%w: default_activities must not be empty", ErrInvalidPetTypeConfig)
+	}
+	for _, activity := range c.DefaultActivities {
+		if activity == "" {
+			return fmt.Errorf("%w: default_activities must not contain empty names", ErrInvalidPetTypeConfig)
+		}
+	}
+
+	if len(c.MoodDecay) == 0 || c.MoodDecay[0].AfterHours != 0 {
+		return fmt.Errorf("%w: mood_decay must start at 0 hours", ErrInvalidPetTypeConfig)
+	}
+	for i, step := range c.MoodDecay {
+		if _, ok := moodRanks[step.Mood]; !ok {
+			return fmt.Errorf("%w: unknown mood %q in mood_decay", ErrInvalidPetTypeConfig, step.Mood)
+		}
+		if i > 0 && step.AfterHours <= c.MoodDecay[i-1].AfterHours {
+			return fmt.Errorf("%w: mood_decay hours must increase", ErrInvalidPetTypeConfig)
+		}
+	}
+
+	for gameType, multiplier := range c.XPMultipliers {
+		if multiplier <= 0 || math.IsInf(multiplier, 0) || math.IsNaN(multiplier) {
+			return fmt.Errorf("%w: xp multiplier for %s must be positive", ErrInvalidPetTypeConfig, gameType)
+		}
+	}
+
+	if len(c.LevelTitles) == 0 || c.LevelTitles[0].Level != 1 {
+		return fmt.Errorf("%w: level_titles must start at level 1", ErrInvalidPetTypeConfig)
+	}
+	for i, title := range c.LevelTitles {
+		if title.Title == "" {
+			return fmt.Errorf("%w: level titles must not be empty", ErrInvalidPetTypeConfig)
+		}
+		if i > 0 && title.Level <= c.LevelTitles[i-1].Level {
+			return fmt.Errorf("%w: level_titles levels must increase", ErrInvalidPetTypeConfig)
+		}
+	}
+
+	if c.YoungStage == "" {
+		return fmt.Errorf("%w: young_stage is required", ErrInvalidPetTypeConfig)
+	}
+	if c.AdultAtMonths <= 0 || c.SeniorAtYears*12 <= c.AdultAtMonths {
+		return fmt.Errorf("%w: pets must become adults before they become seniors", ErrInvalidPetTypeConfig)
+	}
+	for size, aging := range c.SizeAging {
+		if aging.AdultAtMonths < 0 || aging.SeniorAtYears < 0 {
+			return fmt.Errorf("%w: size_aging for %s must not be negative", ErrInvalidPetTypeConfig, size)
+		}
+	}
+	if c.DailyExerciseMinutes <= 0 {
+		return fmt.Errorf("%w: daily_exercise_minutes must be positive", ErrInvalidPetTypeConfig)
+	}
+
+	return nil
+}
+
+// Mood is how a pet feels after the given hours without activity.
+func (c PetTypeConfig) Mood(hoursIdle float64) Mood {
+	mood := c.MoodDecay[0].Mood
+	for _, step := range c.MoodDecay {
+		if hoursIdle < step.AfterHours {
+			break
+		}
+		mood = step.Mood
+	}
+	return mood
+}
+
+// NeverPlayedMood is the mood of a pet nobody has played with yet.
+func (c PetTypeConfig) NeverPlayedMood() Mood {
+	return c.MoodDecay[len(c.MoodDecay)-1].Mood
+}
+
+// FirstDropAfter is how many idle hours it takes for the mood to first
+// fall below content, or 0 if it never does.
+func (c PetTypeConfig) FirstDropAfter() float64 {
+	for _, step := range c.MoodDecay {
+		if step.Mood.Rank() < MoodContent.Rank() {
+			return step.AfterHours
+		}
+	}
+	return 0
+}
+
+// XPMultiplier scales XP earned in the game type; 1 unless configured.
+func (c PetTypeConfig) XPMultiplier(gameTypeID string) float64 {
+	if multiplier, ok := c.XPMultipliers[gameTypeID]; ok {
+		return multiplier
+	}
+	return 1
+}
+
+// PlaysActivity reports whether the game type is one of the pet type's
+// activities.
+func (c PetTypeConfig) PlaysActivity(gameTypeID string) bool {
+	for _, activity := range c.DefaultActivities {
+		if activity == gameTypeID {
+			return true
+		}
+	}
+	return false
+}
+
+// LevelTitle names the given level.
+func (c PetTypeConfig) LevelTitle(level int) string {
+	i := sort.Search(len(c.LevelTitles), func(i int) bool { return c.LevelTitles[i].Level > level })
+	if i == 0 {
+		return c.LevelTitles[0].Title
+	}
+	return c.LevelTitles[i-1].Title
+}
+
+// adultAtMonths and seniorAtYears apply the size class overrides.
+func (c PetTypeConfig) adultAtMonths(size SizeClass) int {
+	if aging, ok := c.SizeAging[size]; ok && aging.AdultAtMonths > 0 {
+		return aging.AdultAtMonths
+	}
+	return c.AdultAtMonths
+}
+
+func (c PetTypeConfig) seniorAtYears(size SizeClass) int {
+	if aging, ok := c.SizeAging[size]; ok && aging.SeniorAtYears > 0 {
+		return aging.SeniorAtYears
+	}
+	return c.SeniorAtYears
+}
+
+// Settings returns the pet type's config, or the defaults when the pet
+// type wasn't loaded.
+func (pt *PetType) Settings() PetTypeConfig {
+	if pt == nil {
+		return DefaultPetTypeConfig()
+	}
+	return pt.Config
+}
+
+// moodRanks orders moods from worst to best.
+var moodRanks = map[Mood]int{
+	MoodBored:   0,
+	MoodSad:     1,
+	MoodTired:   2,
+	MoodContent: 3,
+	MoodHappy:   4,
+	MoodExcited: 5,
+}
+
+// Rank orders moods from worst (bored) to best (excited).
+func (m Mood) Rank() int {
+	return moodRanks[m]
+}
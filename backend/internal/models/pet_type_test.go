@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// The dog and cat configs as seeded by the migrations
+const (
+	dogConfigJSON = `{
+		"default_activities": ["walk", "fetch"],
+		"mood_decay": [
+			{"after_hours": 0, "mood": "happy"},
+			{"after_hours": 6, "mood": "content"},
+			{"after_hours": 12, "mood": "tired"},
+			{"after_hours": 24, "mood": "sad"},
+			{"after_hours": 48, "mood": "bored"}
+		],
+		"level_titles": [{"level": 1, "title": "Pup"}, {"level": 5, "title": "Good Dog"}, {"level": 10, "title": "Top Dog"}],
+		"young_stage": "puppy",
+		"adult_at_months": 12,
+		"senior_at_years": 9,
+		"size_aging": {
+			"toy": {"senior_at_years": 11},
+			"small": {"senior_at_years": 10},
+			"large": {"adult_at_months": 18, "senior_at_years": 7},
+			"giant": {"adult_at_months": 24, "senior_at_years": 6}
+		},
+		"daily_exercise_minutes": 60
+	}`
+	catConfigJSON = `{
+		"default_activities": ["walk", "play"],
+		"mood_decay": [
+			{"after_hours": 0, "mood": "happy"},
+			{"after_hours": 12, "mood": "content"},
+			{"after_hours": 36, "mood": "tired"},
+			{"after_hours": 72, "mood": "sad"},
+			{"after_hours": 120, "mood": "bored"}
+		],
+		"xp_multipliers": {"walk": 1.5},
+		"level_titles": [{"level": 1, "title": "Furball"}, {"level": 5, "title": "House Cat"}],
+		"young_stage": "kitten",
+		"adult_at_months": 12,
+		"senior_at_years": 11,
+		"daily_exercise_minutes": 20
+	}`
+)
+
+func testDogConfig(t *testing.T) PetTypeConfig {
+	t.Helper()
+	config, err := ParsePetTypeConfig([]byte(dogConfigJSON))
+	if err != nil {
+		t.Fatalf("ParsePetTypeConfig(dog) error = %v", err)
+	}
+	return config
+}
+
+func testCatConfig(t *testing.T) PetTypeConfig {
+	t.Helper()
+	config, err := ParsePetTypeConfig([]byte(catConfigJSON))
+	if err != nil {
+		t.Fatalf("ParsePetTypeConfig(cat) error = %v", err)
+	}
+	return config
+}
+
+func TestParsePetTypeConfig_Defaults(t *testing.T) {
+	// A new pet type only needs its activities
+	config, err := ParsePetTypeConfig([]byte(`{"default_activities": ["walk", "graze"]}`))
+	if err != nil {
+		t.Fatalf("ParsePetTypeConfig() error = %v", err)
+	}
+
+	if !config.PlaysActivity("graze") || config.PlaysActivity("fetch") {
+		t.Errorf("DefaultActivities = %v", config.DefaultActivities)
+	}
+	if config.Mood(30) != MoodSad {
+		t.Errorf("Mood(30) = %s, want the default curve's %s", config.Mood(30), MoodSad)
+	}
+	if config.LevelTitle(7) != "Newcomer" {
+		t.Errorf("LevelTitle(7) = %s, want Newcomer", config.LevelTitle(7))
+	}
+	if config.XPMultiplier("walk") != 1 {
+		t.Errorf("XPMultiplier(walk) = %v, want 1", config.XPMultiplier("walk"))
+	}
+}
+
+func TestParsePetTypeConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"Not JSON", `{`},
+		{"Unknown key", `{"mood_curve": []}`},
+		{"No activities", `{"default_activities": []}`},
+		{"Curve not starting at 0", `{"mood_decay": [{"after_hours": 2, "mood": "happy"}]}`},
+		{"Curve going back", `{"mood_decay": [{"after_hours": 0, "mood": "happy"}, {"after_hours": 10, "mood": "tired"}, {"after_hours": 5, "mood": "sad"}]}`},
+		{"Unknown mood", `{"mood_decay": [{"after_hours": 0, "mood": "grumpy"}]}`},
+		{"Zero multiplier", `{"xp_multipliers": {"walk": 0}}`},
+		{"Titles not from level 1", `{"level_titles": [{"level": 2, "title": "Foal"}]}`},
+		{"Empty title", `{"level_titles": [{"level": 1, "title": ""}]}`},
+		{"Senior before adult", `{"adult_at_months": 24, "senior_at_years": 1}`},
+		{"No exercise", `{"daily_exercise_minutes": 0}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePetTypeConfig([]byte(tt.config)); !errors.Is(err, ErrInvalidPetTypeConfig) {
+				t.Errorf("ParsePetTypeConfig(%s) error = %v, want %v", tt.config, err, ErrInvalidPetTypeConfig)
+			}
+		})
+	}
+}
+
+func TestPetTypeConfig_Mood(t *testing.T) {
+	dog, cat := testDogConfig(t), testCatConfig(t)
+
+	tests := []struct {
+		name   string
+		config PetTypeConfig
+		hours  float64
+		want   Mood
+	}{
+		{"dog just played", dog, 1, MoodHappy},
+		{"dog after a day", dog, 30, MoodSad},
+		{"cat after a day", cat, 30, MoodContent},
+		{"cat after two days", cat, 48, MoodTired},
+		{"cat after a week", cat, 168, MoodBored},
+	}
+	for _, tt := range tests {
+		if got := tt.config.Mood(tt.hours); got != tt.want {
+			t.Errorf("%s: Mood(%v) = %s, want %s", tt.name, tt.hours, got, tt.want)
+		}
+	}
+
+	if got := cat.FirstDropAfter(); got != 36 {
+		t.Errorf("cat FirstDropAfter() = %v, want 36", got)
+	}
+}
+
+func TestPetTypeConfig_LevelTitle(t *testing.T) {
+	dog := testDogConfig(t)
+
+	tests := []struct {
+		level int
+		want  string
+	}{
+		{1, "Pup"},
+		{4, "Pup"},
+		{5, "Good Dog"},
+		{42, "Top Dog"},
+	}
+	for _, tt := range tests {
+		if got := dog.LevelTitle(tt.level); got != tt.want {
+			t.Errorf("LevelTitle(%d) = %s, want %s", tt.level, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// PlayToy is what a cat played with.
+type PlayToy string
+
+const (
+	PlayToyWand    PlayToy = "wand"
+	PlayToyLaser   PlayToy = "laser"
+	PlayToyBall    PlayToy = "ball"
+	PlayToyMouse   PlayToy = "mouse"
+	PlayToyFeather PlayToy = "feather"
+	PlayToyOther   PlayToy = "other"
+)
+
+func (t PlayToy) Valid() bool {
+	switch t {
+	case PlayToyWand, PlayToyLaser, PlayToyBall, PlayToyMouse, PlayToyFeather, PlayToyOther:
+		return true
+	}
+	return false
+}
+
+// PlayIntensity is how worked up the cat got, as the owner judged it.
+type PlayIntensity string
+
+const (
+	PlayIntensityLow    PlayIntensity = "low"
+	PlayIntensityMedium PlayIntensity = "medium"
+	PlayIntensityHigh   PlayIntensity = "high"
+)
+
+func (i PlayIntensity) Valid() bool {
+	return i == PlayIntensityLow || i == PlayIntensityMedium || i == PlayIntensityHigh
+}
+
+// PlayGameData is an indoor play session. A round is one bout of chasing
+// the toy until the cat loses interest or catches it; pounces are counted
+// across rounds.
+type PlayGameData struct {
+	ToyType   PlayToy       `json:"toy_type,omitempty"`
+	Rounds    int           `json:"rounds"`
+	Pounces   int           `json:"pounces"`
+	Intensity PlayIntensity `json:"intensity,omitempty"`
+}
+
+const (
+	// maxPlayRounds is more rounds than any cat keeps up in one session.
+	maxPlayRounds = 200
+	// maxPouncesPerRound is more than a cat pounces in one bout.
+	maxPouncesPerRound = 30
+	// minSecondsPerRound is the shortest plausible round.
+	minSecondsPerRound = 10
+	// playMissionPounces is the target of a daily play mission.
+	playMissionPounces = 15
+)
+
+func (d *PlayGameData) Validate() error {
+	if d.ToyType != "" && !d.ToyType.Valid() {
+		return fmt.Errorf("%w: unknown toy %q", ErrInvalidGameData, d.ToyType)
+	}
+	if d.Intensity != "" && !d.Intensity.Valid() {
+		return fmt.Errorf("%w: intensity must be low, medium or high", ErrInvalidGameData)
+	}
+	if d.Rounds < 0 || d.Pounces < 0 {
+		return fmt.Errorf("%w: rounds and pounces can't be negative", ErrInvalidGameData)
+	}
+	if d.Rounds > maxPlayRounds {
+		return fmt.Errorf("%w: more than %d rounds", ErrInvalidGameData, maxPlayRounds)
+	}
+	if d.Pounces > d.Rounds*maxPouncesPerRound {
+		return fmt.Errorf("%w: more than %d pounces per round", ErrInvalidGameData, maxPouncesPerRound)
+	}
+	return nil
+}
+
+// playXPConfig is how play is scored, from the game type's xp_config.
+// Intensities left out of the multipliers count as 1.
+type playXPConfig struct {
+	BaseXPPerMinute      float64                   `json:"base_xp_per_minute"`
+	XPPerRound           int                       `json:"xp_per_round"`
+	XPPerPounce          int                       `json:"xp_per_pounce"`
+	IntensityMultipliers map[PlayIntensity]float64 `json:"intensity_multipliers"`
+}
+
+type playGame struct{ baseGame }
+
+// decodePlay reads play game data. Unlike walks and fetch, which predate
+// it, play rejects keys it doesn't know.
+func decodePlay(data json.RawMessage) (*PlayGameData, error) {
+	var play PlayGameData
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &play, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&play); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGameData, err)
+	}
+	return &play, nil
+}
+
+func (playGame) Validate(a *Activity) error {
+	play, err := decodePlay(a.GameData)
+	if err != nil {
+		return err
+	}
+	return play.Validate()
+}
+
+// XP earns base_xp_per_minute played, xp_per_round and xp_per_pounce,
+// scaled by the session's intensity.
+func (playGame) XP(config json.RawMessage, a *Activity) int {
+	var xpConfig playXPConfig
+	if err := json.Unmarshal(config, &xpConfig); err != nil {
+		return 0
+	}
+
+	xp := 0.0
+	if a.DurationSeconds != nil {
+		xp += float64(*a.DurationSeconds) / 60 * xpConfig.BaseXPPerMinute
+	}
+	play, err := decodePlay(a.GameData)
+	if err != nil {
+		return int(xp)
+	}
+	xp += float64(play.Rounds*xpConfig.XPPerRound + play.Pounces*xpConfig.XPPerPounce)
+	if multiplier, ok := xpConfig.IntensityMultipliers[play.Intensity]; ok {
+		xp *= multiplier
+	}
+	return int(xp)
+}
+
+func (playGame) Check(a *Activity) *ActivityFlag {
+	play, err := decodePlay(a.GameData)
+	if err != nil || play.Rounds <= 0 {
+		return nil
+	}
+	if play.Rounds*minSecondsPerRound > *a.DurationSeconds {
+		flag := ActivityFlagTooManyRounds
+		return &flag
+	}
+	return nil
+}
+
+func (playGame) MissionProgress(a *Activity, progress map[MissionType]int) {
+	if play, err := decodePlay(a.GameData); err == nil {
+		progress[MissionTypePlayPounces] = play.Pounces
+	}
+}
+
+func (playGame) DailyMission(pet *Pet) *MissionGoal {
+	return &MissionGoal{
+		Type:        MissionTypePlayPounces,
+		Target:      playMissionPounces,
+		Description: fmt.Sprintf("Play with %s until they pounce %d times", pet.Name, playMissionPounces),
+	}
+}
+
+func (playGame) Records(a *Activity) []RecordValue {
+	play, err := decodePlay(a.GameData)
+	if err != nil {
+		return nil
+	}
+
+	var records []RecordValue
+	if play.Pounces > 0 {
+		records = append(records, RecordValue{RecordMostPounces, float64(play.Pounces)})
+	}
+	if play.Rounds > 0 {
+		records = append(records, RecordValue{RecordMostRounds, float64(play.Rounds)})
+	}
+	return records
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func playActivity(seconds int, gameData string) *Activity {
+	endedAt := time.Date(2025, 10, 9, 20, 0, 0, 0, time.UTC)
+	return &Activity{
+		GameTypeID:      "play",
+		StartedAt:       endedAt.Add(-time.Duration(seconds) * time.Second),
+		EndedAt:         &endedAt,
+		DurationSeconds: &seconds,
+		GameData:        json.RawMessage(gameData),
+	}
+}
+
+func TestPlayGame_Validate(t *testing.T) {
+	game := GameFor("play")
+
+	valid := []string{
+		``,
+		`{}`,
+		`{"toy_type": "wand", "rounds": 6, "pounces": 22, "intensity": "high"}`,
+		`{"toy_type": "laser", "rounds": 1, "pounces": 30}`,
+	}
+	for _, data := range valid {
+		if err := game.Validate(playActivity(600, data)); err != nil {
+			t.Errorf("Validate(%s) error = %v", data, err)
+		}
+	}
+
+	invalid := []string{
+		`{"toy_type": "cucumber"}`,
+		`{"intensity": "extreme"}`,
+		`{"rounds": -1}`,
+		`{"rounds": 201}`,
+		`{"rounds": 1, "pounces": 31}`,
+		`{"pounces": 5}`,
+		`{"rounds": 2, "zoomies": true}`,
+		`{"rounds": "two"}`,
+	}
+	for _, data := range invalid {
+		if err := game.Validate(playActivity(600, data)); !errors.Is(err, ErrInvalidGameData) {
+			t.Errorf("Validate(%s) error = %v, want ErrInvalidGameData", data, err)
+		}
+	}
+}
+
+func TestPlayGame_XP(t *testing.T) {
+	game := GameFor("play")
+	config := json.RawMessage(`{"base_xp_per_minute": 2, "xp_per_round": 2, "xp_per_pounce": 1,
+		"intensity_multipliers": {"low": 1, "medium": 1.25, "high": 1.5}}`)
+
+	tests := []struct {
+		name     string
+		gameData string
+		want     int
+	}{
+		// 10 minutes × 2 + 6 rounds × 2 + 22 pounces
+		{"without intensity", `{"rounds": 6, "pounces": 22}`, 54},
+		{"low", `{"rounds": 6, "pounces": 22, "intensity": "low"}`, 54},
+		{"high", `{"rounds": 6, "pounces": 22, "intensity": "high"}`, 81},
+		{"just time", `{}`, 20},
+		{"unreadable", `{"rounds": "six"}`, 20},
+	}
+	for _, tt := range tests {
+		if got := game.XP(config, playActivity(600, tt.gameData)); got != tt.want {
+			t.Errorf("%s: XP() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlayGame_Hooks(t *testing.T) {
+	game := GameFor("play")
+	activity := playActivity(600, `{"toy_type": "mouse", "rounds": 8, "pounces": 17}`)
+
+	progress := map[MissionType]int{}
+	game.MissionProgress(activity, progress)
+	if progress[MissionTypePlayPounces] != 17 {
+		t.Errorf("MissionProgress() = %v, want 17 pounces", progress)
+	}
+
+	goal := game.DailyMission(&Pet{Name: "Mia"})
+	if goal == nil || goal.Type != MissionTypePlayPounces || goal.Target != playMissionPounces {
+		t.Errorf("DailyMission() = %+v", goal)
+	}
+
+	records := recordValues(ActivityRecords(activity))
+	if records[RecordMostPounces] != 17 || records[RecordMostRounds] != 8 || records[RecordLongestDuration] != 600 {
+		t.Errorf("ActivityRecords() = %v", records)
+	}
+
+	// A round every five seconds is more than any cat keeps up
+	if flag := CheckActivity(playActivity(60, `{"rounds": 12, "pounces": 30}`)); flag == nil || *flag != ActivityFlagTooManyRounds {
+		t.Errorf("CheckActivity() = %v, want too_many_rounds", flag)
+	}
+	if flag := CheckActivity(activity); flag != nil {
+		t.Errorf("CheckActivity() = %s, want nil", *flag)
+	}
+}
+
+func TestGameFor_Unregistered(t *testing.T) {
+	game := GameFor("swim")
+	activity := &Activity{GameTypeID: "swim", GameData: json.RawMessage(`{"laps": 3}`)}
+
+	if err := game.Validate(activity); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if xp := game.XP(json.RawMessage(`{"base_xp_per_minute": 2}`), activity); xp != 0 {
+		t.Errorf("XP() = %d, want 0", xp)
+	}
+	if goal := game.DailyMission(&Pet{Name: "Nemo"}); goal != nil {
+		t.Errorf("DailyMission() = %+v, want nil", goal)
+	}
+}
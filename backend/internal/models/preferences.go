@@ -0,0 +1,202 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+type Units string
+
+const (
+	UnitsMetric   Units = "metric"
+	UnitsImperial Units = "imperial"
+)
+
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"
+	VisibilityFriends Visibility = "friends"
+	VisibilityPrivate Visibility = "private"
+)
+
+func (v Visibility) valid() bool {
+	return v == VisibilityPublic || v == VisibilityFriends || v == VisibilityPrivate
+}
+
+// MaxNudgesPerDayLimit is the most streak and mood nudges a user can opt
+// into per day.
+const MaxNudgesPerDayLimit = 5
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// UserPreferences configures the app for a user. It is stored as a JSON
+// document and returned with the user on login.
+type UserPreferences struct {
+	Units         Units                   `json:"units"`
+	Timezone      string                  `json:"timezone"`
+	Language      string                  `json:"language"`
+	Notifications NotificationPreferences `json:"notifications"`
+	Privacy       PrivacyPreferences      `json:"privacy"`
+}
+
+type NotificationPreferences struct {
+	Enabled      bool `json:"enabled"`
+	Reminders    bool `json:"reminders"`
+	StreakAlerts bool `json:"streak_alerts"`
+	MoodAlerts   bool `json:"mood_alerts"`
+	Missions     bool `json:"missions"`
+	Social       bool `json:"social"`
+	// MaxNudgesPerDay caps streak and mood nudges; 0 turns them off.
+	MaxNudgesPerDay int         `json:"max_nudges_per_day"`
+	QuietHours      *QuietHours `json:"quiet_hours,omitempty"`
+}
+
+// QuietHours is a local time window ("22:00" to "07:00") in which no push
+// notifications are sent. The window may wrap past midnight.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type PrivacyPreferences struct {
+	ProfileVisibility  Visibility `json:"profile_visibility"`
+	ActivityVisibility Visibility `json:"activity_visibility"`
+	ShareRoutes        bool       `json:"share_routes"`
+}
+
+func DefaultPreferences() UserPreferences {
+	return UserPreferences{
+		Units:    UnitsMetric,
+		Timezone: "UTC",
+		Language: "en",
+		Notifications: NotificationPreferences{
+			Enabled:         true,
+			Reminders:       true,
+			StreakAlerts:    true,
+			MoodAlerts:      true,
+			Missions:        true,
+			Social:          true,
+			MaxNudgesPerDay: 2,
+		},
+		Privacy: PrivacyPreferences{
+			ProfileVisibility:  VisibilityFriends,
+			ActivityVisibility: VisibilityFriends,
+			ShareRoutes:        false,
+		},
+	}
+}
+
+// ParsePreferences reads a stored document on top of the defaults, so keys
+// added after the user last saved get sensible values.
+func ParsePreferences(data []byte) (UserPreferences, error) {
+	prefs := DefaultPreferences()
+	if len(data) == 0 {
+		return prefs, nil
+	}
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return DefaultPreferences(), err
+	}
+	return prefs, nil
+}
+
+// Merge applies a partial update. Unknown keys are rejected and the result
+// is validated.
+func (p UserPreferences) Merge(patch []byte) (UserPreferences, error) {
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return p, fmt.Errorf("%w: %v", ErrInvalidPreferences, err)
+	}
+	if err := p.Validate(); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func (p UserPreferences) Validate() error {
+	switch p.Units {
+	case UnitsMetric, UnitsImperial:
+	default:
+		return fmt.Errorf("%w: units must be %q or %q", ErrInvalidPreferences, UnitsMetric, UnitsImperial)
+	}
+
+	if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "" {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidPreferences, p.Timezone)
+	}
+
+	if !languagePattern.MatchString(p.Language) {
+		return fmt.Errorf("%w: language must look like \"en\" or \"pt-BR\"", ErrInvalidPreferences)
+	}
+
+	if qh := p.Notifications.QuietHours; qh != nil {
+		if _, err := time.Parse("15:04", qh.Start); err != nil {
+			return fmt.Errorf("%w: quiet_hours.start must be HH:MM", ErrInvalidPreferences)
+		}
+		if _, err := time.Parse("15:04", qh.End); err != nil {
+			return fmt.Errorf("%w: quiet_hours.end must be HH:MM", ErrInvalidPreferences)
+		}
+	}
+
+	if n := p.Notifications.MaxNudgesPerDay; n < 0 || n > MaxNudgesPerDayLimit {
+		return fmt.Errorf("%w: max_nudges_per_day must be between 0 and %d", ErrInvalidPreferences, MaxNudgesPerDayLimit)
+	}
+
+	if !p.Privacy.ProfileVisibility.valid() {
+		return fmt.Errorf("%w: profile_visibility must be public, friends or private", ErrInvalidPreferences)
+	}
+	if !p.Privacy.ActivityVisibility.valid() {
+		return fmt.Errorf("%w: activity_visibility must be public, friends or private", ErrInvalidPreferences)
+	}
+
+	return nil
+}
+
+// Location returns the user's timezone, falling back to UTC.
+func (p UserPreferences) Location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// EndsAt reports whether now falls inside the quiet hours in loc and, if
+// so, when they end. A window whose start equals its end is empty.
+func (q QuietHours) EndsAt(now time.Time, loc *time.Location) (time.Time, bool) {
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	var inside bool
+	if startMinute <= endMinute {
+		inside = minute >= startMinute && minute < endMinute
+	} else {
+		inside = minute >= startMinute || minute < endMinute
+	}
+	if !inside {
+		return time.Time{}, false
+	}
+
+	endsAt := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+	if !endsAt.After(now) {
+		endsAt = time.Date(local.Year(), local.Month(), local.Day()+1, end.Hour(), end.Minute(), 0, 0, loc)
+	}
+	return endsAt, true
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePreferences_Defaults(t *testing.T) {
+	prefs, err := ParsePreferences([]byte(`{"units": "imperial"}`))
+	if err != nil {
+		t.Fatalf("ParsePreferences() error = %v", err)
+	}
+
+	if prefs.Units != UnitsImperial {
+		t.Errorf("Units = %s, want %s", prefs.Units, UnitsImperial)
+	}
+	if prefs.Timezone != "UTC" {
+		t.Errorf("Timezone = %s, want default UTC", prefs.Timezone)
+	}
+	if !prefs.Notifications.Enabled {
+		t.Error("Notifications.Enabled should default to true")
+	}
+}
+
+func TestPreferencesMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		wantErr bool
+	}{
+		{"Change units", `{"units": "imperial"}`, false},
+		{"Change timezone", `{"timezone": "Europe/Lisbon"}`, false},
+		{"Regional language", `{"language": "pt-BR"}`, false},
+		{"Quiet hours", `{"notifications": {"quiet_hours": {"start": "22:00", "end": "07:30"}}}`, false},
+		{"Privacy", `{"privacy": {"activity_visibility": "private"}}`, false},
+		{"Nudges off", `{"notifications": {"max_nudges_per_day": 0}}`, false},
+		{"Unknown units", `{"units": "furlongs"}`, true},
+		{"Unknown timezone", `{"timezone": "Mars/Olympus"}`, true},
+		{"Empty timezone", `{"timezone": ""}`, true},
+		{"Bad language", `{"language": "english"}`, true},
+		{"Bad quiet hours", `{"notifications": {"quiet_hours": {"start": "10pm", "end": "07:00"}}}`, true},
+		{"Too many nudges", `{"notifications": {"max_nudges_per_day": 10}}`, true},
+		{"Bad visibility", `{"privacy": {"profile_visibility": "everyone"}}`, true},
+		{"Unknown key", `{"theme": "dark"}`, true},
+		{"Wrong type", `{"units": 1}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DefaultPreferences().Merge([]byte(tt.patch))
+			if tt.wantErr && !errors.Is(err, ErrInvalidPreferences) {
+				t.Errorf("Merge(%s) error = %v, want %v", tt.patch, err, ErrInvalidPreferences)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Merge(%s) unexpected error = %v", tt.patch, err)
+			}
+		})
+	}
+}
+
+func TestPreferencesMerge_KeepsUnchangedFields(t *testing.T) {
+	prefs := DefaultPreferences()
+	prefs.Language = "pt"
+
+	merged, err := prefs.Merge([]byte(`{"notifications": {"social": false}}`))
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	if merged.Language != "pt" {
+		t.Errorf("Language = %s, want pt", merged.Language)
+	}
+	if merged.Notifications.Social {
+		t.Error("Notifications.Social should be false")
+	}
+	if !merged.Notifications.Reminders {
+		t.Error("Notifications.Reminders should be unchanged")
+	}
+}
+
+func TestQuietHours_EndsAt(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Lisbon")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	overnight := QuietHours{Start: "22:00", End: "07:00"}
+	afternoon := QuietHours{Start: "13:00", End: "15:30"}
+
+	tests := []struct {
+		name   string
+		quiet  QuietHours
+		now    time.Time
+		inside bool
+		endsAt time.Time
+	}{
+		{"before overnight window", overnight, time.Date(2025, 6, 1, 21, 59, 0, 0, loc), false, time.Time{}},
+		{"late evening", overnight, time.Date(2025, 6, 1, 23, 30, 0, 0, loc), true, time.Date(2025, 6, 2, 7, 0, 0, 0, loc)},
+		{"early morning", overnight, time.Date(2025, 6, 2, 3, 0, 0, 0, loc), true, time.Date(2025, 6, 2, 7, 0, 0, 0, loc)},
+		{"window end is exclusive", overnight, time.Date(2025, 6, 2, 7, 0, 0, 0, loc), false, time.Time{}},
+		{"daytime window", afternoon, time.Date(2025, 6, 1, 14, 0, 0, 0, loc), true, time.Date(2025, 6, 1, 15, 30, 0, 0, loc)},
+		{"outside daytime window", afternoon, time.Date(2025, 6, 1, 16, 0, 0, 0, loc), false, time.Time{}},
+		{"empty window", QuietHours{Start: "08:00", End: "08:00"}, time.Date(2025, 6, 1, 8, 0, 0, 0, loc), false, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Evaluated in the user's timezone regardless of the input zone
+			endsAt, inside := tt.quiet.EndsAt(tt.now.UTC(), loc)
+			if inside != tt.inside {
+				t.Fatalf("EndsAt() inside = %v, want %v", inside, tt.inside)
+			}
+			if inside && !endsAt.Equal(tt.endsAt) {
+				t.Errorf("EndsAt() = %v, want %v", endsAt, tt.endsAt)
+			}
+		})
+	}
+}
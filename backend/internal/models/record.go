@@ -0,0 +1,126 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecordType is what a personal record measures, within a game type.
+type RecordType string
+
+const (
+	RecordLongestDistance RecordType = "longest_distance"
+	RecordLongestDuration RecordType = "longest_duration"
+	RecordFastestKM       RecordType = "fastest_km"
+	RecordMostThrows      RecordType = "most_throws"
+	RecordLongestCombo    RecordType = "longest_combo"
+	RecordMostPounces     RecordType = "most_pounces"
+	RecordMostRounds      RecordType = "most_rounds"
+	// The most time spent playing the game in one local day or week, from
+	// Monday, in the owner's timezone
+	RecordMostActiveDay  RecordType = "most_active_day"
+	RecordMostActiveWeek RecordType = "most_active_week"
+)
+
+// Unit is what the record's value counts.
+func (r RecordType) Unit() string {
+	switch r {
+	case RecordLongestDistance:
+		return "meters"
+	case RecordMostThrows, RecordLongestCombo:
+		return "throws"
+	case RecordMostPounces:
+		return "pounces"
+	case RecordMostRounds:
+		return "rounds"
+	default:
+		return "seconds"
+	}
+}
+
+// Beats reports whether a value breaks the record. Only the fastest
+// kilometre is better lower.
+func (r RecordType) Beats(value, record float64) bool {
+	if r == RecordFastestKM {
+		return value < record
+	}
+	return value > record
+}
+
+// PersonalRecord is the best a pet has done at something in a game, the
+// activity it was done in and, when listed, every time it was broken.
+type PersonalRecord struct {
+	GameTypeID string         `json:"game_type_id"`
+	Record     RecordType     `json:"record"`
+	Unit       string         `json:"unit"`
+	Value      float64        `json:"value"`
+	ActivityID *uuid.UUID     `json:"activity_id,omitempty"`
+	SetAt      time.Time      `json:"set_at"`
+	History    []*RecordBreak `json:"history,omitempty"`
+}
+
+// RecordBreak is a record being set or broken. PreviousValue is nil the
+// first time a pet sets the record.
+type RecordBreak struct {
+	Record        RecordType `json:"record"`
+	Value         float64    `json:"value"`
+	PreviousValue *float64   `json:"previous_value,omitempty"`
+	ActivityID    *uuid.UUID `json:"activity_id,omitempty"`
+	SetAt         time.Time  `json:"set_at"`
+}
+
+// RecordValue is what one activity reached towards a record.
+type RecordValue struct {
+	Record RecordType
+	Value  float64
+}
+
+// RecordPeriod is a calendar window whose total time playing the game
+// counts towards a record.
+type RecordPeriod struct {
+	Record RecordType
+	Start  time.Time
+	End    time.Time
+}
+
+// RecordClaim is what a finished activity reached towards its pet's
+// records, saved with the activity: its own values, and the periods whose
+// total time counts.
+type RecordClaim struct {
+	Values  []RecordValue
+	Periods []RecordPeriod
+}
+
+// ActivityRecords returns what a finished activity reached towards its
+// pet's records in the game: the longest session, and the game's own
+// records. Like leaderboard scores, flagged activities count for nothing.
+func ActivityRecords(a *Activity) []RecordValue {
+	if a.EndedAt == nil || a.FlagReason != nil {
+		return nil
+	}
+
+	var records []RecordValue
+	if a.DurationSeconds != nil && *a.DurationSeconds > 0 {
+		records = append(records, RecordValue{RecordLongestDuration, float64(*a.DurationSeconds)})
+	}
+	return append(records, GameFor(a.GameTypeID).Records(a)...)
+}
+
+// ActivityRecordPeriods returns the local day and week the activity started
+// in, whose totals count towards the most active day and week. Activities
+// count towards the day they started, as on leaderboards.
+func ActivityRecordPeriods(a *Activity, loc *time.Location) []RecordPeriod {
+	if a.EndedAt == nil || a.FlagReason != nil {
+		return nil
+	}
+
+	local := a.StartedAt.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	sinceMonday := (int(local.Weekday()) + 6) % 7
+	week := time.Date(local.Year(), local.Month(), local.Day()-sinceMonday, 0, 0, 0, 0, loc)
+	return []RecordPeriod{
+		{RecordMostActiveDay, day, day.AddDate(0, 0, 1)},
+		{RecordMostActiveWeek, week, week.AddDate(0, 0, 7)},
+	}
+}
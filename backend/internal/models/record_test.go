@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func recordValues(records []RecordValue) map[RecordType]float64 {
+	values := make(map[RecordType]float64, len(records))
+	for _, record := range records {
+		values[record.Record] = record.Value
+	}
+	return values
+}
+
+func TestActivityRecords(t *testing.T) {
+	endedAt := time.Date(2025, 10, 9, 9, 10, 0, 0, time.UTC)
+	duration := 600
+	fetch := &Activity{
+		GameTypeID:      "fetch",
+		EndedAt:         &endedAt,
+		DurationSeconds: &duration,
+		GameData:        json.RawMessage(`{"throws": 14, "returns": 9, "max_combo": 6}`),
+	}
+
+	got := recordValues(ActivityRecords(fetch))
+	want := map[RecordType]float64{RecordLongestDuration: 600, RecordMostThrows: 14, RecordLongestCombo: 6}
+	if len(got) != len(want) {
+		t.Fatalf("ActivityRecords() = %v, want %v", got, want)
+	}
+	for record, value := range want {
+		if got[record] != value {
+			t.Errorf("ActivityRecords()[%s] = %v, want %v", record, got[record], value)
+		}
+	}
+
+	reason := ActivityFlagTooManyThrows
+	flagged := *fetch
+	flagged.FlagReason = &reason
+	if records := ActivityRecords(&flagged); records != nil {
+		t.Errorf("ActivityRecords() of a flagged activity = %+v, want none", records)
+	}
+
+	running := *fetch
+	running.EndedAt = nil
+	if records := ActivityRecords(&running); records != nil {
+		t.Errorf("ActivityRecords() of a running activity = %+v, want none", records)
+	}
+}
+
+func TestActivityRecords_Walk(t *testing.T) {
+	endedAt := time.Date(2025, 10, 9, 9, 30, 0, 0, time.UTC)
+	duration := 1800
+	walk := &Activity{
+		GameTypeID:      "walk",
+		EndedAt:         &endedAt,
+		DurationSeconds: &duration,
+		GameData:        json.RawMessage(`{"distance_meters": 2500}`),
+	}
+
+	// Without a route, the average pace stands for the fastest kilometre
+	got := recordValues(ActivityRecords(walk))
+	if got[RecordLongestDistance] != 2500 || got[RecordLongestDuration] != 1800 || got[RecordFastestKM] != 720 {
+		t.Errorf("ActivityRecords() = %v", got)
+	}
+
+	// A route too short for a kilometre sets none, whatever the distance
+	walk.GameData = json.RawMessage(`{"distance_meters": 2500, "route": [[-9.16, 38.70], [-9.16, 38.701]]}`)
+	if got := recordValues(ActivityRecords(walk)); got[RecordFastestKM] != 0 {
+		t.Errorf("ActivityRecords() fastest km of a short route = %v, want none", got[RecordFastestKM])
+	}
+
+	walk.GameData = json.RawMessage(`{"distance_meters": 800}`)
+	if got := recordValues(ActivityRecords(walk)); got[RecordFastestKM] != 0 {
+		t.Errorf("ActivityRecords() fastest km of an 800m walk = %v, want none", got[RecordFastestKM])
+	}
+}
+
+func TestActivityRecordPeriods(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+
+	// Sunday 23:30 in UTC is already Monday in Lisbon, starting a new local
+	// day and week
+	startedAt := time.Date(2025, 10, 12, 23, 30, 0, 0, time.UTC)
+	endedAt := startedAt.Add(20 * time.Minute)
+	activity := &Activity{StartedAt: startedAt, EndedAt: &endedAt}
+
+	periods := ActivityRecordPeriods(activity, lisbon)
+	if len(periods) != 2 {
+		t.Fatalf("ActivityRecordPeriods() = %+v, want a day and a week", periods)
+	}
+	day, week := periods[0], periods[1]
+	if day.Record != RecordMostActiveDay || !day.Start.Equal(time.Date(2025, 10, 13, 0, 0, 0, 0, lisbon)) ||
+		!day.End.Equal(time.Date(2025, 10, 14, 0, 0, 0, 0, lisbon)) {
+		t.Errorf("day = %+v, want Monday the 13th", day)
+	}
+	if week.Record != RecordMostActiveWeek || !week.Start.Equal(time.Date(2025, 10, 13, 0, 0, 0, 0, lisbon)) ||
+		!week.End.Equal(time.Date(2025, 10, 20, 0, 0, 0, 0, lisbon)) {
+		t.Errorf("week = %+v, want the week from Monday the 13th", week)
+	}
+}
+
+func TestRecordType_Beats(t *testing.T) {
+	if !RecordFastestKM.Beats(290, 300) || RecordFastestKM.Beats(300, 300) {
+		t.Error("fastest km should be beaten by a lower time only")
+	}
+	if !RecordMostThrows.Beats(21, 20) || RecordMostThrows.Beats(20, 20) {
+		t.Error("most throws should be beaten by more throws only")
+	}
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/pkg/rrule"
+)
+
+var ErrInvalidReminder = errors.New("invalid reminder")
+
+// Reminder is a one-off or recurring nudge ("walk Rex", "flea treatment").
+// Recurring reminders carry an RFC 5545 RRULE that is evaluated on the
+// wall clock of the owner's timezone, anchored at StartsAt.
+type Reminder struct {
+	ID     uuid.UUID  `json:"id"`
+	UserID uuid.UUID  `json:"user_id"`
+	PetID  *uuid.UUID `json:"pet_id,omitempty"`
+	Title  string     `json:"title"`
+	Notes  *string    `json:"notes,omitempty"`
+	// RRule is nil for a one-off reminder at StartsAt.
+	RRule    *string   `json:"rrule,omitempty"`
+	StartsAt time.Time `json:"starts_at"`
+	// GameTypeID is the activity started when the reminder is marked done.
+	GameTypeID     *string    `json:"game_type_id,omitempty"`
+	Enabled        bool       `json:"enabled"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty"`
+	LastDoneAt     *time.Time `json:"last_done_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// NextOccurrence returns the first occurrence strictly after after, or nil
+// when the reminder has no more occurrences.
+func (r *Reminder) NextOccurrence(after time.Time, loc *time.Location) *time.Time {
+	if r.RRule == nil {
+		if r.StartsAt.After(after) {
+			next := r.StartsAt
+			return &next
+		}
+		return nil
+	}
+
+	rule, err := rrule.Parse(*r.RRule)
+	if err != nil {
+		return nil
+	}
+	next, ok := rule.Next(r.StartsAt, after, loc)
+	if !ok {
+		return nil
+	}
+	return &next
+}
+
+// Occurrences lists up to n upcoming occurrences after after.
+func (r *Reminder) Occurrences(after time.Time, loc *time.Location, n int) []time.Time {
+	occurrences := []time.Time{}
+	for len(occurrences) < n {
+		next := r.NextOccurrence(after, loc)
+		if next == nil {
+			break
+		}
+		occurrences = append(occurrences, *next)
+		after = *next
+	}
+	return occurrences
+}
+
+type ReminderInput struct {
+	PetID      *uuid.UUID `json:"pet_id,omitempty"`
+	Title      string     `json:"title"`
+	Notes      *string    `json:"notes,omitempty"`
+	RRule      *string    `json:"rrule,omitempty"`
+	StartsAt   time.Time  `json:"starts_at"`
+	GameTypeID *string    `json:"game_type_id,omitempty"`
+	Enabled    *bool      `json:"enabled,omitempty"`
+}
+
+// Validate checks the input and normalizes the rule to its canonical form.
+func (i *ReminderInput) Validate() error {
+	i.Title = strings.TrimSpace(i.Title)
+	if i.Title == "" {
+		return reminderError("title is required")
+	}
+	if len(i.Title) > 255 {
+		return reminderError("title must be at most 255 characters")
+	}
+	if i.StartsAt.IsZero() {
+		return reminderError("starts_at is required")
+	}
+	if i.RRule != nil {
+		rule, err := rrule.Parse(*i.RRule)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReminder, err)
+		}
+		canonical := rule.String()
+		i.RRule = &canonical
+	}
+	if i.GameTypeID != nil && i.PetID == nil {
+		return reminderError("game_type_id requires pet_id")
+	}
+	return nil
+}
+
+// ReminderCompletion is the result of marking a reminder done, with the
+// activity it started, if any.
+type ReminderCompletion struct {
+	Reminder *Reminder `json:"reminder"`
+	Activity *Activity `json:"activity,omitempty"`
+}
+
+func reminderError(message string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidReminder, message)
+}
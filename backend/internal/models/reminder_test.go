@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReminderInput_Validate(t *testing.T) {
+	petID := uuid.New()
+	walk := "walk"
+	badRule := "FREQ=HOURLY"
+	rule := "freq=daily;byhour=8;byminute=0"
+	startsAt := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	invalid := []ReminderInput{
+		{Title: " ", StartsAt: startsAt},
+		{Title: "Walk"},
+		{Title: "Walk", StartsAt: startsAt, RRule: &badRule},
+		{Title: "Walk", StartsAt: startsAt, GameTypeID: &walk},
+	}
+	for i, input := range invalid {
+		if err := input.Validate(); !errors.Is(err, ErrInvalidReminder) {
+			t.Errorf("case %d: Validate() error = %v, want ErrInvalidReminder", i, err)
+		}
+	}
+
+	input := ReminderInput{Title: " Walk Rex ", StartsAt: startsAt, RRule: &rule, PetID: &petID, GameTypeID: &walk}
+	if err := input.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if input.Title != "Walk Rex" {
+		t.Errorf("Title = %q, want trimmed", input.Title)
+	}
+	if *input.RRule != "FREQ=DAILY;BYHOUR=8;BYMINUTE=0" {
+		t.Errorf("RRule = %q, want canonical form", *input.RRule)
+	}
+}
+
+func TestReminder_NextOccurrence_OneOff(t *testing.T) {
+	startsAt := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	reminder := &Reminder{StartsAt: startsAt}
+
+	next := reminder.NextOccurrence(startsAt.Add(-time.Minute), time.UTC)
+	if next == nil || !next.Equal(startsAt) {
+		t.Errorf("NextOccurrence() before start = %v, want %v", next, startsAt)
+	}
+	if next := reminder.NextOccurrence(startsAt, time.UTC); next != nil {
+		t.Errorf("NextOccurrence() after start = %v, want nil", next)
+	}
+}
+
+func TestReminder_NextOccurrence_UserTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	rule := "FREQ=DAILY;BYHOUR=8;BYMINUTE=0"
+	reminder := &Reminder{RRule: &rule, StartsAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+	// 10:00 UTC is 07:00 in São Paulo, so the next 8:00 is the same day
+	next := reminder.NextOccurrence(time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC), loc)
+	if want := time.Date(2025, 6, 2, 11, 0, 0, 0, time.UTC); next == nil || !next.Equal(want) {
+		t.Errorf("NextOccurrence() = %v, want %v", next, want)
+	}
+
+	occurrences := reminder.Occurrences(time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC), loc, 3)
+	if len(occurrences) != 3 {
+		t.Fatalf("Occurrences() returned %d, want 3", len(occurrences))
+	}
+	for i, occurrence := range occurrences {
+		if local := occurrence.In(loc); local.Hour() != 8 || local.Day() != 3+i {
+			t.Errorf("occurrence %d = %v, want 08:00 on June %d", i, local, 3+i)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"math"
+
+	"github.com/joaosantos/pettime/pkg/geohash"
+)
+
+// AreaPrecision is the geohash length of a walker's area, a cell of about
+// 5km by 5km: a neighbourhood in a city, a town in the countryside.
+const AreaPrecision = 5
+
+const (
+	// stopRadiusMeters is how far a dog can wander while sniffing one spot.
+	stopRadiusMeters = 15
+	// minStopPoints is how many consecutive route points within the radius
+	// make a stop rather than a slow stretch of the walk. The app records
+	// points at a steady interval, so this is also a minimum duration.
+	minStopPoints = 4
+
+	earthRadiusMeters = 6371000
+)
+
+// RouteArea returns the geohash cell around the middle of a walk's route,
+// and false if the route has no usable points. Only the cell is kept, never
+// the route itself.
+func RouteArea(route [][]float64) (string, bool) {
+	var latSum, lngSum float64
+	points := 0
+	for _, point := range route {
+		lng, lat, ok := routePoint(point)
+		if !ok {
+			continue
+		}
+		latSum += lat
+		lngSum += lng
+		points++
+	}
+	if points == 0 {
+		return "", false
+	}
+	return geohash.Encode(latSum/float64(points), lngSum/float64(points), AreaPrecision), true
+}
+
+// CountStops counts the places a walk lingered: runs of at least
+// minStopPoints consecutive points staying within stopRadiusMeters of where
+// the run began. Malformed points are skipped.
+func CountStops(route [][]float64) int {
+	stops := 0
+	var anchorLng, anchorLat float64
+	run := 0
+	for _, point := range route {
+		lng, lat, ok := routePoint(point)
+		if !ok {
+			continue
+		}
+		if run > 0 && distanceMeters(anchorLat, anchorLng, lat, lng) <= stopRadiusMeters {
+			run++
+			if run == minStopPoints {
+				stops++
+			}
+			continue
+		}
+		anchorLng, anchorLat = lng, lat
+		run = 1
+	}
+	return stops
+}
+
+// routePoint reads a [longitude, latitude] point, reporting false if it is
+// malformed or off the map.
+func routePoint(point []float64) (float64, float64, bool) {
+	if len(point) < 2 {
+		return 0, 0, false
+	}
+	lng, lat := point[0], point[1]
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, false
+	}
+	return lng, lat, true
+}
+
+// distanceMeters is the great-circle distance between two coordinates.
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
+
+// FastestSplit returns the seconds the quickest stretch of a route covering
+// the distance took, and false if the route is shorter. Points are
+// recorded at a steady interval, so each one stands for an equal share of
+// the walk's duration; the stretch's time is scaled down to the exact
+// distance. Malformed points are skipped but still take their share.
+func FastestSplit(route [][]float64, durationSeconds int, meters float64) (float64, bool) {
+	if len(route) < 2 || durationSeconds <= 0 {
+		return 0, false
+	}
+	step := float64(durationSeconds) / float64(len(route)-1)
+
+	// Distance walked up to each usable point, by its position in the route
+	var positions []int
+	var walked []float64
+	var lastLng, lastLat float64
+	for i, point := range route {
+		lng, lat, ok := routePoint(point)
+		if !ok {
+			continue
+		}
+		total := 0.0
+		if len(walked) > 0 {
+			total = walked[len(walked)-1] + distanceMeters(lastLat, lastLng, lat, lng)
+		}
+		positions = append(positions, i)
+		walked = append(walked, total)
+		lastLng, lastLat = lng, lat
+	}
+
+	best, found := 0.0, false
+	from := 0
+	for to := range walked {
+		// Keep the shortest stretch ending here that still covers the distance
+		for from+1 < to && walked[to]-walked[from+1] >= meters {
+			from++
+		}
+		covered := walked[to] - walked[from]
+		if covered < meters {
+			continue
+		}
+		seconds := float64(positions[to]-positions[from]) * step * meters / covered
+		if !found || seconds < best {
+			best, found = seconds, true
+		}
+	}
+	return best, found
+}
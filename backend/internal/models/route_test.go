@@ -0,0 +1,110 @@
+package models
+
+import "testing"
+
+func TestRouteArea(t *testing.T) {
+	// A loop around Lisbon's Jardim da Estrela, [longitude, latitude]
+	route := [][]float64{{-9.1602, 38.7135}, {-9.1590, 38.7140}, {-9.1585, 38.7130}, {-9.1598, 38.7125}}
+	area, ok := RouteArea(route)
+	if !ok || len(area) != AreaPrecision || area[:3] != "eyc" {
+		t.Errorf("RouteArea() = %q, %v, want a Lisbon cell", area, ok)
+	}
+
+	// Malformed and out-of-range points are skipped
+	noisy := append([][]float64{{-9.16}, {200, 95}}, route...)
+	if got, _ := RouteArea(noisy); got != area {
+		t.Errorf("RouteArea() with bad points = %q, want %q", got, area)
+	}
+
+	if _, ok := RouteArea(nil); ok {
+		t.Error("RouteArea() of an empty route reported an area")
+	}
+}
+
+func TestCountStops(t *testing.T) {
+	// About 1.1m per 0.00001 degrees of latitude
+	walking := func(from float64, n int) [][]float64 {
+		points := make([][]float64, n)
+		for i := range points {
+			points[i] = []float64{-9.16, from + float64(i)*0.0003} // ~33m apart
+		}
+		return points
+	}
+	sniffing := func(lat float64, n int) [][]float64 {
+		points := make([][]float64, n)
+		for i := range points {
+			points[i] = []float64{-9.16, lat + float64(i%2)*0.00005} // ~5m of shuffling
+		}
+		return points
+	}
+	join := func(parts ...[][]float64) [][]float64 {
+		var route [][]float64
+		for _, part := range parts {
+			route = append(route, part...)
+		}
+		return route
+	}
+
+	tests := []struct {
+		name  string
+		route [][]float64
+		want  int
+	}{
+		{"no route", nil, 0},
+		{"steady walk", walking(38.71, 20), 0},
+		{"one long sniff", join(walking(38.71, 5), sniffing(38.7115, 12), walking(38.712, 5)), 1},
+		{"two sniffs", join(sniffing(38.71, 5), walking(38.7102, 5), sniffing(38.712, 4)), 2},
+		{"pause too short", join(walking(38.71, 5), sniffing(38.7115, 3), walking(38.712, 5)), 0},
+		{"malformed points inside a stop", join(sniffing(38.71, 2), [][]float64{{-9.16}, {500, 500}}, sniffing(38.71, 2)), 1},
+	}
+	for _, tt := range tests {
+		if got := CountStops(tt.route); got != tt.want {
+			t.Errorf("%s: CountStops() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	// Lisbon to Porto is about 274km as the crow flies
+	got := distanceMeters(38.7223, -9.1393, 41.1579, -8.6291)
+	if got < 270000 || got > 278000 {
+		t.Errorf("distanceMeters(Lisbon, Porto) = %.0f, want about 274km", got)
+	}
+	if got := distanceMeters(38.7, -9.1, 38.7, -9.1); got != 0 {
+		t.Errorf("distanceMeters() of the same point = %v, want 0", got)
+	}
+}
+
+func TestFastestSplit(t *testing.T) {
+	// Points 10 s apart, first strolling ~17m between them, then trotting
+	// ~33m, so the fastest kilometre is the trot's ~300 s
+	var route [][]float64
+	lat := 38.70
+	for i := 0; i < 120; i++ {
+		route = append(route, []float64{-9.16, lat})
+		if i < 60 {
+			lat += 0.00015
+		} else {
+			lat += 0.0003
+		}
+	}
+	duration := (len(route) - 1) * 10
+
+	seconds, ok := FastestSplit(route, duration, 1000)
+	trot := 1000 / distanceMeters(38.70, -9.16, 38.7003, -9.16) * 10
+	if !ok || seconds < trot*0.99 || seconds > trot*1.01 {
+		t.Errorf("FastestSplit() = %.0f, %v, want about %.0f", seconds, ok, trot)
+	}
+
+	// A malformed point still took its share of the time
+	noisy := append([][]float64{}, route[:90]...)
+	noisy = append(noisy, []float64{-9.16})
+	noisy = append(noisy, route[90:]...)
+	if got, _ := FastestSplit(noisy, duration+10, 1000); got < seconds {
+		t.Errorf("FastestSplit() with a malformed point = %.0f, want at least %.0f", got, seconds)
+	}
+
+	if _, ok := FastestSplit(route[:20], 190, 1000); ok {
+		t.Error("FastestSplit() of a 300m route reported a kilometre")
+	}
+}
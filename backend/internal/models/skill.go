@@ -0,0 +1,227 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidSkill = errors.New("invalid skill")
+
+	ErrSkillUnlocked        = errors.New("skill is already unlocked")
+	ErrSkillLevelTooLow     = errors.New("pet's level is too low for this skill")
+	ErrSkillPrerequisites   = errors.New("skill's prerequisites are not unlocked")
+	ErrNotEnoughSkillPoints = errors.New("not enough skill points")
+)
+
+// SkillEffect is how an unlocked skill changes an activity's XP. The
+// effect only applies to activities of GameTypeID (any if empty) lasting
+// at least MinMinutes. Stop bonuses are added first, then the multiplier
+// scales the total.
+type SkillEffect struct {
+	GameTypeID   string  `json:"game_type_id,omitempty"`
+	MinMinutes   int     `json:"min_minutes,omitempty"`
+	XPMultiplier float64 `json:"xp_multiplier,omitempty"`
+	// XPPerStop rewards each stop detected in a walk's route, up to
+	// MaxStops
+	XPPerStop int `json:"xp_per_stop,omitempty"`
+	MaxStops  int `json:"max_stops,omitempty"`
+}
+
+// Applies reports whether the effect counts for the finished activity.
+func (e SkillEffect) Applies(a *Activity) bool {
+	if a.DurationSeconds == nil {
+		return false
+	}
+	if e.GameTypeID != "" && a.GameTypeID != e.GameTypeID {
+		return false
+	}
+	return *a.DurationSeconds >= e.MinMinutes*60
+}
+
+// Skill is a node in the skill tree. A pet unlocks it by spending Cost
+// skill points once it reaches RequiredLevel and has unlocked every
+// prerequisite.
+type Skill struct {
+	ID            string      `json:"id"`
+	Name          string      `json:"name"`
+	Description   string      `json:"description"`
+	RequiredLevel int         `json:"required_level"`
+	Cost          int         `json:"cost"`
+	Prerequisites []string    `json:"prerequisites"`
+	Effect        SkillEffect `json:"effect"`
+}
+
+func (s *Skill) Validate() error {
+	e := s.Effect
+	switch {
+	case strings.TrimSpace(s.Name) == "":
+		return fmt.Errorf("%w: %s: name is required", ErrInvalidSkill, s.ID)
+	case s.RequiredLevel < 1:
+		return fmt.Errorf("%w: %s: required_level must be at least 1", ErrInvalidSkill, s.ID)
+	case s.Cost < 1:
+		return fmt.Errorf("%w: %s: cost must be at least 1", ErrInvalidSkill, s.ID)
+	case e.MinMinutes < 0:
+		return fmt.Errorf("%w: %s: min_minutes must not be negative", ErrInvalidSkill, s.ID)
+	case e.XPMultiplier != 0 && e.XPMultiplier < 1:
+		return fmt.Errorf("%w: %s: xp_multiplier must be at least 1", ErrInvalidSkill, s.ID)
+	case e.XPPerStop < 0 || e.MaxStops < 0:
+		return fmt.Errorf("%w: %s: stop bonuses must not be negative", ErrInvalidSkill, s.ID)
+	case e.XPPerStop > 0 && e.MaxStops == 0:
+		return fmt.Errorf("%w: %s: xp_per_stop needs max_stops", ErrInvalidSkill, s.ID)
+	case e.XPMultiplier == 0 && e.XPPerStop == 0:
+		return fmt.Errorf("%w: %s: effect changes nothing", ErrInvalidSkill, s.ID)
+	}
+	return nil
+}
+
+// ValidateSkillTree checks every skill and that prerequisites name skills
+// in the tree without going round in circles.
+func ValidateSkillTree(skills []*Skill) error {
+	byID := make(map[string]*Skill, len(skills))
+	for _, skill := range skills {
+		if err := skill.Validate(); err != nil {
+			return err
+		}
+		byID[skill.ID] = skill
+	}
+
+	// 1 while a skill's prerequisites are being walked, 2 once they are
+	// known to be fine
+	state := make(map[string]int, len(skills))
+	var visit func(skill *Skill) error
+	visit = func(skill *Skill) error {
+		switch state[skill.ID] {
+		case 1:
+			return fmt.Errorf("%w: %s: prerequisites form a cycle", ErrInvalidSkill, skill.ID)
+		case 2:
+			return nil
+		}
+		state[skill.ID] = 1
+		for _, id := range skill.Prerequisites {
+			prerequisite, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("%w: %s: unknown prerequisite %q", ErrInvalidSkill, skill.ID, id)
+			}
+			if err := visit(prerequisite); err != nil {
+				return err
+			}
+		}
+		state[skill.ID] = 2
+		return nil
+	}
+	for _, skill := range skills {
+		if err := visit(skill); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplySkills returns the activity's XP with the effects of the pet's
+// unlocked skills.
+func ApplySkills(xp int, skills []*Skill, a *Activity) int {
+	stops := -1
+	multiplier := 1.0
+	for _, skill := range skills {
+		e := skill.Effect
+		if !e.Applies(a) {
+			continue
+		}
+		if e.XPPerStop > 0 {
+			if stops < 0 {
+				stops = 0
+				var walk WalkGameData
+				if err := json.Unmarshal(a.GameData, &walk); err == nil {
+					stops = CountStops(walk.Route)
+				}
+			}
+			xp += min(stops, e.MaxStops) * e.XPPerStop
+		}
+		if e.XPMultiplier > 0 {
+			multiplier *= e.XPMultiplier
+		}
+	}
+	return int(float64(xp) * multiplier)
+}
+
+// SkillTree is a pet's view of the skill catalog.
+type SkillTree struct {
+	PetID           uuid.UUID         `json:"pet_id"`
+	Level           int               `json:"level"`
+	PointsEarned    int               `json:"points_earned"`
+	PointsSpent     int               `json:"points_spent"`
+	PointsAvailable int               `json:"points_available"`
+	Skills          []*SkillTreeEntry `json:"skills"`
+}
+
+// SkillTreeEntry is a skill with the pet's progress on it. Unlockable
+// means the pet can spend points on it now.
+type SkillTreeEntry struct {
+	Skill
+	Unlocked   bool       `json:"unlocked"`
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+	Unlockable bool       `json:"unlockable"`
+}
+
+// UnlockedSkill is a skill a pet unlocked, as exported with the owner's
+// data.
+type UnlockedSkill struct {
+	PetID      uuid.UUID `json:"pet_id"`
+	SkillID    string    `json:"skill_id"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+}
+
+// BuildSkillTree lays out the catalog for a pet of the given level and
+// unlocked skills.
+func BuildSkillTree(petID uuid.UUID, level int, leveling *LevelingConfig, skills []*Skill, unlocked map[string]time.Time) *SkillTree {
+	tree := &SkillTree{
+		PetID:        petID,
+		Level:        level,
+		PointsEarned: leveling.SkillPoints(level),
+		Skills:       make([]*SkillTreeEntry, 0, len(skills)),
+	}
+	for _, skill := range skills {
+		if _, ok := unlocked[skill.ID]; ok {
+			tree.PointsSpent += skill.Cost
+		}
+	}
+	// Points spent under an earlier level curve can't go negative
+	tree.PointsAvailable = max(tree.PointsEarned-tree.PointsSpent, 0)
+
+	for _, skill := range skills {
+		entry := &SkillTreeEntry{Skill: *skill}
+		if at, ok := unlocked[skill.ID]; ok {
+			entry.Unlocked = true
+			entry.UnlockedAt = &at
+		} else {
+			entry.Unlockable = tree.CheckUnlock(skill, unlocked) == nil
+		}
+		tree.Skills = append(tree.Skills, entry)
+	}
+	return tree
+}
+
+// CheckUnlock reports why the pet can't unlock the skill, or nil if it can.
+func (t *SkillTree) CheckUnlock(skill *Skill, unlocked map[string]time.Time) error {
+	if _, ok := unlocked[skill.ID]; ok {
+		return ErrSkillUnlocked
+	}
+	if t.Level < skill.RequiredLevel {
+		return ErrSkillLevelTooLow
+	}
+	for _, id := range skill.Prerequisites {
+		if _, ok := unlocked[id]; !ok {
+			return ErrSkillPrerequisites
+		}
+	}
+	if t.PointsAvailable < skill.Cost {
+		return ErrNotEnoughSkillPoints
+	}
+	return nil
+}
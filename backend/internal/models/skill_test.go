@@ -0,0 +1,126 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testSkills() []*Skill {
+	return []*Skill{
+		{ID: "long_walker", Name: "Long Walker", RequiredLevel: 2, Cost: 1,
+			Effect: SkillEffect{GameTypeID: "walk", MinMinutes: 30, XPMultiplier: 1.1}},
+		{ID: "sniffer", Name: "Sniffer", RequiredLevel: 3, Cost: 1,
+			Effect: SkillEffect{GameTypeID: "walk", XPPerStop: 5, MaxStops: 2}},
+		{ID: "trailblazer", Name: "Trailblazer", RequiredLevel: 5, Cost: 2, Prerequisites: []string{"long_walker"},
+			Effect: SkillEffect{GameTypeID: "walk", MinMinutes: 60, XPMultiplier: 1.5}},
+	}
+}
+
+func TestApplySkills(t *testing.T) {
+	skills := testSkills()
+	minutes := func(m int) *int { s := m * 60; return &s }
+
+	// Three stops of five points each, ~5m apart, separated by ~33m strides
+	var route [][]float64
+	for stop := 0; stop < 3; stop++ {
+		lat := 38.71 + float64(stop)*0.0003
+		for i := 0; i < 5; i++ {
+			route = append(route, []float64{-9.16, lat + float64(i%2)*0.00005})
+		}
+	}
+	gameData, _ := json.Marshal(WalkGameData{Route: route})
+
+	tests := []struct {
+		name     string
+		activity Activity
+		skills   []*Skill
+		want     int
+	}{
+		{"no skills", Activity{GameTypeID: "walk", DurationSeconds: minutes(45)}, nil, 100},
+		{"long walk", Activity{GameTypeID: "walk", DurationSeconds: minutes(45)}, skills[:1], 110},
+		{"short walk", Activity{GameTypeID: "walk", DurationSeconds: minutes(20)}, skills[:1], 100},
+		{"other game", Activity{GameTypeID: "fetch", DurationSeconds: minutes(45)}, skills[:1], 100},
+		// Stops are capped at two, then both multipliers apply
+		{"stops and multipliers", Activity{GameTypeID: "walk", DurationSeconds: minutes(90), GameData: gameData}, skills, 181},
+		{"stops without a route", Activity{GameTypeID: "walk", DurationSeconds: minutes(10)}, skills[1:2], 100},
+	}
+	for _, tt := range tests {
+		if got := ApplySkills(100, tt.skills, &tt.activity); got != tt.want {
+			t.Errorf("%s: ApplySkills() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSkillTree(t *testing.T) {
+	if err := ValidateSkillTree(testSkills()); err != nil {
+		t.Errorf("ValidateSkillTree() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func([]*Skill)
+	}{
+		{"unknown prerequisite", func(s []*Skill) { s[2].Prerequisites = []string{"flying"} }},
+		{"cycle", func(s []*Skill) { s[0].Prerequisites = []string{"trailblazer"} }},
+		{"free skill", func(s []*Skill) { s[0].Cost = 0 }},
+		{"no effect", func(s []*Skill) { s[0].Effect = SkillEffect{GameTypeID: "walk"} }},
+		{"penalty", func(s []*Skill) { s[0].Effect.XPMultiplier = 0.5 }},
+		{"uncapped stops", func(s []*Skill) { s[1].Effect.MaxStops = 0 }},
+	}
+	for _, tt := range tests {
+		skills := testSkills()
+		tt.modify(skills)
+		if err := ValidateSkillTree(skills); !errors.Is(err, ErrInvalidSkill) {
+			t.Errorf("%s: ValidateSkillTree() error = %v, want ErrInvalidSkill", tt.name, err)
+		}
+	}
+}
+
+func TestBuildSkillTree(t *testing.T) {
+	skills := testSkills()
+	leveling := DefaultLevelingConfig()
+	now := time.Now()
+
+	// Level 5 has earned four points, one spent on Long Walker
+	unlocked := map[string]time.Time{"long_walker": now}
+	tree := BuildSkillTree(uuid.New(), 5, &leveling, skills, unlocked)
+	if tree.Level != 5 || tree.PointsEarned != 4 || tree.PointsSpent != 1 || tree.PointsAvailable != 3 {
+		t.Fatalf("tree = level %d, %d earned, %d spent, %d available, want 5, 4, 1, 3",
+			tree.Level, tree.PointsEarned, tree.PointsSpent, tree.PointsAvailable)
+	}
+	for _, entry := range tree.Skills {
+		wantUnlocked := entry.ID == "long_walker"
+		if entry.Unlocked != wantUnlocked || entry.Unlockable == wantUnlocked {
+			t.Errorf("%s: unlocked %v unlockable %v", entry.ID, entry.Unlocked, entry.Unlockable)
+		}
+	}
+
+	if err := tree.CheckUnlock(skills[0], unlocked); !errors.Is(err, ErrSkillUnlocked) {
+		t.Errorf("CheckUnlock() of an unlocked skill = %v", err)
+	}
+
+	// Level 3 has two points, but Trailblazer needs level 5
+	young := BuildSkillTree(uuid.New(), 3, &leveling, skills, map[string]time.Time{})
+	if err := young.CheckUnlock(skills[2], nil); !errors.Is(err, ErrSkillLevelTooLow) {
+		t.Errorf("CheckUnlock() below the required level = %v", err)
+	}
+
+	noPrerequisite := BuildSkillTree(uuid.New(), 5, &leveling, skills, map[string]time.Time{})
+	if err := noPrerequisite.CheckUnlock(skills[2], nil); !errors.Is(err, ErrSkillPrerequisites) {
+		t.Errorf("CheckUnlock() without prerequisites = %v", err)
+	}
+
+	// Level 2 has a single point, already spent
+	broke := BuildSkillTree(uuid.New(), 2, &leveling, skills, map[string]time.Time{"long_walker": now})
+	if err := broke.CheckUnlock(skills[1], unlocked); !errors.Is(err, ErrSkillLevelTooLow) {
+		t.Errorf("CheckUnlock() = %v, want ErrSkillLevelTooLow", err)
+	}
+	broke.Level = 3
+	if err := broke.CheckUnlock(skills[1], unlocked); !errors.Is(err, ErrNotEnoughSkillPoints) {
+		t.Errorf("CheckUnlock() without points = %v, want ErrNotEnoughSkillPoints", err)
+	}
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidComment = errors.New("invalid comment")
+	ErrInvalidCursor  = errors.New("invalid cursor")
+)
+
+// UserProfile is what other users see of a user.
+type UserProfile struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	AvatarURL *string   `json:"avatar_url,omitempty"`
+}
+
+// Friends
+
+// FriendCode lets others send the user a friend request. Apps show it as
+// text and as a QR code.
+type FriendCode struct {
+	Code string `json:"code"`
+}
+
+// NormalizeFriendCode accepts codes typed in lower case or with spaces and
+// dashes, e.g. "abcd-2345".
+func NormalizeFriendCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+type Friend struct {
+	UserProfile
+	FriendsSince time.Time `json:"friends_since"`
+}
+
+type FriendRequest struct {
+	ID         uuid.UUID    `json:"id"`
+	FromUserID uuid.UUID    `json:"from_user_id"`
+	ToUserID   uuid.UUID    `json:"to_user_id"`
+	From       *UserProfile `json:"from,omitempty"`
+	To         *UserProfile `json:"to,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	// Accepted is set when sending the request answered one the other
+	// user had already sent, making the two friends.
+	Accepted bool `json:"accepted,omitempty"`
+}
+
+// FriendRequests are the user's pending requests in both directions.
+type FriendRequests struct {
+	Incoming []*FriendRequest `json:"incoming"`
+	Outgoing []*FriendRequest `json:"outgoing"`
+}
+
+type SendFriendRequestInput struct {
+	Code string `json:"code"`
+}
+
+type BlockedUser struct {
+	UserProfile
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// Feed
+
+type FeedEventKind string
+
+const (
+	FeedEventActivity    FeedEventKind = "activity"
+	FeedEventLevelUp     FeedEventKind = "level_up"
+	FeedEventAchievement FeedEventKind = "achievement"
+)
+
+// FeedEvent is something worth sharing with friends. Details depend on the
+// kind: game type, duration, distance and XP for activities, the new level
+// for level-ups, the achievement's ID, name and icon for achievements.
+type FeedEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	UserID     uuid.UUID       `json:"user_id"`
+	PetID      uuid.UUID       `json:"pet_id"`
+	Kind       FeedEventKind   `json:"kind"`
+	ActivityID *uuid.UUID      `json:"activity_id,omitempty"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"created_at"`
+
+	User         *UserProfile `json:"user,omitempty"`
+	Pet          *FeedPet     `json:"pet,omitempty"`
+	PawFives     int          `json:"paw_fives"`
+	PawFivedByMe bool         `json:"paw_fived_by_me"`
+	CommentCount int          `json:"comment_count"`
+}
+
+type FeedPet struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	AvatarURL *string   `json:"avatar_url,omitempty"`
+}
+
+// FeedPage is one page of the feed, newest first. NextCursor fetches the
+// following page and is nil on the last one.
+type FeedPage struct {
+	Events     []*FeedEvent `json:"events"`
+	NextCursor *string      `json:"next_cursor"`
+}
+
+// FeedCursor points just past the last event of a page.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c FeedCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseFeedCursor(cursor string) (FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	return FeedCursor{CreatedAt: createdAt, ID: eventID}, nil
+}
+
+// MaxCommentLength is the longest comment, in characters.
+const MaxCommentLength = 500
+
+type FeedComment struct {
+	ID        uuid.UUID    `json:"id"`
+	EventID   uuid.UUID    `json:"event_id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	User      *UserProfile `json:"user,omitempty"`
+	Body      string       `json:"body"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type CommentInput struct {
+	Body string `json:"body"`
+}
+
+// Validate trims the comment and checks its length.
+func (i *CommentInput) Validate() error {
+	i.Body = strings.TrimSpace(i.Body)
+	if i.Body == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidComment)
+	}
+	if utf8.RuneCountInString(i.Body) > MaxCommentLength {
+		return fmt.Errorf("%w: body must be at most %d characters", ErrInvalidComment, MaxCommentLength)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFeedCursor_RoundTrip(t *testing.T) {
+	cursor := FeedCursor{
+		CreatedAt: time.Date(2024, 3, 10, 18, 30, 15, 123456789, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	parsed, err := ParseFeedCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("ParseFeedCursor() error = %v", err)
+	}
+	if !parsed.CreatedAt.Equal(cursor.CreatedAt) || parsed.ID != cursor.ID {
+		t.Errorf("ParseFeedCursor() = %+v, want %+v", parsed, cursor)
+	}
+}
+
+func TestParseFeedCursor_Invalid(t *testing.T) {
+	tests := []string{
+		"not base64!",
+		"bm8tc2VwYXJhdG9y", // "no-separator"
+		FeedCursor{ID: uuid.New()}.Encode()[:10],
+	}
+	for _, cursor := range tests {
+		if _, err := ParseFeedCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("ParseFeedCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestNormalizeFriendCode(t *testing.T) {
+	tests := map[string]string{
+		"ABCD2345":    "ABCD2345",
+		"abcd2345":    "ABCD2345",
+		" abcd-2345 ": "ABCD2345",
+		"AB CD 23 45": "ABCD2345",
+	}
+	for input, want := range tests {
+		if got := NormalizeFriendCode(input); got != want {
+			t.Errorf("NormalizeFriendCode(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCommentInput_Validate(t *testing.T) {
+	input := CommentInput{Body: "  Good dog!  "}
+	if err := input.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if input.Body != "Good dog!" {
+		t.Errorf("Validate() body = %q, want it trimmed", input.Body)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"empty", ""},
+		{"blank", "   "},
+		{"too long", strings.Repeat("a", MaxCommentLength+1)},
+	}
+	for _, tt := range tests {
+		input := CommentInput{Body: tt.body}
+		if err := input.Validate(); !errors.Is(err, ErrInvalidComment) {
+			t.Errorf("%s: Validate() error = %v, want ErrInvalidComment", tt.name, err)
+		}
+	}
+
+	// Length counts characters, not bytes
+	input = CommentInput{Body: strings.Repeat("🐾", MaxCommentLength)}
+	if err := input.Validate(); err != nil {
+		t.Errorf("Validate() with %d emoji error = %v", MaxCommentLength, err)
+	}
+}
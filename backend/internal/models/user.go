@@ -22,8 +22,12 @@ type User struct {
 	AvatarURL      *string      `json:"avatar_url,omitempty"`
 	AuthProvider   AuthProvider `json:"auth_provider"`
 	AuthProviderID *string      `json:"-"`
-	CreatedAt      time.Time    `json:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at"`
+	// DeletionScheduledAt is set when the user asked to delete their
+	// account. Signing in again before then cancels the deletion.
+	DeletionScheduledAt *time.Time       `json:"deletion_scheduled_at,omitempty"`
+	Preferences         *UserPreferences `json:"preferences,omitempty"`
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
 }
 
 type CreateUserInput struct {
@@ -39,17 +43,22 @@ type UpdateUserInput struct {
 	AvatarURL *string `json:"avatar_url,omitempty"`
 }
 
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
 type LoginInput struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
 
+// SocialLoginInput signs in with a provider's ID token. The provider ID and
+// email come from the verified token.
 type SocialLoginInput struct {
-	Provider   AuthProvider `json:"provider" validate:"required"`
-	Token      string       `json:"token" validate:"required"`
-	Name       string       `json:"name"`
-	Email      string       `json:"email"`
-	ProviderID string       `json:"provider_id"`
+	Provider AuthProvider `json:"provider" validate:"required"`
+	Token    string       `json:"token" validate:"required"`
+	Name     string       `json:"name"`
 }
 
 type AuthTokens struct {
@@ -62,6 +71,29 @@ type RefreshToken struct {
 	ID        uuid.UUID `json:"id"`
 	UserID    uuid.UUID `json:"user_id"`
 	TokenHash string    `json:"-"`
+	AuthTime  time.Time `json:"auth_time"`
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// Identities
+
+// UserIdentity is a login method linked to a user. Email identities use the
+// email address as ProviderID and the password stored on the user.
+type UserIdentity struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Provider   AuthProvider `json:"provider"`
+	ProviderID string       `json:"-"`
+	Email      *string      `json:"email,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty"`
+}
+
+// LinkIdentityInput links a provider: Google and Apple with their ID token,
+// email with a new password.
+type LinkIdentityInput struct {
+	Provider AuthProvider `json:"provider" validate:"required"`
+	Token    string       `json:"token"`
+	Password string       `json:"password"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// maxWalkSpeedKmh is faster than any pet keeps up on a walk.
+const maxWalkSpeedKmh = 20
+
+type walkGame struct{ baseGame }
+
+func (walkGame) Validate(a *Activity) error {
+	var walk WalkGameData
+	if err := json.Unmarshal(a.GameData, &walk); err != nil {
+		return nil
+	}
+	if walk.DistanceMeters < 0 {
+		return fmt.Errorf("%w: distance_meters can't be negative", ErrInvalidGameData)
+	}
+	return nil
+}
+
+// XP earns base_xp_per_minute walked and distance_bonus_per_km. Walks
+// with game data that doesn't parse still earn their minutes.
+func (walkGame) XP(config json.RawMessage, a *Activity) int {
+	var xpConfig struct {
+		BaseXPPerMinute    float64 `json:"base_xp_per_minute"`
+		DistanceBonusPerKM float64 `json:"distance_bonus_per_km"`
+	}
+	if err := json.Unmarshal(config, &xpConfig); err != nil {
+		return 0
+	}
+
+	xp := 0
+	if a.DurationSeconds != nil {
+		minutes := float64(*a.DurationSeconds) / 60
+		xp += int(minutes * xpConfig.BaseXPPerMinute)
+	}
+	var walk WalkGameData
+	if err := json.Unmarshal(a.GameData, &walk); err == nil {
+		xp += int(walk.DistanceMeters / 1000 * xpConfig.DistanceBonusPerKM)
+	}
+	return xp
+}
+
+func (walkGame) Check(a *Activity) *ActivityFlag {
+	var walk WalkGameData
+	if err := json.Unmarshal(a.GameData, &walk); err != nil || walk.DistanceMeters <= 0 {
+		return nil
+	}
+	seconds := *a.DurationSeconds
+	if seconds <= 0 || walk.DistanceMeters/1000/(float64(seconds)/3600) > maxWalkSpeedKmh {
+		flag := ActivityFlagTooFast
+		return &flag
+	}
+	return nil
+}
+
+func (walkGame) MissionProgress(a *Activity, progress map[MissionType]int) {
+	if a.DurationSeconds != nil {
+		progress[MissionTypeWalkDuration] = *a.DurationSeconds / 60
+	}
+	var walk WalkGameData
+	if err := json.Unmarshal(a.GameData, &walk); err == nil {
+		progress[MissionTypeWalkDistance] = int(walk.DistanceMeters)
+	}
+}
+
+// DailyMission aims for the pet's daily exercise target.
+func (walkGame) DailyMission(pet *Pet) *MissionGoal {
+	minutes := pet.PetType.Settings().DailyExerciseMinutes
+	if pet.DailyTarget != nil {
+		minutes = pet.DailyTarget.ExerciseMinutes
+	}
+	return &MissionGoal{
+		Type:        MissionTypeWalkDuration,
+		Target:      minutes,
+		Description: fmt.Sprintf("Walk %s for %d minutes", pet.Name, minutes),
+	}
+}
+
+func (walkGame) Records(a *Activity) []RecordValue {
+	var walk WalkGameData
+	if err := json.Unmarshal(a.GameData, &walk); err != nil {
+		return nil
+	}
+
+	var records []RecordValue
+	if walk.DistanceMeters > 0 {
+		records = append(records, RecordValue{RecordLongestDistance, walk.DistanceMeters})
+	}
+	if a.DurationSeconds == nil {
+		return records
+	}
+	// The route shows the quickest stretch; without one, the walk's
+	// average pace is the best known
+	if seconds, ok := FastestSplit(walk.Route, *a.DurationSeconds, 1000); ok {
+		records = append(records, RecordValue{RecordFastestKM, seconds})
+	} else if len(walk.Route) == 0 && walk.DistanceMeters >= 1000 {
+		records = append(records, RecordValue{RecordFastestKM, float64(*a.DurationSeconds) * 1000 / walk.DistanceMeters})
+	}
+	return records
+}
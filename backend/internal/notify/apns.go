@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+	// apnsTokenTTL is how long a provider token is reused. Apple rejects
+	// tokens older than an hour and throttles refreshing more often than
+	// every 20 minutes.
+	apnsTokenTTL = 50 * time.Minute
+)
+
+type APNsConfig struct {
+	// Key is the contents of the .p8 signing key from the developer portal.
+	Key    []byte
+	KeyID  string
+	TeamID string
+	// Topic is the app's bundle ID.
+	Topic      string
+	Production bool
+}
+
+// APNsProvider sends directly to Apple Push Notification service over
+// HTTP/2 with token-based authentication.
+type APNsProvider struct {
+	key     *ecdsa.PrivateKey
+	keyID   string
+	teamID  string
+	topic   string
+	baseURL string
+	client  *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func NewAPNsProvider(cfg APNsConfig) (*APNsProvider, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs key: %w", err)
+	}
+	if cfg.KeyID == "" || cfg.TeamID == "" || cfg.Topic == "" {
+		return nil, fmt.Errorf("APNs key ID, team ID and topic are required")
+	}
+
+	baseURL := apnsSandboxURL
+	if cfg.Production {
+		baseURL = apnsProductionURL
+	}
+
+	return &APNsProvider{
+		key:     key,
+		keyID:   cfg.KeyID,
+		teamID:  cfg.TeamID,
+		topic:   cfg.Topic,
+		baseURL: baseURL,
+		client:  newHTTPClient(),
+	}, nil
+}
+
+func (p *APNsProvider) Send(ctx context.Context, msg *PushMessage) error {
+	token, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	// Custom data sits next to the aps dictionary
+	body := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+			"sound": "default",
+		},
+	}
+	for k, v := range msg.Data {
+		if k != "aps" {
+			body[k] = v
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/3/device/"+msg.Token, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", "bearer "+token)
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		result.Reason == "BadDeviceToken",
+		result.Reason == "DeviceTokenNotForTopic",
+		result.Reason == "Unregistered":
+		return ErrInvalidToken
+	case resp.StatusCode == http.StatusForbidden && result.Reason == "ExpiredProviderToken":
+		p.mu.Lock()
+		p.token = ""
+		p.mu.Unlock()
+		return fmt.Errorf("apns: %s", result.Reason)
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge:
+		return fmt.Errorf("%w: %s", ErrRejected, result.Reason)
+	default:
+		return fmt.Errorf("apns: status %d: %s", resp.StatusCode, result.Reason)
+	}
+}
+
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenTTL {
+		return p.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.keyID
+
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+
+	p.token = signed
+	p.issuedAt = now
+	return signed, nil
+}
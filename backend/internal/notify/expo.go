@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const expoPushURL = "https://exp.host/--/api/v2/push/send"
+
+// ExpoProvider sends through the Expo push service, which forwards to FCM
+// and APNs for apps built with Expo.
+type ExpoProvider struct {
+	url         string
+	accessToken string
+	client      *http.Client
+}
+
+// NewExpoProvider creates a provider. accessToken is only needed when
+// enhanced push security is enabled for the Expo project.
+func NewExpoProvider(accessToken string) *ExpoProvider {
+	return &ExpoProvider{url: expoPushURL, accessToken: accessToken, client: newHTTPClient()}
+}
+
+type expoMessage struct {
+	To    string            `json:"to"`
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data,omitempty"`
+	Sound string            `json:"sound"`
+}
+
+type expoTicket struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Details struct {
+		Error string `json:"error"`
+	} `json:"details"`
+}
+
+func (p *ExpoProvider) Send(ctx context.Context, msg *PushMessage) error {
+	payload, err := json.Marshal(expoMessage{
+		To:    msg.Token,
+		Title: msg.Title,
+		Body:  msg.Body,
+		Data:  msg.Data,
+		Sound: "default",
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if p.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.accessToken)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest:
+		return fmt.Errorf("%w: %v", ErrRejected, statusError(resp))
+	case resp.StatusCode != http.StatusOK:
+		return statusError(resp)
+	}
+
+	var result struct {
+		Data expoTicket `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode Expo response: %w", err)
+	}
+
+	if result.Data.Status == "error" {
+		switch result.Data.Details.Error {
+		case "DeviceNotRegistered":
+			return ErrInvalidToken
+		case "MessageTooBig", "InvalidCredentials":
+			return fmt.Errorf("%w: %s", ErrRejected, result.Data.Message)
+		default:
+			return fmt.Errorf("expo: %s", result.Data.Message)
+		}
+	}
+
+	return nil
+}
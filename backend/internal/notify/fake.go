@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// FakeProvider records pushes instead of sending them. It stands in for the
+// real providers in development and tests, and can be told to reject
+// tokens or fail transiently.
+type FakeProvider struct {
+	mu       sync.Mutex
+	sent     []PushMessage
+	invalid  map[string]bool
+	failures int
+	verbose  bool
+}
+
+// NewFakeProvider creates a fake. A verbose fake logs every push.
+func NewFakeProvider(verbose bool) *FakeProvider {
+	return &FakeProvider{invalid: map[string]bool{}, verbose: verbose}
+}
+
+func (f *FakeProvider) Send(ctx context.Context, msg *PushMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.invalid[msg.Token] {
+		return ErrInvalidToken
+	}
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("fake provider unavailable")
+	}
+
+	f.sent = append(f.sent, *msg)
+	if f.verbose {
+		log.Printf("Push to %s: %s - %s", msg.Token, msg.Title, msg.Body)
+	}
+	return nil
+}
+
+// Sent returns the pushes delivered so far.
+func (f *FakeProvider) Sent() []PushMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]PushMessage(nil), f.sent...)
+}
+
+// Invalidate makes every later send to token fail with ErrInvalidToken.
+func (f *FakeProvider) Invalidate(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalid[token] = true
+}
+
+// FailNext makes the next n sends fail with a transient error.
+func (f *FakeProvider) FailNext(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = n
+}
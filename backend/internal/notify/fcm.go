@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	fcmScope   = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmBaseURL = "https://fcm.googleapis.com"
+)
+
+// FCMCredentials is the relevant part of a Google service account key file.
+type FCMCredentials struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider sends through the Firebase Cloud Messaging HTTP v1 API,
+// authenticating with a service account.
+type FCMProvider struct {
+	credentials FCMCredentials
+	key         *rsa.PrivateKey
+	baseURL     string
+	client      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMProvider parses a service account key file.
+func NewFCMProvider(serviceAccountJSON []byte) (*FCMProvider, error) {
+	var credentials FCMCredentials
+	if err := json.Unmarshal(serviceAccountJSON, &credentials); err != nil {
+		return nil, fmt.Errorf("invalid FCM service account: %w", err)
+	}
+	if credentials.ProjectID == "" || credentials.ClientEmail == "" || credentials.PrivateKey == "" {
+		return nil, fmt.Errorf("invalid FCM service account: project_id, client_email and private_key are required")
+	}
+	if credentials.TokenURI == "" {
+		credentials.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(credentials.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FCM private key: %w", err)
+	}
+
+	return &FCMProvider{credentials: credentials, key: key, baseURL: fcmBaseURL, client: newHTTPClient()}, nil
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func (p *FCMProvider) Send(ctx context.Context, msg *PushMessage) error {
+	accessToken, err := p.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(fcmRequest{Message: fcmMessage{
+		Token:        msg.Token,
+		Notification: fcmNotification{Title: msg.Title, Body: msg.Body},
+		Data:         msg.Data,
+	}})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", p.baseURL, p.credentials.ProjectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		// UNREGISTERED: the app instance is gone
+		return ErrInvalidToken
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %v", ErrRejected, statusError(resp))
+	case http.StatusUnauthorized:
+		p.mu.Lock()
+		p.accessToken = ""
+		p.mu.Unlock()
+		return statusError(resp)
+	default:
+		return statusError(resp)
+	}
+}
+
+// token returns a cached OAuth access token, exchanging a freshly signed
+// service account assertion when it is about to expire.
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.credentials.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.credentials.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.credentials.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get FCM access token: %w", statusError(resp))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode FCM access token: %w", err)
+	}
+
+	p.accessToken = result.AccessToken
+	// Renew a minute early so a token never expires mid-request
+	p.expiresAt = now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return p.accessToken, nil
+}
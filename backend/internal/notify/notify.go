@@ -0,0 +1,40 @@
+// Package notify delivers user-facing notifications. Services build a
+// Notification and hand it to a Channel without knowing how it reaches the
+// user.
+package notify
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Category groups notifications so users can opt out of each kind.
+type Category string
+
+const (
+	CategoryReminder Category = "reminder"
+	CategoryStreak   Category = "streak"
+	CategoryMood     Category = "mood"
+	CategoryMission  Category = "mission"
+	CategorySocial   Category = "social"
+)
+
+type Notification struct {
+	UserID   uuid.UUID
+	Category Category
+	// Template names a message in the catalog, rendered in the user's
+	// language with Params. Without a template Title and Body are sent as
+	// they are.
+	Template string
+	Params   map[string]string
+	Title    string
+	Body     string
+	// Data is passed to the client untouched, e.g. to deep link into the
+	// app.
+	Data map[string]string
+}
+
+type Channel interface {
+	Send(ctx context.Context, n *Notification) error
+}
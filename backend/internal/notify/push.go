@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrInvalidToken means the provider no longer accepts the device
+	// token, typically because the app was uninstalled. The token should
+	// be removed.
+	ErrInvalidToken = errors.New("push token is invalid")
+	// ErrRejected means the provider refused the message itself; sending
+	// it again will not help.
+	ErrRejected = errors.New("push message rejected")
+)
+
+// PushMessage is a single push to a single device.
+type PushMessage struct {
+	Token string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// PushProvider sends pushes through one vendor (Expo, FCM, APNs). Errors
+// other than ErrInvalidToken and ErrRejected are treated as transient and
+// retried.
+type PushProvider interface {
+	Send(ctx context.Context, msg *PushMessage) error
+}
+
+const pushTimeout = 10 * time.Second
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: pushTimeout}
+}
+
+// statusError describes an unexpected HTTP response for logs.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+}
@@ -0,0 +1,237 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRender_LanguageFallback(t *testing.T) {
+	params := map[string]string{"title": "Walk Rex", "notes": "", "pet": "Rex"}
+
+	tests := []struct {
+		language string
+		body     string
+	}{
+		{"en", "Time to take care of Rex"},
+		{"pt-BR", "Hora de cuidar de Rex"},
+		{"de", "Time to take care of Rex"},
+	}
+	for _, tt := range tests {
+		title, body, err := Render("reminder", tt.language, params)
+		if err != nil {
+			t.Fatalf("Render(%s) error = %v", tt.language, err)
+		}
+		if title != "Walk Rex" || body != tt.body {
+			t.Errorf("Render(%s) = %q, %q, want %q", tt.language, title, body, tt.body)
+		}
+	}
+
+	if _, _, err := Render("reminder", "en", map[string]string{"title": "Walk"}); err == nil {
+		t.Error("Render() with missing params should fail")
+	}
+	if _, _, err := Render("nope", "en", params); err == nil {
+		t.Error("Render() with unknown template should fail")
+	}
+}
+
+func TestRender_Nudge(t *testing.T) {
+	tests := []struct {
+		language string
+		hours    string
+		body     string
+	}{
+		{"en", "3", "Our 14-day streak ends in 3 hours. Play with me?"},
+		{"en", "1", "Our 14-day streak ends in 1 hour. Play with me?"},
+		{"pt", "1", "Nossa sequência de 14 dias termina em 1 hora. Vamos brincar?"},
+	}
+	for _, tt := range tests {
+		title, body, err := Render("nudge_streak", tt.language, map[string]string{"pet": "Rex", "streak": "14", "hours": tt.hours})
+		if err != nil {
+			t.Fatalf("Render(%s) error = %v", tt.language, err)
+		}
+		if body != tt.body {
+			t.Errorf("Render(%s) body = %q, want %q", tt.language, body, tt.body)
+		}
+		if !strings.HasPrefix(title, "Rex") {
+			t.Errorf("Render(%s) title = %q, want it to name the pet", tt.language, title)
+		}
+	}
+}
+
+func TestExpoProvider(t *testing.T) {
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg expoMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		if msg.To != "ExponentPushToken[abc]" || msg.Title != "Hi" {
+			t.Errorf("unexpected message %+v", msg)
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	provider := NewExpoProvider("")
+	provider.url = server.URL
+	msg := &PushMessage{Token: "ExponentPushToken[abc]", Title: "Hi", Body: "There"}
+
+	response = `{"data":{"status":"ok","id":"1"}}`
+	if err := provider.Send(context.Background(), msg); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+
+	response = `{"data":{"status":"error","message":"gone","details":{"error":"DeviceNotRegistered"}}}`
+	if err := provider.Send(context.Background(), msg); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Send() error = %v, want ErrInvalidToken", err)
+	}
+
+	response = `{"data":{"status":"error","message":"slow down","details":{"error":"MessageRateExceeded"}}}`
+	if err := provider.Send(context.Background(), msg); err == nil || errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrRejected) {
+		t.Errorf("Send() error = %v, want a transient error", err)
+	}
+}
+
+func TestFCMProvider(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			assertion := r.FormValue("assertion")
+			if _, err := jwt.Parse(assertion, func(*jwt.Token) (interface{}, error) { return &private.PublicKey, nil }); err != nil {
+				t.Errorf("invalid assertion: %v", err)
+			}
+			w.Write([]byte(`{"access_token":"access","expires_in":3600}`))
+		case "/v1/projects/pettime/messages:send":
+			if r.Header.Get("Authorization") != "Bearer access" {
+				t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+			}
+			var req fcmRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Message.Token == "gone" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"status":"NOT_FOUND"}}`))
+				return
+			}
+			w.Write([]byte(`{"name":"projects/pettime/messages/1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	account, _ := json.Marshal(FCMCredentials{
+		ProjectID:   "pettime",
+		ClientEmail: "push@pettime.iam.gserviceaccount.com",
+		PrivateKey:  string(keyPEM),
+		TokenURI:    server.URL + "/token",
+	})
+	provider, err := NewFCMProvider(account)
+	if err != nil {
+		t.Fatalf("NewFCMProvider() error = %v", err)
+	}
+	provider.baseURL = server.URL
+
+	if err := provider.Send(context.Background(), &PushMessage{Token: "device", Title: "Hi"}); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+	if err := provider.Send(context.Background(), &PushMessage{Token: "gone", Title: "Hi"}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Send() error = %v, want ErrInvalidToken", err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("access token requested %d times, want 1 (cached)", tokenRequests)
+	}
+}
+
+func TestAPNsProvider(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(private)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := strings.TrimPrefix(r.Header.Get("authorization"), "bearer ")
+		token, err := jwt.Parse(auth, func(*jwt.Token) (interface{}, error) { return &private.PublicKey, nil })
+		if err != nil || token.Header["kid"] != "KEY123" {
+			t.Errorf("invalid provider token: %v", err)
+		}
+		if r.Header.Get("apns-topic") != "app.pettime" {
+			t.Errorf("apns-topic = %q", r.Header.Get("apns-topic"))
+		}
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["pet_id"] != "42" || body["aps"] == nil {
+			t.Errorf("unexpected payload %v", body)
+		}
+
+		switch strings.TrimPrefix(r.URL.Path, "/3/device/") {
+		case "gone":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+		case "big":
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(`{"reason":"PayloadTooLarge"}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewAPNsProvider(APNsConfig{Key: keyPEM, KeyID: "KEY123", TeamID: "TEAM", Topic: "app.pettime"})
+	if err != nil {
+		t.Fatalf("NewAPNsProvider() error = %v", err)
+	}
+	provider.baseURL = server.URL
+
+	data := map[string]string{"pet_id": "42"}
+	if err := provider.Send(context.Background(), &PushMessage{Token: "device", Title: "Hi", Data: data}); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+	if err := provider.Send(context.Background(), &PushMessage{Token: "gone", Title: "Hi", Data: data}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Send() error = %v, want ErrInvalidToken", err)
+	}
+	if err := provider.Send(context.Background(), &PushMessage{Token: "big", Title: "Hi", Data: data}); !errors.Is(err, ErrRejected) {
+		t.Errorf("Send() error = %v, want ErrRejected", err)
+	}
+}
+
+func TestFakeProvider(t *testing.T) {
+	fake := NewFakeProvider(false)
+	fake.Invalidate("old")
+	fake.FailNext(1)
+	ctx := context.Background()
+
+	if err := fake.Send(ctx, &PushMessage{Token: "new"}); err == nil {
+		t.Error("first Send() should fail transiently")
+	}
+	if err := fake.Send(ctx, &PushMessage{Token: "new"}); err != nil {
+		t.Errorf("second Send() error = %v", err)
+	}
+	if err := fake.Send(ctx, &PushMessage{Token: "old"}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Send() to invalidated token error = %v", err)
+	}
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].Token != "new" {
+		t.Errorf("Sent() = %v", sent)
+	}
+}
@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultLanguage is used when a template has no translation for the
+// user's language.
+const DefaultLanguage = "en"
+
+type message struct {
+	Title string
+	Body  string
+}
+
+// catalog holds the message templates by language and name. Params are
+// referenced as {{.name}}; every param a template uses must be supplied.
+var catalog = map[string]map[string]message{
+	"en": {
+		"reminder": {
+			Title: "{{.title}}",
+			Body:  "{{if .notes}}{{.notes}}{{else if .pet}}Time to take care of {{.pet}}{{else}}Reminder{{end}}",
+		},
+		// Nudges speak in the pet's voice
+		"nudge_streak": {
+			Title: "{{.pet}} misses you!",
+			Body:  "Our {{.streak}}-day streak ends in {{.hours}} {{if eq .hours \"1\"}}hour{{else}}hours{{end}}. Play with me?",
+		},
+		"nudge_tired": {
+			Title: "{{.pet}} is getting restless",
+			Body:  "It's been a while since we played. Can we go out soon?",
+		},
+		"nudge_sad": {
+			Title: "{{.pet}} misses you!",
+			Body:  "I haven't seen you all day. A quick game would cheer me up!",
+		},
+		"nudge_bored": {
+			Title: "{{.pet}} is bored",
+			Body:  "Nothing to do for days... Let's play together!",
+		},
+		"friend_request": {
+			Title: "New friend request",
+			Body:  "{{.name}} wants to be friends",
+		},
+		"friend_accepted": {
+			Title: "You're now friends",
+			Body:  "{{.name}} accepted your friend request",
+		},
+		"feed_paw_five": {
+			Title: "Paw-five!",
+			Body:  "{{.name}} gave {{.pet}} a paw-five",
+		},
+		"feed_comment": {
+			Title: "{{.name}} commented",
+			Body:  "{{.comment}}",
+		},
+		"challenge_invite": {
+			Title: "Join the pack",
+			Body:  "{{.name}} invited you to {{.challenge}}",
+		},
+		"challenge_won": {
+			Title: "You won {{.challenge}}!",
+			Body:  "You're the pack's {{.title}} for the next week.",
+		},
+	},
+	"pt": {
+		"reminder": {
+			Title: "{{.title}}",
+			Body:  "{{if .notes}}{{.notes}}{{else if .pet}}Hora de cuidar de {{.pet}}{{else}}Lembrete{{end}}",
+		},
+		"nudge_streak": {
+			Title: "{{.pet}} está com saudades!",
+			Body:  "Nossa sequência de {{.streak}} dias termina em {{.hours}} {{if eq .hours \"1\"}}hora{{else}}horas{{end}}. Vamos brincar?",
+		},
+		"nudge_tired": {
+			Title: "{{.pet}} está ficando inquieto",
+			Body:  "Faz tempo que não brincamos. Vamos sair logo?",
+		},
+		"nudge_sad": {
+			Title: "{{.pet}} está com saudades!",
+			Body:  "Não te vi o dia todo. Uma brincadeira rápida me deixaria feliz!",
+		},
+		"nudge_bored": {
+			Title: "{{.pet}} está entediado",
+			Body:  "Dias sem nada para fazer... Vamos brincar juntos!",
+		},
+		"friend_request": {
+			Title: "Novo pedido de amizade",
+			Body:  "{{.name}} quer ser seu amigo",
+		},
+		"friend_accepted": {
+			Title: "Vocês agora são amigos",
+			Body:  "{{.name}} aceitou seu pedido de amizade",
+		},
+		"feed_paw_five": {
+			Title: "Toca aqui!",
+			Body:  "{{.name}} deu um toca aqui para {{.pet}}",
+		},
+		"feed_comment": {
+			Title: "{{.name}} comentou",
+			Body:  "{{.comment}}",
+		},
+		"challenge_invite": {
+			Title: "Junte-se à matilha",
+			Body:  "{{.name}} convidou você para {{.challenge}}",
+		},
+		"challenge_won": {
+			Title: "Você venceu {{.challenge}}!",
+			Body:  "Você é o {{.title}} da matilha pela próxima semana.",
+		},
+	},
+}
+
+type compiled struct {
+	title *template.Template
+	body  *template.Template
+}
+
+var templates = compileCatalog()
+
+func compileCatalog() map[string]map[string]compiled {
+	result := make(map[string]map[string]compiled, len(catalog))
+	for language, messages := range catalog {
+		result[language] = make(map[string]compiled, len(messages))
+		for name, m := range messages {
+			key := language + "/" + name
+			result[language][name] = compiled{
+				title: template.Must(template.New(key + "/title").Option("missingkey=error").Parse(m.Title)),
+				body:  template.Must(template.New(key + "/body").Option("missingkey=error").Parse(m.Body)),
+			}
+		}
+	}
+	return result
+}
+
+// Render produces the title and body of a template in language, falling
+// back from a regional variant ("pt-BR") to the base language and then to
+// DefaultLanguage.
+func Render(name, language string, params map[string]string) (string, string, error) {
+	base, _, _ := strings.Cut(language, "-")
+	for _, candidate := range []string{language, base, DefaultLanguage} {
+		t, ok := templates[candidate][name]
+		if !ok {
+			continue
+		}
+
+		var title, body bytes.Buffer
+		if err := t.title.Execute(&title, params); err != nil {
+			return "", "", fmt.Errorf("failed to render %s title: %w", name, err)
+		}
+		if err := t.body.Execute(&body, params); err != nil {
+			return "", "", fmt.Errorf("failed to render %s body: %w", name, err)
+		}
+		return title.String(), body.String(), nil
+	}
+
+	return "", "", fmt.Errorf("unknown notification template %q", name)
+}
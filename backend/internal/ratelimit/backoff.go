@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Backoff slows down repeated failed logins for one account. After
+// FreeAttempts failures each further attempt has to wait twice as long as
+// the previous one, up to MaxDelay. Failures are forgotten after Window
+// without a new one.
+//
+// Every attempt is counted as a failure before the credentials are checked
+// and cleared by Success, so concurrent guesses can't all pass on the same
+// count. Attempts refused for being too early count as well, which keeps an
+// account that is being hammered locked.
+type Backoff struct {
+	store        Store
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Window       time.Duration
+}
+
+func NewBackoff(store Store) *Backoff {
+	return &Backoff{
+		store:        store,
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     15 * time.Minute,
+		Window:       time.Hour,
+	}
+}
+
+// Attempt records an attempt for the account and returns how long the
+// caller has to wait before trying again. The attempt must be refused when
+// the wait isn't zero. Call Success once the credentials are verified.
+func (b *Backoff) Attempt(ctx context.Context, account string, now time.Time) (time.Duration, error) {
+	count, last, err := b.store.AddFailure(ctx, b.key(account), now, b.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	// The wait is set by the failures before this attempt
+	previous := count - 1
+	if previous == 0 {
+		return 0, nil
+	}
+
+	retryAt := last.Add(b.delay(previous))
+	if now.Before(retryAt) {
+		return retryAt.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// Success clears the account's failures.
+func (b *Backoff) Success(ctx context.Context, account string) error {
+	return b.store.ResetFailures(ctx, b.key(account))
+}
+
+func (b *Backoff) delay(failures int) time.Duration {
+	over := failures - b.FreeAttempts
+	if over < 0 {
+		return 0
+	}
+
+	delay := b.BaseDelay
+	for i := 0; i < over; i++ {
+		delay *= 2
+		if delay >= b.MaxDelay {
+			return b.MaxDelay
+		}
+	}
+	return delay
+}
+
+// addFailure returns the failure count after one more failure at now,
+// starting over when the last one is older than window.
+func addFailure(count int, last, now time.Time, window time.Duration) int {
+	if count > 0 && now.Sub(last) > window {
+		return 1
+	}
+	return count + 1
+}
+
+func (b *Backoff) key(account string) string {
+	return "login:" + strings.ToLower(strings.TrimSpace(account))
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type failureRecord struct {
+	count int
+	last  time.Time
+}
+
+// MemoryStore keeps limiter state in process. Limits are per instance.
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]Bucket
+	failures map[string]failureRecord
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]Bucket),
+		failures: make(map[string]failureRecord),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *Bucket
+	if bucket, ok := s.buckets[key]; ok {
+		current = &bucket
+	}
+
+	bucket, result := take(current, policy, now)
+	s.buckets[key] = bucket
+
+	return result, nil
+}
+
+func (s *MemoryStore) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.failures[key]
+	count := addFailure(record.count, record.last, now, window)
+	s.failures[key] = failureRecord{count: count, last: now}
+	return count, record.last, nil
+}
+
+func (s *MemoryStore) ResetFailures(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *MemoryStore) Prune(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, bucket := range s.buckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, record := range s.failures {
+		if record.last.Before(before) {
+			delete(s.failures, key)
+		}
+	}
+	return nil
+}
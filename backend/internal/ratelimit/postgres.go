@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore shares limiter state between API instances. Each bucket and
+// failure row is locked while it is updated so concurrent requests are
+// counted once.
+type PostgresStore struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Create the bucket full if it is new, so there is always a row to lock.
+	// Requests racing to create it wait for the first insert and then
+	// queue on the lock instead of each starting from a full bucket.
+	_, err = tx.Exec(ctx, `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, key, float64(policy.Burst), now)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create bucket: %w", err)
+	}
+
+	var bucket Bucket
+	err = tx.QueryRow(ctx, `SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`, key).
+		Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get bucket: %w", err)
+	}
+
+	next, result := take(&bucket, policy, now)
+
+	_, err = tx.Exec(ctx, `UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3 WHERE key = $1`,
+		key, next.Tokens, next.UpdatedAt)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to save bucket: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Result{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *PostgresStore) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, time.Time, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// As with buckets, create the row first so concurrent attempts queue on
+	// its lock and each sees the count the previous one left
+	_, err = tx.Exec(ctx, `
+		INSERT INTO login_failures (key, failures, last_failure_at)
+		VALUES ($1, 0, $2)
+		ON CONFLICT (key) DO NOTHING
+	`, key, now)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to create failure record: %w", err)
+	}
+
+	var count int
+	var last time.Time
+	err = tx.QueryRow(ctx, `SELECT failures, last_failure_at FROM login_failures WHERE key = $1 FOR UPDATE`, key).
+		Scan(&count, &last)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to get failures: %w", err)
+	}
+
+	count = addFailure(count, last, now, window)
+	_, err = tx.Exec(ctx, `UPDATE login_failures SET failures = $2, last_failure_at = $3 WHERE key = $1`, key, count, now)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to record failure: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return count, last, nil
+}
+
+func (s *PostgresStore) ResetFailures(ctx context.Context, key string) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM login_failures WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to reset failures: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Prune(ctx context.Context, before time.Time) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to prune buckets: %w", err)
+	}
+	if _, err := s.db.Exec(ctx, `DELETE FROM login_failures WHERE last_failure_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to prune failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy is a token bucket: Burst requests at once, refilled at Rate
+// requests per Period.
+type Policy struct {
+	Name   string
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+func PerMinute(name string, rate, burst int) Policy {
+	return Policy{Name: name, Rate: rate, Period: time.Minute, Burst: burst}
+}
+
+func PerHour(name string, rate, burst int) Policy {
+	return Policy{Name: name, Rate: rate, Period: time.Hour, Burst: burst}
+}
+
+func (p Policy) tokensPerSecond() float64 {
+	return float64(p.Rate) / p.Period.Seconds()
+}
+
+// Result describes the bucket after a request was counted.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Bucket is the persisted state of one token bucket.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Store keeps limiter state. MemoryStore suits a single instance and
+// PostgresStore shares state between instances.
+type Store interface {
+	// Take counts one request against the bucket for key.
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+
+	// AddFailure counts a failure for key in one step and returns the
+	// consecutive failures including it and when the previous one happened.
+	// Failures older than window are forgotten first.
+	AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, time.Time, error)
+	ResetFailures(ctx context.Context, key string) error
+
+	// Prune drops state that has not been touched since before.
+	Prune(ctx context.Context, before time.Time) error
+}
+
+// take applies the token bucket algorithm. A missing bucket starts full.
+func take(bucket *Bucket, policy Policy, now time.Time) (Bucket, Result) {
+	rate := policy.tokensPerSecond()
+	burst := float64(policy.Burst)
+
+	tokens := burst
+	if bucket != nil {
+		elapsed := now.Sub(bucket.UpdatedAt).Seconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		tokens = math.Min(burst, bucket.Tokens+elapsed*rate)
+	}
+
+	result := Result{Limit: policy.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = secondsToDuration((burst - tokens) / rate)
+
+	return Bucket{Tokens: tokens, UpdatedAt: now}, result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds)) * time.Second
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	policy := PerMinute("test", 60, 3) // 1 token per second, burst of 3
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		result, _ := store.Take(ctx, "key", policy, now)
+		if !result.Allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("request %d: Remaining = %d, want %d", i+1, result.Remaining, 2-i)
+		}
+	}
+
+	result, _ := store.Take(ctx, "key", policy, now)
+	if result.Allowed {
+		t.Fatal("request over burst should be throttled")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", result.RetryAfter)
+	}
+	if result.Reset != 3*time.Second {
+		t.Errorf("Reset = %v, want 3s", result.Reset)
+	}
+
+	// Other keys have their own bucket
+	if result, _ := store.Take(ctx, "other", policy, now); !result.Allowed {
+		t.Error("separate key should not be throttled")
+	}
+
+	// One second later one token has been refilled
+	if result, _ := store.Take(ctx, "key", policy, now.Add(time.Second)); !result.Allowed {
+		t.Error("request after refill should be allowed")
+	}
+}
+
+func TestMemoryStore_Prune(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	store.Take(ctx, "old", PerMinute("test", 1, 1), now.Add(-time.Hour))
+	store.Take(ctx, "new", PerMinute("test", 1, 1), now)
+	store.Prune(ctx, now.Add(-time.Minute))
+
+	if _, ok := store.buckets["old"]; ok {
+		t.Error("stale bucket should be pruned")
+	}
+	if _, ok := store.buckets["new"]; !ok {
+		t.Error("recent bucket should be kept")
+	}
+}
+
+func TestBackoff_Progressive(t *testing.T) {
+	backoff := NewBackoff(NewMemoryStore())
+	ctx := context.Background()
+	now := time.Now()
+
+	// Free attempts do not slow the user down
+	for i := 0; i < backoff.FreeAttempts; i++ {
+		if wait, _ := backoff.Attempt(ctx, "rex@example.com", now); wait != 0 {
+			t.Fatalf("attempt %d wait = %v, want 0", i+1, wait)
+		}
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for i, want := range expected {
+		if wait, _ := backoff.Attempt(ctx, "rex@example.com", now); wait != want {
+			t.Errorf("after %d failures wait = %v, want %v", backoff.FreeAttempts+i, wait, want)
+		}
+	}
+
+	// Accounts are matched case-insensitively
+	if wait, _ := backoff.Attempt(ctx, " REX@example.com", now); wait == 0 {
+		t.Error("backoff should apply regardless of email case")
+	}
+
+	backoff.Success(ctx, "rex@example.com")
+	if wait, _ := backoff.Attempt(ctx, "rex@example.com", now); wait != 0 {
+		t.Errorf("after success wait = %v, want 0", wait)
+	}
+}
+
+func TestBackoff_Concurrent(t *testing.T) {
+	backoff := NewBackoff(NewMemoryStore())
+	ctx := context.Background()
+	now := time.Now()
+
+	// Guesses racing each other are counted one after the other, so only
+	// the free attempts get through
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if wait, _ := backoff.Attempt(ctx, "rex@example.com", now); wait == 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := int(allowed.Load()); got != backoff.FreeAttempts {
+		t.Errorf("allowed attempts = %d, want %d", got, backoff.FreeAttempts)
+	}
+}
+
+func TestBackoff_MaxDelayAndWindow(t *testing.T) {
+	store := NewMemoryStore()
+	backoff := NewBackoff(store)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 50; i++ {
+		backoff.Attempt(ctx, "rex@example.com", now)
+	}
+	if wait, _ := backoff.Attempt(ctx, "rex@example.com", now); wait != backoff.MaxDelay {
+		t.Errorf("wait = %v, want max delay %v", wait, backoff.MaxDelay)
+	}
+
+	// Failures are forgotten once the window has passed
+	later := now.Add(backoff.Window + time.Minute)
+	if wait, _ := backoff.Attempt(ctx, "rex@example.com", later); wait != 0 {
+		t.Errorf("wait after window = %v, want 0", wait)
+	}
+	if count := store.failures[backoff.key("rex@example.com")].count; count != 1 {
+		t.Errorf("failures after window = %d, want 1", count)
+	}
+}
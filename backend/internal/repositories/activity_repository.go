@@ -2,8 +2,10 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -11,7 +13,11 @@ import (
 	"github.com/joaosantos/pettime/internal/models"
 )
 
-var ErrActivityNotFound = errors.New("activity not found")
+var (
+	ErrActivityNotFound  = errors.New("activity not found")
+	ErrActivityFinished  = errors.New("activity is already finished")
+	ErrTooManyIMUSamples = errors.New("too many IMU samples")
+)
 
 type ActivityRepository struct {
 	db *pgxpool.Pool
@@ -21,16 +27,32 @@ func NewActivityRepository(db *pgxpool.Pool) *ActivityRepository {
 	return &ActivityRepository{db: db}
 }
 
-func (r *ActivityRepository) Create(ctx context.Context, activity *models.Activity) error {
+// Create saves a new activity. The record claim of a finished activity is
+// raised in the same transaction, so an activity is never saved without
+// the records it broke; it returns those records.
+func (r *ActivityRepository) Create(ctx context.Context, activity *models.Activity, claim *models.RecordClaim) ([]*models.RecordBreak, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if claim != nil {
+		if err := lockPetRecords(ctx, tx, activity.PetID); err != nil {
+			return nil, err
+		}
+	}
+
 	query := `
-		INSERT INTO activities (id, pet_id, game_type_id, started_at, ended_at, duration_seconds, xp_earned, game_data, client_id, synced_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO activities (id, pet_id, game_type_id, performed_by, started_at, ended_at, duration_seconds, xp_earned, game_data, client_id, synced_at, created_at, flag_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		activity.ID,
 		activity.PetID,
 		activity.GameTypeID,
+		activity.PerformedBy,
 		activity.StartedAt,
 		activity.EndedAt,
 		activity.DurationSeconds,
@@ -39,19 +61,29 @@ func (r *ActivityRepository) Create(ctx context.Context, activity *models.Activi
 		activity.ClientID,
 		activity.SyncedAt,
 		activity.CreatedAt,
+		activity.FlagReason,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create activity: %w", err)
+		return nil, fmt.Errorf("failed to create activity: %w", err)
 	}
 
-	return nil
+	broken, err := raiseRecords(ctx, tx, activity, claim)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return broken, nil
 }
 
 func (r *ActivityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Activity, error) {
 	query := `
-		SELECT a.id, a.pet_id, a.game_type_id, a.started_at, a.ended_at, a.duration_seconds,
-		       a.xp_earned, a.game_data, a.client_id, a.synced_at, a.created_at,
-		       gt.id, gt.name, gt.description, gt.icon, gt.xp_config, gt.supported_pet_types, gt.enabled
+		SELECT a.id, a.pet_id, a.game_type_id, a.performed_by, a.started_at, a.ended_at, a.duration_seconds,
+		       a.xp_earned, a.game_data, a.client_id, a.synced_at, a.created_at, a.flag_reason,
+		       gt.id, gt.name, gt.description, gt.icon, gt.xp_config, gt.throw_detection, gt.supported_pet_types, gt.enabled
 		FROM activities a
 		JOIN game_types gt ON a.game_type_id = gt.id
 		WHERE a.id = $1
@@ -64,6 +96,7 @@ func (r *ActivityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 		&activity.ID,
 		&activity.PetID,
 		&activity.GameTypeID,
+		&activity.PerformedBy,
 		&activity.StartedAt,
 		&activity.EndedAt,
 		&activity.DurationSeconds,
@@ -72,11 +105,13 @@ func (r *ActivityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 		&activity.ClientID,
 		&activity.SyncedAt,
 		&activity.CreatedAt,
+		&activity.FlagReason,
 		&gameType.ID,
 		&gameType.Name,
 		&gameType.Description,
 		&gameType.Icon,
 		&gameType.XPConfig,
+		&gameType.ThrowDetection,
 		&gameType.SupportedPetTypes,
 		&gameType.Enabled,
 	)
@@ -93,8 +128,8 @@ func (r *ActivityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 
 func (r *ActivityRepository) GetByClientID(ctx context.Context, clientID uuid.UUID) (*models.Activity, error) {
 	query := `
-		SELECT a.id, a.pet_id, a.game_type_id, a.started_at, a.ended_at, a.duration_seconds,
-		       a.xp_earned, a.game_data, a.client_id, a.synced_at, a.created_at
+		SELECT a.id, a.pet_id, a.game_type_id, a.performed_by, a.started_at, a.ended_at, a.duration_seconds,
+		       a.xp_earned, a.game_data, a.client_id, a.synced_at, a.created_at, a.flag_reason
 		FROM activities a
 		WHERE a.client_id = $1
 	`
@@ -104,6 +139,7 @@ func (r *ActivityRepository) GetByClientID(ctx context.Context, clientID uuid.UU
 		&activity.ID,
 		&activity.PetID,
 		&activity.GameTypeID,
+		&activity.PerformedBy,
 		&activity.StartedAt,
 		&activity.EndedAt,
 		&activity.DurationSeconds,
@@ -112,6 +148,7 @@ func (r *ActivityRepository) GetByClientID(ctx context.Context, clientID uuid.UU
 		&activity.ClientID,
 		&activity.SyncedAt,
 		&activity.CreatedAt,
+		&activity.FlagReason,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -125,9 +162,9 @@ func (r *ActivityRepository) GetByClientID(ctx context.Context, clientID uuid.UU
 
 func (r *ActivityRepository) List(ctx context.Context, filter models.ActivityFilter) ([]*models.Activity, error) {
 	query := `
-		SELECT a.id, a.pet_id, a.game_type_id, a.started_at, a.ended_at, a.duration_seconds,
-		       a.xp_earned, a.game_data, a.client_id, a.synced_at, a.created_at,
-		       gt.id, gt.name, gt.description, gt.icon, gt.xp_config, gt.supported_pet_types, gt.enabled
+		SELECT a.id, a.pet_id, a.game_type_id, a.performed_by, a.started_at, a.ended_at, a.duration_seconds,
+		       a.xp_earned, a.game_data, a.client_id, a.synced_at, a.created_at, a.flag_reason,
+		       gt.id, gt.name, gt.description, gt.icon, gt.xp_config, gt.throw_detection, gt.supported_pet_types, gt.enabled
 		FROM activities a
 		JOIN game_types gt ON a.game_type_id = gt.id
 		WHERE 1=1
@@ -136,6 +173,12 @@ func (r *ActivityRepository) List(ctx context.Context, filter models.ActivityFil
 	args := []interface{}{}
 	argIndex := 1
 
+	if filter.MemberUserID != nil {
+		query += fmt.Sprintf(" AND a.pet_id IN (SELECT pm.pet_id FROM pet_members pm JOIN pets p ON p.id = pm.pet_id WHERE pm.user_id = $%d AND p.deleted_at IS NULL)", argIndex)
+		args = append(args, *filter.MemberUserID)
+		argIndex++
+	}
+
 	if filter.PetID != nil {
 		query += fmt.Sprintf(" AND a.pet_id = $%d", argIndex)
 		args = append(args, *filter.PetID)
@@ -188,6 +231,7 @@ func (r *ActivityRepository) List(ctx context.Context, filter models.ActivityFil
 			&activity.ID,
 			&activity.PetID,
 			&activity.GameTypeID,
+			&activity.PerformedBy,
 			&activity.StartedAt,
 			&activity.EndedAt,
 			&activity.DurationSeconds,
@@ -196,11 +240,13 @@ func (r *ActivityRepository) List(ctx context.Context, filter models.ActivityFil
 			&activity.ClientID,
 			&activity.SyncedAt,
 			&activity.CreatedAt,
+			&activity.FlagReason,
 			&gameType.ID,
 			&gameType.Name,
 			&gameType.Description,
 			&gameType.Icon,
 			&gameType.XPConfig,
+			&gameType.ThrowDetection,
 			&gameType.SupportedPetTypes,
 			&gameType.Enabled,
 		)
@@ -215,30 +261,54 @@ func (r *ActivityRepository) List(ctx context.Context, filter models.ActivityFil
 	return activities, nil
 }
 
-func (r *ActivityRepository) Update(ctx context.Context, activity *models.Activity) error {
+// Update saves an activity. Like Create, the record claim of an activity
+// being finished is raised in the same transaction.
+func (r *ActivityRepository) Update(ctx context.Context, activity *models.Activity, claim *models.RecordClaim) ([]*models.RecordBreak, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if claim != nil {
+		if err := lockPetRecords(ctx, tx, activity.PetID); err != nil {
+			return nil, err
+		}
+	}
+
 	query := `
 		UPDATE activities
-		SET ended_at = $2, duration_seconds = $3, xp_earned = $4, game_data = $5, synced_at = $6
+		SET ended_at = $2, duration_seconds = $3, xp_earned = $4, game_data = $5, synced_at = $6, flag_reason = $7
 		WHERE id = $1
 	`
 
-	result, err := r.db.Exec(ctx, query,
+	result, err := tx.Exec(ctx, query,
 		activity.ID,
 		activity.EndedAt,
 		activity.DurationSeconds,
 		activity.XPEarned,
 		activity.GameData,
 		activity.SyncedAt,
+		activity.FlagReason,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update activity: %w", err)
+		return nil, fmt.Errorf("failed to update activity: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return ErrActivityNotFound
+		return nil, ErrActivityNotFound
 	}
 
-	return nil
+	broken, err := raiseRecords(ctx, tx, activity, claim)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return broken, nil
 }
 
 func (r *ActivityRepository) Delete(ctx context.Context, id uuid.UUID) error {
@@ -256,11 +326,136 @@ func (r *ActivityRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// IMU samples
+
+// IMUDetect feeds samples to a running activity's throw detector and
+// returns the activity's game data with the throws so far applied.
+type IMUDetect func(detector *models.ThrowDetector, gameData json.RawMessage, samples []models.IMUSample) (json.RawMessage, error)
+
+// AddIMUBatch stores a batch of accelerometer samples for a running
+// activity, unless it would then have more than maxSamples, and saves the
+// game data detect works out from the activity's throw detector. Batches
+// for one activity take turns on a lock, so each sees the detector as the
+// one before left it. Only the game data and detector are written, and
+// only while the activity runs: it returns ErrActivityFinished once it
+// has ended.
+func (r *ActivityRepository) AddIMUBatch(ctx context.Context, activityID uuid.UUID, samples []models.IMUSample, maxSamples int, detect IMUDetect) (json.RawMessage, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var gameData, state json.RawMessage
+	var endedAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT game_data, throw_detector, ended_at FROM activities WHERE id = $1 FOR UPDATE
+	`, activityID).Scan(&gameData, &state, &endedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrActivityNotFound
+		}
+		return nil, fmt.Errorf("failed to lock activity: %w", err)
+	}
+	if endedAt != nil {
+		return nil, ErrActivityFinished
+	}
+
+	var stored int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(sample_count), 0) FROM activity_imu_batches WHERE activity_id = $1
+	`, activityID).Scan(&stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count IMU samples: %w", err)
+	}
+	if stored+len(samples) > maxSamples {
+		return nil, ErrTooManyIMUSamples
+	}
+
+	var detector models.ThrowDetector
+	if state != nil {
+		if err := json.Unmarshal(state, &detector); err != nil {
+			return nil, fmt.Errorf("failed to read throw detector: %w", err)
+		}
+	} else if stored > 0 {
+		// Batches stored before detectors were kept are fed in once
+		earlier, err := listIMUSamples(ctx, tx, activityID)
+		if err != nil {
+			return nil, err
+		}
+		if gameData, err = detect(&detector, gameData, earlier); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO activity_imu_batches (activity_id, samples, sample_count) VALUES ($1, $2, $3)
+	`, activityID, samples, len(samples))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add IMU batch: %w", err)
+	}
+
+	if gameData, err = detect(&detector, gameData, samples); err != nil {
+		return nil, err
+	}
+	result, err := tx.Exec(ctx, `
+		UPDATE activities SET game_data = $2, throw_detector = $3
+		WHERE id = $1 AND ended_at IS NULL
+	`, activityID, gameData, detector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update game data: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, ErrActivityFinished
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return gameData, nil
+}
+
+// listIMUSamples returns every sample uploaded for the activity, batch by
+// batch in the order they arrived.
+func listIMUSamples(ctx context.Context, tx pgx.Tx, activityID uuid.UUID) ([]models.IMUSample, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT samples FROM activity_imu_batches WHERE activity_id = $1 ORDER BY received_at, id
+	`, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IMU samples: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []models.IMUSample
+	for rows.Next() {
+		var batch []models.IMUSample
+		if err := rows.Scan(&batch); err != nil {
+			return nil, fmt.Errorf("failed to scan IMU batch: %w", err)
+		}
+		samples = append(samples, batch...)
+	}
+	return samples, rows.Err()
+}
+
+// DeleteIMUBatchesEndedBefore removes the samples of activities that ended
+// before the given time. Throws are only detected while an activity runs.
+func (r *ActivityRepository) DeleteIMUBatchesEndedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM activity_imu_batches b
+		USING activities a
+		WHERE a.id = b.activity_id AND a.ended_at < $1
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete IMU batches: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
 // Game Types
 
 func (r *ActivityRepository) GetAllGameTypes(ctx context.Context) ([]*models.GameType, error) {
 	query := `
-		SELECT id, name, description, icon, xp_config, supported_pet_types, enabled
+		SELECT id, name, description, icon, xp_config, throw_detection, supported_pet_types, enabled
 		FROM game_types
 		WHERE enabled = true
 		ORDER BY name
@@ -275,7 +470,7 @@ func (r *ActivityRepository) GetAllGameTypes(ctx context.Context) ([]*models.Gam
 	var gameTypes []*models.GameType
 	for rows.Next() {
 		var gt models.GameType
-		if err := rows.Scan(&gt.ID, &gt.Name, &gt.Description, &gt.Icon, &gt.XPConfig, &gt.SupportedPetTypes, &gt.Enabled); err != nil {
+		if err := rows.Scan(&gt.ID, &gt.Name, &gt.Description, &gt.Icon, &gt.XPConfig, &gt.ThrowDetection, &gt.SupportedPetTypes, &gt.Enabled); err != nil {
 			return nil, fmt.Errorf("failed to scan game type: %w", err)
 		}
 		gameTypes = append(gameTypes, &gt)
@@ -286,14 +481,14 @@ func (r *ActivityRepository) GetAllGameTypes(ctx context.Context) ([]*models.Gam
 
 func (r *ActivityRepository) GetGameType(ctx context.Context, id string) (*models.GameType, error) {
 	query := `
-		SELECT id, name, description, icon, xp_config, supported_pet_types, enabled
+		SELECT id, name, description, icon, xp_config, throw_detection, supported_pet_types, enabled
 		FROM game_types
 		WHERE id = $1
 	`
 
 	var gt models.GameType
 	err := r.db.QueryRow(ctx, query, id).Scan(
-		&gt.ID, &gt.Name, &gt.Description, &gt.Icon, &gt.XPConfig, &gt.SupportedPetTypes, &gt.Enabled,
+		&gt.ID, &gt.Name, &gt.Description, &gt.Icon, &gt.XPConfig, &gt.ThrowDetection, &gt.SupportedPetTypes, &gt.Enabled,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -307,6 +502,23 @@ func (r *ActivityRepository) GetGameType(ctx context.Context, id string) (*model
 
 // Stats
 
+// ExerciseSecondsSince sums the duration of the pet's finished activities
+// that started at or after since.
+func (r *ActivityRepository) ExerciseSecondsSince(ctx context.Context, petID uuid.UUID, since time.Time) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(duration_seconds), 0)
+		FROM activities
+		WHERE pet_id = $1 AND ended_at IS NOT NULL AND started_at >= $2
+	`
+
+	var seconds int
+	if err := r.db.QueryRow(ctx, query, petID, since).Scan(&seconds); err != nil {
+		return 0, fmt.Errorf("failed to get exercise time: %w", err)
+	}
+
+	return seconds, nil
+}
+
 func (r *ActivityRepository) GetPetStats(ctx context.Context, petID uuid.UUID) (*models.PetStats, error) {
 	query := `
 		SELECT
@@ -0,0 +1,552 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var (
+	ErrChallengeNotFound         = errors.New("challenge not found")
+	ErrChallengeTemplateNotFound = errors.New("challenge template not found")
+	ErrInviteCodeTaken           = errors.New("invite code taken")
+	ErrChallengeFull             = errors.New("challenge full")
+	ErrChallengeBlocked          = errors.New("blocked by or blocking a challenge participant")
+)
+
+type ChallengeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewChallengeRepository(db *pgxpool.Pool) *ChallengeRepository {
+	return &ChallengeRepository{db: db}
+}
+
+// Templates
+
+const challengeTemplateColumns = `t.id, t.name, t.description, t.metric, t.aggregation, t.period, t.filter,
+		       t.reward_title, t.reward_cosmetic_id, t.enabled`
+
+func scanChallengeTemplate(row pgx.Row) (*models.ChallengeTemplate, error) {
+	var t models.ChallengeTemplate
+	err := row.Scan(
+		&t.ID,
+		&t.Name,
+		&t.Description,
+		&t.Metric,
+		&t.Aggregation,
+		&t.Period,
+		&t.Filter,
+		&t.RewardTitle,
+		&t.RewardCosmeticID,
+		&t.Enabled,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTemplates returns all templates, including disabled ones.
+func (r *ChallengeRepository) ListTemplates(ctx context.Context) ([]*models.ChallengeTemplate, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+challengeTemplateColumns+`
+		FROM challenge_templates t
+		ORDER BY t.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list challenge templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*models.ChallengeTemplate
+	for rows.Next() {
+		template, err := scanChallengeTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan challenge template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *ChallengeRepository) GetTemplate(ctx context.Context, id string) (*models.ChallengeTemplate, error) {
+	template, err := scanChallengeTemplate(r.db.QueryRow(ctx, `
+		SELECT `+challengeTemplateColumns+`
+		FROM challenge_templates t
+		WHERE t.id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrChallengeTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to get challenge template: %w", err)
+	}
+	return template, nil
+}
+
+// Challenges
+
+const challengeColumns = `c.id, c.template_id, c.created_by, c.invite_code, c.timezone, c.starts_at, c.ends_at,
+		       c.finished_at, c.created_at, ` + challengeTemplateColumns
+
+const challengeTables = `challenges c
+		JOIN challenge_templates t ON t.id = c.template_id`
+
+func scanChallenge(row pgx.Row) (*models.Challenge, error) {
+	var c models.Challenge
+	var t models.ChallengeTemplate
+	err := row.Scan(
+		&c.ID,
+		&c.TemplateID,
+		&c.CreatedBy,
+		&c.InviteCode,
+		&c.Timezone,
+		&c.StartsAt,
+		&c.EndsAt,
+		&c.FinishedAt,
+		&c.CreatedAt,
+		&t.ID,
+		&t.Name,
+		&t.Description,
+		&t.Metric,
+		&t.Aggregation,
+		&t.Period,
+		&t.Filter,
+		&t.RewardTitle,
+		&t.RewardCosmeticID,
+		&t.Enabled,
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.Template = &t
+	return &c, nil
+}
+
+func collectChallenges(rows pgx.Rows) ([]*models.Challenge, error) {
+	defer rows.Close()
+
+	var challenges []*models.Challenge
+	for rows.Next() {
+		challenge, err := scanChallenge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan challenge: %w", err)
+		}
+		challenges = append(challenges, challenge)
+	}
+
+	return challenges, rows.Err()
+}
+
+// Create stores a challenge with its creator taking part and the invitees
+// invited. It returns ErrInviteCodeTaken if another challenge has the
+// invite code.
+func (r *ChallengeRepository) Create(ctx context.Context, challenge *models.Challenge, creatorID uuid.UUID, inviteeIDs []uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO challenges (id, template_id, created_by, invite_code, timezone, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, challenge.ID, challenge.TemplateID, challenge.CreatedBy, challenge.InviteCode, challenge.Timezone,
+		challenge.StartsAt, challenge.EndsAt, challenge.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrInviteCodeTaken
+		}
+		return fmt.Errorf("failed to create challenge: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO challenge_participants (challenge_id, user_id, joined_at, accepted_at)
+		VALUES ($1, $2, $3, $3)
+	`, challenge.ID, creatorID, challenge.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add challenge creator: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO challenge_participants (challenge_id, user_id, joined_at)
+		SELECT $1, unnest($2::uuid[]), $3
+		ON CONFLICT DO NOTHING
+	`, challenge.ID, inviteeIDs, challenge.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to invite challenge participants: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ChallengeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Challenge, error) {
+	challenge, err := scanChallenge(r.db.QueryRow(ctx, `
+		SELECT `+challengeColumns+`
+		FROM `+challengeTables+`
+		WHERE c.id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+func (r *ChallengeRepository) GetByInviteCode(ctx context.Context, code string) (*models.Challenge, error) {
+	challenge, err := scanChallenge(r.db.QueryRow(ctx, `
+		SELECT `+challengeColumns+`
+		FROM `+challengeTables+`
+		WHERE c.invite_code = $1
+	`, code))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, fmt.Errorf("failed to get challenge by invite code: %w", err)
+	}
+	return challenge, nil
+}
+
+// ListForUser returns the challenges the user takes part in that are
+// running or ended after since, newest first.
+func (r *ChallengeRepository) ListForUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.Challenge, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+challengeColumns+`
+		FROM `+challengeTables+`
+		JOIN challenge_participants cp ON cp.challenge_id = c.id
+		WHERE cp.user_id = $1 AND cp.accepted_at IS NOT NULL AND c.ends_at > $2
+		ORDER BY c.ends_at DESC, c.id
+	`, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list challenges: %w", err)
+	}
+	return collectChallenges(rows)
+}
+
+// ListInvitations returns the running challenges the user was invited to
+// and hasn't answered, soonest to end first.
+func (r *ChallengeRepository) ListInvitations(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.Challenge, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+challengeColumns+`
+		FROM `+challengeTables+`
+		JOIN challenge_participants cp ON cp.challenge_id = c.id
+		WHERE cp.user_id = $1 AND cp.accepted_at IS NULL AND c.finished_at IS NULL AND c.ends_at > $2
+		ORDER BY c.ends_at, c.id
+	`, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list challenge invitations: %w", err)
+	}
+	return collectChallenges(rows)
+}
+
+// ListDue returns up to limit challenges that ended but aren't finished.
+func (r *ChallengeRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*models.Challenge, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+challengeColumns+`
+		FROM `+challengeTables+`
+		WHERE c.finished_at IS NULL AND c.ends_at <= $1
+		ORDER BY c.ends_at
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due challenges: %w", err)
+	}
+	return collectChallenges(rows)
+}
+
+// Participants
+
+func (r *ChallengeRepository) IsParticipant(ctx context.Context, challengeID, userID uuid.UUID) (bool, error) {
+	var participant bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM challenge_participants
+			WHERE challenge_id = $1 AND user_id = $2 AND accepted_at IS NOT NULL
+		)
+	`, challengeID, userID).Scan(&participant)
+	if err != nil {
+		return false, fmt.Errorf("failed to check challenge participant: %w", err)
+	}
+	return participant, nil
+}
+
+// ListParticipants returns the challenge's participants with their
+// timezones, which decide their local hours. Invitees who haven't accepted
+// aren't participants yet.
+func (r *ChallengeRepository) ListParticipants(ctx context.Context, challengeID uuid.UUID) ([]*models.ChallengeParticipant, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT u.id, u.name, u.avatar_url, COALESCE(u.preferences->>'timezone', 'UTC'),
+		       cp.final_rank, cp.final_value, cp.final_activities
+		FROM challenge_participants cp
+		JOIN users u ON u.id = cp.user_id
+		WHERE cp.challenge_id = $1 AND cp.accepted_at IS NOT NULL
+		ORDER BY cp.joined_at
+	`, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list challenge participants: %w", err)
+	}
+	defer rows.Close()
+
+	var participants []*models.ChallengeParticipant
+	for rows.Next() {
+		var p models.ChallengeParticipant
+		if err := rows.Scan(&p.ID, &p.Name, &p.AvatarURL, &p.Timezone, &p.FinalRank, &p.FinalValue, &p.FinalActivities); err != nil {
+			return nil, fmt.Errorf("failed to scan challenge participant: %w", err)
+		}
+		participants = append(participants, &p)
+	}
+
+	return participants, rows.Err()
+}
+
+// AddParticipant adds a user to a challenge, or accepts their invitation,
+// unless it would then have more than maxParticipants, in which case it
+// returns ErrChallengeFull. Users blocked by or blocking a participant
+// can't take part: it returns ErrChallengeBlocked. Joining twice is a
+// no-op.
+func (r *ChallengeRepository) AddParticipant(ctx context.Context, challengeID, userID uuid.UUID, maxParticipants int, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the challenge so concurrent joins can't overfill it or slip in
+	// alongside someone who blocked them
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM challenges WHERE id = $1 FOR UPDATE`, challengeID); err != nil {
+		return fmt.Errorf("failed to lock challenge: %w", err)
+	}
+
+	var blocked bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM challenge_participants cp
+			JOIN user_blocks b ON (b.blocker_id = cp.user_id AND b.blocked_id = $2)
+			                   OR (b.blocker_id = $2 AND b.blocked_id = cp.user_id)
+			WHERE cp.challenge_id = $1 AND cp.accepted_at IS NOT NULL
+		)
+	`, challengeID, userID).Scan(&blocked)
+	if err != nil {
+		return fmt.Errorf("failed to check challenge blocks: %w", err)
+	}
+	if blocked {
+		return ErrChallengeBlocked
+	}
+
+	// Invitations hold a place, so they count towards the cap
+	var count int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM challenge_participants WHERE challenge_id = $1 AND user_id <> $2
+	`, challengeID, userID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count challenge participants: %w", err)
+	}
+	if count >= maxParticipants {
+		return ErrChallengeFull
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO challenge_participants (challenge_id, user_id, joined_at, accepted_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (challenge_id, user_id) DO UPDATE
+		SET accepted_at = COALESCE(challenge_participants.accepted_at, EXCLUDED.accepted_at)
+	`, challengeID, userID, at)
+	if err != nil {
+		return fmt.Errorf("failed to add challenge participant: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// IsInvited reports whether the user has an unanswered invitation to the
+// challenge.
+func (r *ChallengeRepository) IsInvited(ctx context.Context, challengeID, userID uuid.UUID) (bool, error) {
+	var invited bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM challenge_participants
+			WHERE challenge_id = $1 AND user_id = $2 AND accepted_at IS NULL
+		)
+	`, challengeID, userID).Scan(&invited)
+	if err != nil {
+		return false, fmt.Errorf("failed to check challenge invitation: %w", err)
+	}
+	return invited, nil
+}
+
+// DeclineInvitation reports whether the user had an unanswered invitation
+// to the challenge.
+func (r *ChallengeRepository) DeclineInvitation(ctx context.Context, challengeID, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM challenge_participants
+		WHERE challenge_id = $1 AND user_id = $2 AND accepted_at IS NULL
+	`, challengeID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to decline challenge invitation: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// RemoveParticipant reports whether the user took part in the challenge.
+func (r *ChallengeRepository) RemoveParticipant(ctx context.Context, challengeID, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM challenge_participants
+		WHERE challenge_id = $1 AND user_id = $2 AND accepted_at IS NOT NULL
+	`, challengeID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove challenge participant: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// ListActivities returns the finished activities the users performed, or
+// logged for their own pets, that started in [from, to). Flagged activities
+// and those of deleted pets don't count.
+func (r *ChallengeRepository) ListActivities(ctx context.Context, userIDs []uuid.UUID, from, to time.Time) ([]models.ChallengeActivity, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT COALESCE(a.performed_by, p.user_id), a.game_type_id, a.started_at, a.duration_seconds, a.game_data
+		FROM activities a
+		JOIN pets p ON p.id = a.pet_id
+		WHERE COALESCE(a.performed_by, p.user_id) = ANY($1)
+		  AND a.started_at >= $2 AND a.started_at < $3
+		  AND a.ended_at IS NOT NULL
+		  AND a.flag_reason IS NULL
+		  AND p.deleted_at IS NULL
+	`, userIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list challenge activities: %w", err)
+	}
+	defer rows.Close()
+
+	var activities []models.ChallengeActivity
+	for rows.Next() {
+		var a models.ChallengeActivity
+		var duration *int
+		if err := rows.Scan(&a.UserID, &a.GameTypeID, &a.StartedAt, &duration, &a.GameData); err != nil {
+			return nil, fmt.Errorf("failed to scan challenge activity: %w", err)
+		}
+		if duration != nil {
+			a.DurationSeconds = *duration
+		}
+		activities = append(activities, a)
+	}
+
+	return activities, rows.Err()
+}
+
+// Finish records the final standings and the winners' rewards, adding any
+// item won to their inventory. It returns false without changes if the
+// challenge was already finished.
+func (r *ChallengeRepository) Finish(ctx context.Context, challengeID uuid.UUID, standings []*models.ChallengeStanding, rewards []*models.ChallengeReward, at time.Time) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE challenges SET finished_at = $2 WHERE id = $1 AND finished_at IS NULL
+	`, challengeID, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to finish challenge: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	for _, standing := range standings {
+		_, err := tx.Exec(ctx, `
+			UPDATE challenge_participants SET final_rank = $3, final_value = $4, final_activities = $5
+			WHERE challenge_id = $1 AND user_id = $2
+		`, challengeID, standing.ID, standing.Rank, standing.Value, standing.Activities)
+		if err != nil {
+			return false, fmt.Errorf("failed to record challenge standing: %w", err)
+		}
+	}
+
+	for _, reward := range rewards {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO challenge_rewards (id, challenge_id, user_id, title, cosmetic_id, title_expires_at, granted_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (challenge_id, user_id) DO NOTHING
+		`, reward.ID, reward.ChallengeID, reward.UserID, reward.Title, reward.CosmeticID, reward.TitleExpiresAt, reward.GrantedAt)
+		if err != nil {
+			return false, fmt.Errorf("failed to grant challenge reward: %w", err)
+		}
+
+		if reward.CosmeticID != nil {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO user_items (user_id, item_id, source, acquired_at)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT DO NOTHING
+			`, reward.UserID, *reward.CosmeticID, models.ItemSourceChallenge, reward.GrantedAt)
+			if err != nil {
+				return false, fmt.Errorf("failed to grant challenge item: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// ListRewards returns the user's challenge rewards, newest first.
+func (r *ChallengeRepository) ListRewards(ctx context.Context, userID uuid.UUID) ([]*models.ChallengeReward, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, challenge_id, user_id, title, cosmetic_id, title_expires_at, granted_at
+		FROM challenge_rewards
+		WHERE user_id = $1
+		ORDER BY granted_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list challenge rewards: %w", err)
+	}
+	defer rows.Close()
+
+	var rewards []*models.ChallengeReward
+	for rows.Next() {
+		var reward models.ChallengeReward
+		err := rows.Scan(
+			&reward.ID,
+			&reward.ChallengeID,
+			&reward.UserID,
+			&reward.Title,
+			&reward.CosmeticID,
+			&reward.TitleExpiresAt,
+			&reward.GrantedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan challenge reward: %w", err)
+		}
+		rewards = append(rewards, &reward)
+	}
+
+	return rewards, rows.Err()
+}
@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var ErrCosmeticItemNotFound = errors.New("cosmetic item not found")
+
+type CosmeticRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCosmeticRepository(db *pgxpool.Pool) *CosmeticRepository {
+	return &CosmeticRepository{db: db}
+}
+
+const cosmeticItemColumns = `ci.id, ci.slot, ci.name, ci.description, ci.unlock_level`
+
+func scanCosmeticItem(row pgx.Row, extra ...any) (*models.CosmeticItem, error) {
+	var item models.CosmeticItem
+	dest := []any{&item.ID, &item.Slot, &item.Name, &item.Description, &item.UnlockLevel}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListItems returns the whole catalog in display order.
+func (r *CosmeticRepository) ListItems(ctx context.Context) ([]*models.CosmeticItem, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+cosmeticItemColumns+`
+		FROM cosmetic_items ci
+		ORDER BY ci.sort_order, ci.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cosmetic items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.CosmeticItem
+	for rows.Next() {
+		item, err := scanCosmeticItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cosmetic item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *CosmeticRepository) GetItem(ctx context.Context, id string) (*models.CosmeticItem, error) {
+	item, err := scanCosmeticItem(r.db.QueryRow(ctx, `
+		SELECT `+cosmeticItemColumns+`
+		FROM cosmetic_items ci
+		WHERE ci.id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCosmeticItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get cosmetic item: %w", err)
+	}
+	return item, nil
+}
+
+// ListInventory returns the items the user owns in display order.
+func (r *CosmeticRepository) ListInventory(ctx context.Context, userID uuid.UUID) ([]*models.InventoryItem, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+cosmeticItemColumns+`, ui.source, ui.acquired_at
+		FROM user_items ui
+		JOIN cosmetic_items ci ON ci.id = ui.item_id
+		WHERE ui.user_id = $1
+		ORDER BY ci.sort_order, ci.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.InventoryItem
+	for rows.Next() {
+		var owned models.InventoryItem
+		item, err := scanCosmeticItem(rows, &owned.Source, &owned.AcquiredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inventory item: %w", err)
+		}
+		owned.CosmeticItem = *item
+		items = append(items, &owned)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *CosmeticRepository) Owns(ctx context.Context, userID uuid.UUID, itemID string) (bool, error) {
+	var owns bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM user_items WHERE user_id = $1 AND item_id = $2)
+	`, userID, itemID).Scan(&owns)
+	if err != nil {
+		return false, fmt.Errorf("failed to check inventory: %w", err)
+	}
+	return owns, nil
+}
+
+// GrantLevelItems gives the user the items unlocked by levels above from
+// up to and including to. Items the user already has are kept as they are.
+func (r *CosmeticRepository) GrantLevelItems(ctx context.Context, userID uuid.UUID, from, to int, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_items (user_id, item_id, source, acquired_at)
+		SELECT $1, id, $4, $5
+		FROM cosmetic_items
+		WHERE unlock_level > $2 AND unlock_level <= $3
+		ON CONFLICT DO NOTHING
+	`, userID, from, to, models.ItemSourceLevel, at)
+	if err != nil {
+		return fmt.Errorf("failed to grant level items: %w", err)
+	}
+	return nil
+}
+
+// GrantAchievementItems gives the user the reward items of the given
+// achievements. Items the user already has are kept as they are.
+func (r *CosmeticRepository) GrantAchievementItems(ctx context.Context, userID uuid.UUID, achievementIDs []string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_items (user_id, item_id, source, acquired_at)
+		SELECT $1, reward_item_id, $3, $4
+		FROM achievements
+		WHERE id = ANY($2) AND reward_item_id IS NOT NULL
+		ON CONFLICT DO NOTHING
+	`, userID, achievementIDs, models.ItemSourceAchievement, at)
+	if err != nil {
+		return fmt.Errorf("failed to grant achievement items: %w", err)
+	}
+	return nil
+}
+
+// Equip puts the item on the pet, replacing whatever it wore in that slot.
+func (r *CosmeticRepository) Equip(ctx context.Context, petID uuid.UUID, item *models.CosmeticItem, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO pet_loadouts (pet_id, slot, item_id, equipped_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (pet_id, slot) DO UPDATE SET item_id = EXCLUDED.item_id, equipped_at = EXCLUDED.equipped_at
+	`, petID, item.Slot, item.ID, at)
+	if err != nil {
+		return fmt.Errorf("failed to equip item: %w", err)
+	}
+	return nil
+}
+
+// Unequip empties the slot, reporting whether the pet wore anything there.
+func (r *CosmeticRepository) Unequip(ctx context.Context, petID uuid.UUID, slot models.CosmeticSlot) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM pet_loadouts WHERE pet_id = $1 AND slot = $2
+	`, petID, slot)
+	if err != nil {
+		return false, fmt.Errorf("failed to unequip item: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
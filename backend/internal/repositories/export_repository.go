@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var ErrExportNotFound = errors.New("export not found")
+
+type ExportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewExportRepository(db *pgxpool.Pool) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+func (r *ExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	query := `
+		INSERT INTO data_exports (id, user_id, status, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.Exec(ctx, query, export.ID, export.UserID, export.Status, export.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create export: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ExportRepository) GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	query := `
+		SELECT id, user_id, status, error, expires_at, completed_at, created_at
+		FROM data_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	return r.scanOne(r.db.QueryRow(ctx, query, userID))
+}
+
+func (r *ExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	query := `
+		SELECT id, user_id, status, error, expires_at, completed_at, created_at
+		FROM data_exports
+		WHERE id = $1
+	`
+
+	return r.scanOne(r.db.QueryRow(ctx, query, id))
+}
+
+// ClaimPending moves up to limit queued exports to processing and returns
+// them, so several API instances never build the same export. Exports
+// claimed before claimTTL ago were left by a worker that died and are
+// claimed again.
+func (r *ExportRepository) ClaimPending(ctx context.Context, now time.Time, claimTTL time.Duration, limit int) ([]*models.DataExport, error) {
+	query := `
+		UPDATE data_exports
+		SET status = $1, claimed_at = $3
+		WHERE id IN (
+			SELECT id FROM data_exports
+			WHERE status = $2 OR (status = $1 AND claimed_at < $4)
+			ORDER BY created_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, status, error, expires_at, completed_at, created_at
+	`
+
+	rows, err := r.db.Query(ctx, query,
+		models.DataExportStatusProcessing, models.DataExportStatusPending, now, now.Add(-claimTTL), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending exports: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []*models.DataExport
+	for rows.Next() {
+		export, err := r.scanOne(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim pending exports: %w", err)
+	}
+
+	return exports, nil
+}
+
+func (r *ExportRepository) GetArchive(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	query := `SELECT archive FROM data_exports WHERE id = $1 AND archive IS NOT NULL`
+
+	var archive []byte
+	if err := r.db.QueryRow(ctx, query, id).Scan(&archive); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get export archive: %w", err)
+	}
+
+	return archive, nil
+}
+
+func (r *ExportRepository) Complete(ctx context.Context, id uuid.UUID, archive []byte, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = $2, archive = $3, expires_at = $4, completed_at = NOW(), claimed_at = NULL
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, id, models.DataExportStatusCompleted, archive, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ExportRepository) Fail(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `UPDATE data_exports SET status = $2, error = $3, completed_at = NOW(), claimed_at = NULL WHERE id = $1`
+
+	_, err := r.db.Exec(ctx, query, id, models.DataExportStatusFailed, reason)
+	if err != nil {
+		return fmt.Errorf("failed to mark export failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired drops archives whose download link has expired.
+func (r *ExportRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM data_exports WHERE expires_at IS NOT NULL AND expires_at <= $1`
+
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired exports: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *ExportRepository) scanOne(row pgx.Row) (*models.DataExport, error) {
+	var export models.DataExport
+	err := row.Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.Error,
+		&export.ExpiresAt,
+		&export.CompletedAt,
+		&export.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to scan export: %w", err)
+	}
+
+	return &export, nil
+}
@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var (
+	ErrFeedEventNotFound   = errors.New("feed event not found")
+	ErrFeedCommentNotFound = errors.New("feed comment not found")
+)
+
+type FeedRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFeedRepository(db *pgxpool.Pool) *FeedRepository {
+	return &FeedRepository{db: db}
+}
+
+func (r *FeedRepository) CreateEvent(ctx context.Context, event *models.FeedEvent) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO feed_events (id, user_id, pet_id, kind, activity_id, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, event.ID, event.UserID, event.PetID, event.Kind, event.ActivityID, event.Details, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create feed event: %w", err)
+	}
+	return nil
+}
+
+// visibleEvents limits feed events to those the viewer ($1) may see: their
+// own, and their friends' unless the friend keeps activity private. Events
+// of deleted pets drop out.
+const visibleEvents = `
+		JOIN users u ON u.id = e.user_id
+		JOIN pets p ON p.id = e.pet_id AND p.deleted_at IS NULL
+		WHERE (
+		    e.user_id = $1
+		    OR (
+		        EXISTS (SELECT 1 FROM friendships f WHERE f.user_id = $1 AND f.friend_id = e.user_id)
+		        AND COALESCE(u.preferences->'privacy'->>'activity_visibility', 'friends') <> 'private'
+		    )
+		)`
+
+const feedEventColumns = `e.id, e.user_id, e.pet_id, e.kind, e.activity_id, e.details, e.created_at,
+		       u.id, u.name, u.avatar_url, p.id, p.name, p.avatar_url,
+		       (SELECT COUNT(*) FROM feed_reactions fr WHERE fr.event_id = e.id),
+		       EXISTS (SELECT 1 FROM feed_reactions fr WHERE fr.event_id = e.id AND fr.user_id = $1),
+		       (SELECT COUNT(*) FROM feed_comments c WHERE c.event_id = e.id
+		            AND NOT EXISTS (SELECT 1 FROM user_blocks b WHERE b.blocker_id = $1 AND b.blocked_id = c.user_id))`
+
+func scanFeedEvent(row pgx.Row) (*models.FeedEvent, error) {
+	event := models.FeedEvent{User: &models.UserProfile{}, Pet: &models.FeedPet{}}
+	err := row.Scan(
+		&event.ID,
+		&event.UserID,
+		&event.PetID,
+		&event.Kind,
+		&event.ActivityID,
+		&event.Details,
+		&event.CreatedAt,
+		&event.User.ID,
+		&event.User.Name,
+		&event.User.AvatarURL,
+		&event.Pet.ID,
+		&event.Pet.Name,
+		&event.Pet.AvatarURL,
+		&event.PawFives,
+		&event.PawFivedByMe,
+		&event.CommentCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// ListFeed returns up to limit events the viewer may see, newest first,
+// starting after the cursor if one is given.
+func (r *FeedRepository) ListFeed(ctx context.Context, viewerID uuid.UUID, after *models.FeedCursor, limit int) ([]*models.FeedEvent, error) {
+	query := `
+		SELECT ` + feedEventColumns + `
+		FROM feed_events e` + visibleEvents
+
+	args := []any{viewerID, limit}
+	if after != nil {
+		query += ` AND (e.created_at, e.id) < ($3, $4)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += ` ORDER BY e.created_at DESC, e.id DESC LIMIT $2`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feed: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.FeedEvent
+	for rows.Next() {
+		event, err := scanFeedEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// ListByUser returns the events the user posted, newest first, including
+// those of pets that are waiting to be deleted.
+func (r *FeedRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.FeedEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+feedEventColumns+`
+		FROM feed_events e
+		JOIN users u ON u.id = e.user_id
+		JOIN pets p ON p.id = e.pet_id
+		WHERE e.user_id = $1
+		ORDER BY e.created_at DESC, e.id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feed events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.FeedEvent
+	for rows.Next() {
+		event, err := scanFeedEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetVisibleEvent returns the event if the viewer may see it.
+func (r *FeedRepository) GetVisibleEvent(ctx context.Context, viewerID, eventID uuid.UUID) (*models.FeedEvent, error) {
+	event, err := scanFeedEvent(r.db.QueryRow(ctx, `
+		SELECT `+feedEventColumns+`
+		FROM feed_events e`+visibleEvents+` AND e.id = $2
+	`, viewerID, eventID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFeedEventNotFound
+		}
+		return nil, fmt.Errorf("failed to get feed event: %w", err)
+	}
+	return event, nil
+}
+
+// AddReaction reports whether the user hadn't paw-fived the event yet.
+func (r *FeedRepository) AddReaction(ctx context.Context, eventID, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO feed_reactions (event_id, user_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, eventID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *FeedRepository) RemoveReaction(ctx context.Context, eventID, userID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM feed_reactions WHERE event_id = $1 AND user_id = $2`, eventID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+	return nil
+}
+
+func (r *FeedRepository) CreateComment(ctx context.Context, comment *models.FeedComment) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO feed_comments (id, event_id, user_id, body, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, comment.ID, comment.EventID, comment.UserID, comment.Body, comment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	return nil
+}
+
+// ListComments returns the event's comments, oldest first, leaving out
+// those of users the viewer blocked.
+func (r *FeedRepository) ListComments(ctx context.Context, viewerID, eventID uuid.UUID) ([]*models.FeedComment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT c.id, c.event_id, c.user_id, c.body, c.created_at, u.id, u.name, u.avatar_url
+		FROM feed_comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.event_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM user_blocks b WHERE b.blocker_id = $1 AND b.blocked_id = c.user_id)
+		ORDER BY c.created_at, c.id
+	`, viewerID, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*models.FeedComment
+	for rows.Next() {
+		comment := models.FeedComment{User: &models.UserProfile{}}
+		err := rows.Scan(
+			&comment.ID,
+			&comment.EventID,
+			&comment.UserID,
+			&comment.Body,
+			&comment.CreatedAt,
+			&comment.User.ID,
+			&comment.User.Name,
+			&comment.User.AvatarURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, &comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// ListCommentsByUser returns the comments the user wrote, oldest first.
+func (r *FeedRepository) ListCommentsByUser(ctx context.Context, userID uuid.UUID) ([]*models.FeedComment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, event_id, user_id, body, created_at FROM feed_comments
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*models.FeedComment
+	for rows.Next() {
+		var comment models.FeedComment
+		if err := rows.Scan(&comment.ID, &comment.EventID, &comment.UserID, &comment.Body, &comment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, &comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (r *FeedRepository) GetComment(ctx context.Context, eventID, commentID uuid.UUID) (*models.FeedComment, error) {
+	var comment models.FeedComment
+	err := r.db.QueryRow(ctx, `
+		SELECT id, event_id, user_id, body, created_at FROM feed_comments
+		WHERE id = $1 AND event_id = $2
+	`, commentID, eventID).Scan(&comment.ID, &comment.EventID, &comment.UserID, &comment.Body, &comment.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFeedCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	return &comment, nil
+}
+
+func (r *FeedRepository) DeleteComment(ctx context.Context, commentID uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM feed_comments WHERE id = $1`, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrFeedCommentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var (
+	ErrFriendCodeTaken       = errors.New("friend code taken")
+	ErrFriendRequestNotFound = errors.New("friend request not found")
+	ErrFriendRequestExists   = errors.New("friend request already exists")
+)
+
+type FriendRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFriendRepository(db *pgxpool.Pool) *FriendRepository {
+	return &FriendRepository{db: db}
+}
+
+// GetFriendCode returns the user's friend code, or nil if they don't have
+// one yet.
+func (r *FriendRepository) GetFriendCode(ctx context.Context, userID uuid.UUID) (*string, error) {
+	var code *string
+	err := r.db.QueryRow(ctx, `SELECT friend_code FROM users WHERE id = $1`, userID).Scan(&code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get friend code: %w", err)
+	}
+	return code, nil
+}
+
+// SetFriendCode replaces the user's friend code. It returns
+// ErrFriendCodeTaken if another user has the code.
+func (r *FriendRepository) SetFriendCode(ctx context.Context, userID uuid.UUID, code string) error {
+	result, err := r.db.Exec(ctx, `UPDATE users SET friend_code = $2 WHERE id = $1`, userID, code)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrFriendCodeTaken
+		}
+		return fmt.Errorf("failed to set friend code: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// GetProfileByFriendCode returns the user with the friend code.
+func (r *FriendRepository) GetProfileByFriendCode(ctx context.Context, code string) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	err := r.db.QueryRow(ctx, `
+		SELECT id, name, avatar_url FROM users
+		WHERE friend_code = $1 AND deletion_scheduled_at IS NULL
+	`, code).Scan(&profile.ID, &profile.Name, &profile.AvatarURL)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find friend code: %w", err)
+	}
+	return &profile, nil
+}
+
+// AreFriends reports whether the two users are friends.
+func (r *FriendRepository) AreFriends(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	var friends bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM friendships WHERE user_id = $1 AND friend_id = $2)
+	`, userID, otherID).Scan(&friends)
+	if err != nil {
+		return false, fmt.Errorf("failed to check friendship: %w", err)
+	}
+	return friends, nil
+}
+
+// IsBlocked reports whether either user blocked the other.
+func (r *FriendRepository) IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	var blocked bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`, userID, otherID).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check blocks: %w", err)
+	}
+	return blocked, nil
+}
+
+func (r *FriendRepository) ListFriends(ctx context.Context, userID uuid.UUID) ([]*models.Friend, error) {
+	query := `
+		SELECT u.id, u.name, u.avatar_url, f.created_at
+		FROM friendships f
+		JOIN users u ON u.id = f.friend_id
+		WHERE f.user_id = $1
+		ORDER BY u.name
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list friends: %w", err)
+	}
+	defer rows.Close()
+
+	var friends []*models.Friend
+	for rows.Next() {
+		var f models.Friend
+		if err := rows.Scan(&f.ID, &f.Name, &f.AvatarURL, &f.FriendsSince); err != nil {
+			return nil, fmt.Errorf("failed to scan friend: %w", err)
+		}
+		friends = append(friends, &f)
+	}
+
+	return friends, rows.Err()
+}
+
+// RemoveFriend ends a friendship in both directions. It reports whether
+// the users were friends.
+func (r *FriendRepository) RemoveFriend(ctx context.Context, userID, friendID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM friendships
+		WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)
+	`, userID, friendID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove friend: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// Friend requests
+
+const friendRequestColumns = `fr.id, fr.from_user_id, fr.to_user_id, fr.created_at,
+		       fu.id, fu.name, fu.avatar_url, tu.id, tu.name, tu.avatar_url`
+
+const friendRequestTables = `friend_requests fr
+		JOIN users fu ON fu.id = fr.from_user_id
+		JOIN users tu ON tu.id = fr.to_user_id`
+
+func scanFriendRequest(row pgx.Row) (*models.FriendRequest, error) {
+	request := models.FriendRequest{From: &models.UserProfile{}, To: &models.UserProfile{}}
+	err := row.Scan(
+		&request.ID,
+		&request.FromUserID,
+		&request.ToUserID,
+		&request.CreatedAt,
+		&request.From.ID,
+		&request.From.Name,
+		&request.From.AvatarURL,
+		&request.To.ID,
+		&request.To.Name,
+		&request.To.AvatarURL,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// CreateRequest stores a friend request. It returns ErrFriendRequestExists
+// if the user already asked.
+func (r *FriendRepository) CreateRequest(ctx context.Context, request *models.FriendRequest) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO friend_requests (id, from_user_id, to_user_id, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, request.ID, request.FromUserID, request.ToUserID, request.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrFriendRequestExists
+		}
+		return fmt.Errorf("failed to create friend request: %w", err)
+	}
+	return nil
+}
+
+func (r *FriendRepository) GetRequest(ctx context.Context, id uuid.UUID) (*models.FriendRequest, error) {
+	request, err := scanFriendRequest(r.db.QueryRow(ctx, `
+		SELECT `+friendRequestColumns+`
+		FROM `+friendRequestTables+`
+		WHERE fr.id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFriendRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get friend request: %w", err)
+	}
+	return request, nil
+}
+
+// GetRequestBetween returns the pending request from one user to another.
+func (r *FriendRepository) GetRequestBetween(ctx context.Context, fromUserID, toUserID uuid.UUID) (*models.FriendRequest, error) {
+	request, err := scanFriendRequest(r.db.QueryRow(ctx, `
+		SELECT `+friendRequestColumns+`
+		FROM `+friendRequestTables+`
+		WHERE fr.from_user_id = $1 AND fr.to_user_id = $2
+	`, fromUserID, toUserID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFriendRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get friend request: %w", err)
+	}
+	return request, nil
+}
+
+// ListRequests returns the pending requests sent to or by the user, newest
+// first.
+func (r *FriendRepository) ListRequests(ctx context.Context, userID uuid.UUID) ([]*models.FriendRequest, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+friendRequestColumns+`
+		FROM `+friendRequestTables+`
+		WHERE fr.from_user_id = $1 OR fr.to_user_id = $1
+		ORDER BY fr.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list friend requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*models.FriendRequest
+	for rows.Next() {
+		request, err := scanFriendRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan friend request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// CountOutgoingRequests counts the user's unanswered requests.
+func (r *FriendRepository) CountOutgoingRequests(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM friend_requests WHERE from_user_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count friend requests: %w", err)
+	}
+	return count, nil
+}
+
+func (r *FriendRepository) DeleteRequest(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM friend_requests WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete friend request: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrFriendRequestNotFound
+	}
+	return nil
+}
+
+// AcceptRequest turns a pending request into a friendship.
+func (r *FriendRepository) AcceptRequest(ctx context.Context, request *models.FriendRequest, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `DELETE FROM friend_requests WHERE id = $1`, request.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete friend request: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrFriendRequestNotFound
+	}
+
+	// A request the other way is answered too
+	_, err = tx.Exec(ctx, `
+		DELETE FROM friend_requests WHERE from_user_id = $1 AND to_user_id = $2
+	`, request.ToUserID, request.FromUserID)
+	if err != nil {
+		return fmt.Errorf("failed to delete friend request: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO friendships (user_id, friend_id, created_at)
+		VALUES ($1, $2, $3), ($2, $1, $3)
+		ON CONFLICT DO NOTHING
+	`, request.FromUserID, request.ToUserID, at)
+	if err != nil {
+		return fmt.Errorf("failed to create friendship: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Blocks
+
+// Block stops two users from seeing each other: it ends their friendship
+// and drops requests between them.
+func (r *FriendRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM friendships
+		WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)
+	`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to remove friend: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM friend_requests
+		WHERE (from_user_id = $1 AND to_user_id = $2) OR (from_user_id = $2 AND to_user_id = $1)
+	`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to delete friend requests: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_blocks (blocker_id, blocked_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, blockerID, blockedID, at)
+	if err != nil {
+		return fmt.Errorf("failed to block user: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Unblock reports whether the user had been blocked.
+func (r *FriendRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2
+	`, blockerID, blockedID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unblock user: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *FriendRepository) ListBlocked(ctx context.Context, blockerID uuid.UUID) ([]*models.BlockedUser, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT u.id, u.name, u.avatar_url, b.created_at
+		FROM user_blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC
+	`, blockerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked users: %w", err)
+	}
+	defer rows.Close()
+
+	var blocked []*models.BlockedUser
+	for rows.Next() {
+		var b models.BlockedUser
+		if err := rows.Scan(&b.ID, &b.Name, &b.AvatarURL, &b.BlockedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocked user: %w", err)
+		}
+		blocked = append(blocked, &b)
+	}
+
+	return blocked, rows.Err()
+}
@@ -0,0 +1,362 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+type GamificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGamificationRepository(db *pgxpool.Pool) *GamificationRepository {
+	return &GamificationRepository{db: db}
+}
+
+// Achievements
+
+func (r *GamificationRepository) GetUserAchievements(ctx context.Context, userID uuid.UUID) ([]*models.UserAchievement, error) {
+	query := `
+		SELECT ua.user_id, ua.achievement_id, ua.pet_id, ua.unlocked_at,
+		       a.id, a.name, a.description, a.icon, a.category, a.criteria, a.xp_reward, a.reward_item_id
+		FROM user_achievements ua
+		JOIN achievements a ON ua.achievement_id = a.id
+		WHERE ua.user_id = $1
+		ORDER BY ua.unlocked_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user achievements: %w", err)
+	}
+	defer rows.Close()
+
+	var achievements []*models.UserAchievement
+	for rows.Next() {
+		var ua models.UserAchievement
+		var a models.Achievement
+
+		err := rows.Scan(
+			&ua.UserID,
+			&ua.AchievementID,
+			&ua.PetID,
+			&ua.UnlockedAt,
+			&a.ID,
+			&a.Name,
+			&a.Description,
+			&a.Icon,
+			&a.Category,
+			&a.Criteria,
+			&a.XPReward,
+			&a.RewardItemID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user achievement: %w", err)
+		}
+
+		ua.Achievement = &a
+		achievements = append(achievements, &ua)
+	}
+
+	return achievements, nil
+}
+
+// ListLockedAchievements returns the achievements the user hasn't unlocked
+// with the pet yet.
+func (r *GamificationRepository) ListLockedAchievements(ctx context.Context, userID, petID uuid.UUID) ([]*models.Achievement, error) {
+	query := `
+		SELECT a.id, a.name, a.description, a.icon, a.category, a.criteria, a.xp_reward, a.reward_item_id
+		FROM achievements a
+		WHERE NOT EXISTS (
+			SELECT 1 FROM user_achievements ua
+			WHERE ua.user_id = $1 AND ua.pet_id = $2 AND ua.achievement_id = a.id
+		)
+		ORDER BY a.id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locked achievements: %w", err)
+	}
+	defer rows.Close()
+
+	var achievements []*models.Achievement
+	for rows.Next() {
+		var a models.Achievement
+		err := rows.Scan(
+			&a.ID,
+			&a.Name,
+			&a.Description,
+			&a.Icon,
+			&a.Category,
+			&a.Criteria,
+			&a.XPReward,
+			&a.RewardItemID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %w", err)
+		}
+		achievements = append(achievements, &a)
+	}
+
+	return achievements, rows.Err()
+}
+
+// GetAchievementProgress counts the pet's finished, unflagged activities by
+// game type and sums their distance. The streak is left to the caller.
+func (r *GamificationRepository) GetAchievementProgress(ctx context.Context, petID uuid.UUID) (*models.AchievementProgress, error) {
+	query := `
+		SELECT game_type_id, COUNT(*), COALESCE(SUM((game_data->>'distance_meters')::float), 0)
+		FROM activities
+		WHERE pet_id = $1 AND ended_at IS NOT NULL AND flag_reason IS NULL
+		GROUP BY game_type_id
+	`
+
+	rows, err := r.db.Query(ctx, query, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievement progress: %w", err)
+	}
+	defer rows.Close()
+
+	progress := &models.AchievementProgress{ActivityCounts: make(map[string]int)}
+	for rows.Next() {
+		var gameTypeID string
+		var count int
+		var distance float64
+		if err := rows.Scan(&gameTypeID, &count, &distance); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement progress: %w", err)
+		}
+		progress.ActivityCounts[gameTypeID] = count
+		progress.DistanceMeters += distance
+	}
+
+	return progress, rows.Err()
+}
+
+// UnlockAchievement records the achievement for the user and pet. It
+// reports false when they had already unlocked it.
+func (r *GamificationRepository) UnlockAchievement(ctx context.Context, ua *models.UserAchievement) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO user_achievements (user_id, achievement_id, pet_id, unlocked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, ua.UserID, ua.AchievementID, ua.PetID, ua.UnlockedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock achievement: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// Cards
+
+func (r *GamificationRepository) GetUserCards(ctx context.Context, userID uuid.UUID) ([]*models.UserCard, error) {
+	query := `
+		SELECT uc.id, uc.user_id, uc.card_id, uc.obtained_at, uc.activity_id,
+		       c.id, c.name, c.description, c.image_url, c.rarity, c.category, c.drop_config
+		FROM user_cards uc
+		JOIN cards c ON uc.card_id = c.id
+		WHERE uc.user_id = $1
+		ORDER BY uc.obtained_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user cards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []*models.UserCard
+	for rows.Next() {
+		var uc models.UserCard
+		var c models.Card
+
+		err := rows.Scan(
+			&uc.ID,
+			&uc.UserID,
+			&uc.CardID,
+			&uc.ObtainedAt,
+			&uc.ActivityID,
+			&c.ID,
+			&c.Name,
+			&c.Description,
+			&c.ImageURL,
+			&c.Rarity,
+			&c.Category,
+			&c.DropConfig,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user card: %w", err)
+		}
+
+		uc.Card = &c
+		cards = append(cards, &uc)
+	}
+
+	return cards, nil
+}
+
+// Missions
+
+func (r *GamificationRepository) GetUserMissions(ctx context.Context, userID uuid.UUID) ([]*models.Mission, error) {
+	query := `
+		SELECT ` + missionColumns + `
+		FROM missions
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get missions: %w", err)
+	}
+	defer rows.Close()
+
+	return collectMissions(rows)
+}
+
+// GetActiveMissions returns the user's missions that haven't expired, the
+// ones still open first.
+func (r *GamificationRepository) GetActiveMissions(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.Mission, error) {
+	query := `
+		SELECT ` + missionColumns + `
+		FROM missions
+		WHERE user_id = $1 AND expires_at > $2
+		ORDER BY completed_at IS NOT NULL, expires_at, created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active missions: %w", err)
+	}
+	defer rows.Close()
+
+	return collectMissions(rows)
+}
+
+const missionColumns = `id, user_id, pet_id, game_type_id, mission_type, description, target_value, current_value,
+		       xp_reward, expires_at, completed_at, created_at`
+
+func collectMissions(rows pgx.Rows) ([]*models.Mission, error) {
+	var missions []*models.Mission
+	for rows.Next() {
+		var m models.Mission
+		err := rows.Scan(
+			&m.ID,
+			&m.UserID,
+			&m.PetID,
+			&m.GameTypeID,
+			&m.MissionType,
+			&m.Description,
+			&m.TargetValue,
+			&m.CurrentValue,
+			&m.XPReward,
+			&m.ExpiresAt,
+			&m.CompletedAt,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan mission: %w", err)
+		}
+		missions = append(missions, &m)
+	}
+
+	return missions, rows.Err()
+}
+
+// ListPetsNeedingMissions returns active pets without an unexpired mission,
+// ordered by ID and starting after afterID so callers can page through
+// them.
+func (r *GamificationRepository) ListPetsNeedingMissions(ctx context.Context, now time.Time, afterID uuid.UUID, limit int) ([]*models.Pet, error) {
+	query := `
+		SELECT ` + petColumns + `
+		FROM ` + petTables + `
+		WHERE p.id > $1 AND p.status = 'active' AND p.deleted_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM missions m WHERE m.pet_id = p.id AND m.expires_at > $2)
+		ORDER BY p.id
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pets needing missions: %w", err)
+	}
+	defer rows.Close()
+
+	var pets []*models.Pet
+	for rows.Next() {
+		pet, err := scanPet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pet: %w", err)
+		}
+		pets = append(pets, pet)
+	}
+
+	return pets, rows.Err()
+}
+
+// CreateMissions inserts missions, skipping any the pet already has for
+// the same period.
+func (r *GamificationRepository) CreateMissions(ctx context.Context, missions []*models.Mission) error {
+	query := `
+		INSERT INTO missions (id, user_id, pet_id, game_type_id, mission_type, description, target_value, current_value, xp_reward, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (pet_id, mission_type, expires_at) WHERE pet_id IS NOT NULL DO NOTHING
+	`
+
+	batch := &pgx.Batch{}
+	for _, m := range missions {
+		batch.Queue(query, m.ID, m.UserID, m.PetID, m.GameTypeID, m.MissionType, m.Description,
+			m.TargetValue, m.CurrentValue, m.XPReward, m.ExpiresAt, m.CreatedAt)
+	}
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to create missions: %w", err)
+	}
+
+	return nil
+}
+
+// AdvanceMissions adds progress, keyed by mission type, to the pet's open
+// missions for the game type and completes those that reach their target.
+// It returns the missions it completed.
+func (r *GamificationRepository) AdvanceMissions(ctx context.Context, petID uuid.UUID, gameTypeID string, progress map[models.MissionType]int, now time.Time) ([]*models.Mission, error) {
+	amounts, err := json.Marshal(progress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mission progress: %w", err)
+	}
+
+	query := `
+		UPDATE missions
+		SET current_value = LEAST(target_value, current_value + ($3::jsonb->>mission_type)::int),
+		    completed_at = CASE WHEN current_value + ($3::jsonb->>mission_type)::int >= target_value THEN $4 END
+		WHERE pet_id = $1 AND game_type_id = $2 AND completed_at IS NULL AND expires_at > $4
+		  AND $3::jsonb ? mission_type
+		RETURNING ` + missionColumns + `
+	`
+
+	rows, err := r.db.Query(ctx, query, petID, gameTypeID, amounts, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to advance missions: %w", err)
+	}
+	defer rows.Close()
+
+	missions, err := collectMissions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var completed []*models.Mission
+	for _, m := range missions {
+		if m.CompletedAt != nil {
+			completed = append(completed, m)
+		}
+	}
+	return completed, nil
+}
@@ -0,0 +1,414 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var ErrHealthRecordNotFound = errors.New("health record not found")
+
+// HealthRepository stores a pet's health journal. Every query is scoped by
+// pet so a record can only be reached through the pet it belongs to.
+type HealthRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHealthRepository(db *pgxpool.Pool) *HealthRepository {
+	return &HealthRepository{db: db}
+}
+
+// Weights
+
+func (r *HealthRepository) CreateWeight(ctx context.Context, entry *models.WeightEntry) error {
+	query := `
+		INSERT INTO pet_weights (id, pet_id, weight_kg, measured_at, notes, recorded_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		entry.ID,
+		entry.PetID,
+		entry.WeightKg,
+		entry.MeasuredAt,
+		entry.Notes,
+		entry.RecordedBy,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create weight entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HealthRepository) ListWeights(ctx context.Context, petID uuid.UUID) ([]*models.WeightEntry, error) {
+	query := `
+		SELECT id, pet_id, weight_kg, measured_at, notes, recorded_by, created_at
+		FROM pet_weights
+		WHERE pet_id = $1
+		ORDER BY measured_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list weights: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.WeightEntry
+	for rows.Next() {
+		var e models.WeightEntry
+		if err := rows.Scan(&e.ID, &e.PetID, &e.WeightKg, &e.MeasuredAt, &e.Notes, &e.RecordedBy, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan weight entry: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *HealthRepository) UpdateWeight(ctx context.Context, entry *models.WeightEntry) error {
+	query := `
+		UPDATE pet_weights
+		SET weight_kg = $3, measured_at = $4, notes = $5
+		WHERE id = $1 AND pet_id = $2
+		RETURNING recorded_by, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query, entry.ID, entry.PetID, entry.WeightKg, entry.MeasuredAt, entry.Notes).
+		Scan(&entry.RecordedBy, &entry.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrHealthRecordNotFound
+		}
+		return fmt.Errorf("failed to update weight entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HealthRepository) DeleteWeight(ctx context.Context, petID, id uuid.UUID) error {
+	return r.delete(ctx, "pet_weights", petID, id)
+}
+
+// Vet visits
+
+const vetVisitColumns = `id, pet_id, visited_at, clinic, vet_name, reason, notes, recorded_by, created_at, updated_at`
+
+func (r *HealthRepository) CreateVetVisit(ctx context.Context, visit *models.VetVisit) error {
+	query := `
+		INSERT INTO vet_visits (` + vetVisitColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		visit.ID,
+		visit.PetID,
+		visit.VisitedAt,
+		visit.Clinic,
+		visit.VetName,
+		visit.Reason,
+		visit.Notes,
+		visit.RecordedBy,
+		visit.CreatedAt,
+		visit.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create vet visit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HealthRepository) GetVetVisit(ctx context.Context, petID, id uuid.UUID) (*models.VetVisit, error) {
+	query := `SELECT ` + vetVisitColumns + ` FROM vet_visits WHERE id = $1 AND pet_id = $2`
+	return scanVetVisit(r.db.QueryRow(ctx, query, id, petID))
+}
+
+func (r *HealthRepository) ListVetVisits(ctx context.Context, petID uuid.UUID) ([]*models.VetVisit, error) {
+	query := `SELECT ` + vetVisitColumns + ` FROM vet_visits WHERE pet_id = $1 ORDER BY visited_at DESC`
+
+	rows, err := r.db.Query(ctx, query, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vet visits: %w", err)
+	}
+	defer rows.Close()
+
+	var visits []*models.VetVisit
+	for rows.Next() {
+		visit, err := scanVetVisit(rows)
+		if err != nil {
+			return nil, err
+		}
+		visits = append(visits, visit)
+	}
+
+	return visits, rows.Err()
+}
+
+func (r *HealthRepository) UpdateVetVisit(ctx context.Context, visit *models.VetVisit) error {
+	query := `
+		UPDATE vet_visits
+		SET visited_at = $3, clinic = $4, vet_name = $5, reason = $6, notes = $7, updated_at = $8
+		WHERE id = $1 AND pet_id = $2
+		RETURNING recorded_by, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		visit.ID,
+		visit.PetID,
+		visit.VisitedAt,
+		visit.Clinic,
+		visit.VetName,
+		visit.Reason,
+		visit.Notes,
+		visit.UpdatedAt,
+	).Scan(&visit.RecordedBy, &visit.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrHealthRecordNotFound
+		}
+		return fmt.Errorf("failed to update vet visit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HealthRepository) DeleteVetVisit(ctx context.Context, petID, id uuid.UUID) error {
+	return r.delete(ctx, "vet_visits", petID, id)
+}
+
+func scanVetVisit(row pgx.Row) (*models.VetVisit, error) {
+	var v models.VetVisit
+	err := row.Scan(&v.ID, &v.PetID, &v.VisitedAt, &v.Clinic, &v.VetName, &v.Reason, &v.Notes, &v.RecordedBy, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrHealthRecordNotFound
+		}
+		return nil, fmt.Errorf("failed to scan vet visit: %w", err)
+	}
+	return &v, nil
+}
+
+// Vaccinations
+
+const vaccinationColumns = `id, pet_id, name, administered_at, due_at, clinic, batch_number, notes, recorded_by, created_at, updated_at`
+
+func (r *HealthRepository) CreateVaccination(ctx context.Context, v *models.Vaccination) error {
+	query := `
+		INSERT INTO vaccinations (` + vaccinationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		v.ID,
+		v.PetID,
+		v.Name,
+		v.AdministeredAt,
+		v.DueAt,
+		v.Clinic,
+		v.BatchNumber,
+		v.Notes,
+		v.RecordedBy,
+		v.CreatedAt,
+		v.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create vaccination: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HealthRepository) GetVaccination(ctx context.Context, petID, id uuid.UUID) (*models.Vaccination, error) {
+	query := `SELECT ` + vaccinationColumns + ` FROM vaccinations WHERE id = $1 AND pet_id = $2`
+	return scanVaccination(r.db.QueryRow(ctx, query, id, petID))
+}
+
+func (r *HealthRepository) ListVaccinations(ctx context.Context, petID uuid.UUID) ([]*models.Vaccination, error) {
+	query := `SELECT ` + vaccinationColumns + ` FROM vaccinations WHERE pet_id = $1 ORDER BY administered_at DESC`
+
+	rows, err := r.db.Query(ctx, query, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vaccinations: %w", err)
+	}
+	defer rows.Close()
+
+	var vaccinations []*models.Vaccination
+	for rows.Next() {
+		v, err := scanVaccination(rows)
+		if err != nil {
+			return nil, err
+		}
+		vaccinations = append(vaccinations, v)
+	}
+
+	return vaccinations, rows.Err()
+}
+
+func (r *HealthRepository) UpdateVaccination(ctx context.Context, v *models.Vaccination) error {
+	query := `
+		UPDATE vaccinations
+		SET name = $3, administered_at = $4, due_at = $5, clinic = $6, batch_number = $7, notes = $8, updated_at = $9
+		WHERE id = $1 AND pet_id = $2
+		RETURNING recorded_by, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		v.ID,
+		v.PetID,
+		v.Name,
+		v.AdministeredAt,
+		v.DueAt,
+		v.Clinic,
+		v.BatchNumber,
+		v.Notes,
+		v.UpdatedAt,
+	).Scan(&v.RecordedBy, &v.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrHealthRecordNotFound
+		}
+		return fmt.Errorf("failed to update vaccination: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HealthRepository) DeleteVaccination(ctx context.Context, petID, id uuid.UUID) error {
+	return r.delete(ctx, "vaccinations", petID, id)
+}
+
+func scanVaccination(row pgx.Row) (*models.Vaccination, error) {
+	var v models.Vaccination
+	err := row.Scan(&v.ID, &v.PetID, &v.Name, &v.AdministeredAt, &v.DueAt, &v.Clinic, &v.BatchNumber, &v.Notes, &v.RecordedBy, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrHealthRecordNotFound
+		}
+		return nil, fmt.Errorf("failed to scan vaccination: %w", err)
+	}
+	return &v, nil
+}
+
+// Medications
+
+const medicationColumns = `id, pet_id, name, dosage, interval_hours, start_date, end_date, notes, recorded_by, created_at, updated_at`
+
+func (r *HealthRepository) CreateMedication(ctx context.Context, m *models.Medication) error {
+	query := `
+		INSERT INTO medications (` + medicationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		m.ID,
+		m.PetID,
+		m.Name,
+		m.Dosage,
+		m.IntervalHours,
+		m.StartDate,
+		m.EndDate,
+		m.Notes,
+		m.RecordedBy,
+		m.CreatedAt,
+		m.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create medication: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HealthRepository) GetMedication(ctx context.Context, petID, id uuid.UUID) (*models.Medication, error) {
+	query := `SELECT ` + medicationColumns + ` FROM medications WHERE id = $1 AND pet_id = $2`
+	return scanMedication(r.db.QueryRow(ctx, query, id, petID))
+}
+
+func (r *HealthRepository) ListMedications(ctx context.Context, petID uuid.UUID) ([]*models.Medication, error) {
+	query := `SELECT ` + medicationColumns + ` FROM medications WHERE pet_id = $1 ORDER BY start_date DESC`
+
+	rows, err := r.db.Query(ctx, query, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list medications: %w", err)
+	}
+	defer rows.Close()
+
+	var medications []*models.Medication
+	for rows.Next() {
+		m, err := scanMedication(rows)
+		if err != nil {
+			return nil, err
+		}
+		medications = append(medications, m)
+	}
+
+	return medications, rows.Err()
+}
+
+func (r *HealthRepository) UpdateMedication(ctx context.Context, m *models.Medication) error {
+	query := `
+		UPDATE medications
+		SET name = $3, dosage = $4, interval_hours = $5, start_date = $6, end_date = $7, notes = $8, updated_at = $9
+		WHERE id = $1 AND pet_id = $2
+		RETURNING recorded_by, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		m.ID,
+		m.PetID,
+		m.Name,
+		m.Dosage,
+		m.IntervalHours,
+		m.StartDate,
+		m.EndDate,
+		m.Notes,
+		m.UpdatedAt,
+	).Scan(&m.RecordedBy, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrHealthRecordNotFound
+		}
+		return fmt.Errorf("failed to update medication: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HealthRepository) DeleteMedication(ctx context.Context, petID, id uuid.UUID) error {
+	return r.delete(ctx, "medications", petID, id)
+}
+
+func scanMedication(row pgx.Row) (*models.Medication, error) {
+	var m models.Medication
+	err := row.Scan(&m.ID, &m.PetID, &m.Name, &m.Dosage, &m.IntervalHours, &m.StartDate, &m.EndDate, &m.Notes, &m.RecordedBy, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrHealthRecordNotFound
+		}
+		return nil, fmt.Errorf("failed to scan medication: %w", err)
+	}
+	return &m, nil
+}
+
+// delete removes a record from one of the health tables. table is always a
+// constant from this file, never user input.
+func (r *HealthRepository) delete(ctx context.Context, table string, petID, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM `+table+` WHERE id = $1 AND pet_id = $2`, id, petID)
+	if err != nil {
+		return fmt.Errorf("failed to delete from %s: %w", table, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrHealthRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+type LeaderboardRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLeaderboardRepository(db *pgxpool.Pool) *LeaderboardRepository {
+	return &LeaderboardRepository{db: db}
+}
+
+// AddScores adds an activity's scores to the pet's leaderboards for every
+// period, starting on the given dates. Cumulative metrics add up; the
+// others keep the best value.
+func (r *LeaderboardRepository) AddScores(ctx context.Context, petID uuid.UUID, periodStarts map[models.LeaderboardPeriod]time.Time, scores []models.LeaderboardScore) error {
+	var metrics, periods []string
+	var starts []time.Time
+	var values []float64
+	for period, start := range periodStarts {
+		for _, score := range scores {
+			metrics = append(metrics, string(score.Metric))
+			periods = append(periods, string(period))
+			starts = append(starts, start)
+			values = append(values, score.Value)
+		}
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	var best []string
+	for _, score := range scores {
+		if !score.Metric.Cumulative() {
+			best = append(best, string(score.Metric))
+		}
+	}
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO leaderboard_scores (metric, period, period_start, pet_id, value, updated_at)
+		SELECT s.metric, s.period, s.period_start, $1, s.value, NOW()
+		FROM unnest($2::text[], $3::text[], $4::date[], $5::float8[]) AS s(metric, period, period_start, value)
+		ON CONFLICT (metric, period, period_start, pet_id) DO UPDATE
+		SET value = CASE
+		        WHEN leaderboard_scores.metric = ANY($6::text[]) THEN GREATEST(leaderboard_scores.value, EXCLUDED.value)
+		        ELSE leaderboard_scores.value + EXCLUDED.value
+		    END,
+		    updated_at = NOW()
+	`, petID, metrics, periods, starts, values, best)
+	if err != nil {
+		return fmt.Errorf("failed to add leaderboard scores: %w", err)
+	}
+	return nil
+}
+
+// DeletePeriodsBefore removes scores of periods that started before the
+// given date. Only the current period of a leaderboard is ever read.
+func (r *LeaderboardRepository) DeletePeriodsBefore(ctx context.Context, period models.LeaderboardPeriod, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM leaderboard_scores WHERE period = $1 AND period_start < $2
+	`, period, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete leaderboard scores: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// GetUserArea returns the area the user walks in, or nil before their first
+// walk with a route.
+func (r *LeaderboardRepository) GetUserArea(ctx context.Context, userID uuid.UUID) (*string, error) {
+	var area *string
+	err := r.db.QueryRow(ctx, `SELECT area FROM users WHERE id = $1`, userID).Scan(&area)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user area: %w", err)
+	}
+	return area, nil
+}
+
+func (r *LeaderboardRepository) SetUserArea(ctx context.Context, userID uuid.UUID, area string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE users SET area = $2 WHERE id = $1 AND area IS DISTINCT FROM $2
+	`, userID, area)
+	if err != nil {
+		return fmt.Errorf("failed to set user area: %w", err)
+	}
+	return nil
+}
+
+// Leaderboard scopes, limiting ranked pets by their owner (u). The viewer
+// ($1) always sees their own pets. Strangers only appear to those who
+// haven't blocked them, and only if they made their activity public;
+// friends appear unless they keep it private.
+const (
+	publicOwner = `(
+		    COALESCE(u.preferences->'privacy'->>'activity_visibility', 'friends') = 'public'
+		    AND NOT EXISTS (
+		        SELECT 1 FROM user_blocks b
+		        WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $1)
+		    )
+		)`
+	friendOwner = `(
+		    EXISTS (SELECT 1 FROM friendships f WHERE f.user_id = $1 AND f.friend_id = u.id)
+		    AND COALESCE(u.preferences->'privacy'->>'activity_visibility', 'friends') <> 'private'
+		)`
+)
+
+// Rank returns the top of a leaderboard and, separately, the viewer's own
+// pets wherever they place. periodStart is the first day of the viewer's
+// current period; area is only used by the area scope. Pets with the same
+// value share a rank, and only active pets scoring above zero are ranked.
+func (r *LeaderboardRepository) Rank(
+	ctx context.Context,
+	viewerID uuid.UUID,
+	metric models.LeaderboardMetric,
+	period models.LeaderboardPeriod,
+	periodStart time.Time,
+	scope models.LeaderboardScope,
+	area string,
+	limit int,
+) ([]*models.LeaderboardEntry, []*models.LeaderboardEntry, error) {
+	args := []any{viewerID, metric, period, periodStart, limit}
+	var filter string
+	switch scope {
+	case models.LeaderboardScopeFriends:
+		filter = `u.id = $1 OR ` + friendOwner
+	case models.LeaderboardScopeArea:
+		filter = `u.area = $6 AND (u.id = $1 OR ` + publicOwner + `)`
+		args = append(args, area)
+	default:
+		filter = `u.id = $1 OR ` + publicOwner
+	}
+
+	rows, err := r.db.Query(ctx, `
+		WITH board AS (
+		    SELECT s.value, p.id AS pet_id, p.name AS pet_name, p.avatar_url AS pet_avatar,
+		           u.id AS owner_id, u.name AS owner_name, u.avatar_url AS owner_avatar,
+		           RANK() OVER (ORDER BY s.value DESC) AS rank,
+		           ROW_NUMBER() OVER (ORDER BY s.value DESC, p.name, p.id) AS position
+		    FROM leaderboard_scores s
+		    JOIN pets p ON p.id = s.pet_id AND p.deleted_at IS NULL AND p.status = 'active'
+		    JOIN users u ON u.id = p.user_id
+		    WHERE s.metric = $2 AND s.period = $3 AND s.period_start = $4 AND s.value > 0
+		      AND (`+filter+`)
+		)
+		SELECT rank, value, pet_id, pet_name, pet_avatar, owner_id, owner_name, owner_avatar, position <= $5
+		FROM board
+		WHERE position <= $5 OR owner_id = $1
+		ORDER BY position
+	`, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to rank leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var top, mine []*models.LeaderboardEntry
+	for rows.Next() {
+		entry := &models.LeaderboardEntry{Pet: &models.FeedPet{}, Owner: &models.UserProfile{}}
+		var inTop bool
+		err := rows.Scan(
+			&entry.Rank,
+			&entry.Value,
+			&entry.Pet.ID,
+			&entry.Pet.Name,
+			&entry.Pet.AvatarURL,
+			&entry.Owner.ID,
+			&entry.Owner.Name,
+			&entry.Owner.AvatarURL,
+			&inTop,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan leaderboard entry: %w", err)
+		}
+		if inTop {
+			top = append(top, entry)
+		}
+		if entry.Owner.ID == viewerID {
+			mine = append(mine, entry)
+		}
+	}
+
+	return top, mine, rows.Err()
+}
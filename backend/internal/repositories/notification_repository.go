@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var ErrDeviceNotFound = errors.New("device not found")
+
+// NotificationRepository stores registered push devices and the queue of
+// outgoing pushes.
+type NotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Devices
+
+// UpsertDevice registers a token for a session. A token that is already
+// known moves to the new session, e.g. when another account signs in on the
+// same phone.
+func (r *NotificationRepository) UpsertDevice(ctx context.Context, device *models.DeviceToken) error {
+	query := `
+		INSERT INTO device_tokens (id, user_id, session_id, provider, token, device_name, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (provider, token) DO UPDATE
+		SET user_id = EXCLUDED.user_id, session_id = EXCLUDED.session_id,
+		    device_name = EXCLUDED.device_name, last_seen_at = EXCLUDED.last_seen_at
+		RETURNING id, created_at, last_seen_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		device.ID,
+		device.UserID,
+		device.SessionID,
+		device.Provider,
+		device.Token,
+		device.DeviceName,
+		device.LastSeenAt,
+	).Scan(&device.ID, &device.CreatedAt, &device.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to register device: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NotificationRepository) ListDevices(ctx context.Context, userID uuid.UUID) ([]*models.DeviceToken, error) {
+	query := `
+		SELECT id, user_id, session_id, provider, token, device_name, created_at, last_seen_at
+		FROM device_tokens
+		WHERE user_id = $1
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*models.DeviceToken
+	for rows.Next() {
+		var d models.DeviceToken
+		if err := rows.Scan(&d.ID, &d.UserID, &d.SessionID, &d.Provider, &d.Token, &d.DeviceName, &d.CreatedAt, &d.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		devices = append(devices, &d)
+	}
+
+	return devices, rows.Err()
+}
+
+func (r *NotificationRepository) DeleteDevice(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM device_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrDeviceNotFound
+	}
+
+	return nil
+}
+
+// PruneDevice removes a token the provider reported as invalid, along with
+// its queued pushes.
+func (r *NotificationRepository) PruneDevice(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM device_tokens WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to prune device: %w", err)
+	}
+	return nil
+}
+
+// DeleteStaleDevices removes tokens not seen since before and tokens whose
+// session has expired.
+func (r *NotificationRepository) DeleteStaleDevices(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM device_tokens d
+		USING refresh_tokens s
+		WHERE s.id = d.session_id AND (d.last_seen_at < $1 OR s.expires_at < NOW())
+	`
+
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale devices: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Deliveries
+
+// EnqueueForUser queues the push for every device of the user and returns
+// how many were queued.
+func (r *NotificationRepository) EnqueueForUser(ctx context.Context, userID uuid.UUID, delivery *models.PushDelivery) (int64, error) {
+	data, err := json.Marshal(delivery.Data)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO push_deliveries (device_token_id, category, title, body, data, status, next_attempt_at, created_at)
+		SELECT id, $2, $3, $4, $5, $6, $7, $8
+		FROM device_tokens
+		WHERE user_id = $1
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		userID,
+		delivery.Category,
+		delivery.Title,
+		delivery.Body,
+		data,
+		models.PushDeliveryPending,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue push: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// ClaimDueDeliveries marks up to limit pending pushes as claimed and returns
+// them with their device token. Claims older than claimTTL are taken over.
+func (r *NotificationRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, claimTTL time.Duration, limit int) ([]*models.PushDelivery, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE push_deliveries
+			SET claimed_at = $1
+			WHERE id IN (
+				SELECT id FROM push_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= $1 AND (claimed_at IS NULL OR claimed_at < $2)
+				ORDER BY next_attempt_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, device_token_id, category, title, body, data, status, attempts, last_error, next_attempt_at, sent_at, created_at
+		)
+		SELECT c.id, c.device_token_id, d.provider, d.token, c.category, c.title, c.body, c.data,
+		       c.status, c.attempts, c.last_error, c.next_attempt_at, c.sent_at, c.created_at
+		FROM claimed c
+		JOIN device_tokens d ON d.id = c.device_token_id
+	`
+
+	rows, err := r.db.Query(ctx, query, now, now.Add(-claimTTL), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pushes: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.PushDelivery
+	for rows.Next() {
+		var d models.PushDelivery
+		var data []byte
+		err := rows.Scan(
+			&d.ID,
+			&d.DeviceTokenID,
+			&d.Provider,
+			&d.Token,
+			&d.Category,
+			&d.Title,
+			&d.Body,
+			&data,
+			&d.Status,
+			&d.Attempts,
+			&d.LastError,
+			&d.NextAttemptAt,
+			&d.SentAt,
+			&d.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan push: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &d.Data); err != nil {
+				return nil, fmt.Errorf("failed to decode push data: %w", err)
+			}
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// UpdateDelivery stores the outcome of an attempt and releases the claim.
+func (r *NotificationRepository) UpdateDelivery(ctx context.Context, delivery *models.PushDelivery) error {
+	query := `
+		UPDATE push_deliveries
+		SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, sent_at = $6, claimed_at = NULL
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.SentAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update push: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFinishedDeliveries drops sent and failed pushes created before
+// before.
+func (r *NotificationRepository) DeleteFinishedDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM push_deliveries WHERE status <> 'pending' AND created_at < $1`
+
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished pushes: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
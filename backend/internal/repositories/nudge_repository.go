@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+type NudgeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNudgeRepository(db *pgxpool.Pool) *NudgeRepository {
+	return &NudgeRepository{db: db}
+}
+
+// ListCandidates returns active pets that may need a nudge: pets with a
+// streak whose last activity was after streakSince, and pets idle since
+// before idleSince that aren't bored yet. Results are ordered by ID and
+// start after afterID so callers can page through them.
+func (r *NudgeRepository) ListCandidates(ctx context.Context, streakSince, idleSince time.Time, minStreak int, afterID uuid.UUID, limit int) ([]*models.Pet, error) {
+	query := `
+		SELECT id, user_id, pet_type_id, name, mood, streak_days, last_activity_at
+		FROM pets
+		WHERE id > $1 AND status = 'active' AND deleted_at IS NULL AND last_activity_at IS NOT NULL
+		  AND ((streak_days >= $2 AND last_activity_at >= $3)
+		       OR (mood <> 'bored' AND last_activity_at < $4))
+		ORDER BY id
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, minStreak, streakSince, idleSince, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nudge candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var pets []*models.Pet
+	for rows.Next() {
+		var pet models.Pet
+		if err := rows.Scan(
+			&pet.ID,
+			&pet.UserID,
+			&pet.PetTypeID,
+			&pet.Name,
+			&pet.Mood,
+			&pet.StreakDays,
+			&pet.LastActivityAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan nudge candidate: %w", err)
+		}
+		pets = append(pets, &pet)
+	}
+
+	return pets, rows.Err()
+}
+
+// History counts the nudges sent to a user since the given time.
+func (r *NudgeRepository) History(ctx context.Context, userID uuid.UUID, since time.Time) (models.NudgeHistory, error) {
+	query := `SELECT COUNT(*), MAX(sent_at) FROM nudges WHERE user_id = $1 AND sent_at >= $2`
+
+	var history models.NudgeHistory
+	if err := r.db.QueryRow(ctx, query, userID, since).Scan(&history.Count, &history.LastSentAt); err != nil {
+		return history, fmt.Errorf("failed to get nudge history: %w", err)
+	}
+
+	return history, nil
+}
+
+// ListByUser returns every nudge sent to the user, newest first.
+func (r *NudgeRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Nudge, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, pet_id, kind, dedupe_key, mood, streak_days, sent_at, activity_id, converted_at
+		FROM nudges
+		WHERE user_id = $1
+		ORDER BY sent_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nudges: %w", err)
+	}
+	defer rows.Close()
+
+	var nudges []*models.Nudge
+	for rows.Next() {
+		var nudge models.Nudge
+		if err := rows.Scan(
+			&nudge.ID,
+			&nudge.UserID,
+			&nudge.PetID,
+			&nudge.Kind,
+			&nudge.DedupeKey,
+			&nudge.Mood,
+			&nudge.StreakDays,
+			&nudge.SentAt,
+			&nudge.ActivityID,
+			&nudge.ConvertedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan nudge: %w", err)
+		}
+		nudges = append(nudges, &nudge)
+	}
+
+	return nudges, rows.Err()
+}
+
+// Create records a nudge. It reports false when the user was already nudged
+// about the same event, in which case nothing should be sent.
+func (r *NudgeRepository) Create(ctx context.Context, nudge *models.Nudge) (bool, error) {
+	query := `
+		INSERT INTO nudges (id, user_id, pet_id, kind, dedupe_key, mood, streak_days, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		nudge.ID,
+		nudge.UserID,
+		nudge.PetID,
+		nudge.Kind,
+		nudge.DedupeKey,
+		nudge.Mood,
+		nudge.StreakDays,
+		nudge.SentAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create nudge: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// Delete removes a nudge that could not be sent.
+func (r *NudgeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM nudges WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete nudge: %w", err)
+	}
+	return nil
+}
+
+// AttributeActivities links nudges sent since the given time to the first
+// activity the nudged user started for the pet within window of the nudge.
+func (r *NudgeRepository) AttributeActivities(ctx context.Context, since time.Time, window time.Duration) (int64, error) {
+	query := `
+		WITH matches AS (
+			SELECT DISTINCT ON (n.id) n.id AS nudge_id, a.id AS activity_id, a.started_at
+			FROM nudges n
+			JOIN activities a ON a.pet_id = n.pet_id
+			    AND (a.performed_by = n.user_id OR a.performed_by IS NULL)
+			    AND a.started_at >= n.sent_at
+			    AND a.started_at < n.sent_at + $2 * INTERVAL '1 second'
+			WHERE n.converted_at IS NULL AND n.sent_at >= $1
+			ORDER BY n.id, a.started_at
+		)
+		UPDATE nudges
+		SET activity_id = matches.activity_id, converted_at = matches.started_at
+		FROM matches
+		WHERE nudges.id = matches.nudge_id
+	`
+
+	result, err := r.db.Exec(ctx, query, since, window.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to attribute activities to nudges: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
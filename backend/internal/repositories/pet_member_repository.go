@@ -0,0 +1,294 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var (
+	ErrMemberNotFound      = errors.New("pet member not found")
+	ErrMemberAlreadyExists = errors.New("user is already a member of this pet")
+	ErrInvitationNotFound  = errors.New("invitation not found")
+)
+
+type PetMemberRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPetMemberRepository(db *pgxpool.Pool) *PetMemberRepository {
+	return &PetMemberRepository{db: db}
+}
+
+// Members
+
+func (r *PetMemberRepository) GetMember(ctx context.Context, petID, userID uuid.UUID) (*models.PetMember, error) {
+	query := `
+		SELECT pet_id, user_id, role, invited_by, created_at
+		FROM pet_members
+		WHERE pet_id = $1 AND user_id = $2
+	`
+
+	var member models.PetMember
+	err := r.db.QueryRow(ctx, query, petID, userID).Scan(
+		&member.PetID,
+		&member.UserID,
+		&member.Role,
+		&member.InvitedBy,
+		&member.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get pet member: %w", err)
+	}
+
+	return &member, nil
+}
+
+func (r *PetMemberRepository) ListMembers(ctx context.Context, petID uuid.UUID) ([]*models.PetMember, error) {
+	query := `
+		SELECT pm.pet_id, pm.user_id, pm.role, pm.invited_by, pm.created_at,
+		       u.id, u.email, u.name, u.avatar_url
+		FROM pet_members pm
+		JOIN users u ON pm.user_id = u.id
+		WHERE pm.pet_id = $1
+		ORDER BY pm.created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pet members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*models.PetMember
+	for rows.Next() {
+		var member models.PetMember
+		var user models.User
+
+		err := rows.Scan(
+			&member.PetID,
+			&member.UserID,
+			&member.Role,
+			&member.InvitedBy,
+			&member.CreatedAt,
+			&user.ID,
+			&user.Email,
+			&user.Name,
+			&user.AvatarURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pet member: %w", err)
+		}
+
+		member.User = &user
+		members = append(members, &member)
+	}
+
+	return members, nil
+}
+
+func (r *PetMemberRepository) RemoveMember(ctx context.Context, petID, userID uuid.UUID) error {
+	query := `DELETE FROM pet_members WHERE pet_id = $1 AND user_id = $2 AND role <> $3`
+
+	result, err := r.db.Exec(ctx, query, petID, userID, models.PetRoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to remove pet member: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
+
+// Invitations
+
+func (r *PetMemberRepository) CreateInvitation(ctx context.Context, invitation *models.PetInvitation) error {
+	query := `
+		INSERT INTO pet_invitations (id, pet_id, role, email, code, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		invitation.ID,
+		invitation.PetID,
+		invitation.Role,
+		invitation.Email,
+		invitation.Code,
+		invitation.InvitedBy,
+		invitation.ExpiresAt,
+		invitation.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PetMemberRepository) GetInvitation(ctx context.Context, id uuid.UUID) (*models.PetInvitation, error) {
+	query := `
+		SELECT id, pet_id, role, email, code, invited_by, expires_at, accepted_by, accepted_at, revoked_at, created_at
+		FROM pet_invitations
+		WHERE id = $1
+	`
+
+	return scanInvitation(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *PetMemberRepository) GetInvitationByCode(ctx context.Context, code string) (*models.PetInvitation, error) {
+	query := `
+		SELECT id, pet_id, role, email, code, invited_by, expires_at, accepted_by, accepted_at, revoked_at, created_at
+		FROM pet_invitations
+		WHERE code = $1
+	`
+
+	return scanInvitation(r.db.QueryRow(ctx, query, code))
+}
+
+// ListPendingInvitations returns open invitations for a pet.
+func (r *PetMemberRepository) ListPendingInvitations(ctx context.Context, petID uuid.UUID) ([]*models.PetInvitation, error) {
+	query := `
+		SELECT id, pet_id, role, email, code, invited_by, expires_at, accepted_by, accepted_at, revoked_at, created_at
+		FROM pet_invitations
+		WHERE pet_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	return r.queryInvitations(ctx, query, petID)
+}
+
+// ListInvitationsForEmail returns open invitations addressed to an email.
+func (r *PetMemberRepository) ListInvitationsForEmail(ctx context.Context, email string) ([]*models.PetInvitation, error) {
+	query := `
+		SELECT id, pet_id, role, email, code, invited_by, expires_at, accepted_by, accepted_at, revoked_at, created_at
+		FROM pet_invitations
+		WHERE LOWER(email) = LOWER($1) AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	return r.queryInvitations(ctx, query, email)
+}
+
+func (r *PetMemberRepository) RevokeInvitation(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE pet_invitations SET revoked_at = NOW() WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvitation marks the invitation used and adds the member. The
+// invitation row is locked so a code can only be redeemed once.
+func (r *PetMemberRepository) AcceptInvitation(ctx context.Context, invitationID, userID uuid.UUID) (*models.PetMember, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	invitation, err := scanInvitation(tx.QueryRow(ctx, `
+		SELECT id, pet_id, role, email, code, invited_by, expires_at, accepted_by, accepted_at, revoked_at, created_at
+		FROM pet_invitations
+		WHERE id = $1
+		FOR UPDATE
+	`, invitationID))
+	if err != nil {
+		return nil, err
+	}
+	if !invitation.IsPending() {
+		return nil, ErrInvitationNotFound
+	}
+
+	now := time.Now()
+	member := &models.PetMember{
+		PetID:     invitation.PetID,
+		UserID:    userID,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy,
+		CreatedAt: now,
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO pet_members (pet_id, user_id, role, invited_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, member.PetID, member.UserID, member.Role, member.InvitedBy, member.CreatedAt)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, ErrMemberAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to add pet member: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE pet_invitations SET accepted_by = $2, accepted_at = $3 WHERE id = $1`, invitation.ID, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return member, nil
+}
+
+func (r *PetMemberRepository) queryInvitations(ctx context.Context, query string, arg interface{}) ([]*models.PetInvitation, error) {
+	rows, err := r.db.Query(ctx, query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*models.PetInvitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+func scanInvitation(row pgx.Row) (*models.PetInvitation, error) {
+	var invitation models.PetInvitation
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.PetID,
+		&invitation.Role,
+		&invitation.Email,
+		&invitation.Code,
+		&invitation.InvitedBy,
+		&invitation.ExpiresAt,
+		&invitation.AcceptedBy,
+		&invitation.AcceptedAt,
+		&invitation.RevokedAt,
+		&invitation.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to scan invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,18 +23,26 @@ func NewPetRepository(db *pgxpool.Pool) *PetRepository {
 	return &PetRepository{db: db}
 }
 
+// Create inserts the pet and makes its user the owner member.
 func (r *PetRepository) Create(ctx context.Context, pet *models.Pet) error {
 	query := `
-		INSERT INTO pets (id, user_id, pet_type_id, name, breed, avatar_url, birth_date, total_xp, level, mood, streak_days, last_activity_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		INSERT INTO pets (id, user_id, pet_type_id, name, breed, breed_id, avatar_url, birth_date, total_xp, level, mood, streak_days, last_activity_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query,
 		pet.ID,
 		pet.UserID,
 		pet.PetTypeID,
 		pet.Name,
 		pet.Breed,
+		pet.BreedID,
 		pet.AvatarURL,
 		pet.BirthDate,
 		pet.TotalXP,
@@ -48,112 +57,197 @@ func (r *PetRepository) Create(ctx context.Context, pet *models.Pet) error {
 		return fmt.Errorf("failed to create pet: %w", err)
 	}
 
+	_, err = tx.Exec(ctx, `
+		INSERT INTO pet_members (pet_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, pet.ID, pet.UserID, models.PetRoleOwner, pet.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add pet owner: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
+// GetByID returns a pet unless it has been deleted.
 func (r *PetRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Pet, error) {
+	return r.get(ctx, id, false)
+}
+
+// GetDeletedByID returns a deleted pet that hasn't been purged yet.
+func (r *PetRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Pet, error) {
+	return r.get(ctx, id, true)
+}
+
+func (r *PetRepository) get(ctx context.Context, id uuid.UUID, deleted bool) (*models.Pet, error) {
 	query := `
-		SELECT p.id, p.user_id, p.pet_type_id, p.name, p.breed, p.avatar_url, p.birth_date,
-		       p.total_xp, p.level, p.mood, p.streak_days, p.last_activity_at, p.created_at, p.updated_at,
-		       pt.id, pt.name, pt.icon, pt.config
-		FROM pets p
-		JOIN pet_types pt ON p.pet_type_id = pt.id
-		WHERE p.id = $1
+		SELECT ` + petColumns + `
+		FROM ` + petTables + `
+		WHERE p.id = $1 AND (p.deleted_at IS NOT NULL) = $2
 	`
 
+	pet, err := scanPet(r.db.QueryRow(ctx, query, id, deleted))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPetNotFound
+		}
+		return nil, fmt.Errorf("failed to get pet: %w", err)
+	}
+
+	return pet, nil
+}
+
+// GetByUserID returns every pet the user is a member of, with their role.
+// A nil status returns pets in any status; deleted pets are left out.
+func (r *PetRepository) GetByUserID(ctx context.Context, userID uuid.UUID, status *models.PetStatus) ([]*models.Pet, error) {
+	query := `
+		SELECT ` + petColumns + `, pm.role
+		FROM ` + petTables + `
+		JOIN pet_members pm ON pm.pet_id = p.id
+		WHERE pm.user_id = $1 AND p.deleted_at IS NULL AND ($2::varchar IS NULL OR p.status = $2)
+		ORDER BY p.created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pets: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPets(rows)
+}
+
+// ListDeleted returns the pets the user owns that are in their restore
+// window, most recently deleted first.
+func (r *PetRepository) ListDeleted(ctx context.Context, userID uuid.UUID) ([]*models.Pet, error) {
+	query := `
+		SELECT ` + petColumns + `, pm.role
+		FROM ` + petTables + `
+		JOIN pet_members pm ON pm.pet_id = p.id
+		WHERE pm.user_id = $1 AND pm.role = $2 AND p.deleted_at IS NOT NULL
+		ORDER BY p.deleted_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, models.PetRoleOwner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted pets: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPets(rows)
+}
+
+// petColumns and petTables select a pet with its type, catalog breed,
+// latest weight and loadout, in the order scanPet reads them.
+const petColumns = `p.id, p.user_id, p.pet_type_id, p.name, p.breed, p.breed_id, p.avatar_url, p.avatar_thumbnails, p.birth_date,
+		       p.total_xp, p.level, p.mood, p.streak_days, p.last_activity_at,
+		       p.status, p.status_since, p.passed_away_on, p.deleted_at, p.created_at, p.updated_at,
+		       pt.id, pt.name, pt.icon, pt.config,
+		       b.pet_type_id, b.name, b.size_class, b.energy_level, b.daily_exercise_minutes,
+		       b.adult_weight_min_kg, b.adult_weight_max_kg, b.senior_age_years, w.weight_kg,
+		       lo.items`
+
+const petTables = `pets p
+		JOIN pet_types pt ON p.pet_type_id = pt.id
+		LEFT JOIN breeds b ON b.id = p.breed_id
+		LEFT JOIN LATERAL (
+			SELECT weight_kg FROM pet_weights WHERE pet_id = p.id ORDER BY measured_at DESC LIMIT 1
+		) w ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT jsonb_object_agg(pl.slot, to_jsonb(ci)) AS items
+			FROM pet_loadouts pl JOIN cosmetic_items ci ON ci.id = pl.item_id
+			WHERE pl.pet_id = p.id
+		) lo ON TRUE`
+
+// scanPet reads a row selected with petColumns, followed by extra, and
+// derives the pet's age and daily target.
+func scanPet(row pgx.Row, extra ...any) (*models.Pet, error) {
 	var pet models.Pet
 	var petType models.PetType
+	var breedTypeID, breedName, sizeClass, energyLevel *string
+	var exerciseMinutes *int
+	var breed models.Breed
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	dest := []any{
 		&pet.ID,
 		&pet.UserID,
 		&pet.PetTypeID,
 		&pet.Name,
 		&pet.Breed,
+		&pet.BreedID,
 		&pet.AvatarURL,
+		&pet.Avatars,
 		&pet.BirthDate,
 		&pet.TotalXP,
 		&pet.Level,
 		&pet.Mood,
 		&pet.StreakDays,
 		&pet.LastActivityAt,
+		&pet.Status,
+		&pet.StatusSince,
+		&pet.PassedAwayOn,
+		&pet.DeletedAt,
 		&pet.CreatedAt,
 		&pet.UpdatedAt,
 		&petType.ID,
 		&petType.Name,
 		&petType.Icon,
 		&petType.Config,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrPetNotFound
-		}
-		return nil, fmt.Errorf("failed to get pet: %w", err)
+		&breedTypeID,
+		&breedName,
+		&sizeClass,
+		&energyLevel,
+		&exerciseMinutes,
+		&breed.AdultWeightMinKg,
+		&breed.AdultWeightMaxKg,
+		&breed.SeniorAgeYears,
+		&pet.LatestWeightKg,
+		&pet.Loadout,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
 	}
 
 	pet.PetType = &petType
+	if pet.BreedID != nil && breedName != nil {
+		breed.ID = *pet.BreedID
+		breed.PetTypeID = *breedTypeID
+		breed.Name = *breedName
+		breed.SizeClass = models.SizeClass(*sizeClass)
+		breed.EnergyLevel = models.EnergyLevel(*energyLevel)
+		breed.DailyExerciseMinutes = *exerciseMinutes
+		pet.BreedDetails = &breed
+	}
+	pet.Derive(time.Now())
+
 	return &pet, nil
 }
 
-func (r *PetRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Pet, error) {
-	query := `
-		SELECT p.id, p.user_id, p.pet_type_id, p.name, p.breed, p.avatar_url, p.birth_date,
-		       p.total_xp, p.level, p.mood, p.streak_days, p.last_activity_at, p.created_at, p.updated_at,
-		       pt.id, pt.name, pt.icon, pt.config
-		FROM pets p
-		JOIN pet_types pt ON p.pet_type_id = pt.id
-		WHERE p.user_id = $1
-		ORDER BY p.created_at DESC
-	`
-
-	rows, err := r.db.Query(ctx, query, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get pets: %w", err)
-	}
-	defer rows.Close()
-
+func collectPets(rows pgx.Rows) ([]*models.Pet, error) {
 	var pets []*models.Pet
 	for rows.Next() {
-		var pet models.Pet
-		var petType models.PetType
-
-		err := rows.Scan(
-			&pet.ID,
-			&pet.UserID,
-			&pet.PetTypeID,
-			&pet.Name,
-			&pet.Breed,
-			&pet.AvatarURL,
-			&pet.BirthDate,
-			&pet.TotalXP,
-			&pet.Level,
-			&pet.Mood,
-			&pet.StreakDays,
-			&pet.LastActivityAt,
-			&pet.CreatedAt,
-			&pet.UpdatedAt,
-			&petType.ID,
-			&petType.Name,
-			&petType.Icon,
-			&petType.Config,
-		)
+		var role models.PetRole
+		pet, err := scanPet(rows, &role)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan pet: %w", err)
 		}
 
-		pet.PetType = &petType
-		pets = append(pets, &pet)
+		pet.Role = &role
+		pets = append(pets, pet)
 	}
 
-	return pets, nil
+	return pets, rows.Err()
 }
 
 func (r *PetRepository) Update(ctx context.Context, pet *models.Pet) error {
 	query := `
 		UPDATE pets
-		SET name = $2, breed = $3, avatar_url = $4, birth_date = $5,
-		    total_xp = $6, level = $7, mood = $8, streak_days = $9,
-		    last_activity_at = $10, updated_at = $11
+		SET name = $2, breed = $3, breed_id = $4, avatar_url = $5, birth_date = $6,
+		    total_xp = $7, level = $8, mood = $9, streak_days = $10,
+		    last_activity_at = $11, updated_at = $12
 		WHERE id = $1
 	`
 
@@ -162,6 +256,7 @@ func (r *PetRepository) Update(ctx context.Context, pet *models.Pet) error {
 		pet.ID,
 		pet.Name,
 		pet.Breed,
+		pet.BreedID,
 		pet.AvatarURL,
 		pet.BirthDate,
 		pet.TotalXP,
@@ -182,10 +277,37 @@ func (r *PetRepository) Update(ctx context.Context, pet *models.Pet) error {
 	return nil
 }
 
-func (r *PetRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM pets WHERE id = $1`
+// SetAvatar stores a new uploaded avatar and returns the storage prefix of
+// the one it replaced, if any.
+func (r *PetRepository) SetAvatar(ctx context.Context, petID uuid.UUID, key, avatarURL string, thumbnails models.Thumbnails) (*string, error) {
+	query := `
+		WITH previous AS (
+			SELECT id, avatar_key FROM pets WHERE id = $1 FOR UPDATE
+		)
+		UPDATE pets p
+		SET avatar_key = $2, avatar_url = $3, avatar_thumbnails = $4, updated_at = NOW()
+		FROM previous
+		WHERE p.id = previous.id
+		RETURNING previous.avatar_key
+	`
 
-	result, err := r.db.Exec(ctx, query, id)
+	var previousKey *string
+	err := r.db.QueryRow(ctx, query, petID, key, avatarURL, thumbnails).Scan(&previousKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPetNotFound
+		}
+		return nil, fmt.Errorf("failed to set avatar: %w", err)
+	}
+
+	return previousKey, nil
+}
+
+// SoftDelete hides the pet until it is restored or purged.
+func (r *PetRepository) SoftDelete(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `UPDATE pets SET deleted_at = $2, updated_at = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, id, at)
 	if err != nil {
 		return fmt.Errorf("failed to delete pet: %w", err)
 	}
@@ -197,32 +319,42 @@ func (r *PetRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (r *PetRepository) AddXP(ctx context.Context, petID uuid.UUID, xp int) error {
+func (r *PetRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE pets SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore pet: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrPetNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeleted removes pets deleted before the given time, with their
+// activities and health records.
+func (r *PetRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM pets WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted pets: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *PetRepository) UpdateStatus(ctx context.Context, pet *models.Pet) error {
 	query := `
 		UPDATE pets
-		SET total_xp = total_xp + $2,
-		    level = (
-		        SELECT CASE
-		            WHEN total_xp + $2 >= 8100 THEN 10
-		            WHEN total_xp + $2 >= 6400 THEN 9
-		            WHEN total_xp + $2 >= 4900 THEN 8
-		            WHEN total_xp + $2 >= 3600 THEN 7
-		            WHEN total_xp + $2 >= 2500 THEN 6
-		            WHEN total_xp + $2 >= 1600 THEN 5
-		            WHEN total_xp + $2 >= 900 THEN 4
-		            WHEN total_xp + $2 >= 400 THEN 3
-		            WHEN total_xp + $2 >= 100 THEN 2
-		            ELSE 1
-		        END
-		    ),
-		    last_activity_at = NOW(),
-		    updated_at = NOW()
-		WHERE id = $1
+		SET status = $2, status_since = $3, passed_away_on = $4, updated_at = $5
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.Exec(ctx, query, petID, xp)
+	pet.UpdatedAt = time.Now()
+	result, err := r.db.Exec(ctx, query, pet.ID, pet.Status, pet.StatusSince, pet.PassedAwayOn, pet.UpdatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to add XP: %w", err)
+		return fmt.Errorf("failed to update pet status: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
@@ -232,6 +364,101 @@ func (r *PetRepository) AddXP(ctx context.Context, petID uuid.UUID, xp int) erro
 	return nil
 }
 
+// Timeline returns the moments of a pet's life before the given time,
+// newest first: birth, joining PetTime, activities, health records and
+// passing away.
+func (r *PetRepository) Timeline(ctx context.Context, petID uuid.UUID, before time.Time, limit int) ([]*models.TimelineEvent, error) {
+	query := `
+		SELECT type, occurred_at, record_id, details FROM (
+			SELECT 'born' AS type, birth_date::timestamptz AS occurred_at, NULL::uuid AS record_id, NULL::jsonb AS details
+			FROM pets WHERE id = $1 AND birth_date IS NOT NULL
+			UNION ALL
+			SELECT 'joined', created_at, NULL, NULL FROM pets WHERE id = $1
+			UNION ALL
+			SELECT 'passed_away', passed_away_on::timestamptz, NULL, NULL
+			FROM pets WHERE id = $1 AND status = 'memorial' AND passed_away_on IS NOT NULL
+			UNION ALL
+			SELECT 'activity', started_at, id, jsonb_build_object(
+			           'game_type_id', game_type_id, 'duration_seconds', duration_seconds,
+			           'distance_meters', game_data->'distance_meters', 'xp_earned', xp_earned)
+			FROM activities WHERE pet_id = $1
+			UNION ALL
+			SELECT 'weight', measured_at, id, jsonb_build_object('weight_kg', weight_kg)
+			FROM pet_weights WHERE pet_id = $1
+			UNION ALL
+			SELECT 'vet_visit', visited_at, id, jsonb_build_object('reason', reason, 'clinic', clinic)
+			FROM vet_visits WHERE pet_id = $1
+			UNION ALL
+			SELECT 'vaccination', administered_at, id, jsonb_build_object('name', name)
+			FROM vaccinations WHERE pet_id = $1
+			UNION ALL
+			SELECT 'medication', start_date, id, jsonb_build_object('name', name, 'dosage', dosage)
+			FROM medications WHERE pet_id = $1
+		) events
+		WHERE occurred_at < $2
+		ORDER BY occurred_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, petID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timeline: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.TimelineEvent
+	for rows.Next() {
+		var event models.TimelineEvent
+		if err := rows.Scan(&event.Type, &event.OccurredAt, &event.RecordID, &event.Details); err != nil {
+			return nil, fmt.Errorf("failed to scan timeline event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
+
+// AddXP credits a finished activity and returns the pet's new level on
+// the leveling curve. Levels never go down, even if the curve got steeper.
+// Playing cheers the pet up, so its mood resets to happy.
+func (r *PetRepository) AddXP(ctx context.Context, petID uuid.UUID, xp int, leveling *models.LevelingConfig) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var totalXP, level int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(total_xp, 0) + $2, COALESCE(level, 1) FROM pets WHERE id = $1 FOR UPDATE
+	`, petID, xp).Scan(&totalXP, &level)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrPetNotFound
+		}
+		return 0, fmt.Errorf("failed to add XP: %w", err)
+	}
+	level = max(level, leveling.Level(totalXP))
+
+	_, err = tx.Exec(ctx, `
+		UPDATE pets
+		SET total_xp = $2,
+		    level = $3,
+		    mood = 'happy',
+		    last_activity_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $1
+	`, petID, totalXP, level)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add XP: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return level, nil
+}
+
 func (r *PetRepository) UpdateStreak(ctx context.Context, petID uuid.UUID, streakDays int) error {
 	query := `
 		UPDATE pets
@@ -293,6 +520,16 @@ func (r *PetRepository) GetAllPetTypes(ctx context.Context) ([]*models.PetType,
 	return petTypes, nil
 }
 
+// GetLevelingConfig returns how pets level up.
+func (r *PetRepository) GetLevelingConfig(ctx context.Context) (*models.LevelingConfig, error) {
+	var data []byte
+	err := r.db.QueryRow(ctx, `SELECT config FROM leveling_config`).Scan(&data)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get leveling config: %w", err)
+	}
+	return models.ParseLevelingConfig(data)
+}
+
 func (r *PetRepository) GetPetType(ctx context.Context, id string) (*models.PetType, error) {
 	query := `SELECT id, name, icon, config FROM pet_types WHERE id = $1`
 
@@ -307,3 +544,75 @@ func (r *PetRepository) GetPetType(ctx context.Context, id string) (*models.PetT
 
 	return &pt, nil
 }
+
+// Breeds
+
+var ErrBreedNotFound = errors.New("breed not found")
+
+const breedColumns = `id, pet_type_id, name, size_class, energy_level, daily_exercise_minutes,
+		       adult_weight_min_kg, adult_weight_max_kg, senior_age_years`
+
+func scanBreed(row pgx.Row) (*models.Breed, error) {
+	var b models.Breed
+	err := row.Scan(
+		&b.ID,
+		&b.PetTypeID,
+		&b.Name,
+		&b.SizeClass,
+		&b.EnergyLevel,
+		&b.DailyExerciseMinutes,
+		&b.AdultWeightMinKg,
+		&b.AdultWeightMaxKg,
+		&b.SeniorAgeYears,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// SearchBreeds returns the pet type's breeds whose name, or a word in it,
+// starts with query. Whole-name prefix matches come first.
+func (r *PetRepository) SearchBreeds(ctx context.Context, petTypeID, query string, limit int) ([]*models.Breed, error) {
+	sql := `
+		SELECT ` + breedColumns + `
+		FROM breeds
+		WHERE pet_type_id = $1
+		  AND ($2::text = '' OR lower(name) LIKE $2 || '%' OR lower(name) LIKE '% ' || $2 || '%')
+		ORDER BY lower(name) LIKE $2 || '%' DESC, name
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, sql, petTypeID, escapeLike(strings.ToLower(query)), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search breeds: %w", err)
+	}
+	defer rows.Close()
+
+	var breeds []*models.Breed
+	for rows.Next() {
+		breed, err := scanBreed(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan breed: %w", err)
+		}
+		breeds = append(breeds, breed)
+	}
+
+	return breeds, rows.Err()
+}
+
+// escapeLike makes s match literally in a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (r *PetRepository) GetBreed(ctx context.Context, id string) (*models.Breed, error) {
+	breed, err := scanBreed(r.db.QueryRow(ctx, `SELECT `+breedColumns+` FROM breeds WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBreedNotFound
+		}
+		return nil, fmt.Errorf("failed to get breed: %w", err)
+	}
+	return breed, nil
+}
@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var (
+	ErrTransferNotFound = errors.New("transfer not found")
+	ErrTransferPending  = errors.New("pet already has a pending transfer")
+)
+
+const transferColumns = `id, pet_id, pet_name, from_user_id, from_email, to_email, to_user_id, status, expires_at, responded_at, created_at`
+
+type PetTransferRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPetTransferRepository(db *pgxpool.Pool) *PetTransferRepository {
+	return &PetTransferRepository{db: db}
+}
+
+// Create stores a new pending transfer. Expired pending rows for the pet are
+// closed first so they don't block a new transfer.
+func (r *PetTransferRepository) Create(ctx context.Context, transfer *models.PetTransfer) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE pet_transfers SET status = $2
+		WHERE pet_id = $1 AND status = $3 AND expires_at <= NOW()
+	`, transfer.PetID, models.TransferStatusExpired, models.TransferStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to expire transfers: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO pet_transfers (`+transferColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`,
+		transfer.ID,
+		transfer.PetID,
+		transfer.PetName,
+		transfer.FromUserID,
+		transfer.FromEmail,
+		transfer.ToEmail,
+		transfer.ToUserID,
+		transfer.Status,
+		transfer.ExpiresAt,
+		transfer.RespondedAt,
+		transfer.CreatedAt,
+	)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return ErrTransferPending
+		}
+		return fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PetTransferRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PetTransfer, error) {
+	return scanTransfer(r.db.QueryRow(ctx, `SELECT `+transferColumns+` FROM pet_transfers WHERE id = $1`, id))
+}
+
+// GetPendingForPet returns the open transfer for a pet, if any.
+func (r *PetTransferRepository) GetPendingForPet(ctx context.Context, petID uuid.UUID) (*models.PetTransfer, error) {
+	query := `
+		SELECT ` + transferColumns + `
+		FROM pet_transfers
+		WHERE pet_id = $1 AND status = $2 AND expires_at > NOW()
+	`
+	return scanTransfer(r.db.QueryRow(ctx, query, petID, models.TransferStatusPending))
+}
+
+// ListPendingForEmail returns open transfers addressed to an email.
+func (r *PetTransferRepository) ListPendingForEmail(ctx context.Context, email string) ([]*models.PetTransfer, error) {
+	query := `
+		SELECT ` + transferColumns + `
+		FROM pet_transfers
+		WHERE LOWER(to_email) = LOWER($1) AND status = $2 AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, email, models.TransferStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []*models.PetTransfer
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// Close moves a pending transfer to a final status without transferring the
+// pet (declined or cancelled). A recipient who declines is recorded.
+func (r *PetTransferRepository) Close(ctx context.Context, id uuid.UUID, status models.TransferStatus, toUserID *uuid.UUID) error {
+	query := `
+		UPDATE pet_transfers
+		SET status = $2, responded_at = NOW(), to_user_id = COALESCE($4, to_user_id)
+		WHERE id = $1 AND status = $3 AND expires_at > NOW()
+	`
+
+	result, err := r.db.Exec(ctx, query, id, status, models.TransferStatusPending, toUserID)
+	if err != nil {
+		return fmt.Errorf("failed to close transfer: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTransferNotFound
+	}
+
+	return nil
+}
+
+// CancelPendingForPet closes the pet's open transfer, if any, for when the
+// pet can no longer be handed over.
+func (r *PetTransferRepository) CancelPendingForPet(ctx context.Context, petID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE pet_transfers SET status = $2, responded_at = NOW()
+		WHERE pet_id = $1 AND status = $3
+	`, petID, models.TransferStatusCancelled, models.TransferStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to cancel transfers: %w", err)
+	}
+	return nil
+}
+
+// Accept hands the pet to the recipient in one transaction. Activities hang
+// off the pet and move with it; the owner's achievements for the pet are
+// re-assigned. Cards, missions and items are per user and stay where they
+// are, so the pet takes off items the recipient doesn't own. The old
+// household loses access: members and open invitations are cleared.
+func (r *PetTransferRepository) Accept(ctx context.Context, id, userID uuid.UUID) (*models.PetTransfer, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	transfer, err := scanTransfer(tx.QueryRow(ctx, `SELECT `+transferColumns+` FROM pet_transfers WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return nil, err
+	}
+	if !transfer.IsPending() || transfer.PetID == nil || transfer.FromUserID == nil {
+		return nil, ErrTransferNotFound
+	}
+	petID, fromUserID := *transfer.PetID, *transfer.FromUserID
+
+	// The pet must still belong to whoever started the transfer, and be
+	// neither deleted nor a memorial since
+	result, err := tx.Exec(ctx, `
+		UPDATE pets SET user_id = $3, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND status <> $4
+	`, petID, fromUserID, userID, models.PetStatusMemorial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer pet: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, ErrTransferNotFound
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM pet_members WHERE pet_id = $1`, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear pet members: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO pet_members (pet_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, petID, userID, models.PetRoleOwner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add new owner: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE pet_invitations SET revoked_at = NOW()
+		WHERE pet_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke invitations: %w", err)
+	}
+
+	// Drop duplicates the recipient may already hold (e.g. as a former
+	// co-owner) before moving the rest across.
+	_, err = tx.Exec(ctx, `
+		DELETE FROM user_achievements
+		WHERE user_id = $3 AND pet_id = $1
+		  AND achievement_id IN (SELECT achievement_id FROM user_achievements WHERE user_id = $2 AND pet_id = $1)
+	`, petID, fromUserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge achievements: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE user_achievements SET user_id = $3
+		WHERE pet_id = $1 AND user_id = $2
+	`, petID, fromUserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move achievements: %w", err)
+	}
+
+	// Items are the previous owner's; the pet keeps only what the recipient
+	// also has in their inventory.
+	_, err = tx.Exec(ctx, `
+		DELETE FROM pet_loadouts pl
+		WHERE pl.pet_id = $1
+		  AND NOT EXISTS (SELECT 1 FROM user_items ui WHERE ui.user_id = $2 AND ui.item_id = pl.item_id)
+	`, petID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear loadout: %w", err)
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(ctx, `
+		UPDATE pet_transfers SET status = $2, to_user_id = $3, responded_at = $4
+		WHERE id = $1
+	`, transfer.ID, models.TransferStatusAccepted, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept transfer: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	transfer.Status = models.TransferStatusAccepted
+	transfer.ToUserID = &userID
+	transfer.RespondedAt = &now
+	return transfer, nil
+}
+
+func scanTransfer(row pgx.Row) (*models.PetTransfer, error) {
+	var transfer models.PetTransfer
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.PetID,
+		&transfer.PetName,
+		&transfer.FromUserID,
+		&transfer.FromEmail,
+		&transfer.ToEmail,
+		&transfer.ToUserID,
+		&transfer.Status,
+		&transfer.ExpiresAt,
+		&transfer.RespondedAt,
+		&transfer.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to scan transfer: %w", err)
+	}
+
+	if transfer.Status == models.TransferStatusPending && !transfer.IsPending() {
+		transfer.Status = models.TransferStatusExpired
+	}
+
+	return &transfer, nil
+}
@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+type RecordRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRecordRepository(db *pgxpool.Pool) *RecordRepository {
+	return &RecordRepository{db: db}
+}
+
+// raiseRecords breaks the pet's records in the activity's game that the
+// claim beat, in the transaction saving the activity: the totals of the
+// periods are summed over the pet's finished, unflagged activities of the
+// game, and each broken record is updated and added to its history. The
+// pet must be locked with lockPetRecords first. It returns the records
+// broken.
+func raiseRecords(ctx context.Context, tx pgx.Tx, activity *models.Activity, claim *models.RecordClaim) ([]*models.RecordBreak, error) {
+	if claim == nil {
+		return nil, nil
+	}
+
+	values := append([]models.RecordValue(nil), claim.Values...)
+	for _, period := range claim.Periods {
+		var seconds float64
+		err := tx.QueryRow(ctx, `
+			SELECT COALESCE(SUM(duration_seconds), 0)
+			FROM activities
+			WHERE pet_id = $1 AND game_type_id = $2 AND ended_at IS NOT NULL AND flag_reason IS NULL
+			  AND started_at >= $3 AND started_at < $4
+		`, activity.PetID, activity.GameTypeID, period.Start, period.End).Scan(&seconds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum activity time: %w", err)
+		}
+		if seconds > 0 {
+			values = append(values, models.RecordValue{Record: period.Record, Value: seconds})
+		}
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	current := make(map[models.RecordType]float64)
+	rows, err := tx.Query(ctx, `
+		SELECT record, value FROM pet_records WHERE pet_id = $1 AND game_type_id = $2
+	`, activity.PetID, activity.GameTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get records: %w", err)
+	}
+	for rows.Next() {
+		var record models.RecordType
+		var value float64
+		if err := rows.Scan(&record, &value); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan record: %w", err)
+		}
+		current[record] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get records: %w", err)
+	}
+
+	setAt := *activity.EndedAt
+	var broken []*models.RecordBreak
+	for _, value := range values {
+		record, exists := current[value.Record]
+		if exists && !value.Record.Beats(value.Value, record) {
+			continue
+		}
+		recordBreak := &models.RecordBreak{
+			Record:     value.Record,
+			Value:      value.Value,
+			ActivityID: &activity.ID,
+			SetAt:      setAt,
+		}
+		if exists {
+			recordBreak.PreviousValue = &record
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO pet_records (pet_id, game_type_id, record, value, activity_id, set_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (pet_id, game_type_id, record) DO UPDATE
+			SET value = EXCLUDED.value, activity_id = EXCLUDED.activity_id, set_at = EXCLUDED.set_at
+		`, activity.PetID, activity.GameTypeID, value.Record, value.Value, activity.ID, setAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set record: %w", err)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO pet_record_history (pet_id, game_type_id, record, value, previous_value, activity_id, set_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, activity.PetID, activity.GameTypeID, value.Record, value.Value, recordBreak.PreviousValue, activity.ID, setAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add record history: %w", err)
+		}
+		broken = append(broken, recordBreak)
+	}
+	return broken, nil
+}
+
+// lockPetRecords makes activities of the pet finishing at once take turns,
+// so each sees the other's records and period totals. It must run before
+// the activity is written, whose foreign key would otherwise hold a lock on
+// the pet that two finishing activities could deadlock upgrading.
+func lockPetRecords(ctx context.Context, tx pgx.Tx, petID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM pets WHERE id = $1 FOR UPDATE`, petID); err != nil {
+		return fmt.Errorf("failed to lock pet: %w", err)
+	}
+	return nil
+}
+
+// ListByPet returns the pet's records by game type, or of one game type.
+// With history, each record lists every time it was set or broken, newest
+// first.
+func (r *RecordRepository) ListByPet(ctx context.Context, petID uuid.UUID, gameTypeID *string, history bool) ([]*models.PersonalRecord, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT game_type_id, record, value, activity_id, set_at
+		FROM pet_records
+		WHERE pet_id = $1 AND ($2::text IS NULL OR game_type_id = $2)
+		ORDER BY game_type_id, record
+	`, petID, gameTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records: %w", err)
+	}
+	defer rows.Close()
+
+	type recordKey struct {
+		gameTypeID string
+		record     models.RecordType
+	}
+	records := []*models.PersonalRecord{}
+	byKey := make(map[recordKey]*models.PersonalRecord)
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan record: %w", err)
+		}
+		records = append(records, record)
+		byKey[recordKey{record.GameTypeID, record.Record}] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list records: %w", err)
+	}
+	if !history || len(records) == 0 {
+		return records, nil
+	}
+
+	historyRows, err := r.db.Query(ctx, `
+		SELECT game_type_id, record, value, previous_value, activity_id, set_at
+		FROM pet_record_history
+		WHERE pet_id = $1 AND ($2::text IS NULL OR game_type_id = $2)
+		ORDER BY set_at DESC
+	`, petID, gameTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list record history: %w", err)
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var gameType string
+		var recordBreak models.RecordBreak
+		err := historyRows.Scan(&gameType, &recordBreak.Record, &recordBreak.Value, &recordBreak.PreviousValue, &recordBreak.ActivityID, &recordBreak.SetAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan record history: %w", err)
+		}
+		if record, ok := byKey[recordKey{gameType, recordBreak.Record}]; ok {
+			record.History = append(record.History, &recordBreak)
+		}
+	}
+	return records, historyRows.Err()
+}
+
+func scanRecord(row pgx.Row) (*models.PersonalRecord, error) {
+	var record models.PersonalRecord
+	if err := row.Scan(&record.GameTypeID, &record.Record, &record.Value, &record.ActivityID, &record.SetAt); err != nil {
+		return nil, err
+	}
+	record.Unit = record.Record.Unit()
+	return &record, nil
+}
@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var ErrReminderNotFound = errors.New("reminder not found")
+
+const reminderColumns = `id, user_id, pet_id, title, notes, rrule, starts_at, game_type_id, enabled,
+	next_run_at, last_notified_at, last_done_at, created_at, updated_at`
+
+type ReminderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReminderRepository(db *pgxpool.Pool) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+func (r *ReminderRepository) Create(ctx context.Context, reminder *models.Reminder) error {
+	query := `
+		INSERT INTO reminders (` + reminderColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		reminder.ID,
+		reminder.UserID,
+		reminder.PetID,
+		reminder.Title,
+		reminder.Notes,
+		reminder.RRule,
+		reminder.StartsAt,
+		reminder.GameTypeID,
+		reminder.Enabled,
+		reminder.NextRunAt,
+		reminder.LastNotifiedAt,
+		reminder.LastDoneAt,
+		reminder.CreatedAt,
+		reminder.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns a reminder owned by userID.
+func (r *ReminderRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*models.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM reminders WHERE id = $1 AND user_id = $2`
+
+	reminder, err := scanReminder(r.db.QueryRow(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReminderNotFound
+		}
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+
+	return reminder, nil
+}
+
+// ListByUser returns the user's reminders, optionally for a single pet,
+// soonest first.
+func (r *ReminderRepository) ListByUser(ctx context.Context, userID uuid.UUID, petID *uuid.UUID) ([]*models.Reminder, error) {
+	query := `
+		SELECT ` + reminderColumns + `
+		FROM reminders
+		WHERE user_id = $1 AND ($2::uuid IS NULL OR pet_id = $2)
+		ORDER BY next_run_at NULLS LAST, created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	defer rows.Close()
+
+	return collectReminders(rows)
+}
+
+func (r *ReminderRepository) Update(ctx context.Context, reminder *models.Reminder) error {
+	query := `
+		UPDATE reminders
+		SET pet_id = $3, title = $4, notes = $5, rrule = $6, starts_at = $7, game_type_id = $8,
+		    enabled = $9, next_run_at = $10, claimed_at = NULL, updated_at = $11
+		WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		reminder.ID,
+		reminder.UserID,
+		reminder.PetID,
+		reminder.Title,
+		reminder.Notes,
+		reminder.RRule,
+		reminder.StartsAt,
+		reminder.GameTypeID,
+		reminder.Enabled,
+		reminder.NextRunAt,
+		reminder.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrReminderNotFound
+	}
+
+	return nil
+}
+
+func (r *ReminderRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM reminders WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrReminderNotFound
+	}
+
+	return nil
+}
+
+// ClaimDue marks up to limit due reminders as claimed and returns them.
+// Claims older than claimTTL are considered abandoned by a crashed worker
+// and can be taken again.
+func (r *ReminderRepository) ClaimDue(ctx context.Context, now time.Time, claimTTL time.Duration, limit int) ([]*models.Reminder, error) {
+	query := `
+		UPDATE reminders
+		SET claimed_at = $1
+		WHERE id IN (
+			SELECT id FROM reminders
+			WHERE enabled AND next_run_at <= $1 AND (claimed_at IS NULL OR claimed_at < $2)
+			ORDER BY next_run_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + reminderColumns
+
+	rows, err := r.db.Query(ctx, query, now, now.Add(-claimTTL), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due reminders: %w", err)
+	}
+	defer rows.Close()
+
+	return collectReminders(rows)
+}
+
+// Reschedule sets the next occurrence and releases the claim. A nil
+// nextRunAt ends the series.
+func (r *ReminderRepository) Reschedule(ctx context.Context, id uuid.UUID, nextRunAt, notifiedAt *time.Time) error {
+	query := `
+		UPDATE reminders
+		SET next_run_at = $2, last_notified_at = COALESCE($3, last_notified_at), claimed_at = NULL
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, id, nextRunAt, notifiedAt); err != nil {
+		return fmt.Errorf("failed to reschedule reminder: %w", err)
+	}
+
+	return nil
+}
+
+// Disable switches a reminder off, e.g. when its owner lost access to the
+// pet.
+func (r *ReminderRepository) Disable(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE reminders SET enabled = FALSE, next_run_at = NULL, claimed_at = NULL, updated_at = NOW() WHERE id = $1`
+
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to disable reminder: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReminderRepository) MarkDone(ctx context.Context, reminder *models.Reminder) error {
+	query := `
+		UPDATE reminders
+		SET last_done_at = $2, next_run_at = $3, enabled = $4, updated_at = $5
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, reminder.ID, reminder.LastDoneAt, reminder.NextRunAt, reminder.Enabled, reminder.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark reminder done: %w", err)
+	}
+
+	return nil
+}
+
+func scanReminder(row pgx.Row) (*models.Reminder, error) {
+	var rem models.Reminder
+	err := row.Scan(
+		&rem.ID,
+		&rem.UserID,
+		&rem.PetID,
+		&rem.Title,
+		&rem.Notes,
+		&rem.RRule,
+		&rem.StartsAt,
+		&rem.GameTypeID,
+		&rem.Enabled,
+		&rem.NextRunAt,
+		&rem.LastNotifiedAt,
+		&rem.LastDoneAt,
+		&rem.CreatedAt,
+		&rem.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rem, nil
+}
+
+func collectReminders(rows pgx.Rows) ([]*models.Reminder, error) {
+	var reminders []*models.Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+var (
+	ErrSkillNotFound        = errors.New("skill not found")
+	ErrSkillAlreadyUnlocked = errors.New("skill already unlocked")
+	ErrSkillPointsSpent     = errors.New("skill points already spent")
+)
+
+type SkillRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSkillRepository(db *pgxpool.Pool) *SkillRepository {
+	return &SkillRepository{db: db}
+}
+
+const skillColumns = `s.id, s.name, s.description, s.required_level, s.cost, s.prerequisites, s.effect`
+
+func scanSkill(row pgx.Row) (*models.Skill, error) {
+	var skill models.Skill
+	err := row.Scan(
+		&skill.ID,
+		&skill.Name,
+		&skill.Description,
+		&skill.RequiredLevel,
+		&skill.Cost,
+		&skill.Prerequisites,
+		&skill.Effect,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+func collectSkills(rows pgx.Rows) ([]*models.Skill, error) {
+	var skills []*models.Skill
+	for rows.Next() {
+		skill, err := scanSkill(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan skill: %w", err)
+		}
+		skills = append(skills, skill)
+	}
+	return skills, rows.Err()
+}
+
+// ListSkills returns the skill tree in display order, failing if it is
+// misconfigured.
+func (r *SkillRepository) ListSkills(ctx context.Context) ([]*models.Skill, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+skillColumns+`
+		FROM skills s
+		ORDER BY s.sort_order, s.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list skills: %w", err)
+	}
+	defer rows.Close()
+
+	skills, err := collectSkills(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.ValidateSkillTree(skills); err != nil {
+		return nil, err
+	}
+	return skills, nil
+}
+
+func (r *SkillRepository) GetSkill(ctx context.Context, id string) (*models.Skill, error) {
+	skill, err := scanSkill(r.db.QueryRow(ctx, `
+		SELECT `+skillColumns+`
+		FROM skills s
+		WHERE s.id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSkillNotFound
+		}
+		return nil, fmt.Errorf("failed to get skill: %w", err)
+	}
+	return skill, nil
+}
+
+// ListUnlocked returns when the pet unlocked each of its skills.
+func (r *SkillRepository) ListUnlocked(ctx context.Context, petID uuid.UUID) (map[string]time.Time, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT skill_id, unlocked_at FROM pet_skills WHERE pet_id = $1
+	`, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unlocked skills: %w", err)
+	}
+	defer rows.Close()
+
+	unlocked := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan unlocked skill: %w", err)
+		}
+		unlocked[id] = at
+	}
+	return unlocked, rows.Err()
+}
+
+// ListActive returns the skills the pet has unlocked.
+func (r *SkillRepository) ListActive(ctx context.Context, petID uuid.UUID) ([]*models.Skill, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+skillColumns+`
+		FROM pet_skills ps
+		JOIN skills s ON s.id = ps.skill_id
+		WHERE ps.pet_id = $1
+		ORDER BY s.sort_order, s.id
+	`, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active skills: %w", err)
+	}
+	defer rows.Close()
+
+	return collectSkills(rows)
+}
+
+// Unlock spends the pet's points on the skill. The pet is locked while the
+// points it already spent are counted, so concurrent unlocks can't spend
+// more than pointsEarned between them.
+func (r *SkillRepository) Unlock(ctx context.Context, petID uuid.UUID, skill *models.Skill, pointsEarned int, userID uuid.UUID, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM pets WHERE id = $1 FOR UPDATE`, petID); err != nil {
+		return fmt.Errorf("failed to lock pet: %w", err)
+	}
+
+	var spent int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(s.cost), 0)
+		FROM pet_skills ps
+		JOIN skills s ON s.id = ps.skill_id
+		WHERE ps.pet_id = $1
+	`, petID).Scan(&spent)
+	if err != nil {
+		return fmt.Errorf("failed to count spent skill points: %w", err)
+	}
+	if pointsEarned-spent < skill.Cost {
+		return ErrSkillPointsSpent
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO pet_skills (pet_id, skill_id, unlocked_by, unlocked_at)
+		VALUES ($1, $2, $3, $4)
+	`, petID, skill.ID, userID, at)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrSkillAlreadyUnlocked
+		}
+		return fmt.Errorf("failed to unlock skill: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -2,18 +2,24 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joaosantos/pettime/internal/models"
 )
 
 var ErrUserNotFound = errors.New("user not found")
 var ErrUserAlreadyExists = errors.New("user already exists")
+var ErrIdentityNotFound = errors.New("identity not found")
+var ErrIdentityAlreadyExists = errors.New("identity already exists")
+var ErrLastIdentity = errors.New("cannot remove the last login method")
+var ErrRefreshTokenNotFound = errors.New("refresh token not found or expired")
 
 type UserRepository struct {
 	db *pgxpool.Pool
@@ -50,14 +56,55 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	return nil
 }
 
+// CreateWithIdentity creates the user and its first login method together.
+func (r *UserRepository) CreateWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO users (id, email, password_hash, name, avatar_url, auth_provider, auth_provider_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`,
+		user.ID,
+		user.Email,
+		user.PasswordHash,
+		user.Name,
+		user.AvatarURL,
+		user.AuthProvider,
+		user.AuthProviderID,
+		user.CreatedAt,
+		user.UpdatedAt,
+	)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return ErrUserAlreadyExists
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if err := insertIdentity(ctx, tx, identity); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, name, avatar_url, auth_provider, auth_provider_id, created_at, updated_at
+		SELECT id, email, password_hash, name, avatar_url, auth_provider, auth_provider_id, deletion_scheduled_at, preferences, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
 
 	var user models.User
+	var preferences []byte
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
@@ -66,6 +113,8 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		&user.AvatarURL,
 		&user.AuthProvider,
 		&user.AuthProviderID,
+		&user.DeletionScheduledAt,
+		&preferences,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -76,17 +125,22 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	if err := setPreferences(&user, preferences); err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, name, avatar_url, auth_provider, auth_provider_id, created_at, updated_at
+		SELECT id, email, password_hash, name, avatar_url, auth_provider, auth_provider_id, deletion_scheduled_at, preferences, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
 
 	var user models.User
+	var preferences []byte
 	err := r.db.QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
@@ -95,6 +149,8 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 		&user.AvatarURL,
 		&user.AuthProvider,
 		&user.AuthProviderID,
+		&user.DeletionScheduledAt,
+		&preferences,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -105,17 +161,23 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
 
+	if err := setPreferences(&user, preferences); err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
 
 func (r *UserRepository) GetByProvider(ctx context.Context, provider models.AuthProvider, providerID string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, name, avatar_url, auth_provider, auth_provider_id, created_at, updated_at
-		FROM users
-		WHERE auth_provider = $1 AND auth_provider_id = $2
+		SELECT u.id, u.email, u.password_hash, u.name, u.avatar_url, u.auth_provider, u.auth_provider_id, u.deletion_scheduled_at, u.preferences, u.created_at, u.updated_at
+		FROM users u
+		JOIN user_identities ui ON ui.user_id = u.id
+		WHERE ui.provider = $1 AND ui.provider_id = $2
 	`
 
 	var user models.User
+	var preferences []byte
 	err := r.db.QueryRow(ctx, query, provider, providerID).Scan(
 		&user.ID,
 		&user.Email,
@@ -124,6 +186,8 @@ func (r *UserRepository) GetByProvider(ctx context.Context, provider models.Auth
 		&user.AvatarURL,
 		&user.AuthProvider,
 		&user.AuthProviderID,
+		&user.DeletionScheduledAt,
+		&preferences,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -134,6 +198,10 @@ func (r *UserRepository) GetByProvider(ctx context.Context, provider models.Auth
 		return nil, fmt.Errorf("failed to get user by provider: %w", err)
 	}
 
+	if err := setPreferences(&user, preferences); err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
 
@@ -177,18 +245,113 @@ func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// ScheduleDeletion marks the account for deletion and revokes every refresh
+// token so existing sessions end once their access token expires.
+func (r *UserRepository) ScheduleDeletion(ctx context.Context, id uuid.UUID, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `UPDATE users SET deletion_scheduled_at = $2, updated_at = NOW() WHERE id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM refresh_tokens WHERE user_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) CancelDeletion(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE users SET deletion_scheduled_at = NULL, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion: %w", err)
+	}
+	return nil
+}
+
+// DeleteScheduled removes accounts whose grace period has passed. Every
+// user-owned table references users with ON DELETE CASCADE.
+func (r *UserRepository) DeleteScheduled(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $1`
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete scheduled users: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// Preferences
+
+func (r *UserRepository) UpdatePreferences(ctx context.Context, userID uuid.UUID, prefs models.UserPreferences) error {
+	query := `UPDATE users SET preferences = $2, updated_at = NOW() WHERE id = $1`
+
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return fmt.Errorf("failed to encode preferences: %w", err)
+	}
+
+	result, err := r.db.Exec(ctx, query, userID, data)
+	if err != nil {
+		return fmt.Errorf("failed to update preferences: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func setPreferences(user *models.User, data []byte) error {
+	prefs, err := models.ParsePreferences(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode preferences: %w", err)
+	}
+	user.Preferences = &prefs
+	return nil
+}
+
 // Refresh token methods
 
 func (r *UserRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
 	query := `
-		INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO refresh_tokens (id, user_id, token_hash, auth_time, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
 	_, err := r.db.Exec(ctx, query,
 		token.ID,
 		token.UserID,
 		token.TokenHash,
+		token.AuthTime,
 		token.ExpiresAt,
 		token.CreatedAt,
 	)
@@ -201,7 +364,7 @@ func (r *UserRepository) CreateRefreshToken(ctx context.Context, token *models.R
 
 func (r *UserRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
 	query := `
-		SELECT id, user_id, token_hash, expires_at, created_at
+		SELECT id, user_id, token_hash, auth_time, expires_at, created_at
 		FROM refresh_tokens
 		WHERE token_hash = $1 AND expires_at > NOW()
 	`
@@ -211,12 +374,13 @@ func (r *UserRepository) GetRefreshToken(ctx context.Context, tokenHash string)
 		&token.ID,
 		&token.UserID,
 		&token.TokenHash,
+		&token.AuthTime,
 		&token.ExpiresAt,
 		&token.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.New("refresh token not found or expired")
+			return nil, ErrRefreshTokenNotFound
 		}
 		return nil, fmt.Errorf("failed to get refresh token: %w", err)
 	}
@@ -224,6 +388,27 @@ func (r *UserRepository) GetRefreshToken(ctx context.Context, tokenHash string)
 	return &token, nil
 }
 
+// RotateRefreshToken replaces the token of a session. It fails if the
+// session's token changed since it was read, so a refresh token can only be
+// used once even by concurrent requests.
+func (r *UserRepository) RotateRefreshToken(ctx context.Context, token *models.RefreshToken, previousHash string) error {
+	query := `
+		UPDATE refresh_tokens
+		SET token_hash = $3, expires_at = $4
+		WHERE id = $1 AND token_hash = $2
+	`
+
+	result, err := r.db.Exec(ctx, query, token.ID, previousHash, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRefreshTokenNotFound
+	}
+
+	return nil
+}
+
 func (r *UserRepository) DeleteRefreshToken(ctx context.Context, tokenHash string) error {
 	query := `DELETE FROM refresh_tokens WHERE token_hash = $1`
 	_, err := r.db.Exec(ctx, query, tokenHash)
@@ -236,6 +421,149 @@ func (r *UserRepository) DeleteUserRefreshTokens(ctx context.Context, userID uui
 	return err
 }
 
+// Identity methods
+
+func (r *UserRepository) GetIdentities(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, provider, provider_id, email, created_at, last_used_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identities: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []*models.UserIdentity
+	for rows.Next() {
+		var identity models.UserIdentity
+		err := rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Provider,
+			&identity.ProviderID,
+			&identity.Email,
+			&identity.CreatedAt,
+			&identity.LastUsedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan identity: %w", err)
+		}
+		identities = append(identities, &identity)
+	}
+
+	return identities, nil
+}
+
+func (r *UserRepository) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	return insertIdentity(ctx, r.db, identity)
+}
+
+// LinkPassword sets the password for a user and adds the matching email
+// identity in one step.
+func (r *UserRepository) LinkPassword(ctx context.Context, identity *models.UserIdentity, passwordHash string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`, identity.UserID, passwordHash); err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	if err := insertIdentity(ctx, tx, identity); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIdentity unlinks a provider from a user. The user row is locked so
+// two concurrent unlinks cannot remove the last two login methods.
+func (r *UserRepository) DeleteIdentity(ctx context.Context, userID uuid.UUID, provider models.AuthProvider) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM user_identities WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count identities: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		return fmt.Errorf("failed to delete identity: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrIdentityNotFound
+	}
+	// Rolled back by the deferred Rollback.
+	if count <= 1 {
+		return ErrLastIdentity
+	}
+
+	if provider == models.AuthProviderEmail {
+		if _, err := tx.Exec(ctx, `UPDATE users SET password_hash = NULL, updated_at = NOW() WHERE id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to clear password: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) TouchIdentity(ctx context.Context, provider models.AuthProvider, providerID string) error {
+	query := `UPDATE user_identities SET last_used_at = NOW() WHERE provider = $1 AND provider_id = $2`
+	_, err := r.db.Exec(ctx, query, provider, providerID)
+	return err
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func insertIdentity(ctx context.Context, db execer, identity *models.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (id, user_id, provider, provider_id, email, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := db.Exec(ctx, query,
+		identity.ID,
+		identity.UserID,
+		identity.Provider,
+		identity.ProviderID,
+		identity.Email,
+		identity.CreatedAt,
+		identity.LastUsedAt,
+	)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return ErrIdentityAlreadyExists
+		}
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	return nil
+}
+
 func isDuplicateKeyError(err error) bool {
 	return err != nil && (contains(err.Error(), "duplicate key") || contains(err.Error(), "unique constraint"))
 }
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+var (
+	ErrExportNotFound    = errors.New("export not found")
+	ErrExportLinkInvalid = errors.New("export link is invalid or expired")
+)
+
+const (
+	// exportBatchSize is how many pending exports one worker pass builds.
+	exportBatchSize = 10
+	// exportClaimTTL is how long a claimed export stays with a worker
+	// before another pass may build it.
+	exportClaimTTL = 30 * time.Minute
+)
+
+type AccountService struct {
+	userRepo            *repositories.UserRepository
+	petRepo             *repositories.PetRepository
+	activityRepo        *repositories.ActivityRepository
+	gamificationRepo    *repositories.GamificationRepository
+	healthRepo          *repositories.HealthRepository
+	recordRepo          *repositories.RecordRepository
+	skillRepo           *repositories.SkillRepository
+	reminderRepo        *repositories.ReminderRepository
+	notificationRepo    *repositories.NotificationRepository
+	nudgeRepo           *repositories.NudgeRepository
+	challengeRepo       *repositories.ChallengeRepository
+	cosmeticRepo        *repositories.CosmeticRepository
+	friendRepo          *repositories.FriendRepository
+	feedRepo            *repositories.FeedRepository
+	exportRepo          *repositories.ExportRepository
+	deletionGracePeriod time.Duration
+	exportLinkTTL       time.Duration
+	signingKey          []byte
+}
+
+func NewAccountService(
+	userRepo *repositories.UserRepository,
+	petRepo *repositories.PetRepository,
+	activityRepo *repositories.ActivityRepository,
+	gamificationRepo *repositories.GamificationRepository,
+	healthRepo *repositories.HealthRepository,
+	recordRepo *repositories.RecordRepository,
+	skillRepo *repositories.SkillRepository,
+	reminderRepo *repositories.ReminderRepository,
+	notificationRepo *repositories.NotificationRepository,
+	nudgeRepo *repositories.NudgeRepository,
+	challengeRepo *repositories.ChallengeRepository,
+	cosmeticRepo *repositories.CosmeticRepository,
+	friendRepo *repositories.FriendRepository,
+	feedRepo *repositories.FeedRepository,
+	exportRepo *repositories.ExportRepository,
+	deletionGracePeriod time.Duration,
+	exportLinkTTL time.Duration,
+	signingKey []byte,
+) *AccountService {
+	return &AccountService{
+		userRepo:            userRepo,
+		petRepo:             petRepo,
+		activityRepo:        activityRepo,
+		gamificationRepo:    gamificationRepo,
+		healthRepo:          healthRepo,
+		recordRepo:          recordRepo,
+		skillRepo:           skillRepo,
+		reminderRepo:        reminderRepo,
+		notificationRepo:    notificationRepo,
+		nudgeRepo:           nudgeRepo,
+		challengeRepo:       challengeRepo,
+		cosmeticRepo:        cosmeticRepo,
+		friendRepo:          friendRepo,
+		feedRepo:            feedRepo,
+		exportRepo:          exportRepo,
+		deletionGracePeriod: deletionGracePeriod,
+		exportLinkTTL:       exportLinkTTL,
+		signingKey:          signingKey,
+	}
+}
+
+// ScheduleDeletion starts the grace period after which the account and all
+// of its data are removed. Signing in again cancels it.
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	deleteAt := time.Now().Add(s.deletionGracePeriod)
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, deleteAt); err != nil {
+		return time.Time{}, err
+	}
+	return deleteAt, nil
+}
+
+// PurgeDeletedAccounts removes accounts whose grace period has ended.
+func (s *AccountService) PurgeDeletedAccounts(ctx context.Context) error {
+	deleted, err := s.userRepo.DeleteScheduled(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d accounts", deleted)
+	}
+	return nil
+}
+
+// Exports
+
+// RequestExport queues a new export unless one is already being built.
+func (s *AccountService) RequestExport(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	latest, err := s.exportRepo.GetLatestByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, repositories.ErrExportNotFound) {
+		return nil, err
+	}
+	if latest != nil && (latest.Status == models.DataExportStatusPending || latest.Status == models.DataExportStatusProcessing) {
+		return latest, nil
+	}
+
+	export := &models.DataExport{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    models.DataExportStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (s *AccountService) GetLatestExport(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	export, err := s.exportRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrExportNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+
+	if export.Status == models.DataExportStatusCompleted && export.ExpiresAt != nil && time.Now().Before(*export.ExpiresAt) {
+		url := s.downloadURL(export)
+		export.DownloadURL = &url
+	}
+
+	return export, nil
+}
+
+// GetExportArchive returns the archive for a signed download link.
+func (s *AccountService) GetExportArchive(ctx context.Context, exportID uuid.UUID, expires, signature string) ([]byte, error) {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresUnix {
+		return nil, ErrExportLinkInvalid
+	}
+
+	expected := s.sign(exportID, expiresUnix)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrExportLinkInvalid
+	}
+
+	archive, err := s.exportRepo.GetArchive(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrExportNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// ProcessPendingExports builds archives for queued exports, claiming them
+// so that each is built by one worker.
+func (s *AccountService) ProcessPendingExports(ctx context.Context) error {
+	pending, err := s.exportRepo.ClaimPending(ctx, time.Now(), exportClaimTTL, exportBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range pending {
+		archive, err := s.BuildArchive(ctx, export.UserID)
+		if err != nil {
+			log.Printf("Export %s failed: %v", export.ID, err)
+			if err := s.exportRepo.Fail(ctx, export.ID, "failed to build archive"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.exportRepo.Complete(ctx, export.ID, archive, time.Now().Add(s.exportLinkTTL)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeExpiredExports drops archives whose download link has expired.
+func (s *AccountService) PurgeExpiredExports(ctx context.Context) error {
+	_, err := s.exportRepo.DeleteExpired(ctx, time.Now())
+	return err
+}
+
+// BuildArchive collects everything stored about a user into a ZIP file with
+// one JSON document per section.
+func (s *AccountService) BuildArchive(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	data, err := s.collectUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"identities.json", data.Identities},
+		{"pets.json", data.Pets},
+		{"activities.json", data.Activities},
+		{"health.json", data.Health},
+		{"records.json", data.Records},
+		{"skills.json", data.Skills},
+		{"reminders.json", data.Reminders},
+		{"devices.json", data.Devices},
+		{"nudges.json", data.Nudges},
+		{"achievements.json", data.Achievements},
+		{"cards.json", data.Cards},
+		{"missions.json", data.Missions},
+		{"challenges.json", data.Challenges},
+		{"challenge_rewards.json", data.ChallengeRewards},
+		{"inventory.json", data.Inventory},
+		{"friends.json", data.Friends},
+		{"friend_requests.json", data.FriendRequests},
+		{"blocked.json", data.Blocked},
+		{"feed_events.json", data.FeedEvents},
+		{"comments.json", data.Comments},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, section := range sections {
+		f, err := zw.Create(section.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(section.data); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", section.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *AccountService) collectUserData(ctx context.Context, userID uuid.UUID) (*models.UserDataArchive, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &models.UserDataArchive{ExportedAt: time.Now(), Profile: user}
+
+	if data.Identities, err = s.userRepo.GetIdentities(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Pets, err = s.petRepo.GetByUserID(ctx, userID, nil); err != nil {
+		return nil, err
+	}
+	for _, pet := range data.Pets {
+		if err := s.collectPetData(ctx, userID, pet, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.Reminders, err = s.reminderRepo.ListByUser(ctx, userID, nil); err != nil {
+		return nil, err
+	}
+	if data.Devices, err = s.notificationRepo.ListDevices(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Nudges, err = s.nudgeRepo.ListByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Achievements, err = s.gamificationRepo.GetUserAchievements(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Cards, err = s.gamificationRepo.GetUserCards(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Missions, err = s.gamificationRepo.GetUserMissions(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Challenges, err = s.challengeRepo.ListForUser(ctx, userID, time.Time{}); err != nil {
+		return nil, err
+	}
+	if data.ChallengeRewards, err = s.challengeRepo.ListRewards(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Inventory, err = s.cosmeticRepo.ListInventory(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Friends, err = s.friendRepo.ListFriends(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.FriendRequests, err = s.friendRepo.ListRequests(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Blocked, err = s.friendRepo.ListBlocked(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.FeedEvents, err = s.feedRepo.ListByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if data.Comments, err = s.feedRepo.ListCommentsByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// collectPetData adds the pet's activities, health log, records and skills
+// to the archive. Activities include game_data, so walk routes are exported
+// as well. For pets shared with the user only the activities and health
+// entries they logged are theirs; records and skills belong to the owner.
+func (s *AccountService) collectPetData(ctx context.Context, userID uuid.UUID, pet *models.Pet, data *models.UserDataArchive) error {
+	petID := pet.ID
+	owned := pet.Role == nil || *pet.Role == models.PetRoleOwner
+
+	activities, err := s.activityRepo.List(ctx, models.ActivityFilter{PetID: &petID})
+	if err != nil {
+		return err
+	}
+	for _, activity := range activities {
+		if owned || (activity.PerformedBy != nil && *activity.PerformedBy == userID) {
+			data.Activities = append(data.Activities, activity)
+		}
+	}
+
+	weights, err := s.healthRepo.ListWeights(ctx, petID)
+	if err != nil {
+		return err
+	}
+	visits, err := s.healthRepo.ListVetVisits(ctx, petID)
+	if err != nil {
+		return err
+	}
+	vaccinations, err := s.healthRepo.ListVaccinations(ctx, petID)
+	if err != nil {
+		return err
+	}
+	medications, err := s.healthRepo.ListMedications(ctx, petID)
+	if err != nil {
+		return err
+	}
+	data.Health.Weights = append(data.Health.Weights, recordedBy(weights, owned, userID, func(e *models.WeightEntry) *uuid.UUID { return e.RecordedBy })...)
+	data.Health.VetVisits = append(data.Health.VetVisits, recordedBy(visits, owned, userID, func(v *models.VetVisit) *uuid.UUID { return v.RecordedBy })...)
+	data.Health.Vaccinations = append(data.Health.Vaccinations, recordedBy(vaccinations, owned, userID, func(v *models.Vaccination) *uuid.UUID { return v.RecordedBy })...)
+	data.Health.Medications = append(data.Health.Medications, recordedBy(medications, owned, userID, func(m *models.Medication) *uuid.UUID { return m.RecordedBy })...)
+
+	if !owned {
+		return nil
+	}
+
+	records, err := s.recordRepo.ListByPet(ctx, petID, nil, true)
+	if err != nil {
+		return err
+	}
+	data.Records = append(data.Records, records...)
+
+	unlocked, err := s.skillRepo.ListUnlocked(ctx, petID)
+	if err != nil {
+		return err
+	}
+	for skillID, at := range unlocked {
+		data.Skills = append(data.Skills, &models.UnlockedSkill{PetID: petID, SkillID: skillID, UnlockedAt: at})
+	}
+
+	return nil
+}
+
+// recordedBy returns the entries of an owned pet, or those the user
+// recorded for a pet shared with them.
+func recordedBy[T any](entries []T, owned bool, userID uuid.UUID, by func(T) *uuid.UUID) []T {
+	if owned {
+		return entries
+	}
+	var mine []T
+	for _, entry := range entries {
+		if id := by(entry); id != nil && *id == userID {
+			mine = append(mine, entry)
+		}
+	}
+	return mine
+}
+
+func (s *AccountService) downloadURL(export *models.DataExport) string {
+	expires := export.ExpiresAt.Unix()
+	return fmt.Sprintf("/api/v1/exports/%s/download?expires=%d&signature=%s", export.ID, expires, s.sign(export.ID, expires))
+}
+
+func (s *AccountService) sign(exportID uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%s:%d", exportID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
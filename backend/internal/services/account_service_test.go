@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+func TestExportDownloadURL_Signature(t *testing.T) {
+	service := &AccountService{signingKey: []byte("test-secret")}
+
+	expiresAt := time.Now().Add(time.Hour)
+	export := &models.DataExport{
+		ID:        uuid.New(),
+		ExpiresAt: &expiresAt,
+	}
+
+	link, err := url.Parse(service.downloadURL(export))
+	if err != nil {
+		t.Fatalf("downloadURL() returned invalid URL: %v", err)
+	}
+	expires := link.Query().Get("expires")
+	signature := link.Query().Get("signature")
+
+	tests := []struct {
+		name      string
+		exportID  uuid.UUID
+		expires   string
+		signature string
+	}{
+		{"Tampered export ID", uuid.New(), expires, signature},
+		{"Extended expiry", export.ID, strconv.FormatInt(expiresAt.Add(time.Hour).Unix(), 10), signature},
+		{"Wrong signature", export.ID, expires, "deadbeef"},
+		{"Expired link", export.ID, strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10), signature},
+		{"Malformed expiry", export.ID, "tomorrow", signature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetExportArchive(context.Background(), tt.exportID, tt.expires, tt.signature)
+			if !errors.Is(err, ErrExportLinkInvalid) {
+				t.Errorf("GetExportArchive() error = %v, want %v", err, ErrExportLinkInvalid)
+			}
+		})
+	}
+
+	if got := service.sign(export.ID, expiresAt.Unix()); got != signature {
+		t.Errorf("sign() = %s, want %s", got, signature)
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+// AchievementService unlocks achievements for pet owners as their pets
+// finish activities, shares them on the feed and grants their reward items.
+// Achievements are kept per pet, so each of a user's pets earns its own
+// first walk.
+type AchievementService struct {
+	gamificationRepo *repositories.GamificationRepository
+	feedService      *FeedService
+	cosmetics        *CosmeticService
+}
+
+func NewAchievementService(
+	gamificationRepo *repositories.GamificationRepository,
+	feedService *FeedService,
+	cosmetics *CosmeticService,
+) *AchievementService {
+	return &AchievementService{
+		gamificationRepo: gamificationRepo,
+		feedService:      feedService,
+		cosmetics:        cosmetics,
+	}
+}
+
+// RecordActivity unlocks the achievements the pet's owner earned with a
+// finished activity, which must already be saved. streakDays is the pet's
+// streak after the activity. Unlocking is best effort: failures are logged
+// and don't fail the activity. Running and flagged activities unlock
+// nothing.
+func (s *AchievementService) RecordActivity(ctx context.Context, pet *models.Pet, activity *models.Activity, streakDays int) {
+	if activity.EndedAt == nil || activity.FlagReason != nil {
+		return
+	}
+
+	unlocked, err := s.unlock(ctx, pet, streakDays, time.Now())
+	if err != nil {
+		log.Printf("Failed to unlock achievements for activity %s: %v", activity.ID, err)
+	}
+	for _, ua := range unlocked {
+		s.feedService.PublishAchievement(ctx, ua)
+	}
+	s.cosmetics.GrantAchievementItems(ctx, pet.UserID, unlocked)
+}
+
+// unlock records the locked achievements the pet now meets and returns
+// those that weren't unlocked concurrently.
+func (s *AchievementService) unlock(ctx context.Context, pet *models.Pet, streakDays int, at time.Time) ([]*models.UserAchievement, error) {
+	locked, err := s.gamificationRepo.ListLockedAchievements(ctx, pet.UserID, pet.ID)
+	if err != nil || len(locked) == 0 {
+		return nil, err
+	}
+
+	progress, err := s.gamificationRepo.GetAchievementProgress(ctx, pet.ID)
+	if err != nil {
+		return nil, err
+	}
+	progress.StreakDays = streakDays
+
+	var unlocked []*models.UserAchievement
+	for _, achievement := range locked {
+		if !achievement.Earned(progress) {
+			continue
+		}
+		ua := &models.UserAchievement{
+			UserID:        pet.UserID,
+			AchievementID: achievement.ID,
+			Achievement:   achievement,
+			PetID:         pet.ID,
+			UnlockedAt:    at,
+		}
+		added, err := s.gamificationRepo.UnlockAchievement(ctx, ua)
+		if err != nil {
+			return unlocked, err
+		}
+		if added {
+			unlocked = append(unlocked, ua)
+		}
+	}
+	return unlocked, nil
+}
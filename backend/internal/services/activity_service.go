@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,30 +13,67 @@ import (
 )
 
 var (
-	ErrActivityNotFound = errors.New("activity not found")
-	ErrInvalidGameType  = errors.New("invalid game type")
+	ErrActivityNotFound  = errors.New("activity not found")
+	ErrInvalidGameType   = errors.New("invalid game type")
+	ErrActivityFinished  = errors.New("activity is already finished")
+	ErrThrowsNotDetected = errors.New("game type doesn't detect throws")
+	ErrTooManyIMUSamples = errors.New("too many IMU samples for one activity")
 )
 
+// imuRetention is how long accelerometer samples are kept after their
+// activity ends.
+const imuRetention = 24 * time.Hour
+
 type ActivityService struct {
-	activityRepo *repositories.ActivityRepository
-	petRepo      *repositories.PetRepository
+	activityRepo   *repositories.ActivityRepository
+	petRepo        *repositories.PetRepository
+	userRepo       *repositories.UserRepository
+	missionService *MissionService
+	skillService   *SkillService
+	feedService    *FeedService
+	leaderboards   *LeaderboardService
+	records        *RecordService
+	cosmetics      *CosmeticService
+	achievements   *AchievementService
+	leveling       *models.LevelingConfig
+	access         petAccess
 }
 
-func NewActivityService(activityRepo *repositories.ActivityRepository, petRepo *repositories.PetRepository) *ActivityService {
+func NewActivityService(
+	activityRepo *repositories.ActivityRepository,
+	petRepo *repositories.PetRepository,
+	memberRepo *repositories.PetMemberRepository,
+	userRepo *repositories.UserRepository,
+	missionService *MissionService,
+	skillService *SkillService,
+	feedService *FeedService,
+	leaderboards *LeaderboardService,
+	records *RecordService,
+	cosmetics *CosmeticService,
+	achievements *AchievementService,
+	leveling *models.LevelingConfig,
+) *ActivityService {
 	return &ActivityService{
-		activityRepo: activityRepo,
-		petRepo:      petRepo,
+		activityRepo:   activityRepo,
+		petRepo:        petRepo,
+		userRepo:       userRepo,
+		missionService: missionService,
+		skillService:   skillService,
+		feedService:    feedService,
+		leaderboards:   leaderboards,
+		records:        records,
+		cosmetics:      cosmetics,
+		achievements:   achievements,
+		leveling:       leveling,
+		access:         petAccess{petRepo: petRepo, memberRepo: memberRepo},
 	}
 }
 
 func (s *ActivityService) Create(ctx context.Context, userID uuid.UUID, input models.CreateActivityInput) (*models.Activity, error) {
-	// Verify pet belongs to user
-	pet, err := s.petRepo.GetByID(ctx, input.PetID)
+	// Verify user may log activities for this pet
+	pet, _, err := s.access.authorize(ctx, userID, input.PetID, models.PermissionLogActivity)
 	if err != nil {
-		return nil, ErrPetNotFound
-	}
-	if pet.UserID != userID {
-		return nil, ErrUnauthorized
+		return nil, err
 	}
 
 	// Verify game type exists and is supported for this pet type
@@ -44,36 +82,53 @@ func (s *ActivityService) Create(ctx context.Context, userID uuid.UUID, input mo
 		return nil, ErrInvalidGameType
 	}
 
-	if !isGameTypeSupported(gameType, pet.PetTypeID) {
+	if !isGameTypeSupported(gameType, pet.PetType) {
 		return nil, ErrInvalidGameType
 	}
 
 	now := time.Now()
 	activity := &models.Activity{
-		ID:         uuid.New(),
-		PetID:      input.PetID,
-		GameTypeID: input.GameTypeID,
-		GameType:   gameType,
-		StartedAt:  input.StartedAt,
-		EndedAt:    input.EndedAt,
-		GameData:   input.GameData,
-		ClientID:   input.ClientID,
-		CreatedAt:  now,
+		ID:          uuid.New(),
+		PetID:       input.PetID,
+		GameTypeID:  input.GameTypeID,
+		GameType:    gameType,
+		StartedAt:   input.StartedAt,
+		EndedAt:     input.EndedAt,
+		GameData:    input.GameData,
+		ClientID:    input.ClientID,
+		CreatedAt:   now,
+		PerformedBy: &userID,
+	}
+
+	if err := prepareGameData(activity); err != nil {
+		return nil, err
 	}
 
 	// If activity is already completed, calculate XP
+	newLevel := pet.Level
+	var streakDays int
 	if input.EndedAt != nil {
 		duration := int(input.EndedAt.Sub(input.StartedAt).Seconds())
 		activity.DurationSeconds = &duration
-		activity.XPEarned = s.calculateXP(gameType, activity)
+		activity.FlagReason = models.CheckActivity(activity)
+		skills, err := s.skillService.Active(ctx, pet.ID)
+		if err != nil {
+			return nil, err
+		}
+		activity.XPEarned = s.calculateXP(gameType, pet.PetType, skills, activity)
+		if err := s.recordMissions(ctx, activity); err != nil {
+			return nil, err
+		}
 
 		// Update pet XP
-		if err := s.petRepo.AddXP(ctx, pet.ID, activity.XPEarned); err != nil {
+		newLevel, err = s.petRepo.AddXP(ctx, pet.ID, activity.XPEarned, s.leveling)
+		if err != nil {
 			return nil, err
 		}
 
 		// Update streak
-		if err := s.updateStreak(ctx, pet); err != nil {
+		streakDays, err = s.updateStreak(ctx, pet)
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -83,10 +138,22 @@ func (s *ActivityService) Create(ctx context.Context, userID uuid.UUID, input mo
 		activity.SyncedAt = &syncedAt
 	}
 
-	if err := s.activityRepo.Create(ctx, activity); err != nil {
+	claim, err := s.records.Claim(ctx, pet, activity)
+	if err != nil {
+		return nil, err
+	}
+	activity.NewRecords, err = s.activityRepo.Create(ctx, activity, claim)
+	if err != nil {
 		return nil, err
 	}
 
+	if activity.EndedAt != nil {
+		s.feedService.PublishActivity(ctx, userID, pet, activity, s.leveling.LevelUp(pet.Level, newLevel))
+		s.leaderboards.RecordActivity(ctx, pet, activity, streakDays)
+		s.cosmetics.GrantLevelItems(ctx, pet, newLevel)
+		s.achievements.RecordActivity(ctx, pet, activity, streakDays)
+	}
+
 	return activity, nil
 }
 
@@ -99,73 +166,119 @@ func (s *ActivityService) GetByID(ctx context.Context, userID, activityID uuid.U
 		return nil, err
 	}
 
-	// Verify ownership through pet
-	pet, err := s.petRepo.GetByID(ctx, activity.PetID)
-	if err != nil {
+	// Verify access through pet membership
+	if _, _, err := s.access.authorize(ctx, userID, activity.PetID, models.PermissionViewPet); err != nil {
 		return nil, err
 	}
-	if pet.UserID != userID {
-		return nil, ErrUnauthorized
-	}
 
 	return activity, nil
 }
 
 func (s *ActivityService) List(ctx context.Context, userID uuid.UUID, filter models.ActivityFilter) ([]*models.Activity, error) {
-	// If filtering by pet, verify ownership
+	// If filtering by pet, verify membership; otherwise limit to pets the
+	// user is a member of
 	if filter.PetID != nil {
-		pet, err := s.petRepo.GetByID(ctx, *filter.PetID)
-		if err != nil {
+		if _, _, err := s.access.authorize(ctx, userID, *filter.PetID, models.PermissionViewPet); err != nil {
 			return nil, err
 		}
-		if pet.UserID != userID {
-			return nil, ErrUnauthorized
-		}
+	} else {
+		filter.MemberUserID = &userID
 	}
 
 	return s.activityRepo.List(ctx, filter)
 }
 
 func (s *ActivityService) Update(ctx context.Context, userID, activityID uuid.UUID, input models.UpdateActivityInput) (*models.Activity, error) {
-	activity, err := s.GetByID(ctx, userID, activityID)
+	activity, err := s.activityRepo.GetByID(ctx, activityID)
 	if err != nil {
+		if errors.Is(err, repositories.ErrActivityNotFound) {
+			return nil, ErrActivityNotFound
+		}
 		return nil, err
 	}
 
-	if input.EndedAt != nil && activity.EndedAt == nil {
+	// Caretakers may only finish activities they logged themselves
+	_, member, err := s.access.authorize(ctx, userID, activity.PetID, models.PermissionLogActivity)
+	if err != nil {
+		return nil, err
+	}
+	ownActivity := activity.PerformedBy != nil && *activity.PerformedBy == userID
+	if !member.Role.Can(models.PermissionEditActivities) && !ownActivity {
+		return nil, ErrUnauthorized
+	}
+
+	// The final game data counts towards the XP of finishing
+	if input.GameData != nil {
+		activity.GameData = keepDetectedThrows(activity.GameData, input.GameData)
+	}
+	finishing := input.EndedAt != nil && activity.EndedAt == nil
+	if finishing {
 		activity.EndedAt = input.EndedAt
 		duration := int(input.EndedAt.Sub(activity.StartedAt).Seconds())
 		activity.DurationSeconds = &duration
+	}
+	if err := prepareGameData(activity); err != nil {
+		return nil, err
+	}
 
+	// A finished activity is shared once it is saved
+	var finishedPet *models.Pet
+	var newLevel, streakDays int
+	if finishing {
 		// Get game type for XP calculation
 		gameType, err := s.activityRepo.GetGameType(ctx, activity.GameTypeID)
 		if err != nil {
 			return nil, err
 		}
 
-		activity.XPEarned = s.calculateXP(gameType, activity)
+		// Load the pet before AddXP moves its last activity to now
+		pet, err := s.petRepo.GetByID(ctx, activity.PetID)
+		if err != nil {
+			return nil, err
+		}
+
+		skills, err := s.skillService.Active(ctx, pet.ID)
+		if err != nil {
+			return nil, err
+		}
+		activity.XPEarned = s.calculateXP(gameType, pet.PetType, skills, activity)
+		if err := s.recordMissions(ctx, activity); err != nil {
+			return nil, err
+		}
 
 		// Update pet XP
-		if err := s.petRepo.AddXP(ctx, activity.PetID, activity.XPEarned); err != nil {
+		newLevel, err = s.petRepo.AddXP(ctx, activity.PetID, activity.XPEarned, s.leveling)
+		if err != nil {
 			return nil, err
 		}
+		finishedPet = pet
 
 		// Update streak
-		pet, err := s.petRepo.GetByID(ctx, activity.PetID)
+		streakDays, err = s.updateStreak(ctx, pet)
 		if err != nil {
 			return nil, err
 		}
-		if err := s.updateStreak(ctx, pet); err != nil {
+	}
+
+	activity.FlagReason = models.CheckActivity(activity)
+
+	var claim *models.RecordClaim
+	if finishedPet != nil {
+		claim, err = s.records.Claim(ctx, finishedPet, activity)
+		if err != nil {
 			return nil, err
 		}
 	}
-
-	if input.GameData != nil {
-		activity.GameData = input.GameData
+	activity.NewRecords, err = s.activityRepo.Update(ctx, activity, claim)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.activityRepo.Update(ctx, activity); err != nil {
-		return nil, err
+	if finishedPet != nil {
+		s.feedService.PublishActivity(ctx, userID, finishedPet, activity, s.leveling.LevelUp(finishedPet.Level, newLevel))
+		s.leaderboards.RecordActivity(ctx, finishedPet, activity, streakDays)
+		s.cosmetics.GrantLevelItems(ctx, finishedPet, newLevel)
+		s.achievements.RecordActivity(ctx, finishedPet, activity, streakDays)
 	}
 
 	return activity, nil
@@ -207,86 +320,198 @@ func (s *ActivityService) GetAllGameTypes(ctx context.Context) ([]*models.GameTy
 	return s.activityRepo.GetAllGameTypes(ctx)
 }
 
-func (s *ActivityService) calculateXP(gameType *models.GameType, activity *models.Activity) int {
-	var xpConfig struct {
-		BaseXPPerMinute    float64 `json:"base_xp_per_minute"`
-		DistanceBonusPerKM float64 `json:"distance_bonus_per_km"`
-		StreakMultiplier   float64 `json:"streak_multiplier"`
-		XPPerThrow         int     `json:"xp_per_throw"`
-		ComboBonus         int     `json:"combo_bonus"`
-		FrenzyMultiplier   float64 `json:"frenzy_multiplier"`
+// recordMissions advances the pet's missions with the finished activity and
+// adds the bonus XP of those it completed to the activity's XP.
+func (s *ActivityService) recordMissions(ctx context.Context, activity *models.Activity) error {
+	bonus, err := s.missionService.RecordActivity(ctx, activity)
+	if err != nil {
+		return err
+	}
+	activity.XPEarned += bonus
+	return nil
+}
+
+// AddIMUSamples takes a batch of accelerometer samples for a running
+// activity whose game type detects throws, and recounts its throws over
+// every sample uploaded so far.
+func (s *ActivityService) AddIMUSamples(ctx context.Context, userID, activityID uuid.UUID, input models.IMUBatchInput) (*models.Activity, error) {
+	activity, err := s.activityRepo.GetByID(ctx, activityID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrActivityNotFound) {
+			return nil, ErrActivityNotFound
+		}
+		return nil, err
 	}
 
-	if err := json.Unmarshal(gameType.XPConfig, &xpConfig); err != nil {
-		return 0
+	// Like finishing it, only whoever logged the activity or may edit the
+	// pet's activities can add to it
+	_, member, err := s.access.authorize(ctx, userID, activity.PetID, models.PermissionLogActivity)
+	if err != nil {
+		return nil, err
+	}
+	ownActivity := activity.PerformedBy != nil && *activity.PerformedBy == userID
+	if !member.Role.Can(models.PermissionEditActivities) && !ownActivity {
+		return nil, ErrUnauthorized
 	}
 
-	xp := 0
+	if activity.EndedAt != nil {
+		return nil, ErrActivityFinished
+	}
+	if activity.GameType == nil || activity.GameType.ThrowDetection == nil {
+		return nil, ErrThrowsNotDetected
+	}
+	if err := input.Validate(activity.StartedAt, time.Now()); err != nil {
+		return nil, err
+	}
 
-	switch gameType.ID {
-	case "walk":
-		// Base XP from duration
-		if activity.DurationSeconds != nil {
-			minutes := float64(*activity.DurationSeconds) / 60
-			xp += int(minutes * xpConfig.BaseXPPerMinute)
-		}
+	config := *activity.GameType.ThrowDetection
+	activity.GameData, err = s.activityRepo.AddIMUBatch(ctx, activity.ID, input.Samples, models.MaxIMUSamples,
+		func(detector *models.ThrowDetector, gameData json.RawMessage, samples []models.IMUSample) (json.RawMessage, error) {
+			detector.Feed(samples, config)
 
-		// Distance bonus
-		var walkData models.WalkGameData
-		if err := json.Unmarshal(activity.GameData, &walkData); err == nil {
-			distanceKM := walkData.DistanceMeters / 1000
-			xp += int(distanceKM * xpConfig.DistanceBonusPerKM)
-		}
+			var fetchData models.FetchGameData
+			if len(gameData) > 0 {
+				// Game data the app sent that doesn't parse is replaced
+				_ = json.Unmarshal(gameData, &fetchData)
+			}
+			fetchData.Apply(detector.Detection(config))
 
-	case "fetch":
-		var fetchData models.FetchGameData
-		if err := json.Unmarshal(activity.GameData, &fetchData); err == nil {
-			xp += fetchData.Throws * xpConfig.XPPerThrow
-			xp += (fetchData.MaxCombo / 5) * xpConfig.ComboBonus
-			if fetchData.FrenzyModeActivated {
-				xp = int(float64(xp) * xpConfig.FrenzyMultiplier)
+			running := *activity
+			var err error
+			if running.GameData, err = json.Marshal(fetchData); err != nil {
+				return nil, err
+			}
+			if err := prepareGameData(&running); err != nil {
+				return nil, err
 			}
+			return running.GameData, nil
+		})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrTooManyIMUSamples):
+			return nil, ErrTooManyIMUSamples
+		case errors.Is(err, repositories.ErrActivityFinished):
+			return nil, ErrActivityFinished
+		case errors.Is(err, repositories.ErrActivityNotFound):
+			return nil, ErrActivityNotFound
 		}
+		return nil, err
 	}
+	return activity, nil
+}
 
-	return xp
+// PurgeIMUSamples drops the accelerometer samples of activities that ended
+// a while ago; their throws were counted when they ended. It runs as a
+// background job.
+func (s *ActivityService) PurgeIMUSamples(ctx context.Context) error {
+	deleted, err := s.activityRepo.DeleteIMUBatchesEndedBefore(ctx, time.Now().Add(-imuRetention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Purged %d IMU sample batches", deleted)
+	}
+	return nil
 }
 
-func (s *ActivityService) updateStreak(ctx context.Context, pet *models.Pet) error {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+// keepDetectedThrows applies game data sent by the app, except for the
+// throws detected from the accelerometer, which the app can't override
+// with its own counts. An event log is scored instead, so it still
+// applies, but keeps the detected throws for the game to check it
+// against.
+func keepDetectedThrows(current, update json.RawMessage) json.RawMessage {
+	var detected models.FetchGameData
+	if err := json.Unmarshal(current, &detected); err != nil || !detected.ThrowsDetected {
+		return update
+	}
+	var fetchData models.FetchGameData
+	if err := json.Unmarshal(update, &fetchData); err != nil {
+		return current
+	}
+	if len(fetchData.Events) > 0 {
+		fetchData.ThrowsDetected = true
+		fetchData.ThrowTimes = detected.ThrowTimes
+	} else {
+		fetchData.Apply(&models.ThrowDetection{
+			ThrowTimes: detected.ThrowTimes,
+			Combos:     detected.Combos,
+			MaxCombo:   detected.MaxCombo,
+		})
+	}
+	merged, err := json.Marshal(fetchData)
+	if err != nil {
+		return current
+	}
+	return merged
+}
 
-	if pet.LastActivityAt == nil {
-		return s.petRepo.UpdateStreak(ctx, pet.ID, 1)
+// prepareGameData checks the game data the app sent with the activity's
+// game and fills in what the server works out itself.
+func prepareGameData(activity *models.Activity) error {
+	game := models.GameFor(activity.GameTypeID)
+	if err := game.Validate(activity); err != nil {
+		return err
 	}
+	return game.Prepare(activity)
+}
 
-	lastActivityDay := time.Date(
-		pet.LastActivityAt.Year(),
-		pet.LastActivityAt.Month(),
-		pet.LastActivityAt.Day(),
-		0, 0, 0, 0, pet.LastActivityAt.Location(),
-	)
+// calculateXP scores a finished activity by its game's XP config, the pet
+// type's multiplier for that game and the pet's unlocked skills.
+func (s *ActivityService) calculateXP(gameType *models.GameType, petType *models.PetType, skills []*models.Skill, activity *models.Activity) int {
+	xp := models.GameFor(gameType.ID).XP(gameType.XPConfig, activity)
+	xp = int(float64(xp) * petType.Settings().XPMultiplier(gameType.ID))
+	return models.ApplySkills(xp, skills, activity)
+}
 
-	daysSinceLastActivity := int(today.Sub(lastActivityDay).Hours() / 24)
+// updateStreak counts days in the owner's timezone, so the streak breaks at
+// their local midnight. It returns the pet's streak after the activity.
+func (s *ActivityService) updateStreak(ctx context.Context, pet *models.Pet) (int, error) {
+	if pet.LastActivityAt == nil {
+		return 1, s.petRepo.UpdateStreak(ctx, pet.ID, 1)
+	}
 
-	switch daysSinceLastActivity {
+	owner, err := s.userRepo.GetByID(ctx, pet.UserID)
+	if err != nil {
+		return 0, err
+	}
+	loc := time.UTC
+	if owner.Preferences != nil {
+		loc = owner.Preferences.Location()
+	}
+
+	switch calendarDaysBetween(*pet.LastActivityAt, time.Now(), loc) {
 	case 0:
 		// Same day, no streak change
-		return nil
+		return pet.StreakDays, nil
 	case 1:
 		// Consecutive day, increment streak
-		return s.petRepo.UpdateStreak(ctx, pet.ID, pet.StreakDays+1)
+		return pet.StreakDays + 1, s.petRepo.UpdateStreak(ctx, pet.ID, pet.StreakDays+1)
 	default:
 		// Streak broken, reset to 1
-		return s.petRepo.UpdateStreak(ctx, pet.ID, 1)
+		return 1, s.petRepo.UpdateStreak(ctx, pet.ID, 1)
 	}
 }
 
-func isGameTypeSupported(gameType *models.GameType, petTypeID string) bool {
+// calendarDaysBetween counts the local midnights between from and to.
+func calendarDaysBetween(from, to time.Time, loc *time.Location) int {
+	from, to = from.In(loc), to.In(loc)
+	// Compare dates in UTC so DST changes don't shorten a day
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// isGameTypeSupported reports whether pets of the type can play the game.
+// Either side may list the other, so a new pet type only needs its own
+// default activities.
+func isGameTypeSupported(gameType *models.GameType, petType *models.PetType) bool {
+	if petType == nil {
+		return false
+	}
 	for _, supported := range gameType.SupportedPetTypes {
-		if supported == petTypeID {
+		if supported == petType.ID {
 			return true
 		}
 	}
-	return false
+	return petType.Config.PlaysActivity(gameType.ID)
 }
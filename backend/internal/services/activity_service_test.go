@@ -2,6 +2,8 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -73,7 +75,7 @@ func TestCalculateXP_WalkGame(t *testing.T) {
 				GameData:        gameDataJSON,
 			}
 
-			xp := service.calculateXP(gameType, activity)
+			xp := service.calculateXP(gameType, nil, nil, activity)
 
 			if xp < tt.expectedMinXP || xp > tt.expectedMaxXP {
 				t.Errorf("calculateXP() = %d, want between %d and %d",
@@ -158,7 +160,7 @@ func TestCalculateXP_FetchGame(t *testing.T) {
 				GameData:        gameDataJSON,
 			}
 
-			xp := service.calculateXP(gameType, activity)
+			xp := service.calculateXP(gameType, nil, nil, activity)
 
 			if xp != tt.expectedXP {
 				t.Errorf("calculateXP() = %d, want %d", xp, tt.expectedXP)
@@ -182,7 +184,7 @@ func TestCalculateXP_InvalidGameData(t *testing.T) {
 	duration := 600
 	activity.DurationSeconds = &duration
 
-	xp := service.calculateXP(gameType, activity)
+	xp := service.calculateXP(gameType, nil, nil, activity)
 	// Even with invalid game data, base XP from duration should still be calculated
 	// 10 minutes * 2 XP/minute = 20 XP
 	expectedXP := 20
@@ -275,3 +277,188 @@ func (s *ActivityService) calculateExpectedStreak(pet *models.Pet) int {
 		return 1 // Streak broken
 	}
 }
+
+func TestCalculateXP_PetTypeMultiplier(t *testing.T) {
+	service := &ActivityService{}
+	gameType := &models.GameType{
+		ID:       "walk",
+		XPConfig: json.RawMessage(`{"base_xp_per_minute": 2}`),
+	}
+	config := models.DefaultPetTypeConfig()
+	config.XPMultipliers = map[string]float64{"walk": 1.5}
+	duration := 600
+	activity := &models.Activity{GameTypeID: "walk", DurationSeconds: &duration}
+
+	if xp := service.calculateXP(gameType, &models.PetType{ID: "cat", Config: config}, nil, activity); xp != 30 {
+		t.Errorf("calculateXP() = %d, want 30", xp)
+	}
+}
+
+func TestCalculateXP_Play(t *testing.T) {
+	service := &ActivityService{}
+	gameType := &models.GameType{
+		ID:       "play",
+		XPConfig: json.RawMessage(`{"base_xp_per_minute": 2, "xp_per_round": 2, "xp_per_pounce": 1, "intensity_multipliers": {"high": 1.5}}`),
+	}
+	duration := 600
+	activity := &models.Activity{
+		GameTypeID:      "play",
+		DurationSeconds: &duration,
+		GameData:        json.RawMessage(`{"toy_type": "wand", "rounds": 6, "pounces": 22, "intensity": "high"}`),
+	}
+
+	// (20 + 12 + 22) × 1.5
+	if xp := service.calculateXP(gameType, nil, nil, activity); xp != 81 {
+		t.Errorf("calculateXP() = %d, want 81", xp)
+	}
+}
+
+func TestCalculateXP_Skills(t *testing.T) {
+	service := &ActivityService{}
+	gameType := &models.GameType{
+		ID:       "walk",
+		XPConfig: json.RawMessage(`{"base_xp_per_minute": 2}`),
+	}
+	duration := 3600
+	activity := &models.Activity{GameTypeID: "walk", DurationSeconds: &duration}
+	longWalker := &models.Skill{ID: "long_walker", Effect: models.SkillEffect{GameTypeID: "walk", MinMinutes: 30, XPMultiplier: 1.1}}
+
+	// 60 minutes at 2 XP a minute, +10%
+	if xp := service.calculateXP(gameType, nil, []*models.Skill{longWalker}, activity); xp != 132 {
+		t.Errorf("calculateXP() = %d, want 132", xp)
+	}
+}
+
+func TestIsGameTypeSupported(t *testing.T) {
+	fetch := &models.GameType{ID: "fetch", SupportedPetTypes: []string{"dog"}}
+	dog := &models.PetType{ID: "dog", Config: models.DefaultPetTypeConfig()}
+
+	rabbitConfig := models.DefaultPetTypeConfig()
+	rabbitConfig.DefaultActivities = []string{"fetch"}
+	rabbit := &models.PetType{ID: "rabbit", Config: rabbitConfig}
+	horse := &models.PetType{ID: "horse", Config: models.DefaultPetTypeConfig()}
+
+	if !isGameTypeSupported(fetch, dog) {
+		t.Error("game types listing a pet type are supported")
+	}
+	if !isGameTypeSupported(fetch, rabbit) {
+		t.Error("pet types listing a game type are supported")
+	}
+	if isGameTypeSupported(fetch, horse) {
+		t.Error("horses don't fetch")
+	}
+}
+
+func TestKeepDetectedThrows(t *testing.T) {
+	detected := json.RawMessage(`{"throws": 3, "returns": 0, "success_rate": 0, "max_combo": 3, "frenzy_mode_activated": false,
+		"throws_detected": true, "throw_times": ["2025-10-09T09:00:01Z", "2025-10-09T09:00:09Z", "2025-10-09T09:00:15Z"]}`)
+	update := json.RawMessage(`{"throws": 12, "returns": 2, "success_rate": 0.17, "max_combo": 12, "frenzy_mode_activated": true}`)
+
+	var fetchData models.FetchGameData
+	if err := json.Unmarshal(keepDetectedThrows(detected, update), &fetchData); err != nil {
+		t.Fatal(err)
+	}
+	// The app's returns and frenzy mode apply; its throw counts don't
+	if fetchData.Throws != 3 || fetchData.MaxCombo != 3 || len(fetchData.ThrowTimes) != 3 ||
+		fetchData.Returns != 2 || !fetchData.FrenzyModeActivated {
+		t.Errorf("keepDetectedThrows() = %+v", fetchData)
+	}
+
+	counted := json.RawMessage(`{"throws": 3, "returns": 3}`)
+	if got := keepDetectedThrows(counted, update); string(got) != string(update) {
+		t.Errorf("keepDetectedThrows() without detected throws = %s, want the update", got)
+	}
+	// An event log is scored rather than taken at its word, and keeps the
+	// detected throws to be checked against
+	logged := json.RawMessage(`{"events": [{"type": "throw", "at": "2025-10-09T09:00:01Z"}]}`)
+	fetchData = models.FetchGameData{}
+	if err := json.Unmarshal(keepDetectedThrows(detected, logged), &fetchData); err != nil {
+		t.Fatal(err)
+	}
+	if len(fetchData.Events) != 1 || !fetchData.ThrowsDetected || len(fetchData.ThrowTimes) != 3 {
+		t.Errorf("keepDetectedThrows() with events = %+v", fetchData)
+	}
+}
+
+// fetchActivity is a fetch activity from 9:00 to 9:10 with the game data.
+func fetchActivity(t *testing.T, gameData string) *models.Activity {
+	t.Helper()
+	startedAt := time.Date(2025, 10, 9, 9, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(10 * time.Minute)
+	duration := 600
+	return &models.Activity{
+		GameTypeID: "fetch",
+		GameType: &models.GameType{
+			ID:       "fetch",
+			XPConfig: json.RawMessage(`{"xp_per_throw": 1, "combo_bonus": 5, "frenzy_multiplier": 2}`),
+		},
+		StartedAt:       startedAt,
+		EndedAt:         &endedAt,
+		DurationSeconds: &duration,
+		GameData:        json.RawMessage(gameData),
+	}
+}
+
+func TestPrepareGameData_Fetch(t *testing.T) {
+	// The app's counts are replaced by the log's: two throws, one returned
+	activity := fetchActivity(t, `{"throws": 50, "returns": 50, "max_combo": 50, "frenzy_mode_activated": true, "events": [
+		{"type": "throw", "at": "2025-10-09T09:01:00Z"}, {"type": "return", "at": "2025-10-09T09:01:05Z"},
+		{"type": "throw", "at": "2025-10-09T09:01:10Z"}, {"type": "miss", "at": "2025-10-09T09:01:20Z"}]}`)
+	if err := prepareGameData(activity); err != nil {
+		t.Fatalf("prepareGameData() error = %v", err)
+	}
+	var fetchData models.FetchGameData
+	if err := json.Unmarshal(activity.GameData, &fetchData); err != nil {
+		t.Fatal(err)
+	}
+	if fetchData.Throws != 2 || fetchData.Returns != 1 || fetchData.SuccessRate != 0.5 ||
+		fetchData.MaxCombo != 1 || fetchData.FrenzyModeActivated || len(fetchData.ThrowLog) != 2 {
+		t.Errorf("prepareGameData() = %+v", fetchData)
+	}
+
+	// Without a log the app's counts stand
+	counted := fetchActivity(t, `{"throws": 50, "returns": 50}`)
+	if err := prepareGameData(counted); err != nil || string(counted.GameData) != `{"throws": 50, "returns": 50}` {
+		t.Errorf("prepareGameData() without events = %s, %v", counted.GameData, err)
+	}
+
+	late := fetchActivity(t, `{"events": [{"type": "throw", "at": "2025-10-09T10:00:00Z"}]}`)
+	if err := prepareGameData(late); !errors.Is(err, models.ErrInvalidFetchEvents) {
+		t.Errorf("prepareGameData() after the activity error = %v, want ErrInvalidFetchEvents", err)
+	}
+
+	// Finishing with a log of throws the accelerometer never saw
+	detected := json.RawMessage(`{"throws": 1, "throws_detected": true, "throw_times": ["2025-10-09T09:01:00Z"]}`)
+	madeUp := fetchActivity(t, "")
+	madeUp.GameData = keepDetectedThrows(detected, json.RawMessage(`{"events": [
+		{"type": "throw", "at": "2025-10-09T09:01:00Z"}, {"type": "return", "at": "2025-10-09T09:01:05Z"},
+		{"type": "throw", "at": "2025-10-09T09:01:10Z"}, {"type": "return", "at": "2025-10-09T09:01:15Z"}]}`))
+	if err := prepareGameData(madeUp); !errors.Is(err, models.ErrInvalidFetchEvents) {
+		t.Errorf("prepareGameData() with undetected throws error = %v, want ErrInvalidFetchEvents", err)
+	}
+
+	// Until it finishes, the samples for logged throws may still be coming
+	madeUp.EndedAt = nil
+	if err := prepareGameData(madeUp); err != nil {
+		t.Errorf("prepareGameData() of a running activity error = %v", err)
+	}
+}
+
+func TestCalculateXP_FetchEvents(t *testing.T) {
+	service := &ActivityService{}
+
+	// Eleven returns in a row earn two combo bonuses and start a frenzy that
+	// doubles the last two of the twelve throws; the app's counts are
+	// ignored
+	events := `{"type": "throw", "at": "2025-10-09T09:00:00Z"}`
+	for i := 0; i < 11; i++ {
+		at := time.Date(2025, 10, 9, 9, 1, i*2, 0, time.UTC)
+		events += fmt.Sprintf(`, {"type": "return", "at": %q}, {"type": "throw", "at": %q}`,
+			at.Format(time.RFC3339), at.Add(time.Second).Format(time.RFC3339))
+	}
+	activity := fetchActivity(t, `{"throws": 100, "max_combo": 100, "events": [`+events+`]}`)
+
+	if xp := service.calculateXP(activity.GameType, nil, nil, activity); xp != 24 {
+		t.Errorf("calculateXP() = %d, want 24", xp)
+	}
+}
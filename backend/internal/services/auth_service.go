@@ -6,30 +6,66 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/ratelimit"
 	"github.com/joaosantos/pettime/internal/repositories"
 	"github.com/joaosantos/pettime/pkg/jwt"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidCredentials    = errors.New("invalid credentials")
+	ErrUserExists            = errors.New("user already exists")
+	ErrAccountLinkRequired   = errors.New("an account with this email already exists; sign in and link the provider")
+	ErrIdentityInUse         = errors.New("identity is linked to another account")
+	ErrIdentityAlreadyLinked = errors.New("provider already linked")
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrLastIdentity          = errors.New("cannot remove the last login method")
+	ErrInvalidProvider       = errors.New("invalid provider")
+	ErrNoPassword            = errors.New("account has no password")
+	ErrTooManyAttempts       = errors.New("too many failed login attempts")
+	ErrProviderNotEnabled    = errors.New("provider is not enabled")
+	ErrInvalidIDToken        = errors.New("invalid identity token")
 )
 
+// IdentityVerifier checks an ID token from a social login provider and
+// returns who it was issued to.
+type IdentityVerifier interface {
+	Verify(ctx context.Context, token string) (*jwt.Identity, error)
+}
+
+// TooManyAttemptsError is returned when an account is locked out after
+// repeated failed logins.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *TooManyAttemptsError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
 type AuthService struct {
 	userRepo        *repositories.UserRepository
 	jwtManager      *jwt.Manager
+	verifiers       map[models.AuthProvider]IdentityVerifier
+	loginBackoff    *ratelimit.Backoff
 	refreshTokenTTL time.Duration
 }
 
-func NewAuthService(userRepo *repositories.UserRepository, jwtManager *jwt.Manager, refreshTokenTTL time.Duration) *AuthService {
+func NewAuthService(userRepo *repositories.UserRepository, jwtManager *jwt.Manager, verifiers map[models.AuthProvider]IdentityVerifier, loginBackoff *ratelimit.Backoff, refreshTokenTTL time.Duration) *AuthService {
 	return &AuthService{
 		userRepo:        userRepo,
 		jwtManager:      jwtManager,
+		verifiers:       verifiers,
+		loginBackoff:    loginBackoff,
 		refreshTokenTTL: refreshTokenTTL,
 	}
 }
@@ -50,17 +86,20 @@ func (s *AuthService) Register(ctx context.Context, input models.CreateUserInput
 
 	// Create user
 	now := time.Now()
+	preferences := models.DefaultPreferences()
 	user := &models.User{
 		ID:           uuid.New(),
 		Email:        input.Email,
 		PasswordHash: &passwordHash,
 		Name:         input.Name,
 		AuthProvider: models.AuthProviderEmail,
+		Preferences:  &preferences,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	identity := newIdentity(user.ID, models.AuthProviderEmail, user.Email, user.Email)
+	if err := s.userRepo.CreateWithIdentity(ctx, user, identity); err != nil {
 		if errors.Is(err, repositories.ErrUserAlreadyExists) {
 			return nil, nil, ErrUserExists
 		}
@@ -68,7 +107,7 @@ func (s *AuthService) Register(ctx context.Context, input models.CreateUserInput
 	}
 
 	// Generate tokens
-	tokens, err := s.generateTokens(ctx, user)
+	tokens, err := s.generateTokens(ctx, user, now)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -77,23 +116,30 @@ func (s *AuthService) Register(ctx context.Context, input models.CreateUserInput
 }
 
 func (s *AuthService) Login(ctx context.Context, input models.LoginInput) (*models.User, *models.AuthTokens, error) {
-	user, err := s.userRepo.GetByEmail(ctx, input.Email)
+	retryAfter, err := s.loginBackoff.Attempt(ctx, input.Email, time.Now())
 	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
-			return nil, nil, ErrInvalidCredentials
-		}
 		return nil, nil, err
 	}
+	if retryAfter > 0 {
+		return nil, nil, &TooManyAttemptsError{RetryAfter: retryAfter}
+	}
 
-	if user.PasswordHash == nil {
-		return nil, nil, ErrInvalidCredentials
+	user, err := s.authenticate(ctx, input)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(input.Password)); err != nil {
-		return nil, nil, ErrInvalidCredentials
+	if err := s.loginBackoff.Success(ctx, input.Email); err != nil {
+		return nil, nil, err
 	}
 
-	tokens, err := s.generateTokens(ctx, user)
+	_ = s.userRepo.TouchIdentity(ctx, models.AuthProviderEmail, user.Email)
+
+	if err := s.cancelPendingDeletion(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.generateTokens(ctx, user, time.Now())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -101,41 +147,88 @@ func (s *AuthService) Login(ctx context.Context, input models.LoginInput) (*mode
 	return user, tokens, nil
 }
 
+// authenticate checks email and password. Unknown emails and wrong
+// passwords both return ErrInvalidCredentials.
+func (s *AuthService) authenticate(ctx context.Context, input models.LoginInput) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if user.PasswordHash == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(input.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// SocialLogin signs in with a linked provider identity. An unknown identity
+// whose email already belongs to an account is not merged automatically: the
+// user has to sign in with an existing method and link the provider.
 func (s *AuthService) SocialLogin(ctx context.Context, input models.SocialLoginInput) (*models.User, *models.AuthTokens, error) {
-	// Try to find existing user by provider
-	user, err := s.userRepo.GetByProvider(ctx, input.Provider, input.ProviderID)
+	verified, err := s.verifyIdentity(ctx, input.Provider, input.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.GetByProvider(ctx, input.Provider, verified.Subject)
 	if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
 		return nil, nil, err
 	}
 
-	if user == nil {
-		// Try to find by email
-		user, err = s.userRepo.GetByEmail(ctx, input.Email)
+	if user != nil {
+		_ = s.userRepo.TouchIdentity(ctx, input.Provider, verified.Subject)
+
+		if err := s.cancelPendingDeletion(ctx, user); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// New accounts are keyed by email, so it has to be one the
+		// provider vouches for
+		if verified.Email == "" {
+			return nil, nil, ErrInvalidIDToken
+		}
+
+		existing, err := s.userRepo.GetByEmail(ctx, verified.Email)
 		if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
 			return nil, nil, err
 		}
+		if existing != nil {
+			return nil, nil, ErrAccountLinkRequired
+		}
 
-		if user == nil {
-			// Create new user
-			providerID := input.ProviderID
-			now := time.Now()
-			user = &models.User{
-				ID:             uuid.New(),
-				Email:          input.Email,
-				Name:           input.Name,
-				AuthProvider:   input.Provider,
-				AuthProviderID: &providerID,
-				CreatedAt:      now,
-				UpdatedAt:      now,
-			}
+		// Create new user
+		providerID := verified.Subject
+		now := time.Now()
+		preferences := models.DefaultPreferences()
+		user = &models.User{
+			ID:             uuid.New(),
+			Email:          verified.Email,
+			Name:           input.Name,
+			AuthProvider:   input.Provider,
+			AuthProviderID: &providerID,
+			Preferences:    &preferences,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
 
-			if err := s.userRepo.Create(ctx, user); err != nil {
-				return nil, nil, err
+		identity := newIdentity(user.ID, input.Provider, verified.Subject, verified.Email)
+		if err := s.userRepo.CreateWithIdentity(ctx, user, identity); err != nil {
+			if errors.Is(err, repositories.ErrUserAlreadyExists) {
+				return nil, nil, ErrAccountLinkRequired
 			}
+			return nil, nil, err
 		}
 	}
 
-	tokens, err := s.generateTokens(ctx, user)
+	tokens, err := s.generateTokens(ctx, user, time.Now())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -156,11 +249,23 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, err
 	}
 
-	// Delete old refresh token
-	_ = s.userRepo.DeleteRefreshToken(ctx, tokenHash)
+	// Rotate the token within the same session, keeping the original login
+	// time. Devices registered for push stay attached to the session.
+	refreshToken, err = newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	storedToken.TokenHash = hashToken(refreshToken)
+	storedToken.ExpiresAt = time.Now().Add(s.refreshTokenTTL)
+
+	if err := s.userRepo.RotateRefreshToken(ctx, storedToken, tokenHash); err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
 
-	// Generate new tokens
-	return s.generateTokens(ctx, user)
+	return s.authTokens(user, storedToken, refreshToken)
 }
 
 func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
@@ -168,19 +273,180 @@ func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	return s.userRepo.DeleteRefreshToken(ctx, tokenHash)
 }
 
-func (s *AuthService) generateTokens(ctx context.Context, user *models.User) (*models.AuthTokens, error) {
-	// Generate access token
-	accessToken, err := s.jwtManager.GenerateAccessToken(user.ID, user.Email)
+// ChangePassword replaces the password of an email user. Every other session
+// is signed out and fresh tokens are returned for the current one.
+func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, input models.ChangePasswordInput) (*models.AuthTokens, error) {
+	// Wrong current passwords back off like logins, so a stolen session
+	// can't be used to guess the password. Keyed by user ID, which never
+	// collides with the emails logins are keyed by.
+	account := userID.String()
+	retryAfter, err := s.loginBackoff.Attempt(ctx, account, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if retryAfter > 0 {
+		return nil, &TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PasswordHash == nil {
+		return nil, ErrNoPassword
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(input.CurrentPassword)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.loginBackoff.Success(ctx, account); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.DeleteUserRefreshTokens(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.generateTokens(ctx, user, time.Now())
+}
+
+// cancelPendingDeletion keeps an account that was scheduled for deletion,
+// since signing in again means the user changed their mind.
+func (s *AuthService) cancelPendingDeletion(ctx context.Context, user *models.User) error {
+	if user.DeletionScheduledAt == nil {
+		return nil
+	}
+	if err := s.userRepo.CancelDeletion(ctx, user.ID); err != nil {
+		return err
+	}
+	user.DeletionScheduledAt = nil
+	return nil
+}
+
+// Identities
+
+func (s *AuthService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error) {
+	return s.userRepo.GetIdentities(ctx, userID)
+}
+
+// LinkIdentity adds a login method to the signed in user. Linking the email
+// provider sets a password on the account.
+func (s *AuthService) LinkIdentity(ctx context.Context, userID uuid.UUID, input models.LinkIdentityInput) (*models.UserIdentity, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate refresh token
-	refreshTokenBytes := make([]byte, 32)
-	if _, err := rand.Read(refreshTokenBytes); err != nil {
+	switch input.Provider {
+	case models.AuthProviderEmail:
+		if len(input.Password) < 8 {
+			return nil, ErrInvalidCredentials
+		}
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+
+		identity := newIdentity(user.ID, models.AuthProviderEmail, user.Email, user.Email)
+		if err := s.userRepo.LinkPassword(ctx, identity, string(hashedPassword)); err != nil {
+			return nil, s.mapLinkError(ctx, user.ID, models.AuthProviderEmail, user.Email, err)
+		}
+		return identity, nil
+
+	case models.AuthProviderGoogle, models.AuthProviderApple:
+		verified, err := s.verifyIdentity(ctx, input.Provider, input.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		identity := newIdentity(user.ID, input.Provider, verified.Subject, verified.Email)
+		if err := s.userRepo.CreateIdentity(ctx, identity); err != nil {
+			return nil, s.mapLinkError(ctx, user.ID, input.Provider, verified.Subject, err)
+		}
+		return identity, nil
+
+	default:
+		return nil, ErrInvalidProvider
+	}
+}
+
+func (s *AuthService) UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider models.AuthProvider) error {
+	err := s.userRepo.DeleteIdentity(ctx, userID, provider)
+	switch {
+	case errors.Is(err, repositories.ErrIdentityNotFound):
+		return ErrIdentityNotFound
+	case errors.Is(err, repositories.ErrLastIdentity):
+		return ErrLastIdentity
+	}
+	return err
+}
+
+// verifyIdentity checks a social login provider's ID token. Identities are
+// only ever taken from verified tokens, never from what the client says.
+func (s *AuthService) verifyIdentity(ctx context.Context, provider models.AuthProvider, token string) (*jwt.Identity, error) {
+	verifier, ok := s.verifiers[provider]
+	if !ok {
+		return nil, ErrProviderNotEnabled
+	}
+	if token == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	identity, err := verifier.Verify(ctx, token)
+	if err != nil {
+		if errors.Is(err, jwt.ErrInvalidIDToken) {
+			return nil, ErrInvalidIDToken
+		}
+		return nil, err
+	}
+	return identity, nil
+}
+
+// mapLinkError tells apart a provider account that belongs to someone else
+// from a provider the user has already linked.
+func (s *AuthService) mapLinkError(ctx context.Context, userID uuid.UUID, provider models.AuthProvider, providerID string, err error) error {
+	if !errors.Is(err, repositories.ErrIdentityAlreadyExists) {
+		return err
+	}
+
+	owner, lookupErr := s.userRepo.GetByProvider(ctx, provider, providerID)
+	if lookupErr == nil && owner.ID != userID {
+		return ErrIdentityInUse
+	}
+	return ErrIdentityAlreadyLinked
+}
+
+func newIdentity(userID uuid.UUID, provider models.AuthProvider, providerID, email string) *models.UserIdentity {
+	identity := &models.UserIdentity{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Provider:   provider,
+		ProviderID: providerID,
+		CreatedAt:  time.Now(),
+	}
+	if email != "" {
+		identity.Email = &email
+	}
+	return identity
+}
+
+// generateTokens starts a new session for the user.
+func (s *AuthService) generateTokens(ctx context.Context, user *models.User, authTime time.Time) (*models.AuthTokens, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
 		return nil, err
 	}
-	refreshToken := hex.EncodeToString(refreshTokenBytes)
 
 	// Store refresh token
 	now := time.Now()
@@ -188,6 +454,7 @@ func (s *AuthService) generateTokens(ctx context.Context, user *models.User) (*m
 		ID:        uuid.New(),
 		UserID:    user.ID,
 		TokenHash: hashToken(refreshToken),
+		AuthTime:  authTime,
 		ExpiresAt: now.Add(s.refreshTokenTTL),
 		CreatedAt: now,
 	}
@@ -196,6 +463,17 @@ func (s *AuthService) generateTokens(ctx context.Context, user *models.User) (*m
 		return nil, err
 	}
 
+	return s.authTokens(user, tokenRecord, refreshToken)
+}
+
+// authTokens signs an access token for the session and pairs it with the
+// session's current refresh token.
+func (s *AuthService) authTokens(user *models.User, session *models.RefreshToken, refreshToken string) (*models.AuthTokens, error) {
+	accessToken, err := s.jwtManager.GenerateAccessToken(user.ID, session.ID, user.Email, session.AuthTime)
+	if err != nil {
+		return nil, err
+	}
+
 	return &models.AuthTokens{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -203,6 +481,14 @@ func (s *AuthService) generateTokens(ctx context.Context, user *models.User) (*m
 	}, nil
 }
 
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 func hashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(hash[:])
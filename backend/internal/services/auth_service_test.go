@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/pkg/jwt"
+)
+
+type fakeVerifier map[string]*jwt.Identity
+
+func (f fakeVerifier) Verify(ctx context.Context, token string) (*jwt.Identity, error) {
+	if identity, ok := f[token]; ok {
+		return identity, nil
+	}
+	return nil, fmt.Errorf("%w: unknown token", jwt.ErrInvalidIDToken)
+}
+
+func TestVerifyIdentity(t *testing.T) {
+	service := &AuthService{verifiers: map[models.AuthProvider]IdentityVerifier{
+		models.AuthProviderGoogle: fakeVerifier{"good": {Subject: "google-1", Email: "mia@example.com"}},
+	}}
+
+	identity, err := service.verifyIdentity(context.Background(), models.AuthProviderGoogle, "good")
+	if err != nil || identity.Subject != "google-1" {
+		t.Errorf("verifyIdentity() = %+v, %v", identity, err)
+	}
+
+	if _, err := service.verifyIdentity(context.Background(), models.AuthProviderGoogle, "forged"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("forged token: error = %v, want ErrInvalidIDToken", err)
+	}
+	if _, err := service.verifyIdentity(context.Background(), models.AuthProviderGoogle, ""); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("missing token: error = %v, want ErrInvalidIDToken", err)
+	}
+	if _, err := service.verifyIdentity(context.Background(), models.AuthProviderApple, "good"); !errors.Is(err, ErrProviderNotEnabled) {
+		t.Errorf("unconfigured provider: error = %v, want ErrProviderNotEnabled", err)
+	}
+}
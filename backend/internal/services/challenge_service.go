@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/notify"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+const (
+	// maxChallengeParticipants caps the size of a pack.
+	maxChallengeParticipants = 50
+	// challengeTitleDuration is how long winners hold a challenge's title.
+	challengeTitleDuration = 7 * 24 * time.Hour
+	// challengeHistory is how long ended challenges stay in the list.
+	challengeHistory = 30 * 24 * time.Hour
+	// challengeBatchSize is how many ended challenges one pass finishes.
+	challengeBatchSize = 100
+	// inviteCodeAttempts is how often a fresh invite code is drawn when
+	// the previous one is taken.
+	inviteCodeAttempts = 5
+)
+
+var (
+	ErrChallengeNotFound         = errors.New("challenge not found")
+	ErrChallengeTemplateNotFound = errors.New("challenge template not found")
+	ErrChallengeFinished         = errors.New("challenge has ended")
+	ErrChallengeFull             = errors.New("challenge is full")
+	ErrNotFriends                = errors.New("only friends can be added to a challenge")
+)
+
+// ChallengeService runs pack challenges: groups of friends competing on a
+// template's metric over a day or a week. Standings are computed from
+// activities on every read; when a challenge ends, a background pass
+// records the results and rewards the winners.
+type ChallengeService struct {
+	challengeRepo *repositories.ChallengeRepository
+	friendRepo    *repositories.FriendRepository
+	userRepo      *repositories.UserRepository
+	channel       notify.Channel
+}
+
+func NewChallengeService(
+	challengeRepo *repositories.ChallengeRepository,
+	friendRepo *repositories.FriendRepository,
+	userRepo *repositories.UserRepository,
+	channel notify.Channel,
+) *ChallengeService {
+	return &ChallengeService{
+		challengeRepo: challengeRepo,
+		friendRepo:    friendRepo,
+		userRepo:      userRepo,
+		channel:       channel,
+	}
+}
+
+// ListTemplates returns the challenges users can start.
+func (s *ChallengeService) ListTemplates(ctx context.Context) ([]*models.ChallengeTemplate, error) {
+	templates, err := s.challengeRepo.ListTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := make([]*models.ChallengeTemplate, 0, len(templates))
+	for _, template := range templates {
+		if template.Enabled {
+			enabled = append(enabled, template)
+		}
+	}
+	return enabled, nil
+}
+
+// Create starts a challenge for the current period in the user's timezone,
+// with the user taking part and the given friends invited. Friends who keep
+// their activity private aren't invited, since taking part would share it.
+// Others join with the invite code.
+func (s *ChallengeService) Create(ctx context.Context, userID uuid.UUID, input models.CreateChallengeInput) (*models.Challenge, error) {
+	template, err := s.challengeRepo.GetTemplate(ctx, input.TemplateID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrChallengeTemplateNotFound) {
+			return nil, ErrChallengeTemplateNotFound
+		}
+		return nil, err
+	}
+	if !template.Enabled {
+		return nil, ErrChallengeTemplateNotFound
+	}
+
+	var inviteeIDs []uuid.UUID
+	for _, friendID := range input.FriendIDs {
+		if friendID == userID || slices.Contains(inviteeIDs, friendID) {
+			continue
+		}
+		friends, err := s.friendRepo.AreFriends(ctx, userID, friendID)
+		if err != nil {
+			return nil, err
+		}
+		if !friends {
+			return nil, ErrNotFriends
+		}
+		friend, err := s.userRepo.GetByID(ctx, friendID)
+		if err != nil {
+			return nil, err
+		}
+		if preferencesOf(friend).Privacy.ActivityVisibility == models.VisibilityPrivate {
+			continue
+		}
+		inviteeIDs = append(inviteeIDs, friendID)
+	}
+	if len(inviteeIDs)+1 > maxChallengeParticipants {
+		return nil, ErrChallengeFull
+	}
+
+	creator, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := preferencesOf(creator)
+
+	now := time.Now()
+	startsAt, endsAt := template.Period.Window(now, prefs.Location())
+	challenge := &models.Challenge{
+		ID:         uuid.New(),
+		TemplateID: template.ID,
+		Template:   template,
+		CreatedBy:  &userID,
+		Timezone:   prefs.Timezone,
+		StartsAt:   startsAt,
+		EndsAt:     endsAt,
+		CreatedAt:  now,
+	}
+
+	for range inviteCodeAttempts {
+		if challenge.InviteCode, err = generateInvitationCode(); err != nil {
+			return nil, err
+		}
+		err = s.challengeRepo.Create(ctx, challenge, userID, inviteeIDs)
+		if !errors.Is(err, repositories.ErrInviteCodeTaken) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inviteeID := range inviteeIDs {
+		err := s.channel.Send(ctx, &notify.Notification{
+			UserID:   inviteeID,
+			Category: notify.CategorySocial,
+			Template: "challenge_invite",
+			Params:   map[string]string{"name": creator.Name, "challenge": template.Name},
+			Data:     map[string]string{"challenge_id": challenge.ID.String()},
+		})
+		if err != nil {
+			log.Printf("Failed to send challenge_invite notification to user %s: %v", inviteeID, err)
+		}
+	}
+
+	return s.withStandings(ctx, challenge)
+}
+
+// ListInvitations returns the running challenges the user was invited to
+// and hasn't answered.
+func (s *ChallengeService) ListInvitations(ctx context.Context, userID uuid.UUID) ([]*models.Challenge, error) {
+	return s.challengeRepo.ListInvitations(ctx, userID, time.Now())
+}
+
+// AcceptInvitation makes the user a participant of a challenge they were
+// invited to.
+func (s *ChallengeService) AcceptInvitation(ctx context.Context, userID, challengeID uuid.UUID) (*models.Challenge, error) {
+	invited, err := s.challengeRepo.IsInvited(ctx, challengeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !invited {
+		return nil, ErrChallengeNotFound
+	}
+
+	challenge, err := s.challengeRepo.GetByID(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrChallengeNotFound) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, err
+	}
+	return s.addParticipant(ctx, userID, challenge)
+}
+
+// DeclineInvitation turns down the user's invitation to a challenge.
+func (s *ChallengeService) DeclineInvitation(ctx context.Context, userID, challengeID uuid.UUID) error {
+	declined, err := s.challengeRepo.DeclineInvitation(ctx, challengeID, userID)
+	if err != nil {
+		return err
+	}
+	if !declined {
+		return ErrChallengeNotFound
+	}
+	return nil
+}
+
+// Join adds the user to the challenge with the invite code.
+func (s *ChallengeService) Join(ctx context.Context, userID uuid.UUID, input models.JoinChallengeInput) (*models.Challenge, error) {
+	challenge, err := s.challengeRepo.GetByInviteCode(ctx, strings.ToUpper(strings.TrimSpace(input.Code)))
+	if err != nil {
+		if errors.Is(err, repositories.ErrChallengeNotFound) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, err
+	}
+
+	return s.addParticipant(ctx, userID, challenge)
+}
+
+// addParticipant adds the user to a running challenge. Users blocked by or
+// blocking a participant are told it doesn't exist, as they are elsewhere.
+func (s *ChallengeService) addParticipant(ctx context.Context, userID uuid.UUID, challenge *models.Challenge) (*models.Challenge, error) {
+	now := time.Now()
+	if challenge.Finished() || !now.Before(challenge.EndsAt) {
+		return nil, ErrChallengeFinished
+	}
+
+	if err := s.challengeRepo.AddParticipant(ctx, challenge.ID, userID, maxChallengeParticipants, now); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrChallengeFull):
+			return nil, ErrChallengeFull
+		case errors.Is(err, repositories.ErrChallengeBlocked):
+			return nil, ErrChallengeNotFound
+		}
+		return nil, err
+	}
+
+	return s.withStandings(ctx, challenge)
+}
+
+// Leave removes the user from a running challenge.
+func (s *ChallengeService) Leave(ctx context.Context, userID, challengeID uuid.UUID) error {
+	challenge, err := s.participating(ctx, userID, challengeID)
+	if err != nil {
+		return err
+	}
+	if challenge.Finished() {
+		return ErrChallengeFinished
+	}
+
+	removed, err := s.challengeRepo.RemoveParticipant(ctx, challenge.ID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrChallengeNotFound
+	}
+	return nil
+}
+
+// List returns the user's running challenges and those that ended
+// recently.
+func (s *ChallengeService) List(ctx context.Context, userID uuid.UUID) ([]*models.Challenge, error) {
+	return s.challengeRepo.ListForUser(ctx, userID, time.Now().Add(-challengeHistory))
+}
+
+// Get returns a challenge the user takes part in with its standings: live
+// while it runs, final once it is finished.
+func (s *ChallengeService) Get(ctx context.Context, userID, challengeID uuid.UUID) (*models.Challenge, error) {
+	challenge, err := s.participating(ctx, userID, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withStandings(ctx, challenge)
+}
+
+// ListRewards returns the titles and cosmetics the user has won.
+func (s *ChallengeService) ListRewards(ctx context.Context, userID uuid.UUID) ([]*models.ChallengeReward, error) {
+	return s.challengeRepo.ListRewards(ctx, userID)
+}
+
+// FinishEnded records the results of challenges that ended and rewards
+// their winners. It runs as a background job.
+func (s *ChallengeService) FinishEnded(ctx context.Context) error {
+	for {
+		challenges, err := s.challengeRepo.ListDue(ctx, time.Now(), challengeBatchSize)
+		if err != nil {
+			return err
+		}
+
+		finished := 0
+		for _, challenge := range challenges {
+			if err := s.finish(ctx, challenge); err != nil {
+				log.Printf("Failed to finish challenge %s: %v", challenge.ID, err)
+				continue
+			}
+			finished++
+		}
+
+		// Stop when the batch is drained or only failures are left
+		if len(challenges) < challengeBatchSize || finished == 0 {
+			return nil
+		}
+	}
+}
+
+func (s *ChallengeService) finish(ctx context.Context, challenge *models.Challenge) error {
+	standings, err := s.liveStandings(ctx, challenge)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	winners := models.ChallengeWinners(standings)
+	rewards := make([]*models.ChallengeReward, 0, len(winners))
+	for _, winner := range winners {
+		rewards = append(rewards, &models.ChallengeReward{
+			ID:             uuid.New(),
+			ChallengeID:    challenge.ID,
+			UserID:         winner.ID,
+			Title:          challenge.Template.RewardTitle,
+			CosmeticID:     challenge.Template.RewardCosmeticID,
+			TitleExpiresAt: challenge.EndsAt.Add(challengeTitleDuration),
+			GrantedAt:      now,
+		})
+	}
+
+	finished, err := s.challengeRepo.Finish(ctx, challenge.ID, standings, rewards, now)
+	if err != nil || !finished {
+		return err
+	}
+
+	for _, reward := range rewards {
+		err := s.channel.Send(ctx, &notify.Notification{
+			UserID:   reward.UserID,
+			Category: notify.CategorySocial,
+			Template: "challenge_won",
+			Params:   map[string]string{"challenge": challenge.Template.Name, "title": reward.Title},
+			Data:     map[string]string{"challenge_id": challenge.ID.String()},
+		})
+		if err != nil {
+			log.Printf("Failed to send challenge_won notification to user %s: %v", reward.UserID, err)
+		}
+	}
+	return nil
+}
+
+func (s *ChallengeService) participating(ctx context.Context, userID, challengeID uuid.UUID) (*models.Challenge, error) {
+	participant, err := s.challengeRepo.IsParticipant(ctx, challengeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !participant {
+		return nil, ErrChallengeNotFound
+	}
+
+	challenge, err := s.challengeRepo.GetByID(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrChallengeNotFound) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (s *ChallengeService) withStandings(ctx context.Context, challenge *models.Challenge) (*models.Challenge, error) {
+	var err error
+	if challenge.Finished() {
+		challenge.Standings, err = s.finalStandings(ctx, challenge)
+	} else {
+		challenge.Standings, err = s.liveStandings(ctx, challenge)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// liveStandings ranks the participants by their activities so far.
+func (s *ChallengeService) liveStandings(ctx context.Context, challenge *models.Challenge) ([]*models.ChallengeStanding, error) {
+	participants, err := s.challengeRepo.ListParticipants(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(participants) == 0 {
+		return []*models.ChallengeStanding{}, nil
+	}
+
+	userIDs := make([]uuid.UUID, len(participants))
+	for i, p := range participants {
+		userIDs[i] = p.ID
+	}
+	activities, err := s.challengeRepo.ListActivities(ctx, userIDs, challenge.StartsAt, challenge.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.RankParticipants(challenge.Template, participants, activities), nil
+}
+
+// finalStandings are the results recorded when the challenge finished.
+func (s *ChallengeService) finalStandings(ctx context.Context, challenge *models.Challenge) ([]*models.ChallengeStanding, error) {
+	participants, err := s.challengeRepo.ListParticipants(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	standings := make([]*models.ChallengeStanding, 0, len(participants))
+	for _, p := range participants {
+		if p.FinalRank == nil || p.FinalValue == nil {
+			continue
+		}
+		standing := &models.ChallengeStanding{
+			UserProfile: p.UserProfile,
+			Rank:        *p.FinalRank,
+			Value:       *p.FinalValue,
+		}
+		if p.FinalActivities != nil {
+			standing.Activities = *p.FinalActivities
+		}
+		standings = append(standings, standing)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Rank != standings[j].Rank {
+			return standings[i].Rank < standings[j].Rank
+		}
+		return standings[i].Name < standings[j].Name
+	})
+	return standings, nil
+}
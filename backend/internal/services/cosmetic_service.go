@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+var (
+	ErrCosmeticItemNotFound = errors.New("cosmetic item not found")
+	ErrItemNotOwned         = errors.New("item is not in your inventory")
+	ErrSlotEmpty            = errors.New("nothing is equipped in that slot")
+)
+
+// CosmeticService runs the item catalog, users' inventories and what their
+// pets wear. Items are earned by levelling up pets, winning challenges and
+// unlocking achievements.
+type CosmeticService struct {
+	cosmeticRepo *repositories.CosmeticRepository
+	petRepo      *repositories.PetRepository
+	access       petAccess
+}
+
+func NewCosmeticService(
+	cosmeticRepo *repositories.CosmeticRepository,
+	petRepo *repositories.PetRepository,
+	memberRepo *repositories.PetMemberRepository,
+) *CosmeticService {
+	return &CosmeticService{
+		cosmeticRepo: cosmeticRepo,
+		petRepo:      petRepo,
+		access:       petAccess{petRepo: petRepo, memberRepo: memberRepo},
+	}
+}
+
+func (s *CosmeticService) ListItems(ctx context.Context) ([]*models.CosmeticItem, error) {
+	return s.cosmeticRepo.ListItems(ctx)
+}
+
+func (s *CosmeticService) ListInventory(ctx context.Context, userID uuid.UUID) ([]*models.InventoryItem, error) {
+	return s.cosmeticRepo.ListInventory(ctx, userID)
+}
+
+// Equip puts an item from the user's inventory on the pet, in the item's
+// slot. It returns the pet with its new loadout.
+func (s *CosmeticService) Equip(ctx context.Context, userID, petID uuid.UUID, input models.EquipItemInput) (*models.Pet, error) {
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionEditPet)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.cosmeticRepo.GetItem(ctx, input.ItemID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCosmeticItemNotFound) {
+			return nil, ErrCosmeticItemNotFound
+		}
+		return nil, err
+	}
+
+	owns, err := s.cosmeticRepo.Owns(ctx, userID, item.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !owns {
+		return nil, ErrItemNotOwned
+	}
+
+	if err := s.cosmeticRepo.Equip(ctx, pet.ID, item, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if pet.Loadout == nil {
+		pet.Loadout = models.Loadout{}
+	}
+	pet.Loadout[item.Slot] = item
+	return pet, nil
+}
+
+// Unequip empties one of the pet's slots.
+func (s *CosmeticService) Unequip(ctx context.Context, userID, petID uuid.UUID, slot models.CosmeticSlot) error {
+	if !slot.Valid() {
+		return models.ErrInvalidCosmeticSlot
+	}
+
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionEditPet)
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.cosmeticRepo.Unequip(ctx, pet.ID, slot)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrSlotEmpty
+	}
+	return nil
+}
+
+// GrantLevelItems gives the pet's owner the items unlocked by the levels
+// the pet just went up. Failures are logged, never returned, so a missing
+// item doesn't fail the activity that levelled the pet up.
+func (s *CosmeticService) GrantLevelItems(ctx context.Context, pet *models.Pet, newLevel int) {
+	if newLevel <= pet.Level {
+		return
+	}
+
+	if err := s.cosmeticRepo.GrantLevelItems(ctx, pet.UserID, pet.Level, newLevel, time.Now()); err != nil {
+		log.Printf("Failed to grant level %d items for pet %s: %v", newLevel, pet.ID, err)
+	}
+}
+
+// GrantAchievementItems gives the user the reward items of achievements
+// they just unlocked. Like level items, failures are only logged.
+func (s *CosmeticService) GrantAchievementItems(ctx context.Context, userID uuid.UUID, unlocked []*models.UserAchievement) {
+	var ids []string
+	for _, ua := range unlocked {
+		if ua.Achievement == nil || ua.Achievement.RewardItemID != nil {
+			ids = append(ids, ua.AchievementID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	if err := s.cosmeticRepo.GrantAchievementItems(ctx, userID, ids, time.Now()); err != nil {
+		log.Printf("Failed to grant achievement items for user %s: %v", userID, err)
+	}
+}
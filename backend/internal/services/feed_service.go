@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/notify"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+const (
+	// defaultFeedPageSize and maxFeedPageSize bound one page of the feed.
+	defaultFeedPageSize = 20
+	maxFeedPageSize     = 50
+)
+
+var (
+	ErrFeedEventNotFound = errors.New("feed event not found")
+	ErrCommentNotFound   = errors.New("comment not found")
+)
+
+// FeedService shares what users do with their pets with their friends:
+// finished activities, level-ups and achievements, which friends can
+// paw-five and comment on.
+type FeedService struct {
+	feedRepo *repositories.FeedRepository
+	userRepo *repositories.UserRepository
+	channel  notify.Channel
+}
+
+func NewFeedService(feedRepo *repositories.FeedRepository, userRepo *repositories.UserRepository, channel notify.Channel) *FeedService {
+	return &FeedService{
+		feedRepo: feedRepo,
+		userRepo: userRepo,
+		channel:  channel,
+	}
+}
+
+// List returns a page of the user's feed: their own events and those of
+// friends who share their activity. An empty cursor starts at the newest.
+func (s *FeedService) List(ctx context.Context, userID uuid.UUID, cursor string, limit int) (*models.FeedPage, error) {
+	var after *models.FeedCursor
+	if cursor != "" {
+		parsed, err := models.ParseFeedCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &parsed
+	}
+	if limit <= 0 || limit > maxFeedPageSize {
+		limit = defaultFeedPageSize
+	}
+
+	// Fetch one extra event to tell whether another page follows
+	events, err := s.feedRepo.ListFeed(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.FeedPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		next := models.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+		page.NextCursor = &next
+	}
+	if page.Events == nil {
+		page.Events = []*models.FeedEvent{}
+	}
+	return page, nil
+}
+
+// PawFive reacts to an event the user can see. Paw-fiving twice is a no-op.
+func (s *FeedService) PawFive(ctx context.Context, userID, eventID uuid.UUID) error {
+	event, err := s.visibleEvent(ctx, userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	added, err := s.feedRepo.AddReaction(ctx, event.ID, userID)
+	if err != nil || !added {
+		return err
+	}
+
+	if event.UserID != userID {
+		s.notifyOwner(ctx, userID, event, "feed_paw_five", nil)
+	}
+	return nil
+}
+
+func (s *FeedService) RemovePawFive(ctx context.Context, userID, eventID uuid.UUID) error {
+	event, err := s.visibleEvent(ctx, userID, eventID)
+	if err != nil {
+		return err
+	}
+	return s.feedRepo.RemoveReaction(ctx, event.ID, userID)
+}
+
+func (s *FeedService) ListComments(ctx context.Context, userID, eventID uuid.UUID) ([]*models.FeedComment, error) {
+	event, err := s.visibleEvent(ctx, userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return s.feedRepo.ListComments(ctx, userID, event.ID)
+}
+
+func (s *FeedService) AddComment(ctx context.Context, userID, eventID uuid.UUID, input models.CommentInput) (*models.FeedComment, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	event, err := s.visibleEvent(ctx, userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	author, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &models.FeedComment{
+		ID:        uuid.New(),
+		EventID:   event.ID,
+		UserID:    userID,
+		User:      &models.UserProfile{ID: author.ID, Name: author.Name, AvatarURL: author.AvatarURL},
+		Body:      input.Body,
+		CreatedAt: time.Now(),
+	}
+	if err := s.feedRepo.CreateComment(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	if event.UserID != userID {
+		s.notifyOwner(ctx, userID, event, "feed_comment", map[string]string{"comment": comment.Body})
+	}
+	return comment, nil
+}
+
+// DeleteComment removes a comment. Authors can delete their comments and
+// users can delete any comment on their own events.
+func (s *FeedService) DeleteComment(ctx context.Context, userID, eventID, commentID uuid.UUID) error {
+	event, err := s.visibleEvent(ctx, userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.feedRepo.GetComment(ctx, event.ID, commentID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrFeedCommentNotFound) {
+			return ErrCommentNotFound
+		}
+		return err
+	}
+	if comment.UserID != userID && event.UserID != userID {
+		return ErrUnauthorized
+	}
+
+	if err := s.feedRepo.DeleteComment(ctx, comment.ID); err != nil {
+		if errors.Is(err, repositories.ErrFeedCommentNotFound) {
+			return ErrCommentNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// PublishActivity shares a finished activity, and the level-up it brought
+// if any, with the user's friends. Publishing is best effort: failures are
+// logged and don't fail the activity.
+func (s *FeedService) PublishActivity(ctx context.Context, userID uuid.UUID, pet *models.Pet, activity *models.Activity, levelUp *models.LevelUp) {
+	details := map[string]any{
+		"game_type_id": activity.GameTypeID,
+		"xp_earned":    activity.XPEarned,
+	}
+	if activity.GameType != nil {
+		details["game_type_name"] = activity.GameType.Name
+	}
+	if activity.DurationSeconds != nil {
+		details["duration_seconds"] = *activity.DurationSeconds
+	}
+	if activity.GameTypeID == "walk" {
+		var walkData models.WalkGameData
+		if err := json.Unmarshal(activity.GameData, &walkData); err == nil && walkData.DistanceMeters > 0 {
+			details["distance_meters"] = walkData.DistanceMeters
+		}
+	}
+
+	now := time.Now()
+	s.publish(ctx, &models.FeedEvent{
+		UserID:     userID,
+		PetID:      pet.ID,
+		Kind:       models.FeedEventActivity,
+		ActivityID: &activity.ID,
+		CreatedAt:  now,
+	}, details)
+
+	if levelUp != nil {
+		details := map[string]any{
+			"level":        levelUp.ToLevel,
+			"from_level":   levelUp.FromLevel,
+			"title":        pet.PetType.Settings().LevelTitle(levelUp.ToLevel),
+			"tier":         levelUp.ToTier.ID,
+			"tier_name":    levelUp.ToTier.Name,
+			"skill_points": levelUp.SkillPoints,
+		}
+		if levelUp.TierChanged() {
+			details["previous_tier"] = levelUp.FromTier.ID
+		}
+		s.publish(ctx, &models.FeedEvent{
+			UserID:    userID,
+			PetID:     pet.ID,
+			Kind:      models.FeedEventLevelUp,
+			CreatedAt: now,
+		}, details)
+	}
+}
+
+// PublishAchievement shares an achievement the user unlocked with their
+// friends. Like activities, failures are only logged.
+func (s *FeedService) PublishAchievement(ctx context.Context, ua *models.UserAchievement) {
+	details := map[string]any{"achievement_id": ua.AchievementID}
+	if a := ua.Achievement; a != nil {
+		details["name"] = a.Name
+		if a.Icon != nil {
+			details["icon"] = *a.Icon
+		}
+	}
+
+	s.publish(ctx, &models.FeedEvent{
+		UserID:    ua.UserID,
+		PetID:     ua.PetID,
+		Kind:      models.FeedEventAchievement,
+		CreatedAt: ua.UnlockedAt,
+	}, details)
+}
+
+func (s *FeedService) publish(ctx context.Context, event *models.FeedEvent, details map[string]any) {
+	event.ID = uuid.New()
+
+	var err error
+	if event.Details, err = json.Marshal(details); err == nil {
+		err = s.feedRepo.CreateEvent(ctx, event)
+	}
+	if err != nil {
+		log.Printf("Failed to publish %s feed event for pet %s: %v", event.Kind, event.PetID, err)
+	}
+}
+
+func (s *FeedService) visibleEvent(ctx context.Context, userID, eventID uuid.UUID) (*models.FeedEvent, error) {
+	event, err := s.feedRepo.GetVisibleEvent(ctx, userID, eventID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrFeedEventNotFound) {
+			return nil, ErrFeedEventNotFound
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+// notifyOwner tells the owner of an event that someone reacted to it.
+func (s *FeedService) notifyOwner(ctx context.Context, actorID uuid.UUID, event *models.FeedEvent, template string, params map[string]string) {
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		log.Printf("Failed to load user %s for %s notification: %v", actorID, template, err)
+		return
+	}
+
+	if params == nil {
+		params = map[string]string{}
+	}
+	params["name"] = actor.Name
+	params["pet"] = event.Pet.Name
+
+	err = s.channel.Send(ctx, &notify.Notification{
+		UserID:   event.UserID,
+		Category: notify.CategorySocial,
+		Template: template,
+		Params:   params,
+		Data:     map[string]string{"feed_event_id": event.ID.String()},
+	})
+	if err != nil {
+		log.Printf("Failed to send %s notification to user %s: %v", template, event.UserID, err)
+	}
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/notify"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+const (
+	// maxPendingFriendRequests caps the unanswered requests a user may
+	// have out at once.
+	maxPendingFriendRequests = 100
+	// friendCodeAttempts is how often a fresh friend code is drawn when
+	// the previous one is taken.
+	friendCodeAttempts = 5
+)
+
+var (
+	ErrFriendRequestNotFound = errors.New("friend request not found")
+	ErrFriendRequestExists   = errors.New("friend request already sent")
+	ErrTooManyFriendRequests = errors.New("too many pending friend requests")
+	ErrAlreadyFriends        = errors.New("already friends")
+	ErrCannotFriendSelf      = errors.New("cannot send a friend request to yourself")
+	ErrFriendNotFound        = errors.New("friend not found")
+	ErrCannotBlockSelf       = errors.New("cannot block yourself")
+	ErrFriendCodeUnavailable = errors.New("could not generate a friend code")
+)
+
+// FriendService manages friend codes, friend requests and blocks.
+type FriendService struct {
+	friendRepo *repositories.FriendRepository
+	userRepo   *repositories.UserRepository
+	channel    notify.Channel
+}
+
+func NewFriendService(friendRepo *repositories.FriendRepository, userRepo *repositories.UserRepository, channel notify.Channel) *FriendService {
+	return &FriendService{
+		friendRepo: friendRepo,
+		userRepo:   userRepo,
+		channel:    channel,
+	}
+}
+
+// GetFriendCode returns the user's friend code, creating it on first use.
+func (s *FriendService) GetFriendCode(ctx context.Context, userID uuid.UUID) (*models.FriendCode, error) {
+	code, err := s.friendRepo.GetFriendCode(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if code != nil {
+		return &models.FriendCode{Code: *code}, nil
+	}
+	return s.RotateFriendCode(ctx, userID)
+}
+
+// RotateFriendCode gives the user a new friend code, so the old one, e.g.
+// a shared screenshot of it, stops working.
+func (s *FriendService) RotateFriendCode(ctx context.Context, userID uuid.UUID) (*models.FriendCode, error) {
+	for range friendCodeAttempts {
+		code, err := generateInvitationCode()
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.friendRepo.SetFriendCode(ctx, userID, code)
+		switch {
+		case err == nil:
+			return &models.FriendCode{Code: code}, nil
+		case errors.Is(err, repositories.ErrFriendCodeTaken):
+			continue
+		case errors.Is(err, repositories.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		default:
+			return nil, err
+		}
+	}
+	return nil, ErrFriendCodeUnavailable
+}
+
+func (s *FriendService) ListFriends(ctx context.Context, userID uuid.UUID) ([]*models.Friend, error) {
+	return s.friendRepo.ListFriends(ctx, userID)
+}
+
+func (s *FriendService) RemoveFriend(ctx context.Context, userID, friendID uuid.UUID) error {
+	removed, err := s.friendRepo.RemoveFriend(ctx, userID, friendID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrFriendNotFound
+	}
+	return nil
+}
+
+// ListRequests splits the user's pending requests into those they
+// received and those they sent.
+func (s *FriendService) ListRequests(ctx context.Context, userID uuid.UUID) (*models.FriendRequests, error) {
+	requests, err := s.friendRepo.ListRequests(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.FriendRequests{
+		Incoming: []*models.FriendRequest{},
+		Outgoing: []*models.FriendRequest{},
+	}
+	for _, request := range requests {
+		if request.ToUserID == userID {
+			result.Incoming = append(result.Incoming, request)
+		} else {
+			result.Outgoing = append(result.Outgoing, request)
+		}
+	}
+	return result, nil
+}
+
+// SendRequest asks the owner of a friend code to be friends. If they
+// already asked the user, the two become friends right away. A code of
+// someone who blocked the user, or whom the user blocked, is reported as
+// not found so blocks stay invisible.
+func (s *FriendService) SendRequest(ctx context.Context, userID uuid.UUID, input models.SendFriendRequestInput) (*models.FriendRequest, error) {
+	code := models.NormalizeFriendCode(input.Code)
+	if code == "" {
+		return nil, ErrUserNotFound
+	}
+
+	target, err := s.friendRepo.GetProfileByFriendCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if target.ID == userID {
+		return nil, ErrCannotFriendSelf
+	}
+
+	blocked, err := s.friendRepo.IsBlocked(ctx, userID, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrUserNotFound
+	}
+
+	friends, err := s.friendRepo.AreFriends(ctx, userID, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if friends {
+		return nil, ErrAlreadyFriends
+	}
+
+	// Asking someone who already asked you answers their request
+	reverse, err := s.friendRepo.GetRequestBetween(ctx, target.ID, userID)
+	if err == nil {
+		if err := s.accept(ctx, reverse); err != nil {
+			return nil, err
+		}
+		reverse.Accepted = true
+		return reverse, nil
+	}
+	if !errors.Is(err, repositories.ErrFriendRequestNotFound) {
+		return nil, err
+	}
+
+	pending, err := s.friendRepo.CountOutgoingRequests(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending >= maxPendingFriendRequests {
+		return nil, ErrTooManyFriendRequests
+	}
+
+	sender, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &models.FriendRequest{
+		ID:         uuid.New(),
+		FromUserID: userID,
+		ToUserID:   target.ID,
+		From:       &models.UserProfile{ID: sender.ID, Name: sender.Name, AvatarURL: sender.AvatarURL},
+		To:         target,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.friendRepo.CreateRequest(ctx, request); err != nil {
+		if errors.Is(err, repositories.ErrFriendRequestExists) {
+			return nil, ErrFriendRequestExists
+		}
+		return nil, err
+	}
+
+	s.notify(ctx, target.ID, "friend_request", sender.Name, map[string]string{"friend_request_id": request.ID.String()})
+	return request, nil
+}
+
+// AcceptRequest accepts a request sent to the user.
+func (s *FriendService) AcceptRequest(ctx context.Context, userID, requestID uuid.UUID) error {
+	request, err := s.incomingRequest(ctx, userID, requestID)
+	if err != nil {
+		return err
+	}
+	return s.accept(ctx, request)
+}
+
+// DeclineRequest drops a request sent to the user, or withdraws one the
+// user sent. The sender isn't told.
+func (s *FriendService) DeclineRequest(ctx context.Context, userID, requestID uuid.UUID) error {
+	request, err := s.friendRepo.GetRequest(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrFriendRequestNotFound) {
+			return ErrFriendRequestNotFound
+		}
+		return err
+	}
+	if request.ToUserID != userID && request.FromUserID != userID {
+		return ErrFriendRequestNotFound
+	}
+
+	if err := s.friendRepo.DeleteRequest(ctx, request.ID); err != nil {
+		if errors.Is(err, repositories.ErrFriendRequestNotFound) {
+			return ErrFriendRequestNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FriendService) incomingRequest(ctx context.Context, userID, requestID uuid.UUID) (*models.FriendRequest, error) {
+	request, err := s.friendRepo.GetRequest(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrFriendRequestNotFound) {
+			return nil, ErrFriendRequestNotFound
+		}
+		return nil, err
+	}
+	if request.ToUserID != userID {
+		return nil, ErrFriendRequestNotFound
+	}
+	return request, nil
+}
+
+func (s *FriendService) accept(ctx context.Context, request *models.FriendRequest) error {
+	if err := s.friendRepo.AcceptRequest(ctx, request, time.Now()); err != nil {
+		if errors.Is(err, repositories.ErrFriendRequestNotFound) {
+			return ErrFriendRequestNotFound
+		}
+		return err
+	}
+
+	s.notify(ctx, request.FromUserID, "friend_accepted", request.To.Name, map[string]string{"user_id": request.ToUserID.String()})
+	return nil
+}
+
+// Block hides the two users from each other: they stop being friends,
+// pending requests between them are dropped and neither can send new ones.
+func (s *FriendService) Block(ctx context.Context, userID, blockedID uuid.UUID) error {
+	if userID == blockedID {
+		return ErrCannotBlockSelf
+	}
+	if _, err := s.userRepo.GetByID(ctx, blockedID); err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return s.friendRepo.Block(ctx, userID, blockedID, time.Now())
+}
+
+func (s *FriendService) Unblock(ctx context.Context, userID, blockedID uuid.UUID) error {
+	unblocked, err := s.friendRepo.Unblock(ctx, userID, blockedID)
+	if err != nil {
+		return err
+	}
+	if !unblocked {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *FriendService) ListBlocked(ctx context.Context, userID uuid.UUID) ([]*models.BlockedUser, error) {
+	return s.friendRepo.ListBlocked(ctx, userID)
+}
+
+// notify tells a user about friend activity. Failures are logged; the
+// request itself already went through.
+func (s *FriendService) notify(ctx context.Context, userID uuid.UUID, template, name string, data map[string]string) {
+	err := s.channel.Send(ctx, &notify.Notification{
+		UserID:   userID,
+		Category: notify.CategorySocial,
+		Template: template,
+		Params:   map[string]string{"name": name},
+		Data:     data,
+	})
+	if err != nil {
+		log.Printf("Failed to send %s notification to user %s: %v", template, userID, err)
+	}
+}
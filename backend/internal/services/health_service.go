@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+var ErrHealthRecordNotFound = errors.New("health record not found")
+
+// HealthService manages a pet's health journal. Members who can view the
+// pet can read it; changing it needs the same permission as editing the pet.
+type HealthService struct {
+	healthRepo *repositories.HealthRepository
+	access     petAccess
+}
+
+func NewHealthService(healthRepo *repositories.HealthRepository, petRepo *repositories.PetRepository, memberRepo *repositories.PetMemberRepository) *HealthService {
+	return &HealthService{
+		healthRepo: healthRepo,
+		access:     petAccess{petRepo: petRepo, memberRepo: memberRepo},
+	}
+}
+
+func (s *HealthService) GetSummary(ctx context.Context, userID, petID uuid.UUID) (*models.HealthSummary, error) {
+	if err := s.canView(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	weights, err := s.healthRepo.ListWeights(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+	visits, err := s.healthRepo.ListVetVisits(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+	vaccinations, err := s.healthRepo.ListVaccinations(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+	medications, err := s.healthRepo.ListMedications(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewHealthSummary(weights, visits, vaccinations, medications, time.Now()), nil
+}
+
+// Weights
+
+func (s *HealthService) ListWeights(ctx context.Context, userID, petID uuid.UUID) ([]*models.WeightEntry, error) {
+	if err := s.canView(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+	return s.healthRepo.ListWeights(ctx, petID)
+}
+
+// GetWeightTrend returns the trend over the last days days, or all entries
+// when days is zero.
+func (s *HealthService) GetWeightTrend(ctx context.Context, userID, petID uuid.UUID, days int) (*models.WeightTrend, error) {
+	weights, err := s.ListWeights(ctx, userID, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	if days > 0 {
+		since := time.Now().AddDate(0, 0, -days)
+		recent := weights[:0]
+		for _, w := range weights {
+			if !w.MeasuredAt.Before(since) {
+				recent = append(recent, w)
+			}
+		}
+		weights = recent
+	}
+
+	return models.NewWeightTrend(weights), nil
+}
+
+func (s *HealthService) AddWeight(ctx context.Context, userID, petID uuid.UUID, input models.WeightInput) (*models.WeightEntry, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	entry := &models.WeightEntry{
+		ID:         uuid.New(),
+		PetID:      petID,
+		WeightKg:   input.WeightKg,
+		MeasuredAt: input.MeasuredAt,
+		Notes:      input.Notes,
+		RecordedBy: &userID,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.healthRepo.CreateWeight(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *HealthService) UpdateWeight(ctx context.Context, userID, petID, entryID uuid.UUID, input models.WeightInput) (*models.WeightEntry, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	entry := &models.WeightEntry{
+		ID:         entryID,
+		PetID:      petID,
+		WeightKg:   input.WeightKg,
+		MeasuredAt: input.MeasuredAt,
+		Notes:      input.Notes,
+	}
+
+	if err := s.healthRepo.UpdateWeight(ctx, entry); err != nil {
+		return nil, mapHealthError(err)
+	}
+
+	return entry, nil
+}
+
+func (s *HealthService) DeleteWeight(ctx context.Context, userID, petID, entryID uuid.UUID) error {
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return err
+	}
+	return mapHealthError(s.healthRepo.DeleteWeight(ctx, petID, entryID))
+}
+
+// Vet visits
+
+func (s *HealthService) ListVetVisits(ctx context.Context, userID, petID uuid.UUID) ([]*models.VetVisit, error) {
+	if err := s.canView(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+	return s.healthRepo.ListVetVisits(ctx, petID)
+}
+
+func (s *HealthService) GetVetVisit(ctx context.Context, userID, petID, visitID uuid.UUID) (*models.VetVisit, error) {
+	if err := s.canView(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+	visit, err := s.healthRepo.GetVetVisit(ctx, petID, visitID)
+	return visit, mapHealthError(err)
+}
+
+func (s *HealthService) CreateVetVisit(ctx context.Context, userID, petID uuid.UUID, input models.VetVisitInput) (*models.VetVisit, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	visit := &models.VetVisit{
+		ID:         uuid.New(),
+		PetID:      petID,
+		VisitedAt:  input.VisitedAt,
+		Clinic:     input.Clinic,
+		VetName:    input.VetName,
+		Reason:     input.Reason,
+		Notes:      input.Notes,
+		RecordedBy: &userID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.healthRepo.CreateVetVisit(ctx, visit); err != nil {
+		return nil, err
+	}
+
+	return visit, nil
+}
+
+func (s *HealthService) UpdateVetVisit(ctx context.Context, userID, petID, visitID uuid.UUID, input models.VetVisitInput) (*models.VetVisit, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	visit := &models.VetVisit{
+		ID:        visitID,
+		PetID:     petID,
+		VisitedAt: input.VisitedAt,
+		Clinic:    input.Clinic,
+		VetName:   input.VetName,
+		Reason:    input.Reason,
+		Notes:     input.Notes,
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.healthRepo.UpdateVetVisit(ctx, visit); err != nil {
+		return nil, mapHealthError(err)
+	}
+
+	return visit, nil
+}
+
+func (s *HealthService) DeleteVetVisit(ctx context.Context, userID, petID, visitID uuid.UUID) error {
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return err
+	}
+	return mapHealthError(s.healthRepo.DeleteVetVisit(ctx, petID, visitID))
+}
+
+// Vaccinations
+
+func (s *HealthService) ListVaccinations(ctx context.Context, userID, petID uuid.UUID) ([]*models.Vaccination, error) {
+	if err := s.canView(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+	return s.healthRepo.ListVaccinations(ctx, petID)
+}
+
+func (s *HealthService) GetVaccination(ctx context.Context, userID, petID, vaccinationID uuid.UUID) (*models.Vaccination, error) {
+	if err := s.canView(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+	vaccination, err := s.healthRepo.GetVaccination(ctx, petID, vaccinationID)
+	return vaccination, mapHealthError(err)
+}
+
+func (s *HealthService) CreateVaccination(ctx context.Context, userID, petID uuid.UUID, input models.VaccinationInput) (*models.Vaccination, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	vaccination := &models.Vaccination{
+		ID:             uuid.New(),
+		PetID:          petID,
+		Name:           input.Name,
+		AdministeredAt: input.AdministeredAt,
+		DueAt:          input.DueAt,
+		Clinic:         input.Clinic,
+		BatchNumber:    input.BatchNumber,
+		Notes:          input.Notes,
+		RecordedBy:     &userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := s.healthRepo.CreateVaccination(ctx, vaccination); err != nil {
+		return nil, err
+	}
+
+	return vaccination, nil
+}
+
+func (s *HealthService) UpdateVaccination(ctx context.Context, userID, petID, vaccinationID uuid.UUID, input models.VaccinationInput) (*models.Vaccination, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	vaccination := &models.Vaccination{
+		ID:             vaccinationID,
+		PetID:          petID,
+		Name:           input.Name,
+		AdministeredAt: input.AdministeredAt,
+		DueAt:          input.DueAt,
+		Clinic:         input.Clinic,
+		BatchNumber:    input.BatchNumber,
+		Notes:          input.Notes,
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := s.healthRepo.UpdateVaccination(ctx, vaccination); err != nil {
+		return nil, mapHealthError(err)
+	}
+
+	return vaccination, nil
+}
+
+func (s *HealthService) DeleteVaccination(ctx context.Context, userID, petID, vaccinationID uuid.UUID) error {
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return err
+	}
+	return mapHealthError(s.healthRepo.DeleteVaccination(ctx, petID, vaccinationID))
+}
+
+// Medications
+
+func (s *HealthService) ListMedications(ctx context.Context, userID, petID uuid.UUID) ([]*models.Medication, error) {
+	if err := s.canView(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+	return s.healthRepo.ListMedications(ctx, petID)
+}
+
+func (s *HealthService) GetMedication(ctx context.Context, userID, petID, medicationID uuid.UUID) (*models.Medication, error) {
+	if err := s.canView(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+	medication, err := s.healthRepo.GetMedication(ctx, petID, medicationID)
+	return medication, mapHealthError(err)
+}
+
+func (s *HealthService) CreateMedication(ctx context.Context, userID, petID uuid.UUID, input models.MedicationInput) (*models.Medication, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	medication := &models.Medication{
+		ID:            uuid.New(),
+		PetID:         petID,
+		Name:          input.Name,
+		Dosage:        input.Dosage,
+		IntervalHours: input.IntervalHours,
+		StartDate:     input.StartDate,
+		EndDate:       input.EndDate,
+		Notes:         input.Notes,
+		RecordedBy:    &userID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := s.healthRepo.CreateMedication(ctx, medication); err != nil {
+		return nil, err
+	}
+
+	return medication, nil
+}
+
+func (s *HealthService) UpdateMedication(ctx context.Context, userID, petID, medicationID uuid.UUID, input models.MedicationInput) (*models.Medication, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return nil, err
+	}
+
+	medication := &models.Medication{
+		ID:            medicationID,
+		PetID:         petID,
+		Name:          input.Name,
+		Dosage:        input.Dosage,
+		IntervalHours: input.IntervalHours,
+		StartDate:     input.StartDate,
+		EndDate:       input.EndDate,
+		Notes:         input.Notes,
+		UpdatedAt:     time.Now(),
+	}
+
+	if err := s.healthRepo.UpdateMedication(ctx, medication); err != nil {
+		return nil, mapHealthError(err)
+	}
+
+	return medication, nil
+}
+
+func (s *HealthService) DeleteMedication(ctx context.Context, userID, petID, medicationID uuid.UUID) error {
+	if err := s.canEdit(ctx, userID, petID); err != nil {
+		return err
+	}
+	return mapHealthError(s.healthRepo.DeleteMedication(ctx, petID, medicationID))
+}
+
+func (s *HealthService) canView(ctx context.Context, userID, petID uuid.UUID) error {
+	_, _, err := s.access.authorize(ctx, userID, petID, models.PermissionViewPet)
+	return err
+}
+
+func (s *HealthService) canEdit(ctx context.Context, userID, petID uuid.UUID) error {
+	_, _, err := s.access.authorize(ctx, userID, petID, models.PermissionEditPet)
+	return err
+}
+
+func mapHealthError(err error) error {
+	if errors.Is(err, repositories.ErrHealthRecordNotFound) {
+		return ErrHealthRecordNotFound
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+var ErrNoLeaderboardArea = errors.New("no walks with a route yet")
+
+// LeaderboardService ranks pets by XP, distance, walk minutes and streak.
+// Scores are kept per calendar period in the owner's timezone and added
+// to as activities finish, so ranking never goes back to the activities.
+type LeaderboardService struct {
+	leaderboardRepo *repositories.LeaderboardRepository
+	userRepo        *repositories.UserRepository
+}
+
+func NewLeaderboardService(leaderboardRepo *repositories.LeaderboardRepository, userRepo *repositories.UserRepository) *LeaderboardService {
+	return &LeaderboardService{
+		leaderboardRepo: leaderboardRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// RecordActivity adds a finished activity to the pet's leaderboards and
+// moves the owner's area to where the walk went. streakDays is the pet's
+// streak after the activity. Leaderboards are best effort: failures are
+// logged, never returned.
+func (s *LeaderboardService) RecordActivity(ctx context.Context, pet *models.Pet, activity *models.Activity, streakDays int) {
+	scores := models.ActivityScores(activity, streakDays)
+	if len(scores) == 0 {
+		return
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, pet.UserID)
+	if err != nil {
+		log.Printf("Failed to load owner of pet %s for leaderboards: %v", pet.ID, err)
+		return
+	}
+	loc := preferencesOf(owner).Location()
+
+	starts := make(map[models.LeaderboardPeriod]time.Time, len(models.LeaderboardPeriods))
+	for _, period := range models.LeaderboardPeriods {
+		starts[period] = period.Start(activity.StartedAt, loc)
+	}
+	if err := s.leaderboardRepo.AddScores(ctx, pet.ID, starts, scores); err != nil {
+		log.Printf("Failed to record leaderboard scores for activity %s: %v", activity.ID, err)
+	}
+
+	var walk models.WalkGameData
+	if activity.GameTypeID != "walk" || json.Unmarshal(activity.GameData, &walk) != nil {
+		return
+	}
+	if area, ok := models.RouteArea(walk.Route); ok {
+		if err := s.leaderboardRepo.SetUserArea(ctx, owner.ID, area); err != nil {
+			log.Printf("Failed to set area of user %s: %v", owner.ID, err)
+		}
+	}
+}
+
+// Get returns the current period of a leaderboard as the viewer sees it:
+// the period is the viewer's own day, week or month, and the area scope
+// ranks pets whose owners walk in the viewer's area.
+func (s *LeaderboardService) Get(ctx context.Context, viewerID uuid.UUID, query models.LeaderboardQuery) (*models.Leaderboard, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultLeaderboardLimit
+	}
+	if query.Limit > maxLeaderboardLimit {
+		query.Limit = maxLeaderboardLimit
+	}
+
+	viewer, err := s.userRepo.GetByID(ctx, viewerID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	board := &models.Leaderboard{Metric: query.Metric, Period: query.Period, Scope: query.Scope}
+	if query.Scope == models.LeaderboardScopeArea {
+		area, err := s.leaderboardRepo.GetUserArea(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if area == nil {
+			return nil, ErrNoLeaderboardArea
+		}
+		board.Area = *area
+	}
+
+	start := query.Period.Start(time.Now(), preferencesOf(viewer).Location())
+	board.Entries, board.Me, err = s.leaderboardRepo.Rank(ctx, viewerID, query.Metric, query.Period, start, query.Scope, board.Area, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if board.Entries == nil {
+		board.Entries = []*models.LeaderboardEntry{}
+	}
+	if board.Me == nil {
+		board.Me = []*models.LeaderboardEntry{}
+	}
+	return board, nil
+}
+
+// PurgeEnded drops the scores of periods that ended. Timezones keep a period
+// current for up to a day after it ends in UTC, so one extra period is
+// kept. It runs as a background job.
+func (s *LeaderboardService) PurgeEnded(ctx context.Context) error {
+	now := time.Now().UTC()
+	cutoffs := map[models.LeaderboardPeriod]time.Time{
+		models.LeaderboardPeriodDaily:   models.LeaderboardPeriodDaily.Start(now.AddDate(0, 0, -1), time.UTC),
+		models.LeaderboardPeriodWeekly:  models.LeaderboardPeriodWeekly.Start(now.AddDate(0, 0, -7), time.UTC),
+		models.LeaderboardPeriodMonthly: models.LeaderboardPeriodMonthly.Start(now.AddDate(0, -1, 0), time.UTC),
+	}
+	for period, before := range cutoffs {
+		deleted, err := s.leaderboardRepo.DeletePeriodsBefore(ctx, period, before)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			log.Printf("Purged %d %s leaderboard scores", deleted, period)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/media"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+	"github.com/joaosantos/pettime/internal/storage"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type")
+	ErrInvalidImage     = errors.New("invalid image")
+)
+
+// avatarDisplayVariant is the size stored in Pet.AvatarURL for clients that
+// only know about a single avatar URL.
+const avatarDisplayVariant = "medium"
+
+type MediaService struct {
+	petRepo *repositories.PetRepository
+	store   storage.BlobStore
+	access  petAccess
+}
+
+func NewMediaService(petRepo *repositories.PetRepository, memberRepo *repositories.PetMemberRepository, store storage.BlobStore) *MediaService {
+	return &MediaService{
+		petRepo: petRepo,
+		store:   store,
+		access:  petAccess{petRepo: petRepo, memberRepo: memberRepo},
+	}
+}
+
+// UploadPetAvatar re-encodes the image into every avatar size and stores
+// them under a content-addressed prefix, so URLs can be cached forever. The
+// previous upload is removed once the pet points at the new one.
+func (s *MediaService) UploadPetAvatar(ctx context.Context, userID, petID uuid.UUID, data []byte) (*models.Pet, error) {
+	if _, _, err := s.access.authorize(ctx, userID, petID, models.PermissionEditPet); err != nil {
+		return nil, err
+	}
+
+	img, err := media.ProcessAvatar(data, media.AvatarVariants)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrUnsupportedType):
+			return nil, ErrUnsupportedImage
+		case errors.Is(err, media.ErrInvalidImage), errors.Is(err, media.ErrImageTooLarge):
+			return nil, ErrInvalidImage
+		}
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("pets/%s/%s", petID, img.Hash)
+	thumbnails := models.Thumbnails{}
+	for _, variant := range media.AvatarVariants {
+		key := avatarKey(prefix, variant.Name)
+		blob := &storage.Blob{
+			Data:         img.Variants[variant.Name],
+			ContentType:  media.ContentTypeJPEG,
+			CacheControl: storage.ImmutableCacheControl,
+		}
+		if err := s.store.Put(ctx, key, blob); err != nil {
+			return nil, err
+		}
+		thumbnails[variant.Name] = s.store.URL(key)
+	}
+
+	previous, err := s.petRepo.SetAvatar(ctx, petID, prefix, thumbnails[avatarDisplayVariant], thumbnails)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && *previous != prefix {
+		s.deleteAvatar(ctx, *previous)
+	}
+
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionViewPet)
+	return pet, err
+}
+
+// GetBlob reads a stored file. Used to serve uploads from the local store.
+func (s *MediaService) GetBlob(ctx context.Context, key string) (*storage.Blob, error) {
+	return s.store.Get(ctx, key)
+}
+
+func (s *MediaService) deleteAvatar(ctx context.Context, prefix string) {
+	for _, variant := range media.AvatarVariants {
+		if err := s.store.Delete(ctx, avatarKey(prefix, variant.Name)); err != nil {
+			log.Printf("Failed to delete old avatar %s: %v", prefix, err)
+		}
+	}
+}
+
+func avatarKey(prefix, variant string) string {
+	return prefix + "/" + variant + ".jpg"
+}
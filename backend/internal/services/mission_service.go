@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+const (
+	// missionBatchSize is how many pets get missions per page of a pass.
+	missionBatchSize = 500
+	// maxDailyMissions caps the missions of one pet per day.
+	maxDailyMissions = 3
+	// missionXPReward is the bonus XP for completing a daily mission.
+	missionXPReward = 50
+)
+
+// MissionService hands out daily missions per pet, drawn from its pet
+// type's default activities, and tracks them as activities finish.
+type MissionService struct {
+	gamificationRepo *repositories.GamificationRepository
+	activityRepo     *repositories.ActivityRepository
+	userRepo         *repositories.UserRepository
+}
+
+func NewMissionService(
+	gamificationRepo *repositories.GamificationRepository,
+	activityRepo *repositories.ActivityRepository,
+	userRepo *repositories.UserRepository,
+) *MissionService {
+	return &MissionService{
+		gamificationRepo: gamificationRepo,
+		activityRepo:     activityRepo,
+		userRepo:         userRepo,
+	}
+}
+
+// ListActive returns the user's missions that haven't expired.
+func (s *MissionService) ListActive(ctx context.Context, userID uuid.UUID) ([]*models.Mission, error) {
+	return s.gamificationRepo.GetActiveMissions(ctx, userID, time.Now())
+}
+
+// Generate gives every active pet without missions its missions for the
+// rest of the owner's day. It runs as a background job.
+func (s *MissionService) Generate(ctx context.Context) error {
+	gameTypes, err := s.activityRepo.GetAllGameTypes(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*models.GameType, len(gameTypes))
+	for _, gameType := range gameTypes {
+		byID[gameType.ID] = gameType
+	}
+
+	now := time.Now()
+	users := make(map[uuid.UUID]*models.User)
+	afterID := uuid.Nil
+	for {
+		pets, err := s.gamificationRepo.ListPetsNeedingMissions(ctx, now, afterID, missionBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, pet := range pets {
+			if err := s.generateFor(ctx, pet, byID, now, users); err != nil {
+				log.Printf("Failed to generate missions for pet %s: %v", pet.ID, err)
+			}
+		}
+
+		if len(pets) < missionBatchSize {
+			return nil
+		}
+		afterID = pets[len(pets)-1].ID
+	}
+}
+
+func (s *MissionService) generateFor(ctx context.Context, pet *models.Pet, gameTypes map[string]*models.GameType, now time.Time, users map[uuid.UUID]*models.User) error {
+	owner, ok := users[pet.UserID]
+	if !ok {
+		var err error
+		if owner, err = s.userRepo.GetByID(ctx, pet.UserID); err != nil {
+			return err
+		}
+		users[pet.UserID] = owner
+	}
+
+	local := now.In(preferencesOf(owner).Location())
+	midnight := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, local.Location())
+
+	missions := dailyMissions(pet, gameTypes, midnight, now)
+	if len(missions) == 0 {
+		return nil
+	}
+	return s.gamificationRepo.CreateMissions(ctx, missions)
+}
+
+// RecordActivity advances the pet's missions with a finished activity and
+// returns the bonus XP of the missions it completed.
+func (s *MissionService) RecordActivity(ctx context.Context, activity *models.Activity) (int, error) {
+	progress := missionProgress(activity)
+	if len(progress) == 0 {
+		return 0, nil
+	}
+
+	completed, err := s.gamificationRepo.AdvanceMissions(ctx, activity.PetID, activity.GameTypeID, progress, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	xp := 0
+	for _, mission := range completed {
+		xp += mission.XPReward
+	}
+	return xp, nil
+}
+
+// dailyMissions builds one mission per default activity of the pet's type
+// that the pet can play, up to maxDailyMissions. Each game sets its own
+// goal; games without one ask to play once.
+func dailyMissions(pet *models.Pet, gameTypes map[string]*models.GameType, expiresAt, now time.Time) []*models.Mission {
+	var missions []*models.Mission
+	for _, activity := range pet.PetType.Settings().DefaultActivities {
+		gameType, ok := gameTypes[activity]
+		if !ok || !gameType.Enabled || !isGameTypeSupported(gameType, pet.PetType) {
+			continue
+		}
+
+		mission := &models.Mission{
+			ID:         uuid.New(),
+			UserID:     pet.UserID,
+			PetID:      &pet.ID,
+			GameTypeID: &gameType.ID,
+			XPReward:   missionXPReward,
+			ExpiresAt:  expiresAt,
+			CreatedAt:  now,
+		}
+		if goal := models.GameFor(gameType.ID).DailyMission(pet); goal != nil {
+			mission.MissionType = goal.Type
+			mission.TargetValue = goal.Target
+			mission.Description = goal.Description
+		} else {
+			mission.MissionType = models.MissionTypeActivityCount
+			mission.TargetValue = 1
+			mission.Description = fmt.Sprintf("%s with %s", gameType.Name, pet.Name)
+		}
+
+		missions = append(missions, mission)
+		if len(missions) == maxDailyMissions {
+			break
+		}
+	}
+	return missions
+}
+
+// missionProgress is how far a finished activity gets each mission type.
+func missionProgress(activity *models.Activity) map[models.MissionType]int {
+	if activity.EndedAt == nil {
+		return nil
+	}
+
+	progress := map[models.MissionType]int{models.MissionTypeActivityCount: 1}
+	models.GameFor(activity.GameTypeID).MissionProgress(activity, progress)
+	return progress
+}
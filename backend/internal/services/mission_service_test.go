@@ -0,0 +1,76 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+func TestDailyMissions(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(6 * time.Hour)
+	gameTypes := map[string]*models.GameType{
+		"walk":  {ID: "walk", Name: "Walk", SupportedPetTypes: []string{"dog", "cat"}, Enabled: true},
+		"fetch": {ID: "fetch", Name: "Fetch", SupportedPetTypes: []string{"dog"}, Enabled: true},
+		"swim":  {ID: "swim", Name: "Swim", Enabled: false},
+	}
+
+	config := models.DefaultPetTypeConfig()
+	config.DefaultActivities = []string{"walk", "fetch", "play", "swim"}
+	pet := &models.Pet{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		Name:        "Rex",
+		PetType:     &models.PetType{ID: "dog", Config: config},
+		DailyTarget: &models.DailyTarget{ExerciseMinutes: 90},
+	}
+
+	missions := dailyMissions(pet, gameTypes, expiresAt, now)
+	if len(missions) != 2 {
+		t.Fatalf("dailyMissions() = %d missions, want walk and fetch only", len(missions))
+	}
+
+	walk, fetch := missions[0], missions[1]
+	if walk.MissionType != models.MissionTypeWalkDuration || walk.TargetValue != 90 {
+		t.Errorf("walk mission = %s with target %d, want the pet's daily target", walk.MissionType, walk.TargetValue)
+	}
+	if fetch.MissionType != models.MissionTypeFetchThrows || fetch.TargetValue != 20 {
+		t.Errorf("fetch mission = %s with target %d", fetch.MissionType, fetch.TargetValue)
+	}
+	if *walk.PetID != pet.ID || walk.UserID != pet.UserID || !walk.ExpiresAt.Equal(expiresAt) {
+		t.Error("missions belong to the pet's owner and expire at the given time")
+	}
+}
+
+func TestMissionProgress(t *testing.T) {
+	ended := time.Now()
+	duration := 25*60 + 30
+
+	walk := &models.Activity{
+		GameTypeID:      "walk",
+		EndedAt:         &ended,
+		DurationSeconds: &duration,
+		GameData:        json.RawMessage(`{"distance_meters": 2100.5}`),
+	}
+	progress := missionProgress(walk)
+	if progress[models.MissionTypeWalkDuration] != 25 || progress[models.MissionTypeWalkDistance] != 2100 || progress[models.MissionTypeActivityCount] != 1 {
+		t.Errorf("missionProgress(walk) = %v", progress)
+	}
+
+	fetch := &models.Activity{GameTypeID: "fetch", EndedAt: &ended, GameData: json.RawMessage(`{"throws": 12}`)}
+	if progress := missionProgress(fetch); progress[models.MissionTypeFetchThrows] != 12 {
+		t.Errorf("missionProgress(fetch) = %v", progress)
+	}
+
+	play := &models.Activity{GameTypeID: "play", EndedAt: &ended, GameData: json.RawMessage(`{"rounds": 4, "pounces": 9}`)}
+	if progress := missionProgress(play); progress[models.MissionTypePlayPounces] != 9 || progress[models.MissionTypeActivityCount] != 1 {
+		t.Errorf("missionProgress(play) = %v", progress)
+	}
+
+	if progress := missionProgress(&models.Activity{GameTypeID: "walk"}); progress != nil {
+		t.Errorf("unfinished activities make no progress, got %v", progress)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/notify"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+var (
+	ErrDeviceNotFound  = errors.New("device not found")
+	ErrSessionRequired = errors.New("session required")
+)
+
+const (
+	// pushBatchSize is how many queued pushes one worker pass sends.
+	pushBatchSize = 200
+	// pushClaimTTL is how long a claimed push stays with a worker before
+	// another pass may retry it.
+	pushClaimTTL = 2 * time.Minute
+	// pushMaxAttempts is how often a push is tried before it is marked
+	// failed. Retries back off exponentially from pushRetryBase.
+	pushMaxAttempts = 5
+	pushRetryBase   = 30 * time.Second
+	// deviceStaleAfter removes devices whose app hasn't registered for a
+	// while; active apps re-register on every launch.
+	deviceStaleAfter = 60 * 24 * time.Hour
+	// pushRetention is how long sent and failed pushes are kept.
+	pushRetention = 7 * 24 * time.Hour
+)
+
+// NotificationService delivers notifications to the user's devices. It is
+// the notify.Channel other services send through: it applies the user's
+// notification preferences and quiet hours, renders templates in their
+// language and queues one push per device for the delivery worker.
+type NotificationService struct {
+	notificationRepo *repositories.NotificationRepository
+	userRepo         *repositories.UserRepository
+	providers        map[models.PushProvider]notify.PushProvider
+}
+
+func NewNotificationService(
+	notificationRepo *repositories.NotificationRepository,
+	userRepo *repositories.UserRepository,
+	providers map[models.PushProvider]notify.PushProvider,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		providers:        providers,
+	}
+}
+
+// RegisterDevice stores the push token of the app signed in with sessionID.
+func (s *NotificationService) RegisterDevice(ctx context.Context, userID, sessionID uuid.UUID, input models.RegisterDeviceInput) (*models.DeviceToken, error) {
+	if sessionID == uuid.Nil {
+		return nil, ErrSessionRequired
+	}
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	device := &models.DeviceToken{
+		ID:         uuid.New(),
+		UserID:     userID,
+		SessionID:  sessionID,
+		Provider:   input.Provider,
+		Token:      input.Token,
+		DeviceName: input.DeviceName,
+		LastSeenAt: now,
+		Current:    true,
+	}
+
+	if err := s.notificationRepo.UpsertDevice(ctx, device); err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+func (s *NotificationService) ListDevices(ctx context.Context, userID, sessionID uuid.UUID) ([]*models.DeviceToken, error) {
+	devices, err := s.notificationRepo.ListDevices(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, device := range devices {
+		device.Current = device.SessionID == sessionID
+	}
+
+	return devices, nil
+}
+
+func (s *NotificationService) UnregisterDevice(ctx context.Context, userID, deviceID uuid.UUID) error {
+	err := s.notificationRepo.DeleteDevice(ctx, userID, deviceID)
+	if errors.Is(err, repositories.ErrDeviceNotFound) {
+		return ErrDeviceNotFound
+	}
+	return err
+}
+
+// Send queues a notification for every device of the user. Notifications
+// the user opted out of are dropped; during quiet hours they are held until
+// the quiet hours end.
+func (s *NotificationService) Send(ctx context.Context, n *notify.Notification) error {
+	user, err := s.userRepo.GetByID(ctx, n.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	prefs := models.DefaultPreferences()
+	if user.Preferences != nil {
+		prefs = *user.Preferences
+	}
+	if user.DeletionScheduledAt != nil || !categoryEnabled(prefs.Notifications, n.Category) {
+		return nil
+	}
+
+	title, body := n.Title, n.Body
+	if n.Template != "" {
+		title, body, err = notify.Render(n.Template, prefs.Language, n.Params)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	sendAt := now
+	if quiet := prefs.Notifications.QuietHours; quiet != nil {
+		if endsAt, inside := quiet.EndsAt(now, prefs.Location()); inside {
+			sendAt = endsAt
+		}
+	}
+
+	_, err = s.notificationRepo.EnqueueForUser(ctx, n.UserID, &models.PushDelivery{
+		Category:      string(n.Category),
+		Title:         title,
+		Body:          body,
+		Data:          n.Data,
+		NextAttemptAt: sendAt,
+		CreatedAt:     now,
+	})
+	return err
+}
+
+// ProcessDeliveries sends queued pushes that are due. Tokens the provider
+// reports as invalid are removed; other failures are retried with backoff.
+func (s *NotificationService) ProcessDeliveries(ctx context.Context) error {
+	now := time.Now()
+	deliveries, err := s.notificationRepo.ClaimDueDeliveries(ctx, now, pushClaimTTL, pushBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := s.attempt(ctx, delivery, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeStale removes old pushes and devices that have gone quiet.
+func (s *NotificationService) PurgeStale(ctx context.Context) error {
+	now := time.Now()
+	if _, err := s.notificationRepo.DeleteFinishedDeliveries(ctx, now.Add(-pushRetention)); err != nil {
+		return err
+	}
+	_, err := s.notificationRepo.DeleteStaleDevices(ctx, now.Add(-deviceStaleAfter))
+	return err
+}
+
+func (s *NotificationService) attempt(ctx context.Context, delivery *models.PushDelivery, now time.Time) error {
+	var err error
+	if provider, ok := s.providers[delivery.Provider]; ok {
+		err = provider.Send(ctx, &notify.PushMessage{
+			Token: delivery.Token,
+			Title: delivery.Title,
+			Body:  delivery.Body,
+			Data:  delivery.Data,
+		})
+	} else {
+		err = fmt.Errorf("%w: no %s provider configured", notify.ErrRejected, delivery.Provider)
+	}
+
+	delivery.Attempts++
+	switch {
+	case err == nil:
+		delivery.Status = models.PushDeliverySent
+		delivery.SentAt = &now
+		delivery.LastError = nil
+	case errors.Is(err, notify.ErrInvalidToken):
+		return s.notificationRepo.PruneDevice(ctx, delivery.DeviceTokenID)
+	case errors.Is(err, notify.ErrRejected) || delivery.Attempts >= pushMaxAttempts:
+		log.Printf("Push %s failed: %v", delivery.ID, err)
+		message := err.Error()
+		delivery.Status = models.PushDeliveryFailed
+		delivery.LastError = &message
+	default:
+		message := err.Error()
+		delivery.LastError = &message
+		delivery.NextAttemptAt = now.Add(pushRetryDelay(delivery.Attempts))
+	}
+
+	return s.notificationRepo.UpdateDelivery(ctx, delivery)
+}
+
+// pushRetryDelay is the wait before retrying a push that failed attempts
+// times: 30s, 1m, 2m, 4m.
+func pushRetryDelay(attempts int) time.Duration {
+	return pushRetryBase << (attempts - 1)
+}
+
+func categoryEnabled(prefs models.NotificationPreferences, category notify.Category) bool {
+	if !prefs.Enabled {
+		return false
+	}
+
+	switch category {
+	case notify.CategoryReminder:
+		return prefs.Reminders
+	case notify.CategoryStreak:
+		return prefs.StreakAlerts
+	case notify.CategoryMood:
+		return prefs.MoodAlerts
+	case notify.CategoryMission:
+		return prefs.Missions
+	case notify.CategorySocial:
+		return prefs.Social
+	default:
+		return true
+	}
+}
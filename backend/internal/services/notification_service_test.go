@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/notify"
+)
+
+func TestPushRetryDelay(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, w := range want {
+		if got := pushRetryDelay(i + 1); got != w {
+			t.Errorf("pushRetryDelay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestCategoryEnabled(t *testing.T) {
+	prefs := models.DefaultPreferences().Notifications
+	prefs.MoodAlerts = false
+
+	if !categoryEnabled(prefs, notify.CategoryReminder) {
+		t.Error("reminders should be enabled by default")
+	}
+	if categoryEnabled(prefs, notify.CategoryMood) {
+		t.Error("mood alerts were switched off")
+	}
+
+	prefs.Enabled = false
+	if categoryEnabled(prefs, notify.CategoryReminder) {
+		t.Error("nothing should be sent when notifications are disabled")
+	}
+}
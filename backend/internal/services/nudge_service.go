@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/notify"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+const (
+	// nudgeBatchSize is how many candidate pets are loaded at a time.
+	nudgeBatchSize = 500
+	// nudgeMinStreak is the shortest streak worth a reminder before it ends.
+	nudgeMinStreak = 3
+	// streakRiskWindow is how long before local midnight a streak counts
+	// as at risk.
+	streakRiskWindow = 4 * time.Hour
+	// nudgeMinGap is the least time between two nudges to the same user,
+	// on top of their daily cap.
+	nudgeMinGap = 3 * time.Hour
+	// nudgeAttributionWindow is how soon after a nudge an activity must
+	// start to count as a result of it.
+	nudgeAttributionWindow = 6 * time.Hour
+)
+
+// NudgeService sends engagement nudges in the pet's voice: in the evening
+// before a streak ends, and when a pet's mood drops because nobody played
+// with it. It also persists the decayed mood, so the stored mood is what
+// the last pass saw.
+type NudgeService struct {
+	nudgeRepo  *repositories.NudgeRepository
+	petRepo    *repositories.PetRepository
+	memberRepo *repositories.PetMemberRepository
+	userRepo   *repositories.UserRepository
+	channel    notify.Channel
+}
+
+func NewNudgeService(
+	nudgeRepo *repositories.NudgeRepository,
+	petRepo *repositories.PetRepository,
+	memberRepo *repositories.PetMemberRepository,
+	userRepo *repositories.UserRepository,
+	channel notify.Channel,
+) *NudgeService {
+	return &NudgeService{
+		nudgeRepo:  nudgeRepo,
+		petRepo:    petRepo,
+		memberRepo: memberRepo,
+		userRepo:   userRepo,
+		channel:    channel,
+	}
+}
+
+// nudgeMessage is a nudge about one pet, before it is addressed to the
+// pet's members.
+type nudgeMessage struct {
+	kind      models.NudgeKind
+	category  notify.Category
+	template  string
+	params    map[string]string
+	dedupeKey string
+	mood      *models.Mood
+	streak    *int
+}
+
+// Process credits recent nudges with the activities they led to, then
+// nudges the members of pets whose streak is at risk or whose mood dropped.
+func (s *NudgeService) Process(ctx context.Context) error {
+	now := time.Now()
+	if _, err := s.nudgeRepo.AttributeActivities(ctx, now.Add(-24*time.Hour), nudgeAttributionWindow); err != nil {
+		return err
+	}
+
+	petTypes, err := s.petRepo.GetAllPetTypes(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*models.PetType, len(petTypes))
+	var idleAfter time.Duration
+	for _, petType := range petTypes {
+		byID[petType.ID] = petType
+		drop := time.Duration(petType.Config.FirstDropAfter() * float64(time.Hour))
+		if drop > 0 && (idleAfter == 0 || drop < idleAfter) {
+			idleAfter = drop
+		}
+	}
+
+	users := make(map[uuid.UUID]*models.User)
+	afterID := uuid.Nil
+	for {
+		// Pets idle for less than the earliest mood drop of any type are
+		// still fine
+		pets, err := s.nudgeRepo.ListCandidates(ctx, now.Add(-48*time.Hour), now.Add(-idleAfter), nudgeMinStreak, afterID, nudgeBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, pet := range pets {
+			pet.PetType = byID[pet.PetTypeID]
+			if err := s.nudgePet(ctx, pet, now, users); err != nil {
+				log.Printf("Failed to nudge for pet %s: %v", pet.ID, err)
+			}
+		}
+
+		if len(pets) < nudgeBatchSize {
+			return nil
+		}
+		afterID = pets[len(pets)-1].ID
+	}
+}
+
+func (s *NudgeService) nudgePet(ctx context.Context, pet *models.Pet, now time.Time, users map[uuid.UUID]*models.User) error {
+	owner, err := s.user(ctx, pet.UserID, users)
+	if err != nil || owner == nil {
+		return err
+	}
+
+	mood := calculateMood(pet)
+	if mood != pet.Mood {
+		if err := s.petRepo.UpdateMood(ctx, pet.ID, mood); err != nil {
+			return err
+		}
+	}
+
+	var msg *nudgeMessage
+	if deadline, ok := streakDeadline(pet, now, preferencesOf(owner).Location()); ok && deadline.Sub(now) <= streakRiskWindow {
+		streak := pet.StreakDays
+		msg = &nudgeMessage{
+			kind:     models.NudgeStreakAtRisk,
+			category: notify.CategoryStreak,
+			template: "nudge_streak",
+			params: map[string]string{
+				"pet":    pet.Name,
+				"streak": strconv.Itoa(streak),
+				"hours":  strconv.Itoa(hoursLeft(deadline.Sub(now))),
+			},
+			dedupeKey: fmt.Sprintf("streak:%s:%s", pet.ID, deadline.Format("2006-01-02")),
+			streak:    &streak,
+		}
+	} else if moodDropped(pet.Mood, mood) {
+		msg = &nudgeMessage{
+			kind:      models.NudgeMood,
+			category:  notify.CategoryMood,
+			template:  "nudge_" + string(mood),
+			params:    map[string]string{"pet": pet.Name},
+			dedupeKey: fmt.Sprintf("mood:%s:%s:%d", pet.ID, mood, pet.LastActivityAt.Unix()),
+			mood:      &mood,
+		}
+	}
+	if msg == nil {
+		return nil
+	}
+
+	// Everyone who can play with the pet may save the day
+	members, err := s.memberRepo.ListMembers(ctx, pet.ID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if !member.Role.Can(models.PermissionLogActivity) {
+			continue
+		}
+		user, err := s.user(ctx, member.UserID, users)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			continue
+		}
+		if err := s.send(ctx, user, pet, msg, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *NudgeService) send(ctx context.Context, user *models.User, pet *models.Pet, msg *nudgeMessage, now time.Time) error {
+	prefs := preferencesOf(user)
+	if user.DeletionScheduledAt != nil || !categoryEnabled(prefs.Notifications, msg.category) {
+		return nil
+	}
+
+	// A streak nudge held back until quiet hours end would arrive after the
+	// streak is gone; a later pass tries again
+	if quiet := prefs.Notifications.QuietHours; msg.kind == models.NudgeStreakAtRisk && quiet != nil {
+		if _, inside := quiet.EndsAt(now, prefs.Location()); inside {
+			return nil
+		}
+	}
+
+	history, err := s.nudgeRepo.History(ctx, user.ID, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if !nudgeAllowed(history, prefs.Notifications.MaxNudgesPerDay, now) {
+		return nil
+	}
+
+	nudge := &models.Nudge{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		PetID:      pet.ID,
+		Kind:       msg.kind,
+		DedupeKey:  msg.dedupeKey,
+		Mood:       msg.mood,
+		StreakDays: msg.streak,
+		SentAt:     now,
+	}
+	created, err := s.nudgeRepo.Create(ctx, nudge)
+	if err != nil || !created {
+		return err
+	}
+
+	err = s.channel.Send(ctx, &notify.Notification{
+		UserID:   user.ID,
+		Category: msg.category,
+		Template: msg.template,
+		Params:   msg.params,
+		Data:     map[string]string{"pet_id": pet.ID.String(), "nudge_id": nudge.ID.String()},
+	})
+	if err != nil {
+		// Forget the nudge so a later pass can send it
+		if deleteErr := s.nudgeRepo.Delete(ctx, nudge.ID); deleteErr != nil {
+			log.Printf("Failed to delete unsent nudge %s: %v", nudge.ID, deleteErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// user loads a user once per pass. Users that no longer exist are nil.
+func (s *NudgeService) user(ctx context.Context, userID uuid.UUID, users map[uuid.UUID]*models.User) (*models.User, error) {
+	if user, ok := users[userID]; ok {
+		return user, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		user, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	users[userID] = user
+	return user, nil
+}
+
+func preferencesOf(user *models.User) models.UserPreferences {
+	if user.Preferences == nil {
+		return models.DefaultPreferences()
+	}
+	return *user.Preferences
+}
+
+// streakDeadline reports when the pet's streak ends: at the next local
+// midnight, if nobody has played with the pet today but someone did
+// yesterday.
+func streakDeadline(pet *models.Pet, now time.Time, loc *time.Location) (time.Time, bool) {
+	if pet.StreakDays < nudgeMinStreak || pet.LastActivityAt == nil {
+		return time.Time{}, false
+	}
+	if calendarDaysBetween(*pet.LastActivityAt, now, loc) != 1 {
+		return time.Time{}, false
+	}
+
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc), true
+}
+
+// hoursLeft rounds the time left up to whole hours, so "ends in 1 hour"
+// also covers the last few minutes.
+func hoursLeft(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Hours())))
+}
+
+// moodDropped reports whether the pet's mood got worse and is now one
+// worth nudging about.
+func moodDropped(from, to models.Mood) bool {
+	switch to {
+	case models.MoodTired, models.MoodSad, models.MoodBored:
+		return to.Rank() < from.Rank()
+	default:
+		return false
+	}
+}
+
+// nudgeAllowed applies the user's frequency caps: at most maxPerDay nudges
+// a day, spaced at least nudgeMinGap apart.
+func nudgeAllowed(history models.NudgeHistory, maxPerDay int, now time.Time) bool {
+	if history.Count >= maxPerDay {
+		return false
+	}
+	return history.LastSentAt == nil || now.Sub(*history.LastSentAt) >= nudgeMinGap
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joaosantos/pettime/internal/models"
+)
+
+func TestCalendarDaysBetween(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int
+	}{
+		{"same day", time.Date(2025, 6, 1, 8, 0, 0, 0, lisbon), time.Date(2025, 6, 1, 23, 0, 0, 0, lisbon), 0},
+		{"across midnight", time.Date(2025, 6, 1, 23, 30, 0, 0, lisbon), time.Date(2025, 6, 2, 0, 30, 0, 0, lisbon), 1},
+		{"UTC day differs from local day", time.Date(2025, 6, 1, 23, 30, 0, 0, time.UTC), time.Date(2025, 6, 2, 1, 0, 0, 0, time.UTC), 0},
+		{"short DST day", time.Date(2025, 3, 29, 12, 0, 0, 0, lisbon), time.Date(2025, 3, 30, 12, 0, 0, 0, lisbon), 1},
+	}
+	for _, tt := range tests {
+		if got := calendarDaysBetween(tt.from, tt.to, lisbon); got != tt.want {
+			t.Errorf("%s: calendarDaysBetween() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStreakDeadline(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	now := time.Date(2025, 6, 2, 21, 0, 0, 0, tokyo)
+	yesterday := time.Date(2025, 6, 1, 18, 0, 0, 0, tokyo)
+	today := time.Date(2025, 6, 2, 7, 0, 0, 0, tokyo)
+
+	pet := &models.Pet{StreakDays: 14, LastActivityAt: &yesterday}
+	deadline, ok := streakDeadline(pet, now, tokyo)
+	if !ok {
+		t.Fatal("streak should be at risk")
+	}
+	if want := time.Date(2025, 6, 3, 0, 0, 0, 0, tokyo); !deadline.Equal(want) {
+		t.Errorf("deadline = %v, want %v", deadline, want)
+	}
+	if hours := hoursLeft(deadline.Sub(now)); hours != 3 {
+		t.Errorf("hoursLeft() = %d, want 3", hours)
+	}
+
+	if _, ok := streakDeadline(&models.Pet{StreakDays: 14, LastActivityAt: &today}, now, tokyo); ok {
+		t.Error("streak already extended today is not at risk")
+	}
+	if _, ok := streakDeadline(&models.Pet{StreakDays: 1, LastActivityAt: &yesterday}, now, tokyo); ok {
+		t.Error("short streaks are not worth a nudge")
+	}
+}
+
+func TestMoodDropped(t *testing.T) {
+	tests := []struct {
+		from, to models.Mood
+		want     bool
+	}{
+		{models.MoodContent, models.MoodTired, true},
+		{models.MoodTired, models.MoodSad, true},
+		{models.MoodHappy, models.MoodBored, true},
+		{models.MoodHappy, models.MoodContent, false},
+		{models.MoodSad, models.MoodTired, false},
+		{models.MoodBored, models.MoodBored, false},
+	}
+	for _, tt := range tests {
+		if got := moodDropped(tt.from, tt.to); got != tt.want {
+			t.Errorf("moodDropped(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestNudgeAllowed(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Hour)
+	earlier := now.Add(-5 * time.Hour)
+
+	tests := []struct {
+		name      string
+		history   models.NudgeHistory
+		maxPerDay int
+		want      bool
+	}{
+		{"first nudge", models.NudgeHistory{}, 2, true},
+		{"nudges off", models.NudgeHistory{}, 0, false},
+		{"spaced out", models.NudgeHistory{Count: 1, LastSentAt: &earlier}, 2, true},
+		{"too soon", models.NudgeHistory{Count: 1, LastSentAt: &recent}, 2, false},
+		{"daily cap", models.NudgeHistory{Count: 2, LastSentAt: &earlier}, 2, false},
+	}
+	for _, tt := range tests {
+		if got := nudgeAllowed(tt.history, tt.maxPerDay, now); got != tt.want {
+			t.Errorf("%s: nudgeAllowed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+// petAccess checks what a user may do with a pet based on their member role.
+// Every service that reads or changes pet data goes through it.
+type petAccess struct {
+	petRepo    *repositories.PetRepository
+	memberRepo *repositories.PetMemberRepository
+}
+
+// authorize loads the pet and the user's membership and fails with
+// ErrUnauthorized unless the role grants permission, or ErrPetReadOnly if
+// the pet's status doesn't allow the change. The returned pet has
+// Role set to the user's role.
+func (a petAccess) authorize(ctx context.Context, userID, petID uuid.UUID, permission models.Permission) (*models.Pet, *models.PetMember, error) {
+	pet, err := a.petRepo.GetByID(ctx, petID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPetNotFound) {
+			return nil, nil, ErrPetNotFound
+		}
+		return nil, nil, err
+	}
+
+	member, err := a.memberRepo.GetMember(ctx, petID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMemberNotFound) {
+			return nil, nil, ErrUnauthorized
+		}
+		return nil, nil, err
+	}
+
+	if !member.Role.Can(permission) {
+		return nil, nil, ErrUnauthorized
+	}
+	if !pet.Status.Allows(permission) {
+		return nil, nil, ErrPetReadOnly
+	}
+
+	pet.Role = &member.Role
+	return pet, member, nil
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+const (
+	invitationTTL        = 7 * 24 * time.Hour
+	invitationCodeLength = 8
+	// Crockford-style alphabet without look-alike characters so codes can be
+	// read out loud or typed from a screenshot.
+	invitationCodeAlphabet = "ABCDEFGHJKMNPQRSTVWXYZ23456789"
+)
+
+var (
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrAlreadyMember      = errors.New("already a member of this pet")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrCannotRemoveOwner  = errors.New("the owner cannot be removed")
+)
+
+type PetMemberService struct {
+	memberRepo *repositories.PetMemberRepository
+	userRepo   *repositories.UserRepository
+	access     petAccess
+}
+
+func NewPetMemberService(memberRepo *repositories.PetMemberRepository, petRepo *repositories.PetRepository, userRepo *repositories.UserRepository) *PetMemberService {
+	return &PetMemberService{
+		memberRepo: memberRepo,
+		userRepo:   userRepo,
+		access:     petAccess{petRepo: petRepo, memberRepo: memberRepo},
+	}
+}
+
+func (s *PetMemberService) ListMembers(ctx context.Context, userID, petID uuid.UUID) ([]*models.PetMember, error) {
+	if _, _, err := s.access.authorize(ctx, userID, petID, models.PermissionViewPet); err != nil {
+		return nil, err
+	}
+	return s.memberRepo.ListMembers(ctx, petID)
+}
+
+// RemoveMember removes a member from a pet. Any member except the owner may
+// leave on their own; removing someone else needs manage_members, and
+// co-owners can only remove caretakers.
+func (s *PetMemberService) RemoveMember(ctx context.Context, userID, petID, memberID uuid.UUID) error {
+	target, err := s.memberRepo.GetMember(ctx, petID, memberID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMemberNotFound) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	if target.Role == models.PetRoleOwner {
+		return ErrCannotRemoveOwner
+	}
+
+	if userID != memberID {
+		_, actor, err := s.access.authorize(ctx, userID, petID, models.PermissionManageMembers)
+		if err != nil {
+			return err
+		}
+		if actor.Role != models.PetRoleOwner && target.Role != models.PetRoleCaretaker {
+			return ErrUnauthorized
+		}
+	}
+
+	if err := s.memberRepo.RemoveMember(ctx, petID, memberID); err != nil {
+		if errors.Is(err, repositories.ErrMemberNotFound) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateInvitation creates a join code for a pet. When an email is given
+// only the user with that email can redeem it. Only the owner can invite
+// co-owners.
+func (s *PetMemberService) CreateInvitation(ctx context.Context, userID, petID uuid.UUID, input models.CreateInvitationInput) (*models.PetInvitation, error) {
+	if !input.Role.Valid() || input.Role == models.PetRoleOwner {
+		return nil, ErrInvalidRole
+	}
+
+	_, actor, err := s.access.authorize(ctx, userID, petID, models.PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+	if input.Role == models.PetRoleCoOwner && actor.Role != models.PetRoleOwner {
+		return nil, ErrUnauthorized
+	}
+
+	code, err := generateInvitationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation := &models.PetInvitation{
+		ID:        uuid.New(),
+		PetID:     petID,
+		Role:      input.Role,
+		Code:      code,
+		InvitedBy: &userID,
+		ExpiresAt: now.Add(invitationTTL),
+		CreatedAt: now,
+	}
+	if input.Email != nil && strings.TrimSpace(*input.Email) != "" {
+		email := strings.ToLower(strings.TrimSpace(*input.Email))
+		invitation.Email = &email
+	}
+
+	if err := s.memberRepo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (s *PetMemberService) ListInvitations(ctx context.Context, userID, petID uuid.UUID) ([]*models.PetInvitation, error) {
+	if _, _, err := s.access.authorize(ctx, userID, petID, models.PermissionManageMembers); err != nil {
+		return nil, err
+	}
+	return s.memberRepo.ListPendingInvitations(ctx, petID)
+}
+
+func (s *PetMemberService) RevokeInvitation(ctx context.Context, userID, petID, invitationID uuid.UUID) error {
+	invitation, err := s.memberRepo.GetInvitation(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvitationNotFound) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	if invitation.PetID != petID {
+		return ErrInvitationNotFound
+	}
+
+	if _, _, err := s.access.authorize(ctx, userID, petID, models.PermissionManageMembers); err != nil {
+		return err
+	}
+
+	if err := s.memberRepo.RevokeInvitation(ctx, invitationID); err != nil {
+		if errors.Is(err, repositories.ErrInvitationNotFound) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListMyInvitations returns pending invitations addressed to the user's
+// email.
+func (s *PetMemberService) ListMyInvitations(ctx context.Context, userID uuid.UUID) ([]*models.PetInvitation, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.memberRepo.ListInvitationsForEmail(ctx, strings.ToLower(user.Email))
+}
+
+// AcceptInvitation redeems a join code. Invitations bound to an email are
+// reported as not found for anyone else so codes cannot be probed.
+func (s *PetMemberService) AcceptInvitation(ctx context.Context, userID uuid.UUID, code string) (*models.PetMember, error) {
+	invitation, err := s.memberRepo.GetInvitationByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvitationNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if !invitation.IsPending() {
+		return nil, ErrInvitationNotFound
+	}
+
+	if invitation.Email != nil {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(user.Email, *invitation.Email) {
+			return nil, ErrInvitationNotFound
+		}
+	}
+
+	member, err := s.memberRepo.AcceptInvitation(ctx, invitation.ID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrMemberAlreadyExists):
+			return nil, ErrAlreadyMember
+		case errors.Is(err, repositories.ErrInvitationNotFound):
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// generateInvitationCode draws each character uniformly from the alphabet.
+func generateInvitationCode() (string, error) {
+	b := make([]byte, invitationCodeLength)
+	size := big.NewInt(int64(len(invitationCodeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		b[i] = invitationCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,21 +11,57 @@ import (
 	"github.com/joaosantos/pettime/internal/repositories"
 )
 
+const (
+	// transferTTL is how long the recipient has to accept an ownership
+	// transfer.
+	transferTTL = 7 * 24 * time.Hour
+	// petRestoreWindow is how long a deleted pet can be restored before it
+	// is removed with its history.
+	petRestoreWindow = 30 * 24 * time.Hour
+	// maxTimelineEvents caps one page of a pet's timeline.
+	maxTimelineEvents = 100
+)
+
 var (
 	ErrPetNotFound    = errors.New("pet not found")
 	ErrUnauthorized   = errors.New("unauthorized")
 	ErrInvalidPetType = errors.New("invalid pet type")
+	ErrPetReadOnly    = errors.New("pet is archived or a memorial")
+	ErrInvalidBreed   = errors.New("invalid breed")
+	ErrFutureBirth    = errors.New("birth date is in the future")
+
+	ErrTransferNotFound = errors.New("transfer not found")
+	ErrTransferPending  = errors.New("pet already has a pending transfer")
+	ErrTransferToSelf   = errors.New("cannot transfer a pet to yourself")
 )
 
 type PetService struct {
 	petRepo      *repositories.PetRepository
 	activityRepo *repositories.ActivityRepository
+	transferRepo *repositories.PetTransferRepository
+	userRepo     *repositories.UserRepository
+	recordRepo   *repositories.RecordRepository
+	leveling     *models.LevelingConfig
+	access       petAccess
 }
 
-func NewPetService(petRepo *repositories.PetRepository, activityRepo *repositories.ActivityRepository) *PetService {
+func NewPetService(
+	petRepo *repositories.PetRepository,
+	activityRepo *repositories.ActivityRepository,
+	memberRepo *repositories.PetMemberRepository,
+	transferRepo *repositories.PetTransferRepository,
+	userRepo *repositories.UserRepository,
+	recordRepo *repositories.RecordRepository,
+	leveling *models.LevelingConfig,
+) *PetService {
 	return &PetService{
 		petRepo:      petRepo,
 		activityRepo: activityRepo,
+		transferRepo: transferRepo,
+		userRepo:     userRepo,
+		recordRepo:   recordRepo,
+		leveling:     leveling,
+		access:       petAccess{petRepo: petRepo, memberRepo: memberRepo},
 	}
 }
 
@@ -36,11 +73,17 @@ func (s *PetService) Create(ctx context.Context, userID uuid.UUID, input models.
 	}
 
 	now := time.Now()
+	if input.BirthDate != nil && input.BirthDate.After(now) {
+		return nil, ErrFutureBirth
+	}
+
+	role := models.PetRoleOwner
 	pet := &models.Pet{
 		ID:         uuid.New(),
 		UserID:     userID,
 		PetTypeID:  input.PetTypeID,
 		PetType:    petType,
+		Role:       &role,
 		Name:       input.Name,
 		Breed:      input.Breed,
 		AvatarURL:  input.AvatarURL,
@@ -49,39 +92,36 @@ func (s *PetService) Create(ctx context.Context, userID uuid.UUID, input models.
 		Level:      1,
 		Mood:       models.MoodHappy,
 		StreakDays: 0,
+		Status:     models.PetStatusActive,
 		CreatedAt:  now,
 		UpdatedAt:  now,
 	}
 
+	if input.BreedID != nil {
+		if err := s.setBreed(ctx, pet, *input.BreedID); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.petRepo.Create(ctx, pet); err != nil {
 		return nil, err
 	}
 
+	pet.Derive(now)
 	return pet, nil
 }
 
 func (s *PetService) GetByID(ctx context.Context, userID, petID uuid.UUID) (*models.Pet, error) {
-	pet, err := s.petRepo.GetByID(ctx, petID)
-	if err != nil {
-		if errors.Is(err, repositories.ErrPetNotFound) {
-			return nil, ErrPetNotFound
-		}
-		return nil, err
-	}
-
-	if pet.UserID != userID {
-		return nil, ErrUnauthorized
-	}
-
-	return pet, nil
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionViewPet)
+	return pet, err
 }
 
-func (s *PetService) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Pet, error) {
-	return s.petRepo.GetByUserID(ctx, userID)
+func (s *PetService) GetByUserID(ctx context.Context, userID uuid.UUID, status *models.PetStatus) ([]*models.Pet, error) {
+	return s.petRepo.GetByUserID(ctx, userID, status)
 }
 
 func (s *PetService) Update(ctx context.Context, userID, petID uuid.UUID, input models.UpdatePetInput) (*models.Pet, error) {
-	pet, err := s.GetByID(ctx, userID, petID)
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionEditPet)
 	if err != nil {
 		return nil, err
 	}
@@ -96,23 +136,176 @@ func (s *PetService) Update(ctx context.Context, userID, petID uuid.UUID, input
 		pet.AvatarURL = input.AvatarURL
 	}
 	if input.BirthDate != nil {
+		if input.BirthDate.After(time.Now()) {
+			return nil, ErrFutureBirth
+		}
 		pet.BirthDate = input.BirthDate
 	}
+	if input.BreedID != nil {
+		if err := s.setBreed(ctx, pet, *input.BreedID); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.petRepo.Update(ctx, pet); err != nil {
 		return nil, err
 	}
 
+	pet.Derive(time.Now())
 	return pet, nil
 }
 
+// setBreed links the pet to a catalog breed of its type, or unlinks it
+// when breedID is empty. The breed's name fills in a missing free-text
+// breed.
+func (s *PetService) setBreed(ctx context.Context, pet *models.Pet, breedID string) error {
+	if breedID == "" {
+		pet.BreedID = nil
+		pet.BreedDetails = nil
+		return nil
+	}
+
+	breed, err := s.petRepo.GetBreed(ctx, breedID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBreedNotFound) {
+			return ErrInvalidBreed
+		}
+		return err
+	}
+	if breed.PetTypeID != pet.PetTypeID {
+		return ErrInvalidBreed
+	}
+
+	pet.BreedID = &breed.ID
+	pet.BreedDetails = breed
+	if pet.Breed == nil || *pet.Breed == "" {
+		pet.Breed = &breed.Name
+	}
+	return nil
+}
+
+// Delete moves the pet to the restore window and cancels its open
+// transfer, which restoring doesn't bring back.
 func (s *PetService) Delete(ctx context.Context, userID, petID uuid.UUID) error {
-	pet, err := s.GetByID(ctx, userID, petID)
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionDeletePet)
 	if err != nil {
 		return err
 	}
 
-	return s.petRepo.Delete(ctx, pet.ID)
+	if err := s.petRepo.SoftDelete(ctx, pet.ID, time.Now()); err != nil {
+		return err
+	}
+	return s.transferRepo.CancelPendingForPet(ctx, pet.ID)
+}
+
+// ListDeleted returns the user's deleted pets that can still be restored.
+func (s *PetService) ListDeleted(ctx context.Context, userID uuid.UUID) ([]*models.Pet, error) {
+	pets, err := s.petRepo.ListDeleted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pet := range pets {
+		restoreUntil := pet.DeletedAt.Add(petRestoreWindow)
+		pet.RestoreUntil = &restoreUntil
+	}
+
+	return pets, nil
+}
+
+// Restore brings back a deleted pet with its history. Only the owner can
+// restore a pet, and only within the restore window.
+func (s *PetService) Restore(ctx context.Context, userID, petID uuid.UUID) (*models.Pet, error) {
+	pet, err := s.petRepo.GetDeletedByID(ctx, petID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPetNotFound) {
+			return nil, ErrPetNotFound
+		}
+		return nil, err
+	}
+	if time.Since(*pet.DeletedAt) > petRestoreWindow {
+		return nil, ErrPetNotFound
+	}
+
+	member, err := s.access.memberRepo.GetMember(ctx, petID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMemberNotFound) {
+			return nil, ErrUnauthorized
+		}
+		return nil, err
+	}
+	if !member.Role.Can(models.PermissionDeletePet) {
+		return nil, ErrUnauthorized
+	}
+
+	if err := s.petRepo.Restore(ctx, petID); err != nil {
+		if errors.Is(err, repositories.ErrPetNotFound) {
+			return nil, ErrPetNotFound
+		}
+		return nil, err
+	}
+
+	return s.GetByID(ctx, userID, petID)
+}
+
+// PurgeDeleted removes pets whose restore window has passed.
+func (s *PetService) PurgeDeleted(ctx context.Context) error {
+	_, err := s.petRepo.PurgeDeleted(ctx, time.Now().Add(-petRestoreWindow))
+	return err
+}
+
+// UpdateStatus archives a pet, turns it into a memorial or makes it active
+// again. Memorials keep their history but can't be changed or transferred,
+// so an open transfer is cancelled. Archived pets can still be rehomed.
+func (s *PetService) UpdateStatus(ctx context.Context, userID, petID uuid.UUID, input models.UpdatePetStatusInput) (*models.Pet, error) {
+	now := time.Now()
+	if err := input.Validate(now); err != nil {
+		return nil, err
+	}
+
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionArchivePet)
+	if err != nil {
+		return nil, err
+	}
+
+	if pet.Status != input.Status {
+		pet.Status = input.Status
+		pet.StatusSince = &now
+	}
+	pet.PassedAwayOn = input.PassedAwayOn
+
+	if err := s.petRepo.UpdateStatus(ctx, pet); err != nil {
+		if errors.Is(err, repositories.ErrPetNotFound) {
+			return nil, ErrPetNotFound
+		}
+		return nil, err
+	}
+
+	if pet.Status == models.PetStatusMemorial {
+		if err := s.transferRepo.CancelPendingForPet(ctx, pet.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return pet, nil
+}
+
+// Timeline returns a page of the pet's timeline, newest first. Pass the
+// occurred_at of the last event seen as before to get the next page.
+func (s *PetService) Timeline(ctx context.Context, userID, petID uuid.UUID, before *time.Time, limit int) ([]*models.TimelineEvent, error) {
+	if _, _, err := s.access.authorize(ctx, userID, petID, models.PermissionViewPet); err != nil {
+		return nil, err
+	}
+
+	cursor := time.Now().Add(time.Minute)
+	if before != nil {
+		cursor = *before
+	}
+	if limit <= 0 || limit > maxTimelineEvents {
+		limit = maxTimelineEvents
+	}
+
+	return s.petRepo.Timeline(ctx, petID, cursor, limit)
 }
 
 func (s *PetService) GetStats(ctx context.Context, userID, petID uuid.UUID) (*models.Pet, *models.PetStats, error) {
@@ -128,17 +321,181 @@ func (s *PetService) GetStats(ctx context.Context, userID, petID uuid.UUID) (*mo
 
 	// Calculate level progress
 	stats.CurrentStreak = pet.StreakDays
-	stats.XPToNextLevel = models.XPToNextLevel(pet.TotalXP)
+	stats.XPToNextLevel = s.leveling.XPToNextLevel(pet.TotalXP)
+	stats.LevelProgress = s.leveling.LevelProgress(pet.TotalXP)
+	stats.Tier = s.leveling.Tier(pet.Level)
+	stats.NextTier = s.leveling.NextTier(pet.Level)
 
-	currentLevelXP := models.XPForLevel(pet.Level)
-	nextLevelXP := models.XPForLevel(pet.Level + 1)
-	if nextLevelXP > currentLevelXP {
-		stats.LevelProgress = float64(pet.TotalXP-currentLevelXP) / float64(nextLevelXP-currentLevelXP)
+	if stats.PersonalBests, err = s.recordRepo.ListByPet(ctx, petID, nil, false); err != nil {
+		return nil, nil, err
+	}
+
+	// Today's exercise against the daily target, in the owner's timezone
+	owner, err := s.userRepo.GetByID(ctx, pet.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	local := time.Now().In(preferencesOf(owner).Location())
+	seconds, err := s.activityRepo.ExerciseSecondsSince(ctx, petID, time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location()))
+	if err != nil {
+		return nil, nil, err
+	}
+	stats.TodayMinutes = seconds / 60
+	if pet.DailyTarget != nil {
+		stats.TargetMinutes = pet.DailyTarget.ExerciseMinutes
+		stats.TargetProgress = models.TargetPercent(stats.TodayMinutes, *pet.DailyTarget)
 	}
 
 	return pet, stats, nil
 }
 
+// InitiateTransfer offers the pet to another user by email. The recipient
+// has transferTTL to accept; only one transfer per pet can be open.
+func (s *PetService) InitiateTransfer(ctx context.Context, userID, petID uuid.UUID, input models.CreateTransferInput) (*models.PetTransfer, error) {
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionTransferPet)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	toEmail := strings.ToLower(strings.TrimSpace(input.Email))
+	if strings.EqualFold(owner.Email, toEmail) {
+		return nil, ErrTransferToSelf
+	}
+
+	now := time.Now()
+	transfer := &models.PetTransfer{
+		ID:         uuid.New(),
+		PetID:      &pet.ID,
+		PetName:    pet.Name,
+		FromUserID: &userID,
+		FromEmail:  owner.Email,
+		ToEmail:    toEmail,
+		Status:     models.TransferStatusPending,
+		ExpiresAt:  now.Add(transferTTL),
+		CreatedAt:  now,
+	}
+
+	if err := s.transferRepo.Create(ctx, transfer); err != nil {
+		if errors.Is(err, repositories.ErrTransferPending) {
+			return nil, ErrTransferPending
+		}
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (s *PetService) GetPendingTransfer(ctx context.Context, userID, petID uuid.UUID) (*models.PetTransfer, error) {
+	if _, _, err := s.access.authorize(ctx, userID, petID, models.PermissionTransferPet); err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.transferRepo.GetPendingForPet(ctx, petID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTransferNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (s *PetService) CancelTransfer(ctx context.Context, userID, petID uuid.UUID) error {
+	transfer, err := s.GetPendingTransfer(ctx, userID, petID)
+	if err != nil {
+		return err
+	}
+
+	return s.closeTransfer(ctx, transfer.ID, models.TransferStatusCancelled, nil)
+}
+
+// ListIncomingTransfers returns open transfers addressed to the user's email.
+func (s *PetService) ListIncomingTransfers(ctx context.Context, userID uuid.UUID) ([]*models.PetTransfer, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.transferRepo.ListPendingForEmail(ctx, user.Email)
+}
+
+// AcceptTransfer moves the pet, its activities and achievements to the
+// user. Cards earned by the previous owner stay with them.
+func (s *PetService) AcceptTransfer(ctx context.Context, userID, transferID uuid.UUID) (*models.Pet, error) {
+	if _, err := s.incomingTransfer(ctx, userID, transferID); err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.transferRepo.Accept(ctx, transferID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTransferNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, err
+	}
+
+	return s.GetByID(ctx, userID, *transfer.PetID)
+}
+
+func (s *PetService) DeclineTransfer(ctx context.Context, userID, transferID uuid.UUID) error {
+	if _, err := s.incomingTransfer(ctx, userID, transferID); err != nil {
+		return err
+	}
+	return s.closeTransfer(ctx, transferID, models.TransferStatusDeclined, &userID)
+}
+
+// incomingTransfer loads a pending transfer addressed to the user. Anything
+// else is reported as not found so transfer IDs cannot be probed.
+func (s *PetService) incomingTransfer(ctx context.Context, userID, transferID uuid.UUID) (*models.PetTransfer, error) {
+	transfer, err := s.transferRepo.GetByID(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTransferNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !transfer.IsPending() || !strings.EqualFold(user.Email, transfer.ToEmail) {
+		return nil, ErrTransferNotFound
+	}
+
+	return transfer, nil
+}
+
+func (s *PetService) closeTransfer(ctx context.Context, transferID uuid.UUID, status models.TransferStatus, userID *uuid.UUID) error {
+	if err := s.transferRepo.Close(ctx, transferID, status, userID); err != nil {
+		if errors.Is(err, repositories.ErrTransferNotFound) {
+			return ErrTransferNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// maxBreedResults caps one page of breed autocomplete.
+const maxBreedResults = 50
+
+// SearchBreeds autocompletes breed names of a pet type.
+func (s *PetService) SearchBreeds(ctx context.Context, petTypeID, query string, limit int) ([]*models.Breed, error) {
+	if _, err := s.petRepo.GetPetType(ctx, petTypeID); err != nil {
+		return nil, ErrInvalidPetType
+	}
+	if limit <= 0 || limit > maxBreedResults {
+		limit = maxBreedResults
+	}
+	return s.petRepo.SearchBreeds(ctx, petTypeID, strings.TrimSpace(query), limit)
+}
+
 func (s *PetService) GetAllPetTypes(ctx context.Context) ([]*models.PetType, error) {
 	return s.petRepo.GetAllPetTypes(ctx)
 }
@@ -157,23 +514,12 @@ func (s *PetService) UpdateMood(ctx context.Context, petID uuid.UUID) error {
 	return nil
 }
 
+// calculateMood decays the pet's mood along its type's mood curve.
 func calculateMood(pet *models.Pet) models.Mood {
+	config := pet.PetType.Settings()
 	if pet.LastActivityAt == nil {
-		return models.MoodBored
+		return config.NeverPlayedMood()
 	}
 
-	hoursSinceActivity := time.Since(*pet.LastActivityAt).Hours()
-
-	switch {
-	case hoursSinceActivity < 6:
-		return models.MoodHappy
-	case hoursSinceActivity < 12:
-		return models.MoodContent
-	case hoursSinceActivity < 24:
-		return models.MoodTired
-	case hoursSinceActivity < 48:
-		return models.MoodSad
-	default:
-		return models.MoodBored
-	}
+	return config.Mood(time.Since(*pet.LastActivityAt).Hours())
 }
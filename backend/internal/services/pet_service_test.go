@@ -155,3 +155,30 @@ func TestMoodBoundaries(t *testing.T) {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
+
+func TestCalculateMood_PetTypeCurve(t *testing.T) {
+	config, err := models.ParsePetTypeConfig([]byte(`{
+		"mood_decay": [
+			{"after_hours": 0, "mood": "happy"},
+			{"after_hours": 12, "mood": "content"},
+			{"after_hours": 36, "mood": "tired"},
+			{"after_hours": 72, "mood": "sad"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParsePetTypeConfig() error = %v", err)
+	}
+	cat := &models.PetType{ID: "cat", Config: config}
+	dog := &models.PetType{ID: "dog", Config: models.DefaultPetTypeConfig()}
+	dayAgo := time.Now().Add(-30 * time.Hour)
+
+	if mood := calculateMood(&models.Pet{PetType: cat, LastActivityAt: &dayAgo}); mood != models.MoodContent {
+		t.Errorf("cat after 30 hours = %s, want %s", mood, models.MoodContent)
+	}
+	if mood := calculateMood(&models.Pet{PetType: dog, LastActivityAt: &dayAgo}); mood != models.MoodSad {
+		t.Errorf("dog after 30 hours = %s, want %s", mood, models.MoodSad)
+	}
+	if mood := calculateMood(&models.Pet{PetType: cat}); mood != models.MoodSad {
+		t.Errorf("cat never played = %s, want the curve's last mood %s", mood, models.MoodSad)
+	}
+}
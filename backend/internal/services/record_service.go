@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+// RecordService keeps each pet's personal records per game type, broken
+// as activities finish, with the history of when each was broken. Records
+// are raised by the activity repository in the transaction that saves the
+// finished activity.
+type RecordService struct {
+	recordRepo *repositories.RecordRepository
+	userRepo   *repositories.UserRepository
+	access     petAccess
+}
+
+func NewRecordService(
+	recordRepo *repositories.RecordRepository,
+	petRepo *repositories.PetRepository,
+	memberRepo *repositories.PetMemberRepository,
+	userRepo *repositories.UserRepository,
+) *RecordService {
+	return &RecordService{
+		recordRepo: recordRepo,
+		userRepo:   userRepo,
+		access:     petAccess{petRepo: petRepo, memberRepo: memberRepo},
+	}
+}
+
+// Claim returns what a finished activity reaches towards its pet's records,
+// raised as the activity is saved. Days and weeks are the owner's.
+// Running and flagged activities claim nothing.
+func (s *RecordService) Claim(ctx context.Context, pet *models.Pet, activity *models.Activity) (*models.RecordClaim, error) {
+	if activity.EndedAt == nil || activity.FlagReason != nil {
+		return nil, nil
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, pet.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RecordClaim{
+		Values:  models.ActivityRecords(activity),
+		Periods: models.ActivityRecordPeriods(activity, preferencesOf(owner).Location()),
+	}, nil
+}
+
+// List returns the pet's records with their history, optionally of one
+// game type.
+func (s *RecordService) List(ctx context.Context, userID, petID uuid.UUID, gameTypeID *string) ([]*models.PersonalRecord, error) {
+	if _, _, err := s.access.authorize(ctx, userID, petID, models.PermissionViewPet); err != nil {
+		return nil, err
+	}
+	return s.recordRepo.ListByPet(ctx, petID, gameTypeID, true)
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/notify"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+var ErrReminderNotFound = errors.New("reminder not found")
+
+const (
+	// reminderBatchSize is how many due reminders one scheduler pass sends.
+	reminderBatchSize = 100
+	// reminderClaimTTL is how long a claimed reminder stays with a worker
+	// before another pass may retry it.
+	reminderClaimTTL = 5 * time.Minute
+	// reminderStaleAfter drops occurrences the scheduler missed by more
+	// than this (e.g. during downtime) instead of sending them late.
+	reminderStaleAfter = time.Hour
+	// reminderDoneWindow is how early marking a reminder done still counts
+	// for its next occurrence, so walking at 7:50 silences the 8:00 nudge.
+	reminderDoneWindow = time.Hour
+)
+
+// ReminderService manages a user's care reminders and delivers them when
+// they fall due. Reminders are private to the user who created them.
+type ReminderService struct {
+	reminderRepo    *repositories.ReminderRepository
+	userRepo        *repositories.UserRepository
+	activityRepo    *repositories.ActivityRepository
+	activityService *ActivityService
+	channel         notify.Channel
+	access          petAccess
+}
+
+func NewReminderService(
+	reminderRepo *repositories.ReminderRepository,
+	userRepo *repositories.UserRepository,
+	activityRepo *repositories.ActivityRepository,
+	petRepo *repositories.PetRepository,
+	memberRepo *repositories.PetMemberRepository,
+	activityService *ActivityService,
+	channel notify.Channel,
+) *ReminderService {
+	return &ReminderService{
+		reminderRepo:    reminderRepo,
+		userRepo:        userRepo,
+		activityRepo:    activityRepo,
+		activityService: activityService,
+		channel:         channel,
+		access:          petAccess{petRepo: petRepo, memberRepo: memberRepo},
+	}
+}
+
+func (s *ReminderService) List(ctx context.Context, userID uuid.UUID, petID *uuid.UUID) ([]*models.Reminder, error) {
+	return s.reminderRepo.ListByUser(ctx, userID, petID)
+}
+
+func (s *ReminderService) GetByID(ctx context.Context, userID, reminderID uuid.UUID) (*models.Reminder, error) {
+	reminder, err := s.reminderRepo.GetByID(ctx, userID, reminderID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrReminderNotFound) {
+			return nil, ErrReminderNotFound
+		}
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func (s *ReminderService) Create(ctx context.Context, userID uuid.UUID, input models.ReminderInput) (*models.Reminder, error) {
+	now := time.Now()
+	reminder := &models.Reminder{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Enabled:   true,
+		CreatedAt: now,
+	}
+
+	if err := s.apply(ctx, reminder, input, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.reminderRepo.Create(ctx, reminder); err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+// Update replaces the reminder's schedule and details and recomputes its
+// next occurrence.
+func (s *ReminderService) Update(ctx context.Context, userID, reminderID uuid.UUID, input models.ReminderInput) (*models.Reminder, error) {
+	reminder, err := s.GetByID(ctx, userID, reminderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(ctx, reminder, input, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.reminderRepo.Update(ctx, reminder); err != nil {
+		if errors.Is(err, repositories.ErrReminderNotFound) {
+			return nil, ErrReminderNotFound
+		}
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+func (s *ReminderService) Delete(ctx context.Context, userID, reminderID uuid.UUID) error {
+	err := s.reminderRepo.Delete(ctx, userID, reminderID)
+	if errors.Is(err, repositories.ErrReminderNotFound) {
+		return ErrReminderNotFound
+	}
+	return err
+}
+
+// Upcoming previews the next count occurrences in the user's timezone.
+func (s *ReminderService) Upcoming(ctx context.Context, userID, reminderID uuid.UUID, count int) ([]time.Time, error) {
+	reminder, err := s.GetByID(ctx, userID, reminderID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := s.preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return reminder.Occurrences(time.Now(), prefs.Location(), count), nil
+}
+
+// MarkDone records that the reminder was acted on. If the next occurrence
+// is close it is skipped, one-off reminders are switched off, and when the
+// reminder names a game type an activity is started for the pet.
+func (s *ReminderService) MarkDone(ctx context.Context, userID, reminderID uuid.UUID) (*models.ReminderCompletion, error) {
+	reminder, err := s.GetByID(ctx, userID, reminderID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := s.preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	completion := &models.ReminderCompletion{Reminder: reminder}
+	now := time.Now()
+
+	if reminder.GameTypeID != nil && reminder.PetID != nil {
+		activity, err := s.activityService.Create(ctx, userID, models.CreateActivityInput{
+			PetID:      *reminder.PetID,
+			GameTypeID: *reminder.GameTypeID,
+			StartedAt:  now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		completion.Activity = activity
+	}
+
+	reminder.LastDoneAt = &now
+	reminder.UpdatedAt = now
+	switch {
+	case reminder.RRule == nil:
+		reminder.Enabled = false
+		reminder.NextRunAt = nil
+	case reminder.NextRunAt != nil && reminder.NextRunAt.Sub(now) <= reminderDoneWindow:
+		reminder.NextRunAt = reminder.NextOccurrence(maxTime(now, *reminder.NextRunAt), prefs.Location())
+	}
+
+	if err := s.reminderRepo.MarkDone(ctx, reminder); err != nil {
+		return nil, err
+	}
+
+	return completion, nil
+}
+
+// RescheduleForUser recomputes every enabled reminder of the user, e.g.
+// after they changed timezone.
+func (s *ReminderService) RescheduleForUser(ctx context.Context, userID uuid.UUID) error {
+	prefs, err := s.preferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	reminders, err := s.reminderRepo.ListByUser(ctx, userID, nil)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, reminder := range reminders {
+		if !reminder.Enabled {
+			continue
+		}
+		if err := s.reminderRepo.Reschedule(ctx, reminder.ID, reminder.NextOccurrence(now, prefs.Location()), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ProcessDue sends reminders whose next occurrence has passed and schedules
+// the following one. It runs as a background job.
+func (s *ReminderService) ProcessDue(ctx context.Context) error {
+	now := time.Now()
+	due, err := s.reminderRepo.ClaimDue(ctx, now, reminderClaimTTL, reminderBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range due {
+		if err := s.deliver(ctx, reminder, now); err != nil {
+			// The claim expires and a later pass retries
+			log.Printf("Failed to deliver reminder %s: %v", reminder.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ReminderService) deliver(ctx context.Context, reminder *models.Reminder, now time.Time) error {
+	prefs, err := s.preferences(ctx, reminder.UserID)
+	if err != nil {
+		return err
+	}
+
+	notification := &notify.Notification{
+		UserID:   reminder.UserID,
+		Category: notify.CategoryReminder,
+		Template: "reminder",
+		Params:   map[string]string{"title": reminder.Title, "notes": "", "pet": ""},
+		Data:     map[string]string{"reminder_id": reminder.ID.String()},
+	}
+	if reminder.Notes != nil {
+		notification.Params["notes"] = *reminder.Notes
+	}
+
+	send := now.Sub(*reminder.NextRunAt) <= reminderStaleAfter
+	if reminder.PetID != nil {
+		// Reminders for a pet the user can no longer see are switched off
+		pet, _, err := s.access.authorize(ctx, reminder.UserID, *reminder.PetID, models.PermissionViewPet)
+		if errors.Is(err, ErrPetNotFound) || errors.Is(err, ErrUnauthorized) {
+			return s.reminderRepo.Disable(ctx, reminder.ID)
+		}
+		if err != nil {
+			return err
+		}
+		notification.Params["pet"] = pet.Name
+		notification.Data["pet_id"] = pet.ID.String()
+		// Archived and memorial pets keep their schedule but stay quiet
+		send = send && pet.Status == models.PetStatusActive
+	}
+
+	// The channel applies the user's notification preferences
+	var notifiedAt *time.Time
+	if send {
+		if err := s.channel.Send(ctx, notification); err != nil {
+			return err
+		}
+		notifiedAt = &now
+	}
+
+	return s.reminderRepo.Reschedule(ctx, reminder.ID, reminder.NextOccurrence(now, prefs.Location()), notifiedAt)
+}
+
+// apply validates input and copies it onto the reminder, computing the next
+// occurrence in the user's timezone.
+func (s *ReminderService) apply(ctx context.Context, reminder *models.Reminder, input models.ReminderInput, now time.Time) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if input.PetID != nil {
+		permission := models.PermissionViewPet
+		if input.GameTypeID != nil {
+			permission = models.PermissionLogActivity
+		}
+		pet, _, err := s.access.authorize(ctx, reminder.UserID, *input.PetID, permission)
+		if err != nil {
+			return err
+		}
+
+		if input.GameTypeID != nil {
+			gameType, err := s.activityRepo.GetGameType(ctx, *input.GameTypeID)
+			if err != nil || !isGameTypeSupported(gameType, pet.PetType) {
+				return ErrInvalidGameType
+			}
+		}
+	}
+
+	prefs, err := s.preferences(ctx, reminder.UserID)
+	if err != nil {
+		return err
+	}
+
+	reminder.PetID = input.PetID
+	reminder.Title = input.Title
+	reminder.Notes = input.Notes
+	reminder.RRule = input.RRule
+	reminder.StartsAt = input.StartsAt
+	reminder.GameTypeID = input.GameTypeID
+	if input.Enabled != nil {
+		reminder.Enabled = *input.Enabled
+	}
+	reminder.UpdatedAt = now
+
+	reminder.NextRunAt = reminder.NextOccurrence(now, prefs.Location())
+	if reminder.NextRunAt == nil && reminder.Enabled {
+		if reminder.RRule == nil {
+			return fmt.Errorf("%w: starts_at must be in the future", models.ErrInvalidReminder)
+		}
+		return fmt.Errorf("%w: rrule has no upcoming occurrences", models.ErrInvalidReminder)
+	}
+	if !reminder.Enabled {
+		reminder.NextRunAt = nil
+	}
+
+	return nil
+}
+
+func (s *ReminderService) preferences(ctx context.Context, userID uuid.UUID) (models.UserPreferences, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return models.UserPreferences{}, err
+	}
+	if user.Preferences == nil {
+		return models.DefaultPreferences(), nil
+	}
+	return *user.Preferences, nil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
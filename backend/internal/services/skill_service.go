@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+var ErrSkillNotFound = errors.New("skill not found")
+
+// SkillService runs pets' skill trees. A pet earns a skill point for every
+// level, plus the leveling config's rewards, and spends them on skills that
+// boost the XP of its activities.
+type SkillService struct {
+	skillRepo *repositories.SkillRepository
+	leveling  *models.LevelingConfig
+	access    petAccess
+}
+
+func NewSkillService(
+	skillRepo *repositories.SkillRepository,
+	petRepo *repositories.PetRepository,
+	memberRepo *repositories.PetMemberRepository,
+	leveling *models.LevelingConfig,
+) *SkillService {
+	return &SkillService{
+		skillRepo: skillRepo,
+		leveling:  leveling,
+		access:    petAccess{petRepo: petRepo, memberRepo: memberRepo},
+	}
+}
+
+// GetTree returns the skill catalog with the pet's points and progress.
+func (s *SkillService) GetTree(ctx context.Context, userID, petID uuid.UUID) (*models.SkillTree, error) {
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionViewPet)
+	if err != nil {
+		return nil, err
+	}
+	tree, _, err := s.tree(ctx, pet)
+	return tree, err
+}
+
+// Unlock spends the pet's skill points on a skill and returns the updated
+// tree.
+func (s *SkillService) Unlock(ctx context.Context, userID, petID uuid.UUID, skillID string) (*models.SkillTree, error) {
+	pet, _, err := s.access.authorize(ctx, userID, petID, models.PermissionEditPet)
+	if err != nil {
+		return nil, err
+	}
+
+	skill, err := s.skillRepo.GetSkill(ctx, skillID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSkillNotFound) {
+			return nil, ErrSkillNotFound
+		}
+		return nil, err
+	}
+
+	tree, unlocked, err := s.tree(ctx, pet)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.CheckUnlock(skill, unlocked); err != nil {
+		return nil, err
+	}
+
+	err = s.skillRepo.Unlock(ctx, pet.ID, skill, tree.PointsEarned, userID, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrSkillAlreadyUnlocked):
+			return nil, models.ErrSkillUnlocked
+		case errors.Is(err, repositories.ErrSkillPointsSpent):
+			return nil, models.ErrNotEnoughSkillPoints
+		}
+		return nil, err
+	}
+
+	tree, _, err = s.tree(ctx, pet)
+	return tree, err
+}
+
+// Active returns the skills whose effects apply to the pet's activities.
+func (s *SkillService) Active(ctx context.Context, petID uuid.UUID) ([]*models.Skill, error) {
+	return s.skillRepo.ListActive(ctx, petID)
+}
+
+func (s *SkillService) tree(ctx context.Context, pet *models.Pet) (*models.SkillTree, map[string]time.Time, error) {
+	skills, err := s.skillRepo.ListSkills(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlocked, err := s.skillRepo.ListUnlocked(ctx, pet.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return models.BuildSkillTree(pet.ID, pet.Level, s.leveling, skills, unlocked), unlocked, nil
+}
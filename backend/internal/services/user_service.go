@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/joaosantos/pettime/internal/models"
+	"github.com/joaosantos/pettime/internal/repositories"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+type UserService struct {
+	userRepo        *repositories.UserRepository
+	reminderService *ReminderService
+}
+
+func NewUserService(userRepo *repositories.UserRepository, reminderService *ReminderService) *UserService {
+	return &UserService{userRepo: userRepo, reminderService: reminderService}
+}
+
+func (s *UserService) GetProfile(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, input models.UpdateUserInput) (*models.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		user.Name = *input.Name
+	}
+	if input.AvatarURL != nil {
+		user.AvatarURL = input.AvatarURL
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) GetPreferences(ctx context.Context, userID uuid.UUID) (*models.UserPreferences, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.Preferences, nil
+}
+
+// UpdatePreferences applies a partial preferences document. Keys that are
+// not part of the schema are rejected.
+func (s *UserService) UpdatePreferences(ctx context.Context, userID uuid.UUID, patch []byte) (*models.UserPreferences, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := user.Preferences.Merge(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdatePreferences(ctx, userID, prefs); err != nil {
+		return nil, err
+	}
+
+	// Reminders fire on the user's wall clock
+	if prefs.Timezone != user.Preferences.Timezone {
+		if err := s.reminderService.RescheduleForUser(ctx, userID); err != nil {
+			log.Printf("Failed to reschedule reminders for user %s: %v", userID, err)
+		}
+	}
+
+	return &prefs, nil
+}
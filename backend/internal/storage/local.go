@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps blobs on the local filesystem. Content type and cache
+// headers are stored next to each file in a small JSON sidecar. Blobs are
+// served by the API under baseURL.
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+type localMeta struct {
+	ContentType  string `json:"content_type"`
+	CacheControl string `json:"cache_control,omitempty"`
+}
+
+func NewLocalStore(dir, baseURL string) *LocalStore {
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, blob *Blob) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	meta, err := json.Marshal(localMeta{ContentType: blob.ContentType, CacheControl: blob.CacheControl})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so readers never see partial blobs
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(blob.Data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.WriteFile(path+".meta", meta, 0o644); err != nil {
+		return fmt.Errorf("failed to write blob metadata: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (*Blob, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+
+	blob := &Blob{Data: data, ContentType: "application/octet-stream"}
+	if raw, err := os.ReadFile(path + ".meta"); err == nil {
+		var meta localMeta
+		if json.Unmarshal(raw, &meta) == nil {
+			blob.ContentType = meta.ContentType
+			blob.CacheControl = meta.CacheControl
+		}
+	}
+
+	return blob, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []string{path, path + ".meta"} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete blob: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3-compatible bucket (AWS S3, MinIO, R2, ...).
+// Objects are addressed path-style: {Endpoint}/{Bucket}/{key}.
+type S3Config struct {
+	Endpoint    string
+	Region      string
+	Bucket      string
+	Credentials Credentials
+	// PublicURL is where clients fetch objects, usually a CDN in front of
+	// the bucket. Defaults to the path-style bucket URL.
+	PublicURL string
+}
+
+// S3Store talks to an S3-compatible API with hand-signed SigV4 requests.
+type S3Store struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Store(cfg S3Config) *S3Store {
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = cfg.Endpoint + "/" + cfg.Bucket
+	}
+	cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+
+	return &S3Store{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, blob *Blob) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, blob.Data)
+	if err != nil {
+		return err
+	}
+	if blob.ContentType != "" {
+		req.Header.Set("Content-Type", blob.ContentType)
+	}
+	if blob.CacheControl != "" {
+		req.Header.Set("Cache-Control", blob.CacheControl)
+	}
+
+	resp, err := s.do(req, blob.Data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError("put", resp)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (*Blob, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, s.responseError("get", resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+
+	return &Blob{
+		Data:         data,
+		ContentType:  resp.Header.Get("Content-Type"),
+		CacheControl: resp.Header.Get("Cache-Control"),
+	}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// S3 answers 204 whether or not the object existed
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError("delete", resp)
+	}
+	return nil
+}
+
+func (s *S3Store) URL(key string) string {
+	return s.cfg.PublicURL + "/" + key
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.cfg.Endpoint+"/"+s.cfg.Bucket+"/"+key, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	return req, nil
+}
+
+func (s *S3Store) do(req *http.Request, body []byte) (*http.Response, error) {
+	payloadHash := emptyPayloadSHA
+	if body != nil {
+		payloadHash = hexSHA256(body)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signV4(req, s.cfg.Credentials, s.cfg.Region, "s3", payloadHash, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage request failed: %w", err)
+	}
+	return resp, nil
+}
+
+func (s *S3Store) responseError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("storage %s failed: %s: %s", op, resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,47 @@
+// Package storage stores uploaded files such as pet avatars.
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// ImmutableCacheControl is used for content-addressed blobs: the key changes
+// whenever the content does, so clients and CDNs may cache them forever.
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// Blob is a stored file.
+type Blob struct {
+	Data         []byte
+	ContentType  string
+	CacheControl string
+}
+
+// BlobStore stores blobs under slash separated keys such as
+// "pets/<id>/<hash>/small.jpg".
+type BlobStore interface {
+	Put(ctx context.Context, key string, blob *Blob) error
+	Get(ctx context.Context, key string) (*Blob, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL clients use to fetch the blob.
+	URL(key string) string
+}
+
+// ValidateKey rejects keys that could escape the store's root.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
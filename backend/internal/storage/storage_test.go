@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignV4_AWSTestSuiteVector(t *testing.T) {
+	// "get-vanilla" from the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	signV4(req, creds, "us-east-1", "service", emptyPayloadSHA, now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	valid := []string{"avatar.jpg", "pets/1/abc/small.jpg"}
+	invalid := []string{"", "/etc/passwd", "../secret", "pets/../../x", "pets//x", `pets\x`}
+
+	for _, key := range valid {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("ValidateKey(%q) = %v, want nil", key, err)
+		}
+	}
+	for _, key := range invalid {
+		if err := ValidateKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ValidateKey(%q) = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	store := NewLocalStore(t.TempDir(), "http://localhost:8080/media/")
+	testBlobStore(t, store)
+
+	if got := store.URL("pets/1/a.jpg"); got != "http://localhost:8080/media/pets/1/a.jpg" {
+		t.Errorf("URL() = %s", got)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	creds := Credentials{AccessKeyID: "minio", SecretAccessKey: "minio-secret"}
+	server := httptest.NewServer(newFakeS3(t, "avatars", creds))
+	defer server.Close()
+
+	store := NewS3Store(S3Config{
+		Endpoint:    server.URL,
+		Bucket:      "avatars",
+		Credentials: creds,
+		PublicURL:   "https://cdn.example.com/",
+	})
+	testBlobStore(t, store)
+
+	if got := store.URL("pets/1/a.jpg"); got != "https://cdn.example.com/pets/1/a.jpg" {
+		t.Errorf("URL() = %s", got)
+	}
+
+	// Wrong credentials are rejected by the server
+	bad := NewS3Store(S3Config{Endpoint: server.URL, Bucket: "avatars", Credentials: Credentials{AccessKeyID: "minio", SecretAccessKey: "wrong"}})
+	if err := bad.Put(context.Background(), "x.jpg", &Blob{Data: []byte("x")}); err == nil {
+		t.Error("Put() with wrong secret should fail")
+	}
+}
+
+func testBlobStore(t *testing.T, store BlobStore) {
+	t.Helper()
+	ctx := context.Background()
+	key := "pets/123/abc/small.jpg"
+
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() before Put: err = %v, want %v", err, ErrNotFound)
+	}
+
+	blob := &Blob{Data: []byte("jpeg bytes"), ContentType: "image/jpeg", CacheControl: ImmutableCacheControl}
+	if err := store.Put(ctx, key, blob); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(got.Data) != "jpeg bytes" || got.ContentType != "image/jpeg" || got.CacheControl != ImmutableCacheControl {
+		t.Errorf("Get() = %+v", got)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete: err = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := store.Put(ctx, "../escape", blob); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put() with traversal key: err = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+type fakeObject struct {
+	data         []byte
+	contentType  string
+	cacheControl string
+}
+
+// newFakeS3 is a tiny MinIO stand-in: a single path-style bucket that
+// checks SigV4 signatures and payload hashes.
+func newFakeS3(t *testing.T, bucket string, creds Credentials) http.Handler {
+	var mu sync.Mutex
+	objects := map[string]fakeObject{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !validSignature(r, creds) {
+			http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Amz-Content-Sha256") != hexSHA256(body) {
+			http.Error(w, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+			return
+		}
+
+		key, ok := strings.CutPrefix(r.URL.Path, "/"+bucket+"/")
+		if !ok {
+			http.Error(w, "NoSuchBucket", http.StatusNotFound)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			objects[key] = fakeObject{body, r.Header.Get("Content-Type"), r.Header.Get("Cache-Control")}
+		case http.MethodGet:
+			obj, ok := objects[key]
+			if !ok {
+				http.Error(w, "NoSuchKey", http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", obj.contentType)
+			w.Header().Set("Cache-Control", obj.cacheControl)
+			w.Write(obj.data)
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+func validSignature(r *http.Request, creds Credentials) bool {
+	signed, err := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+
+	clone, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	for name, values := range r.Header {
+		if name != "Authorization" {
+			clone.Header[name] = values
+		}
+	}
+	signV4(clone, creds, "us-east-1", "s3", r.Header.Get("X-Amz-Content-Sha256"), signed)
+
+	return clone.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
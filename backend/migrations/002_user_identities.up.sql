@@ -0,0 +1,30 @@
+-- Linked login methods. A user can sign in with any of them.
+CREATE TABLE user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    UNIQUE (provider, provider_id),
+    UNIQUE (user_id, provider)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+-- Backfill from the single provider stored on users
+INSERT INTO user_identities (user_id, provider, provider_id, email, created_at)
+SELECT id, 'email', email, email, created_at
+FROM users
+WHERE password_hash IS NOT NULL;
+
+INSERT INTO user_identities (user_id, provider, provider_id, email, created_at)
+SELECT id, auth_provider, auth_provider_id, email, created_at
+FROM users
+WHERE auth_provider <> 'email' AND auth_provider_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- Preserve the original login time across refreshes so sensitive actions
+-- can require a recent login.
+ALTER TABLE refresh_tokens ADD COLUMN auth_time TIMESTAMPTZ NOT NULL DEFAULT NOW();
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS data_exports;
+
+ALTER TABLE user_cards DROP CONSTRAINT user_cards_activity_id_fkey;
+ALTER TABLE user_cards ADD CONSTRAINT user_cards_activity_id_fkey
+    FOREIGN KEY (activity_id) REFERENCES activities(id);
+
+DROP INDEX IF EXISTS idx_users_deletion_scheduled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_scheduled_at;
@@ -0,0 +1,25 @@
+-- Scheduled account deletion. Logging in before this time cancels it.
+ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL;
+
+-- Cards survive the activity that dropped them being deleted
+ALTER TABLE user_cards DROP CONSTRAINT user_cards_activity_id_fkey;
+ALTER TABLE user_cards ADD CONSTRAINT user_cards_activity_id_fkey
+    FOREIGN KEY (activity_id) REFERENCES activities(id) ON DELETE SET NULL;
+
+-- GDPR data exports, built in the background
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    archive BYTEA,
+    error TEXT,
+    expires_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports(user_id);
+CREATE INDEX idx_data_exports_status ON data_exports(status);
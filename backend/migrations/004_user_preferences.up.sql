@@ -0,0 +1,2 @@
+-- Per-user settings document. Missing keys fall back to defaults in code.
+ALTER TABLE users ADD COLUMN preferences JSONB NOT NULL DEFAULT '{}';
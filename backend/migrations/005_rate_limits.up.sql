@@ -0,0 +1,16 @@
+-- Shared rate limiter state for multi-instance deployments
+CREATE TABLE rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+CREATE TABLE login_failures (
+    key VARCHAR(255) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_login_failures_last_failure_at ON login_failures(last_failure_at);
@@ -0,0 +1,3 @@
+ALTER TABLE activities DROP COLUMN IF EXISTS performed_by;
+DROP TABLE IF EXISTS pet_invitations;
+DROP TABLE IF EXISTS pet_members;
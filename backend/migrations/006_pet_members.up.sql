@@ -0,0 +1,43 @@
+-- People who share a pet. pets.user_id stays the single owner.
+CREATE TABLE pet_members (
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (pet_id, user_id)
+);
+
+CREATE INDEX idx_pet_members_user_id ON pet_members(user_id);
+
+INSERT INTO pet_members (pet_id, user_id, role, created_at)
+SELECT id, user_id, 'owner', created_at
+FROM pets
+WHERE user_id IS NOT NULL;
+
+-- Invitations are accepted with a link code. An email restricts who may
+-- accept it.
+CREATE TABLE pet_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL,
+    email VARCHAR(255),
+    code VARCHAR(32) UNIQUE NOT NULL,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    accepted_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_pet_invitations_pet_id ON pet_invitations(pet_id);
+CREATE INDEX idx_pet_invitations_email ON pet_invitations(LOWER(email));
+
+-- Which member logged the activity
+ALTER TABLE activities ADD COLUMN performed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE activities a
+SET performed_by = p.user_id
+FROM pets p
+WHERE a.pet_id = p.id;
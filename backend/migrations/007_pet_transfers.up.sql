@@ -0,0 +1,20 @@
+-- Ownership transfers. Rows are never deleted so they double as the audit
+-- trail; pet name and emails are copied so the record survives the pet or
+-- either account being deleted.
+CREATE TABLE pet_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pet_id UUID REFERENCES pets(id) ON DELETE SET NULL,
+    pet_name VARCHAR(100) NOT NULL,
+    from_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    from_email VARCHAR(255) NOT NULL,
+    to_email VARCHAR(255) NOT NULL,
+    to_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMPTZ NOT NULL,
+    responded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_pet_transfers_pet_id ON pet_transfers(pet_id);
+CREATE INDEX idx_pet_transfers_to_email ON pet_transfers(LOWER(to_email));
+CREATE UNIQUE INDEX idx_pet_transfers_pending ON pet_transfers(pet_id) WHERE status = 'pending';
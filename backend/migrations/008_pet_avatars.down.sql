@@ -0,0 +1,2 @@
+ALTER TABLE pets DROP COLUMN IF EXISTS avatar_thumbnails;
+ALTER TABLE pets DROP COLUMN IF EXISTS avatar_key;
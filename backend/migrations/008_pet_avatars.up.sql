@@ -0,0 +1,5 @@
+-- Uploaded avatars. avatar_key is the storage prefix of the current upload
+-- so old files can be removed when it is replaced; avatar_thumbnails maps
+-- size names to URLs.
+ALTER TABLE pets ADD COLUMN avatar_key VARCHAR(255);
+ALTER TABLE pets ADD COLUMN avatar_thumbnails JSONB;
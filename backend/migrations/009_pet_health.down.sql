@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS medications;
+DROP TABLE IF EXISTS vaccinations;
+DROP TABLE IF EXISTS vet_visits;
+DROP TABLE IF EXISTS pet_weights;
@@ -0,0 +1,60 @@
+-- Health journal
+CREATE TABLE pet_weights (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    weight_kg NUMERIC(6, 2) NOT NULL,
+    measured_at TIMESTAMPTZ NOT NULL,
+    notes TEXT,
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_pet_weights_pet_id ON pet_weights(pet_id, measured_at);
+
+CREATE TABLE vet_visits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    visited_at TIMESTAMPTZ NOT NULL,
+    clinic VARCHAR(255),
+    vet_name VARCHAR(255),
+    reason VARCHAR(255) NOT NULL,
+    notes TEXT,
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_vet_visits_pet_id ON vet_visits(pet_id, visited_at);
+
+CREATE TABLE vaccinations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    administered_at TIMESTAMPTZ NOT NULL,
+    due_at TIMESTAMPTZ,
+    clinic VARCHAR(255),
+    batch_number VARCHAR(100),
+    notes TEXT,
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_vaccinations_pet_id ON vaccinations(pet_id);
+CREATE INDEX idx_vaccinations_due_at ON vaccinations(due_at) WHERE due_at IS NOT NULL;
+
+CREATE TABLE medications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    dosage VARCHAR(100) NOT NULL,
+    interval_hours INTEGER NOT NULL,
+    start_date TIMESTAMPTZ NOT NULL,
+    end_date TIMESTAMPTZ,
+    notes TEXT,
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_medications_pet_id ON medications(pet_id);
@@ -0,0 +1,23 @@
+-- Care reminders. next_run_at is the next occurrence in the owner's
+-- timezone; the scheduler claims due rows with claimed_at so several API
+-- instances don't deliver the same reminder twice.
+CREATE TABLE reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pet_id UUID REFERENCES pets(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    notes TEXT,
+    rrule VARCHAR(255),
+    starts_at TIMESTAMPTZ NOT NULL,
+    game_type_id VARCHAR(50) REFERENCES game_types(id),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ,
+    claimed_at TIMESTAMPTZ,
+    last_notified_at TIMESTAMPTZ,
+    last_done_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_reminders_user_id ON reminders(user_id);
+CREATE INDEX idx_reminders_due ON reminders(next_run_at) WHERE enabled AND next_run_at IS NOT NULL;
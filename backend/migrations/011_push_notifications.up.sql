@@ -0,0 +1,34 @@
+-- Push tokens belong to a login session; signing out removes them
+CREATE TABLE device_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id UUID NOT NULL REFERENCES refresh_tokens(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    token VARCHAR(512) NOT NULL,
+    device_name VARCHAR(100),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_device_tokens_token ON device_tokens(provider, token);
+CREATE INDEX idx_device_tokens_user_id ON device_tokens(user_id);
+
+-- Outgoing pushes, one row per device, retried until sent or failed
+CREATE TABLE push_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    device_token_id UUID NOT NULL REFERENCES device_tokens(id) ON DELETE CASCADE,
+    category VARCHAR(30) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    data JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    claimed_at TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_push_deliveries_due ON push_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_push_deliveries_created_at ON push_deliveries(created_at);
@@ -0,0 +1,20 @@
+-- Engagement nudges ("Rex misses you!"). dedupe_key identifies the event a
+-- nudge is about, so each event nudges a user once even with several API
+-- instances running the job. activity_id records the activity the nudge
+-- led to, for measuring which nudges work.
+CREATE TABLE nudges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,
+    dedupe_key VARCHAR(100) NOT NULL,
+    mood VARCHAR(20),
+    streak_days INTEGER,
+    sent_at TIMESTAMPTZ NOT NULL,
+    activity_id UUID REFERENCES activities(id) ON DELETE SET NULL,
+    converted_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_nudges_dedupe ON nudges(user_id, dedupe_key);
+CREATE INDEX idx_nudges_user_sent_at ON nudges(user_id, sent_at);
+CREATE INDEX idx_nudges_unconverted ON nudges(sent_at) WHERE converted_at IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_pets_deleted_at;
+ALTER TABLE pets DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE pets DROP COLUMN IF EXISTS passed_away_on;
+ALTER TABLE pets DROP COLUMN IF EXISTS status_since;
+ALTER TABLE pets DROP COLUMN IF EXISTS status;
@@ -0,0 +1,8 @@
+-- Pets can be archived or turned into a memorial, and deleting a pet only
+-- marks it; a background job removes it for good after the restore window.
+ALTER TABLE pets ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE pets ADD COLUMN status_since TIMESTAMPTZ;
+ALTER TABLE pets ADD COLUMN passed_away_on DATE;
+ALTER TABLE pets ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_pets_deleted_at ON pets(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE pets DROP COLUMN IF EXISTS breed_id;
+DROP TABLE IF EXISTS breeds;
@@ -0,0 +1,59 @@
+-- Breed catalog per pet type. Exercise minutes are the daily recommendation
+-- for a healthy adult; the API adjusts them for age and weight.
+CREATE TABLE breeds (
+    id VARCHAR(50) PRIMARY KEY,
+    pet_type_id VARCHAR(50) NOT NULL REFERENCES pet_types(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    size_class VARCHAR(20) NOT NULL,
+    energy_level VARCHAR(20) NOT NULL,
+    daily_exercise_minutes INTEGER NOT NULL,
+    adult_weight_min_kg NUMERIC(6, 2),
+    adult_weight_max_kg NUMERIC(6, 2),
+    senior_age_years INTEGER,
+    UNIQUE (pet_type_id, name)
+);
+
+-- Autocomplete matches name prefixes case-insensitively
+CREATE INDEX idx_breeds_search ON breeds(pet_type_id, lower(name) text_pattern_ops);
+
+INSERT INTO breeds (id, pet_type_id, name, size_class, energy_level, daily_exercise_minutes, adult_weight_min_kg, adult_weight_max_kg, senior_age_years) VALUES
+    ('dog-mixed', 'dog', 'Mixed Breed', 'medium', 'moderate', 60, NULL, NULL, NULL),
+    ('labrador-retriever', 'dog', 'Labrador Retriever', 'large', 'high', 90, 25, 36, 8),
+    ('golden-retriever', 'dog', 'Golden Retriever', 'large', 'high', 90, 25, 34, 8),
+    ('german-shepherd', 'dog', 'German Shepherd', 'large', 'high', 90, 22, 40, 7),
+    ('border-collie', 'dog', 'Border Collie', 'medium', 'very_high', 120, 14, 20, 9),
+    ('australian-shepherd', 'dog', 'Australian Shepherd', 'medium', 'very_high', 120, 16, 32, 9),
+    ('siberian-husky', 'dog', 'Siberian Husky', 'medium', 'very_high', 120, 16, 27, 9),
+    ('jack-russell-terrier', 'dog', 'Jack Russell Terrier', 'small', 'very_high', 90, 6, 8, 10),
+    ('boxer', 'dog', 'Boxer', 'large', 'high', 90, 25, 32, 7),
+    ('beagle', 'dog', 'Beagle', 'small', 'high', 60, 9, 11, 10),
+    ('standard-poodle', 'dog', 'Standard Poodle', 'large', 'high', 60, 18, 32, 9),
+    ('miniature-poodle', 'dog', 'Miniature Poodle', 'small', 'moderate', 45, 5, 8, 10),
+    ('rottweiler', 'dog', 'Rottweiler', 'large', 'high', 60, 35, 60, 7),
+    ('dachshund', 'dog', 'Dachshund', 'small', 'moderate', 45, 7, 15, 10),
+    ('cavalier-king-charles-spaniel', 'dog', 'Cavalier King Charles Spaniel', 'small', 'moderate', 45, 5.5, 8, 9),
+    ('great-dane', 'dog', 'Great Dane', 'giant', 'moderate', 60, 45, 80, 6),
+    ('bernese-mountain-dog', 'dog', 'Bernese Mountain Dog', 'giant', 'moderate', 60, 35, 55, 6),
+    ('bulldog', 'dog', 'Bulldog', 'medium', 'low', 30, 18, 25, 7),
+    ('french-bulldog', 'dog', 'French Bulldog', 'small', 'low', 30, 8, 13, 9),
+    ('pug', 'dog', 'Pug', 'small', 'low', 30, 6, 8, 9),
+    ('shih-tzu', 'dog', 'Shih Tzu', 'toy', 'low', 30, 4, 7, 11),
+    ('chihuahua', 'dog', 'Chihuahua', 'toy', 'moderate', 30, 1.5, 3, 11),
+    ('yorkshire-terrier', 'dog', 'Yorkshire Terrier', 'toy', 'moderate', 30, 2, 3.5, 11),
+    ('cat-mixed', 'cat', 'Mixed Breed', 'medium', 'moderate', 20, NULL, NULL, NULL),
+    ('domestic-shorthair', 'cat', 'Domestic Shorthair', 'medium', 'moderate', 20, 3.5, 5.5, 11),
+    ('maine-coon', 'cat', 'Maine Coon', 'large', 'moderate', 25, 5.5, 11, 11),
+    ('siamese', 'cat', 'Siamese', 'medium', 'high', 30, 3, 5, 11),
+    ('bengal', 'cat', 'Bengal', 'medium', 'very_high', 40, 3.5, 7, 11),
+    ('abyssinian', 'cat', 'Abyssinian', 'medium', 'very_high', 35, 3, 5, 11),
+    ('sphynx', 'cat', 'Sphynx', 'medium', 'high', 25, 3, 5, 11),
+    ('ragdoll', 'cat', 'Ragdoll', 'large', 'low', 15, 4.5, 9, 11),
+    ('british-shorthair', 'cat', 'British Shorthair', 'medium', 'low', 15, 4, 8, 11),
+    ('persian', 'cat', 'Persian', 'medium', 'low', 15, 3, 5.5, 11);
+
+-- Pets keep their free-text breed for display; breed_id links the catalog
+ALTER TABLE pets ADD COLUMN breed_id VARCHAR(50) REFERENCES breeds(id) ON DELETE SET NULL;
+
+UPDATE pets p SET breed_id = b.id
+FROM breeds b
+WHERE b.pet_type_id = p.pet_type_id AND lower(b.name) = lower(trim(p.breed));
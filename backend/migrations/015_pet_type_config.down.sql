@@ -0,0 +1,3 @@
+ALTER TABLE pet_types ALTER COLUMN config DROP NOT NULL;
+UPDATE pet_types SET config = '{"default_activities": ["walk", "fetch"]}' WHERE id = 'dog';
+UPDATE pet_types SET config = '{"default_activities": ["walk", "play"]}' WHERE id = 'cat';
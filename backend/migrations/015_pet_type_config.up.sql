@@ -0,0 +1,56 @@
+-- Pet types are configured entirely in data: mood decay, XP multipliers,
+-- level titles and aging. Keys left out fall back to the API's defaults.
+UPDATE pet_types SET config = '{
+    "default_activities": ["walk", "fetch"],
+    "mood_decay": [
+        {"after_hours": 0, "mood": "happy"},
+        {"after_hours": 6, "mood": "content"},
+        {"after_hours": 12, "mood": "tired"},
+        {"after_hours": 24, "mood": "sad"},
+        {"after_hours": 48, "mood": "bored"}
+    ],
+    "level_titles": [
+        {"level": 1, "title": "Pup"},
+        {"level": 5, "title": "Good Dog"},
+        {"level": 10, "title": "Top Dog"},
+        {"level": 20, "title": "Pack Leader"},
+        {"level": 35, "title": "Legend"}
+    ],
+    "young_stage": "puppy",
+    "adult_at_months": 12,
+    "senior_at_years": 9,
+    "size_aging": {
+        "toy": {"senior_at_years": 11},
+        "small": {"senior_at_years": 10},
+        "large": {"adult_at_months": 18, "senior_at_years": 7},
+        "giant": {"adult_at_months": 24, "senior_at_years": 6}
+    },
+    "daily_exercise_minutes": 60
+}' WHERE id = 'dog';
+
+-- Cats are fine on their own for much longer than dogs
+UPDATE pet_types SET config = '{
+    "default_activities": ["walk", "play"],
+    "mood_decay": [
+        {"after_hours": 0, "mood": "happy"},
+        {"after_hours": 12, "mood": "content"},
+        {"after_hours": 36, "mood": "tired"},
+        {"after_hours": 72, "mood": "sad"},
+        {"after_hours": 120, "mood": "bored"}
+    ],
+    "xp_multipliers": {"walk": 1.5},
+    "level_titles": [
+        {"level": 1, "title": "Furball"},
+        {"level": 5, "title": "House Cat"},
+        {"level": 10, "title": "Top Cat"},
+        {"level": 20, "title": "Alley Boss"},
+        {"level": 35, "title": "Legend"}
+    ],
+    "young_stage": "kitten",
+    "adult_at_months": 12,
+    "senior_at_years": 11,
+    "daily_exercise_minutes": 20
+}' WHERE id = 'cat';
+
+UPDATE pet_types SET config = '{}' WHERE config IS NULL;
+ALTER TABLE pet_types ALTER COLUMN config SET NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_missions_pet_daily;
+ALTER TABLE missions DROP COLUMN IF EXISTS game_type_id;
+ALTER TABLE missions DROP COLUMN IF EXISTS pet_id;
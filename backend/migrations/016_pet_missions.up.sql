@@ -0,0 +1,5 @@
+-- Daily missions are generated per pet from its type's default activities
+ALTER TABLE missions ADD COLUMN pet_id UUID REFERENCES pets(id) ON DELETE CASCADE;
+ALTER TABLE missions ADD COLUMN game_type_id VARCHAR(50) REFERENCES game_types(id);
+
+CREATE UNIQUE INDEX idx_missions_pet_daily ON missions(pet_id, mission_type, expires_at) WHERE pet_id IS NOT NULL;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS feed_comments;
+DROP TABLE IF EXISTS feed_reactions;
+DROP TABLE IF EXISTS feed_events;
+DROP TABLE IF EXISTS user_blocks;
+DROP TABLE IF EXISTS friendships;
+DROP TABLE IF EXISTS friend_requests;
+ALTER TABLE users DROP COLUMN IF EXISTS friend_code;
@@ -0,0 +1,59 @@
+-- Friend codes are generated on first use and can be rotated
+ALTER TABLE users ADD COLUMN friend_code VARCHAR(16) UNIQUE;
+
+CREATE TABLE friend_requests (
+    id UUID PRIMARY KEY,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (from_user_id, to_user_id)
+);
+
+CREATE INDEX idx_friend_requests_to_user ON friend_requests(to_user_id);
+
+-- Each friendship is stored in both directions so lookups stay simple
+CREATE TABLE friendships (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    friend_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, friend_id)
+);
+
+CREATE TABLE user_blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE INDEX idx_user_blocks_blocked ON user_blocks(blocked_id);
+
+-- Things worth sharing: finished activities, level-ups and achievements
+CREATE TABLE feed_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    activity_id UUID REFERENCES activities(id) ON DELETE CASCADE,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_feed_events_user_created ON feed_events(user_id, created_at DESC, id DESC);
+
+CREATE TABLE feed_reactions (
+    event_id UUID NOT NULL REFERENCES feed_events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE TABLE feed_comments (
+    id UUID PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES feed_events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_feed_comments_event ON feed_comments(event_id, created_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS challenge_rewards;
+DROP TABLE IF EXISTS challenge_participants;
+DROP TABLE IF EXISTS challenges;
+DROP TABLE IF EXISTS challenge_templates;
+ALTER TABLE activities DROP COLUMN IF EXISTS flag_reason;
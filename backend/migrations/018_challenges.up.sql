@@ -0,0 +1,72 @@
+-- Activities that look implausible keep their XP but don't count towards
+-- competitions
+ALTER TABLE activities ADD COLUMN flag_reason VARCHAR(50);
+
+-- A challenge template scores activities by a metric, aggregated over a
+-- calendar period. The filter narrows which activities count.
+CREATE TABLE challenge_templates (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    metric VARCHAR(30) NOT NULL,
+    aggregation VARCHAR(10) NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    reward_title VARCHAR(100) NOT NULL,
+    reward_cosmetic_id VARCHAR(50),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO challenge_templates (id, name, description, metric, aggregation, period, filter, reward_title, reward_cosmetic_id) VALUES
+    ('walk_minutes', 'Walk Marathon', 'Most walking minutes this week', 'duration_minutes', 'sum', 'week',
+     '{"game_type_id": "walk"}', 'Marathon Walker', 'golden_leash'),
+    ('walk_distance', 'Trailblazers', 'Longest distance walked this week', 'distance_meters', 'sum', 'week',
+     '{"game_type_id": "walk"}', 'Trailblazer', 'explorer_bandana'),
+    ('fetch_throws', 'Fetch Frenzy', 'Most fetch throws this week', 'fetch_throws', 'sum', 'week',
+     '{"game_type_id": "fetch"}', 'Fetch Champion', 'fetch_crown'),
+    ('early_walks', 'Early Birds', 'Most walks before 9am this week', 'activities', 'count', 'week',
+     '{"game_type_id": "walk", "before_hour": 9, "min_duration_minutes": 5}', 'Early Bird', 'sunrise_collar'),
+    ('longest_walk', 'Long Haul', 'Longest single walk today', 'duration_minutes', 'max', 'day',
+     '{"game_type_id": "walk"}', 'Long Hauler', NULL);
+
+-- A challenge is one run of a template among a group of participants. Its
+-- period follows the creator's timezone.
+CREATE TABLE challenges (
+    id UUID PRIMARY KEY,
+    template_id VARCHAR(50) NOT NULL REFERENCES challenge_templates(id),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    invite_code VARCHAR(16) NOT NULL UNIQUE,
+    timezone VARCHAR(64) NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_challenges_unfinished ON challenges(ends_at) WHERE finished_at IS NULL;
+
+CREATE TABLE challenge_participants (
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    final_rank INTEGER,
+    final_value DOUBLE PRECISION,
+    final_activities INTEGER,
+    PRIMARY KEY (challenge_id, user_id)
+);
+
+CREATE INDEX idx_challenge_participants_user ON challenge_participants(user_id);
+
+-- Winners hold the template's title for a while and keep its cosmetic
+CREATE TABLE challenge_rewards (
+    id UUID PRIMARY KEY,
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    cosmetic_id VARCHAR(50),
+    title_expires_at TIMESTAMPTZ NOT NULL,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (challenge_id, user_id)
+);
+
+CREATE INDEX idx_challenge_rewards_user ON challenge_rewards(user_id, title_expires_at);
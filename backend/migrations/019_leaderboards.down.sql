@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS leaderboard_scores;
+DROP INDEX IF EXISTS idx_users_area;
+ALTER TABLE users DROP COLUMN IF EXISTS area;
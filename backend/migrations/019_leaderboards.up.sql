@@ -0,0 +1,21 @@
+-- The coarse area a user walks in, a geohash cell derived from their walk
+-- routes. Routes themselves are never stored.
+ALTER TABLE users ADD COLUMN area VARCHAR(12);
+CREATE INDEX idx_users_area ON users(area) WHERE area IS NOT NULL;
+
+-- Running leaderboard scores, one row per pet, metric and calendar period.
+-- Scores are added to as activities finish, so reading a leaderboard never
+-- scans activities. period_start is the first local day of the period in
+-- the owner's timezone; all-time scores start at the Unix epoch.
+CREATE TABLE leaderboard_scores (
+    metric VARCHAR(30) NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    period_start DATE NOT NULL,
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    value DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (metric, period, period_start, pet_id)
+);
+
+CREATE INDEX idx_leaderboard_scores_rank ON leaderboard_scores(metric, period, period_start, value DESC);
+CREATE INDEX idx_leaderboard_scores_pet ON leaderboard_scores(pet_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS pet_loadouts;
+DROP TABLE IF EXISTS user_items;
+ALTER TABLE achievements DROP COLUMN IF EXISTS reward_item_id;
+ALTER TABLE challenge_templates DROP CONSTRAINT IF EXISTS challenge_templates_reward_cosmetic_id_fkey;
+DROP TABLE IF EXISTS cosmetic_items;
@@ -0,0 +1,80 @@
+-- Cosmetic items dress up a pet's virtual self. Each item fits one slot;
+-- the app draws it from the item id. Items with an unlock level are
+-- granted to the owner when a pet reaches it.
+CREATE TABLE cosmetic_items (
+    id VARCHAR(50) PRIMARY KEY,
+    slot VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    unlock_level INTEGER,
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO cosmetic_items (id, slot, name, description, unlock_level, sort_order) VALUES
+    ('red_collar', 'collar', 'Red Collar', 'A classic for every good pet', 2, 10),
+    ('party_hat', 'hat', 'Party Hat', 'Reached level 3', 3, 20),
+    ('park_background', 'background', 'Park', 'Reached level 4', 4, 30),
+    ('bronze_frame', 'badge_frame', 'Bronze Frame', 'Reached level 5', 5, 40),
+    ('beach_background', 'background', 'Beach', 'Reached level 7', 7, 50),
+    ('silver_frame', 'badge_frame', 'Silver Frame', 'Reached level 10', 10, 60),
+    ('wizard_hat', 'hat', 'Wizard Hat', 'Reached level 15', 15, 70),
+    ('gold_frame', 'badge_frame', 'Gold Frame', 'Reached level 20', 20, 80),
+    ('golden_leash', 'collar', 'Golden Leash', 'Won a Walk Marathon', NULL, 100),
+    ('explorer_bandana', 'collar', 'Explorer Bandana', 'Won a Trailblazers challenge', NULL, 110),
+    ('fetch_crown', 'hat', 'Fetch Crown', 'Won a Fetch Frenzy', NULL, 120),
+    ('sunrise_collar', 'collar', 'Sunrise Collar', 'Won an Early Birds challenge', NULL, 130),
+    ('paw_print_frame', 'badge_frame', 'Paw Print Frame', 'Completed a first walk', NULL, 200),
+    ('flame_frame', 'badge_frame', 'Flame Frame', 'Kept a 7-day streak', NULL, 210),
+    ('trophy_background', 'background', 'Trophy Room', 'Kept a 30-day streak', NULL, 220),
+    ('map_background', 'background', 'Old Map', 'Walked 10km in total', NULL, 230);
+
+ALTER TABLE challenge_templates
+    ADD CONSTRAINT challenge_templates_reward_cosmetic_id_fkey
+    FOREIGN KEY (reward_cosmetic_id) REFERENCES cosmetic_items(id);
+
+ALTER TABLE achievements ADD COLUMN reward_item_id VARCHAR(50) REFERENCES cosmetic_items(id);
+UPDATE achievements SET reward_item_id = 'paw_print_frame' WHERE id = 'first_walk';
+UPDATE achievements SET reward_item_id = 'flame_frame' WHERE id = 'streak_7';
+UPDATE achievements SET reward_item_id = 'trophy_background' WHERE id = 'streak_30';
+UPDATE achievements SET reward_item_id = 'map_background' WHERE id = 'distance_10k';
+
+-- The items a user owns, and what earned them
+CREATE TABLE user_items (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id VARCHAR(50) NOT NULL REFERENCES cosmetic_items(id),
+    source VARCHAR(20) NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, item_id)
+);
+
+-- What each pet wears, one item per slot
+CREATE TABLE pet_loadouts (
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    slot VARCHAR(20) NOT NULL,
+    item_id VARCHAR(50) NOT NULL REFERENCES cosmetic_items(id),
+    equipped_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pet_id, slot)
+);
+
+-- Grant what was already earned
+INSERT INTO user_items (user_id, item_id, source, acquired_at)
+SELECT user_id, cosmetic_id, 'challenge', MIN(granted_at)
+FROM challenge_rewards
+WHERE cosmetic_id IN (SELECT id FROM cosmetic_items)
+GROUP BY user_id, cosmetic_id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_items (user_id, item_id, source, acquired_at)
+SELECT ua.user_id, a.reward_item_id, 'achievement', MIN(ua.unlocked_at)
+FROM user_achievements ua
+JOIN achievements a ON a.id = ua.achievement_id
+WHERE a.reward_item_id IS NOT NULL
+GROUP BY ua.user_id, a.reward_item_id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_items (user_id, item_id, source)
+SELECT DISTINCT p.user_id, ci.id, 'level'
+FROM pets p
+JOIN cosmetic_items ci ON ci.unlock_level <= p.level
+WHERE p.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,37 @@
+-- The skill tree. Pets earn a skill point per level and spend them on
+-- skills whose level requirement and prerequisites they meet; unlocked
+-- skills change the XP of matching activities as described by effect.
+CREATE TABLE skills (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    required_level INTEGER NOT NULL,
+    cost INTEGER NOT NULL DEFAULT 1,
+    prerequisites VARCHAR(50)[] NOT NULL DEFAULT '{}',
+    effect JSONB NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO skills (id, name, description, required_level, cost, prerequisites, effect, sort_order) VALUES
+    ('long_walker', 'Long Walker', '+10% XP on walks of 30 minutes or more', 2, 1, '{}',
+     '{"game_type_id": "walk", "min_minutes": 30, "xp_multiplier": 1.1}', 10),
+    ('sniffer', 'Sniffer', '5 bonus XP for every stop to sniff around, up to 10 stops per walk', 3, 1, '{}',
+     '{"game_type_id": "walk", "xp_per_stop": 5, "max_stops": 10}', 20),
+    ('quick_paws', 'Quick Paws', '+10% XP on fetch', 2, 1, '{}',
+     '{"game_type_id": "fetch", "xp_multiplier": 1.1}', 30),
+    ('trailblazer', 'Trailblazer', '+15% XP on walks of an hour or more', 5, 2, '{long_walker}',
+     '{"game_type_id": "walk", "min_minutes": 60, "xp_multiplier": 1.15}', 40),
+    ('master_sniffer', 'Master Sniffer', '10 more bonus XP for every stop, up to 20 stops per walk', 6, 2, '{sniffer}',
+     '{"game_type_id": "walk", "xp_per_stop": 10, "max_stops": 20}', 50),
+    ('fetch_master', 'Fetch Master', '+20% XP on fetch sessions of 10 minutes or more', 6, 2, '{quick_paws}',
+     '{"game_type_id": "fetch", "min_minutes": 10, "xp_multiplier": 1.2}', 60),
+    ('pack_leader', 'Pack Leader', '+5% XP on every activity', 10, 3, '{trailblazer,master_sniffer}',
+     '{"xp_multiplier": 1.05}', 70);
+
+CREATE TABLE pet_skills (
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    skill_id VARCHAR(50) NOT NULL REFERENCES skills(id),
+    unlocked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    unlocked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pet_id, skill_id)
+);
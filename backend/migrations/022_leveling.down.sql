@@ -0,0 +1,2 @@
+-- Backfilled levels are kept: the API no longer caps them
+DROP TABLE IF EXISTS leveling_config;
@@ -0,0 +1,39 @@
+-- How pets level up is configured in data: the XP curve, the tiers levels
+-- are grouped into and bonus skill points for reaching a level. Keys left
+-- out fall back to the API's defaults. There is only ever one row.
+CREATE TABLE leveling_config (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    config JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO leveling_config (config) VALUES ('{
+    "base_xp": 100,
+    "exponent": 2,
+    "tiers": [
+        {"id": "puppy", "name": "Puppy", "from_level": 1},
+        {"id": "adventurer", "name": "Adventurer", "from_level": 5},
+        {"id": "explorer", "name": "Explorer", "from_level": 10},
+        {"id": "legend", "name": "Legend", "from_level": 20}
+    ],
+    "rewards": [
+        {"level": 5, "skill_points": 1},
+        {"level": 10, "skill_points": 1},
+        {"level": 20, "skill_points": 2}
+    ]
+}');
+
+-- Levels were capped at 10 whatever the XP. Put pets back on the curve
+-- above, level = floor(sqrt(xp / 100)) + 1, and grant the items of the
+-- levels they missed.
+UPDATE pets
+SET level = floor(sqrt(COALESCE(total_xp, 0) / 100.0))::int + 1,
+    updated_at = NOW()
+WHERE level < floor(sqrt(COALESCE(total_xp, 0) / 100.0))::int + 1;
+
+INSERT INTO user_items (user_id, item_id, source)
+SELECT DISTINCT p.user_id, ci.id, 'level'
+FROM pets p
+JOIN cosmetic_items ci ON ci.unlock_level <= p.level
+WHERE p.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
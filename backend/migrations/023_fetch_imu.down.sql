@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS activity_imu_batches;
+ALTER TABLE game_types DROP COLUMN IF EXISTS throw_detection;
@@ -0,0 +1,24 @@
+-- Game types that detect throws from the phone's accelerometer, and how.
+-- Keys left out fall back to the API's defaults.
+ALTER TABLE game_types ADD COLUMN throw_detection JSONB;
+
+UPDATE game_types SET throw_detection = '{
+    "peak_threshold": 25,
+    "reset_threshold": 15,
+    "max_peak_ms": 400,
+    "min_interval_ms": 1000,
+    "combo_gap_seconds": 30
+}' WHERE id = 'fetch';
+
+-- Accelerometer samples uploaded during an activity, as sent. Throws are
+-- detected again over all of an activity's batches as each one arrives.
+CREATE TABLE activity_imu_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    activity_id UUID NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    samples JSONB NOT NULL,
+    sample_count INTEGER NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_activity_imu_batches_activity ON activity_imu_batches(activity_id);
+CREATE INDEX idx_activity_imu_batches_received ON activity_imu_batches(received_at);
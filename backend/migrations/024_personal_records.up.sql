@@ -0,0 +1,14 @@
+-- Each pet's personal records, one row per game type and record, e.g. the
+-- most throws in one fetch session. Raised as activities finish; value is
+-- in the record's own unit.
+CREATE TABLE pet_records (
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    game_type_id VARCHAR(50) NOT NULL REFERENCES game_types(id),
+    record VARCHAR(30) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    activity_id UUID REFERENCES activities(id) ON DELETE SET NULL,
+    set_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pet_id, game_type_id, record)
+);
+
+CREATE INDEX idx_pet_records_activity ON pet_records(activity_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pet_record_history;
+DELETE FROM pet_records WHERE record NOT IN ('most_throws', 'longest_combo');
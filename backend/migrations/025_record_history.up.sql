@@ -0,0 +1,39 @@
+-- Every time a pet set or broke a personal record; previous_value is NULL
+-- the first time. Records stay when the activity is deleted.
+CREATE TABLE pet_record_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    game_type_id VARCHAR(50) NOT NULL REFERENCES game_types(id),
+    record VARCHAR(30) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    previous_value DOUBLE PRECISION,
+    activity_id UUID REFERENCES activities(id) ON DELETE SET NULL,
+    set_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_pet_record_history_pet ON pet_record_history(pet_id, set_at DESC);
+CREATE INDEX idx_pet_record_history_activity ON pet_record_history(activity_id);
+
+-- Longest activities and walks from before records were kept. Fastest
+-- kilometres and most active days need the route and the owner's timezone,
+-- so they start with the next activity.
+INSERT INTO pet_records (pet_id, game_type_id, record, value, activity_id, set_at)
+SELECT DISTINCT ON (pet_id, game_type_id)
+       pet_id, game_type_id, 'longest_duration', duration_seconds, id, ended_at
+FROM activities
+WHERE ended_at IS NOT NULL AND flag_reason IS NULL AND duration_seconds > 0
+ORDER BY pet_id, game_type_id, duration_seconds DESC, ended_at
+ON CONFLICT DO NOTHING;
+
+INSERT INTO pet_records (pet_id, game_type_id, record, value, activity_id, set_at)
+SELECT DISTINCT ON (pet_id)
+       pet_id, game_type_id, 'longest_distance', (game_data->>'distance_meters')::float, id, ended_at
+FROM activities
+WHERE game_type_id = 'walk' AND ended_at IS NOT NULL AND flag_reason IS NULL
+  AND (game_data->>'distance_meters')::float > 0
+ORDER BY pet_id, (game_data->>'distance_meters')::float DESC, ended_at
+ON CONFLICT DO NOTHING;
+
+-- Records so far only kept their latest value, where their history starts
+INSERT INTO pet_record_history (pet_id, game_type_id, record, value, activity_id, set_at)
+SELECT pet_id, game_type_id, record, value, activity_id, set_at FROM pet_records;
@@ -0,0 +1,8 @@
+DELETE FROM user_achievements WHERE achievement_id = 'first_play';
+DELETE FROM achievements WHERE id = 'first_play';
+DELETE FROM missions WHERE game_type_id = 'play';
+DELETE FROM pet_record_history WHERE game_type_id = 'play';
+DELETE FROM pet_records WHERE game_type_id = 'play';
+UPDATE reminders SET game_type_id = NULL WHERE game_type_id = 'play';
+DELETE FROM activities WHERE game_type_id = 'play';
+DELETE FROM game_types WHERE id = 'play';
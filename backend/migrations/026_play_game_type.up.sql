@@ -0,0 +1,11 @@
+-- Indoor play sessions, for cats. Cats already list play among their
+-- default activities, so their daily missions pick it up.
+INSERT INTO game_types (id, name, description, icon, xp_config, supported_pet_types) VALUES
+    ('play', 'Play', 'Play with a toy and count the pounces', 'yarn',
+     '{"base_xp_per_minute": 2, "xp_per_round": 2, "xp_per_pounce": 1,
+       "intensity_multipliers": {"low": 1, "medium": 1.25, "high": 1.5}}',
+     ARRAY['cat']);
+
+INSERT INTO achievements (id, name, description, icon, category, criteria, xp_reward) VALUES
+    ('first_play', 'Pounce!', 'Complete your first play session', 'yarn', 'milestone',
+     '{"type": "activity_count", "game_type": "play", "count": 1}', 50);
@@ -0,0 +1,2 @@
+UPDATE data_exports SET status = 'pending' WHERE status = 'processing';
+ALTER TABLE data_exports DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1,4 @@
+-- The export job claims pending exports by moving them to 'processing', so
+-- several API instances don't build the same archive. Claims older than the
+-- job's TTL were left by a worker that died and are taken over.
+ALTER TABLE data_exports ADD COLUMN claimed_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- The state of each running fetch activity's throw detector, so uploads
+-- only scan their own batch. Activities running when this is applied
+-- rebuild it from their stored batches with their next upload.
+ALTER TABLE activities ADD COLUMN throw_detector JSONB;
@@ -0,0 +1,2 @@
+DELETE FROM challenge_participants WHERE accepted_at IS NULL;
+ALTER TABLE challenge_participants DROP COLUMN IF EXISTS accepted_at;
@@ -0,0 +1,4 @@
+-- Friends added to a challenge are invited and only take part, and show up
+-- in its standings, once they accept. Everyone already in a challenge has.
+ALTER TABLE challenge_participants ADD COLUMN accepted_at TIMESTAMPTZ;
+UPDATE challenge_participants SET accepted_at = joined_at;
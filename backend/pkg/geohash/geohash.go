@@ -0,0 +1,52 @@
+// Package geohash encodes coordinates as geohashes: base32 strings naming a
+// cell of the map, where a longer hash is a smaller cell and cells sharing a
+// prefix are nested. PetTime uses short hashes as coarse areas, so nearby
+// walkers can be grouped without storing where they walked.
+package geohash
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxPrecision is the longest hash Encode produces, a cell of a few
+// centimetres.
+const MaxPrecision = 12
+
+// Encode returns the geohash of the cell of the given length containing the
+// coordinates. Out-of-range coordinates are clamped to the edge of the map.
+func Encode(latitude, longitude float64, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	hash := make([]byte, 0, precision)
+
+	// Bits alternate between longitude and latitude, starting with
+	// longitude, each halving the cell
+	even := true
+	bits, char := 0, 0
+	for len(hash) < precision {
+		value, interval := latitude, &latRange
+		if even {
+			value, interval = longitude, &lonRange
+		}
+		mid := (interval[0] + interval[1]) / 2
+		char <<= 1
+		if value >= mid {
+			char |= 1
+			interval[0] = mid
+		} else {
+			interval[1] = mid
+		}
+		even = !even
+
+		if bits++; bits == 5 {
+			hash = append(hash, base32[char])
+			bits, char = 0, 0
+		}
+	}
+	return string(hash)
+}
@@ -0,0 +1,34 @@
+package geohash
+
+import "testing"
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		precision int
+		want      string
+	}{
+		{"Lisbon", 38.7223, -9.1393, 5, "eycs2"},
+		{"São Paulo", -23.5505, -46.6333, 5, "6gyf4"},
+		{"Jutland reference point", 57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{"precision below one", 38.7223, -9.1393, 0, "e"},
+		{"origin", 0, 0, 4, "s000"},
+		{"out of range", 100, 200, 2, "zz"},
+	}
+	for _, tt := range tests {
+		if got := Encode(tt.latitude, tt.longitude, tt.precision); got != tt.want {
+			t.Errorf("%s: Encode() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncode_NestedCells(t *testing.T) {
+	long := Encode(38.7223, -9.1393, MaxPrecision)
+	for precision := 1; precision < MaxPrecision; precision++ {
+		if got := Encode(38.7223, -9.1393, precision); got != long[:precision] {
+			t.Errorf("Encode() at precision %d = %q, want prefix %q", precision, got, long[:precision])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newIDTokenServer(t *testing.T, keys ...*Key) (*httptest.Server, *int) {
+	t.Helper()
+	fetches := 0
+	jwks := JWKS{}
+	for _, key := range keys {
+		jwk, _ := key.publicJWK()
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func signIDToken(t *testing.T, key *Key, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func idClaims(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":            "https://issuer.example",
+		"aud":            "com.pettime.app",
+		"sub":            "provider-user-1",
+		"email":          "mia@example.com",
+		"email_verified": true,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestIDTokenVerifier_Verify(t *testing.T) {
+	key := newRSAKey(t, "google-1")
+	server, _ := newIDTokenServer(t, key)
+	verifier := NewIDTokenVerifier(server.URL, []string{"https://issuer.example"}, []string{"com.pettime.app"})
+
+	identity, err := verifier.Verify(context.Background(), signIDToken(t, key, idClaims(nil)))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if identity.Subject != "provider-user-1" || identity.Email != "mia@example.com" {
+		t.Errorf("Verify() = %+v", identity)
+	}
+
+	// Apple sends email_verified as a string
+	identity, err = verifier.Verify(context.Background(), signIDToken(t, key, idClaims(jwt.MapClaims{"email_verified": "true"})))
+	if err != nil || identity.Email != "mia@example.com" {
+		t.Errorf("Verify() with a string email_verified = %+v, %v", identity, err)
+	}
+
+	identity, err = verifier.Verify(context.Background(), signIDToken(t, key, idClaims(jwt.MapClaims{"email_verified": false})))
+	if err != nil || identity.Email != "" {
+		t.Errorf("Verify() with an unverified email = %+v, %v, want no email", identity, err)
+	}
+}
+
+func TestIDTokenVerifier_Rejects(t *testing.T) {
+	key := newRSAKey(t, "google-1")
+	server, _ := newIDTokenServer(t, key)
+	verifier := NewIDTokenVerifier(server.URL, []string{"https://issuer.example"}, []string{"com.pettime.app"})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other audience", signIDToken(t, key, idClaims(jwt.MapClaims{"aud": "com.someone.else"}))},
+		{"other issuer", signIDToken(t, key, idClaims(jwt.MapClaims{"iss": "https://evil.example"}))},
+		{"expired", signIDToken(t, key, idClaims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}))},
+		{"no expiry", signIDToken(t, key, idClaims(jwt.MapClaims{"exp": nil}))},
+		{"no subject", signIDToken(t, key, idClaims(jwt.MapClaims{"sub": ""}))},
+		{"unpublished key", signIDToken(t, newRSAKey(t, "google-1"), idClaims(nil))},
+		{"garbage", "not-a-token"},
+	}
+	for _, tt := range tests {
+		if _, err := verifier.Verify(context.Background(), tt.token); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: Verify() error = %v, want ErrInvalidIDToken", tt.name, err)
+		}
+	}
+
+	// An HMAC token signed with the public modulus must not pass as RS256
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, idClaims(nil))
+	hmac.Header["kid"] = key.ID
+	signed, _ := hmac.SignedString([]byte("secret"))
+	if _, err := verifier.Verify(context.Background(), signed); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("HS256: Verify() error = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestIDTokenVerifier_CachesKeys(t *testing.T) {
+	key := newRSAKey(t, "google-1")
+	server, fetches := newIDTokenServer(t, key)
+	verifier := NewIDTokenVerifier(server.URL, []string{"https://issuer.example"}, []string{"com.pettime.app"})
+
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(context.Background(), signIDToken(t, key, idClaims(nil))); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	}
+	// Unknown kids don't refetch within a minute of the last fetch
+	verifier.Verify(context.Background(), signIDToken(t, newRSAKey(t, "forged"), idClaims(nil)))
+
+	if *fetches != 1 {
+		t.Errorf("fetched the keys %d times, want once", *fetches)
+	}
+}
@@ -2,6 +2,8 @@ package jwt
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -16,25 +18,55 @@ var (
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Email  string    `json:"email"`
+	// SessionID identifies the login session (the refresh token chain) the
+	// token was issued for.
+	SessionID uuid.UUID `json:"sid"`
+	// AuthTime is when the user last entered credentials. It is carried
+	// over on refresh, unlike IssuedAt.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Manager signs access tokens with the active key and validates them
+// against every key that has not been retired, so keys can be rotated
+// without logging everyone out.
 type Manager struct {
-	secret         []byte
+	keys           map[string]*Key
+	activeKey      *Key
 	accessTokenTTL time.Duration
 }
 
-func NewManager(secret string, accessTokenTTL time.Duration) *Manager {
-	return &Manager{
-		secret:         []byte(secret),
+func NewManager(keys []*Key, activeKeyID string, accessTokenTTL time.Duration) (*Manager, error) {
+	m := &Manager{
+		keys:           make(map[string]*Key, len(keys)),
 		accessTokenTTL: accessTokenTTL,
 	}
+
+	for _, key := range keys {
+		if _, exists := m.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		m.keys[key.ID] = key
+	}
+
+	active, ok := m.keys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, activeKeyID)
+	}
+	if active.Retired {
+		return nil, fmt.Errorf("active key %q is retired", activeKeyID)
+	}
+	m.activeKey = active
+
+	return m, nil
 }
 
-func (m *Manager) GenerateAccessToken(userID uuid.UUID, email string) (string, error) {
+func (m *Manager) GenerateAccessToken(userID, sessionID uuid.UUID, email string, authTime time.Time) (string, error) {
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
+		AuthTime:  jwt.NewNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.accessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -44,18 +76,13 @@ func (m *Manager) GenerateAccessToken(userID uuid.UUID, email string) (string, e
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	token := jwt.NewWithClaims(m.activeKey.signingMethod(), claims)
+	token.Header["kid"] = m.activeKey.ID
+	return token.SignedString(m.activeKey.signKey)
 }
 
 func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return m.secret, nil
-	})
-
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.keyFunc)
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, ErrExpiredToken
@@ -71,6 +98,40 @@ func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+func (m *Manager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := m.keys[kid]
+	if !ok || key.Retired {
+		return nil, ErrInvalidToken
+	}
+
+	// The algorithm must match the key, otherwise a public RSA key could be
+	// used as an HMAC secret.
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrInvalidToken
+	}
+
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys that other services can use to verify
+// PetTime tokens.
+func (m *Manager) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range m.keys {
+		if key.Retired {
+			continue
+		}
+		if jwk, ok := key.publicJWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+	return jwks
+}
+
 func (m *Manager) GetAccessTokenTTL() time.Duration {
 	return m.accessTokenTTL
 }
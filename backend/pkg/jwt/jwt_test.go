@@ -0,0 +1,150 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newRSAKey(t *testing.T, id string) *Key {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	key, err := NewKeyFromPEM(id, data)
+	if err != nil {
+		t.Fatalf("NewKeyFromPEM() error = %v", err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T, id string) *Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	key, err := NewKeyFromPEM(id, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("NewKeyFromPEM() error = %v", err)
+	}
+	return key
+}
+
+func TestNewKeyFromPEM_Algorithms(t *testing.T) {
+	if key := newRSAKey(t, "rsa"); key.Algorithm != AlgorithmRS256 {
+		t.Errorf("RSA key algorithm = %s, want %s", key.Algorithm, AlgorithmRS256)
+	}
+	if key := newEd25519Key(t, "ed"); key.Algorithm != AlgorithmEdDSA {
+		t.Errorf("Ed25519 key algorithm = %s, want %s", key.Algorithm, AlgorithmEdDSA)
+	}
+}
+
+func TestManager_RoundTrip(t *testing.T) {
+	for _, key := range []*Key{
+		NewHMACKey("hmac", []byte("secret")),
+		newRSAKey(t, "rsa"),
+		newEd25519Key(t, "ed"),
+	} {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			m, err := NewManager([]*Key{key}, key.ID, time.Minute)
+			if err != nil {
+				t.Fatalf("NewManager() error = %v", err)
+			}
+
+			userID, sessionID := uuid.New(), uuid.New()
+			token, err := m.GenerateAccessToken(userID, sessionID, "rex@example.com", time.Now())
+			if err != nil {
+				t.Fatalf("GenerateAccessToken() error = %v", err)
+			}
+
+			claims, err := m.ValidateToken(token)
+			if err != nil {
+				t.Fatalf("ValidateToken() error = %v", err)
+			}
+			if claims.UserID != userID {
+				t.Errorf("UserID = %v, want %v", claims.UserID, userID)
+			}
+			if claims.SessionID != sessionID {
+				t.Errorf("SessionID = %v, want %v", claims.SessionID, sessionID)
+			}
+		})
+	}
+}
+
+func TestManager_Rotation(t *testing.T) {
+	oldKey := newRSAKey(t, "2025-01")
+	newKey := newEd25519Key(t, "2025-06")
+
+	before, err := NewManager([]*Key{oldKey}, oldKey.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	oldToken, err := before.GenerateAccessToken(uuid.New(), uuid.New(), "rex@example.com", time.Now())
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	// New key is active, old key still verifies outstanding tokens.
+	after, err := NewManager([]*Key{oldKey, newKey}, newKey.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if _, err := after.ValidateToken(oldToken); err != nil {
+		t.Errorf("token signed by previous key rejected: %v", err)
+	}
+	if got := len(after.JWKS().Keys); got != 2 {
+		t.Errorf("JWKS published %d keys, want 2", got)
+	}
+
+	// Once retired the old key no longer verifies and is not published.
+	retiredOld := *oldKey
+	retiredOld.Retired = true
+	retired, err := NewManager([]*Key{&retiredOld, newKey}, newKey.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if _, err := retired.ValidateToken(oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed by retired key: err = %v, want %v", err, ErrInvalidToken)
+	}
+	jwks := retired.JWKS()
+	if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != newKey.ID {
+		t.Errorf("JWKS = %+v, want only %s", jwks.Keys, newKey.ID)
+	}
+}
+
+func TestManager_RejectsRetiredActiveKey(t *testing.T) {
+	key := NewHMACKey("hmac", []byte("secret"))
+	key.Retired = true
+
+	if _, err := NewManager([]*Key{key}, key.ID, time.Minute); err == nil {
+		t.Error("NewManager() with retired active key should fail")
+	}
+	if _, err := NewManager([]*Key{key}, "missing", time.Minute); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("NewManager() with unknown active key: err = %v, want %v", err, ErrKeyNotFound)
+	}
+}
+
+func TestManager_JWKSExcludesSymmetricKeys(t *testing.T) {
+	key := NewHMACKey("hmac", []byte("secret"))
+	m, err := NewManager([]*Key{key}, key.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if got := len(m.JWKS().Keys); got != 0 {
+		t.Errorf("JWKS published %d keys, want 0", got)
+	}
+}
@@ -0,0 +1,163 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrKeyNotFound    = errors.New("signing key not found")
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Key is a single signing key identified by its kid. Retired keys are kept
+// around so operators can see them in config, but tokens signed with them
+// no longer validate and they are not published in the JWKS.
+type Key struct {
+	ID        string
+	Algorithm string
+	Retired   bool
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{
+		ID:        id,
+		Algorithm: AlgorithmHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// NewKeyFromPEM parses a PEM encoded private key. RSA keys (PKCS#1 or
+// PKCS#8) sign with RS256 and Ed25519 keys (PKCS#8) sign with EdDSA.
+func NewKeyFromPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM block found", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %s: %w: %s", id, ErrUnsupportedKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %s: failed to parse private key: %w", id, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Algorithm: AlgorithmRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Algorithm: AlgorithmEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	default:
+		return nil, fmt.Errorf("key %s: %w", id, ErrUnsupportedKey)
+	}
+}
+
+// LoadKeysFromDir loads every *.pem file in dir as a signing key. The file
+// name without extension becomes the kid.
+func LoadKeysFromDir(dir string) ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	sort.Strings(paths)
+
+	var keys []*Key
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key: %w", err)
+		}
+
+		id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := NewKeyFromPEM(id, data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (k *Key) signingMethod() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// JWK is the public part of a key in RFC 7517 format.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// publicJWK returns the JWK for asymmetric keys. Symmetric keys have no
+// public part and are never published.
+func (k *Key) publicJWK() (JWK, bool) {
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType:   "RSA",
+			KeyID:     k.ID,
+			Use:       "sig",
+			Algorithm: k.Algorithm,
+			N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType:   "OKP",
+			KeyID:     k.ID,
+			Use:       "sig",
+			Algorithm: k.Algorithm,
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(pub),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
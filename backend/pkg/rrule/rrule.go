@@ -0,0 +1,312 @@
+// Package rrule implements the subset of RFC 5545 recurrence rules PetTime
+// reminders use: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT,
+// UNTIL, BYDAY (plain weekdays), BYMONTHDAY, BYHOUR and BYMINUTE.
+//
+// Occurrences are computed on wall-clock time in a given location, so
+// "daily at 8:00" stays at 8:00 across DST changes.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds the search for the next occurrence so rules that can
+// never match (FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=31 starting in February,
+// say) terminate.
+const maxPeriods = 5000
+
+const untilFormat = "20060102T150405Z"
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	ByHour     []int
+	ByMinute   []int
+}
+
+// Parse reads a rule such as "FREQ=DAILY;BYHOUR=8;BYMINUTE=0". An optional
+// "RRULE:" prefix is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+		case "INTERVAL":
+			rule.Interval, err = parseInt(value, 1, 1000)
+		case "COUNT":
+			rule.Count, err = parseInt(value, 1, 10000)
+		case "UNTIL":
+			var until time.Time
+			until, err = time.Parse(untilFormat, value)
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(value), ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY value %q", ErrInvalidRule, day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(value, 1, 31)
+		case "BYHOUR":
+			rule.ByHour, err = parseIntList(value, 0, 23)
+		case "BYMINUTE":
+			rule.ByMinute, err = parseIntList(value, 0, 59)
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRule, name, err)
+		}
+	}
+
+	switch rule.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	default:
+		return nil, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRule, rule.Freq)
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != Monthly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY requires FREQ=MONTHLY", ErrInvalidRule)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+
+	return rule, nil
+}
+
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilFormat))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, weekday := range r.ByDay {
+			for name, d := range weekdays {
+				if d == weekday {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByHour) > 0 {
+		parts = append(parts, "BYHOUR="+joinInts(r.ByHour))
+	}
+	if len(r.ByMinute) > 0 {
+		parts = append(parts, "BYMINUTE="+joinInts(r.ByMinute))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after after, for a series that
+// starts at dtstart, evaluated on wall-clock time in loc. ok is false when
+// the series has ended.
+func (r *Rule) Next(dtstart, after time.Time, loc *time.Location) (time.Time, bool) {
+	dtstart = dtstart.In(loc)
+	seen := 0
+
+	for period := 0; period < maxPeriods; period++ {
+		for _, occurrence := range r.expand(dtstart, period, loc) {
+			if occurrence.Before(dtstart) {
+				continue
+			}
+			if r.Until != nil && occurrence.After(*r.Until) {
+				return time.Time{}, false
+			}
+			seen++
+			if r.Count > 0 && seen > r.Count {
+				return time.Time{}, false
+			}
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Occurrences returns up to n occurrences after after.
+func (r *Rule) Occurrences(dtstart, after time.Time, loc *time.Location, n int) []time.Time {
+	var result []time.Time
+	for len(result) < n {
+		next, ok := r.Next(dtstart, after, loc)
+		if !ok {
+			break
+		}
+		result = append(result, next)
+		after = next
+	}
+	return result
+}
+
+// expand lists the occurrences in the period-th period of the series,
+// sorted.
+func (r *Rule) expand(dtstart time.Time, period int, loc *time.Location) []time.Time {
+	step := period * r.Interval
+	y, m, d := dtstart.Date()
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+		if r.matchesDay(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		// Weeks start on Monday (WKST=MO)
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		monday := time.Date(y, m, d-offset+7*step, 0, 0, 0, 0, loc)
+		byDay := r.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{dtstart.Weekday()}
+		}
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			for _, weekday := range byDay {
+				if day.Weekday() == weekday {
+					days = append(days, day)
+				}
+			}
+		}
+	case Monthly:
+		first := time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+		monthDays := r.ByMonthDay
+		if len(monthDays) == 0 {
+			monthDays = []int{d}
+		}
+		for _, md := range monthDays {
+			day := time.Date(first.Year(), first.Month(), md, 0, 0, 0, 0, loc)
+			// Days that don't exist in this month are skipped, not rolled over
+			if day.Month() == first.Month() && r.matchesDay(day) {
+				days = append(days, day)
+			}
+		}
+	case Yearly:
+		day := time.Date(y+step, m, d, 0, 0, 0, 0, loc)
+		if day.Month() == m && r.matchesDay(day) {
+			days = append(days, day)
+		}
+	}
+
+	hours := r.ByHour
+	if len(hours) == 0 {
+		hours = []int{dtstart.Hour()}
+	}
+	minutes := r.ByMinute
+	if len(minutes) == 0 {
+		minutes = []int{dtstart.Minute()}
+	}
+
+	var occurrences []time.Time
+	for _, day := range days {
+		for _, h := range hours {
+			for _, min := range minutes {
+				occurrences = append(occurrences, time.Date(day.Year(), day.Month(), day.Day(), h, min, 0, 0, loc))
+			}
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+	return occurrences
+}
+
+// matchesDay applies BYDAY as a filter for non-weekly frequencies.
+func (r *Rule) matchesDay(day time.Time) bool {
+	if len(r.ByDay) == 0 || r.Freq == Weekly {
+		return true
+	}
+	for _, weekday := range r.ByDay {
+		if day.Weekday() == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func parseInt(s string, min, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("%d out of range %d-%d", n, min, max)
+	}
+	return n, nil
+}
+
+func parseIntList(s string, min, max int) ([]int, error) {
+	var values []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := parseInt(part, min, max)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, n)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
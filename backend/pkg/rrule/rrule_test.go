@@ -0,0 +1,185 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, s string) *Rule {
+	t.Helper()
+	rule, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", s, err)
+	}
+	return rule
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q) error = %v", name, err)
+	}
+	return loc
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;COUNT=3;UNTIL=20250101T000000Z",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=YEARLY;BYMONTHDAY=31",
+		"FREQ",
+	} {
+		if _, err := Parse(s); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidRule", s, err)
+		}
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"FREQ=DAILY;BYHOUR=8;BYMINUTE=0",
+		"FREQ=MONTHLY;INTERVAL=3",
+		"FREQ=WEEKLY;BYDAY=MO,WE,FR;BYHOUR=7,19;BYMINUTE=30",
+		"FREQ=YEARLY;UNTIL=20300101T000000Z",
+		"FREQ=MONTHLY;COUNT=6;BYMONTHDAY=1,15",
+	} {
+		rule := mustParse(t, s)
+		if got := rule.String(); got != s {
+			t.Errorf("Parse(%q).String() = %q", s, got)
+		}
+	}
+
+	if got := mustParse(t, "RRULE:freq=daily").String(); got != "FREQ=DAILY" {
+		t.Errorf("prefixed rule String() = %q, want FREQ=DAILY", got)
+	}
+}
+
+func TestNext_DailyAtEight(t *testing.T) {
+	loc := mustLoad(t, "Europe/Lisbon")
+	rule := mustParse(t, "FREQ=DAILY;BYHOUR=8;BYMINUTE=0")
+	dtstart := time.Date(2025, 3, 1, 12, 0, 0, 0, loc)
+
+	next, ok := rule.Next(dtstart, dtstart, loc)
+	if !ok {
+		t.Fatal("Next() ended unexpectedly")
+	}
+	if want := time.Date(2025, 3, 2, 8, 0, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("Next() = %v, want %v", next, want)
+	}
+
+	// Stays at 8:00 local across the spring DST change on March 30
+	next, _ = rule.Next(dtstart, time.Date(2025, 3, 30, 9, 0, 0, 0, loc), loc)
+	if want := time.Date(2025, 3, 31, 8, 0, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("Next() after DST = %v, want %v", next, want)
+	}
+	if next.UTC().Hour() != 7 {
+		t.Errorf("Next() after DST in UTC = %v, want 07:00", next.UTC())
+	}
+}
+
+func TestNext_EveryThreeMonths(t *testing.T) {
+	rule := mustParse(t, "FREQ=MONTHLY;INTERVAL=3")
+	dtstart := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	got := rule.Occurrences(dtstart, dtstart.Add(-time.Second), time.UTC, 4)
+	want := []time.Time{
+		time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 7, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 10, 15, 10, 0, 0, 0, time.UTC),
+	}
+	assertTimes(t, got, want)
+}
+
+func TestNext_MonthlySkipsMissingDays(t *testing.T) {
+	rule := mustParse(t, "FREQ=MONTHLY")
+	dtstart := time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	got := rule.Occurrences(dtstart, dtstart, time.UTC, 3)
+	want := []time.Time{
+		time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 7, 31, 9, 0, 0, 0, time.UTC),
+	}
+	assertTimes(t, got, want)
+}
+
+func TestNext_WeeklyByDay(t *testing.T) {
+	rule := mustParse(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,SA;BYHOUR=18;BYMINUTE=0")
+	// Wednesday
+	dtstart := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+
+	got := rule.Occurrences(dtstart, dtstart, time.UTC, 4)
+	want := []time.Time{
+		time.Date(2025, 6, 7, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 17, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 21, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 7, 1, 18, 0, 0, 0, time.UTC),
+	}
+	assertTimes(t, got, want)
+}
+
+func TestNext_DailyMultipleTimes(t *testing.T) {
+	rule := mustParse(t, "FREQ=DAILY;BYHOUR=8,20;BYMINUTE=0")
+	dtstart := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	got := rule.Occurrences(dtstart, time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC), time.UTC, 3)
+	want := []time.Time{
+		time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 2, 20, 0, 0, 0, time.UTC),
+	}
+	assertTimes(t, got, want)
+}
+
+func TestNext_CountAndUntil(t *testing.T) {
+	dtstart := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	counted := mustParse(t, "FREQ=DAILY;COUNT=3")
+	if got := counted.Occurrences(dtstart, dtstart.Add(-time.Second), time.UTC, 10); len(got) != 3 {
+		t.Errorf("COUNT=3 produced %d occurrences", len(got))
+	}
+	if _, ok := counted.Next(dtstart, time.Date(2025, 6, 3, 8, 0, 0, 0, time.UTC), time.UTC); ok {
+		t.Error("Next() after the last counted occurrence should end the series")
+	}
+
+	until := mustParse(t, "FREQ=DAILY;UNTIL=20250603T080000Z")
+	got := until.Occurrences(dtstart, dtstart.Add(-time.Second), time.UTC, 10)
+	if len(got) != 3 {
+		t.Errorf("UNTIL produced %d occurrences, want 3 (inclusive)", len(got))
+	}
+}
+
+func TestNext_Yearly(t *testing.T) {
+	rule := mustParse(t, "FREQ=YEARLY")
+	dtstart := time.Date(2025, 2, 10, 8, 0, 0, 0, time.UTC)
+	if next, ok := rule.Next(dtstart, dtstart, time.UTC); !ok || !next.Equal(time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Next() = %v, %v", next, ok)
+	}
+
+	// Feb 29 only recurs in leap years
+	feb29 := time.Date(2024, 2, 29, 8, 0, 0, 0, time.UTC)
+	if next, _ := rule.Next(feb29, feb29, time.UTC); next.Year() != 2028 {
+		t.Errorf("Feb 29 yearly next = %v, want 2028", next)
+	}
+}
+
+func assertTimes(t *testing.T, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d occurrences %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("occurrence %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}